@@ -0,0 +1,232 @@
+// Package chaos injects faults into MessageService calls between nodes: it
+// drops, delays, duplicates or fails them per peer following rules that can be
+// changed while the nodes run, so partitions and slow links can be scripted
+// against real processes.
+package chaos
+
+import(
+	"os"
+	"fmt"
+	"sync"
+	"time"
+	"context"
+	"strings"
+	"math/rand"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	pb "neweraft/raftpb"
+)
+
+const(
+	ActionDrop = "drop"
+	ActionDelay = "delay"
+	ActionDuplicate = "duplicate"
+	ActionFail = "fail"
+
+	DirectionOut = "out"
+	DirectionIn = "in"
+)
+
+// fromKey carries the caller's own address, which is what inbound rules
+// match Peer against: the connection only shows an ephemeral port.
+const fromKey = "chaos-from"
+
+const servicePrefix = "/raftpb.MessageService/"
+
+// exempt methods are never faulted, so a cut-off node can still be looked at
+// and healed.
+var exempt = map[string]bool{"Status":true,"SetFaults":true}
+
+// Injector applies FaultRules to the calls of the node at address self. A
+// rule matches a call when its Peer (the other node's address), Method and
+// Direction are empty or equal to the call's; the first matching rule whose
+// Probability fires (0 means always) decides what happens:
+//
+//	drop       the call never arrives and the caller waits out its deadline
+//	delay      the call is held DelayMs plus up to JitterMs
+//	duplicate  the call is made twice
+//	fail       the call fails at once with Unavailable
+type Injector struct{
+	self string
+
+	mu sync.Mutex
+	rules []*pb.FaultRule
+	rnd *rand.Rand
+}
+
+func MakeInjector(self string) *Injector{
+	return &Injector{self:self,rnd:rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func validate(rule *pb.FaultRule) error{
+	switch rule.Action{
+	case ActionDrop,ActionDelay,ActionDuplicate,ActionFail:
+	default:
+		return fmt.Errorf("chaos: unknown action %q",rule.Action)
+	}
+	switch rule.Direction{
+	case "",DirectionOut,DirectionIn:
+	default:
+		return fmt.Errorf("chaos: unknown direction %q",rule.Direction)
+	}
+	if rule.Probability<0 || rule.Probability>1{
+		return fmt.Errorf("chaos: probability %v out of [0,1]",rule.Probability)
+	}
+	return nil
+}
+
+// SetRules replaces the rules, or adds to them when add is set, and returns
+// the rules now in force.
+func (inj *Injector)SetRules(rules []*pb.FaultRule,add bool) ([]*pb.FaultRule,error){
+	for _,rule:=range rules{
+		if err:=validate(rule);err!=nil{
+			return nil,err
+		}
+	}
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if !add{
+		inj.rules=nil
+	}
+	for _,rule:=range rules{
+		inj.rules=append(inj.rules,proto.Clone(rule).(*pb.FaultRule))
+	}
+	return inj.copyRules(),nil
+}
+
+func (inj *Injector)Rules() []*pb.FaultRule{
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	return inj.copyRules()
+}
+
+func (inj *Injector)copyRules() []*pb.FaultRule{
+	rules:=make([]*pb.FaultRule,0,len(inj.rules))
+	for _,rule:=range inj.rules{
+		rules=append(rules,proto.Clone(rule).(*pb.FaultRule))
+	}
+	return rules
+}
+
+// SetFaults serves the admin RPC.
+func (inj *Injector)SetFaults(ctx context.Context,req *pb.SetFaultsRequest) (*pb.SetFaultsResponse,error){
+	rules,err:=inj.SetRules(req.Rules,req.Append)
+	if err!=nil{
+		return nil,status.Error(codes.InvalidArgument,err.Error())
+	}
+	return &pb.SetFaultsResponse{Rules:rules},nil
+}
+
+// LoadRules reads a JSON array of rules, as written with the FaultRule field
+// names.
+func LoadRules(path string) ([]*pb.FaultRule,error){
+	data,err:=os.ReadFile(path)
+	if err!=nil{
+		return nil,err
+	}
+	rules:=[]*pb.FaultRule{}
+	if err:=json.Unmarshal(data,&rules);err!=nil{
+		return nil,fmt.Errorf("chaos: %s: %v",path,err)
+	}
+	for _,rule:=range rules{
+		if err:=validate(rule);err!=nil{
+			return nil,err
+		}
+	}
+	return rules,nil
+}
+
+// match picks the rule for a call, and the delay when it is a delay.
+func (inj *Injector)match(direction string,peer string,method string) (*pb.FaultRule,time.Duration){
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	for _,rule:=range inj.rules{
+		if (rule.Peer!="" && rule.Peer!=peer) || (rule.Method!="" && rule.Method!=method) || (rule.Direction!="" && rule.Direction!=direction){
+			continue
+		}
+		if rule.Probability>0 && inj.rnd.Float64()>=rule.Probability{
+			continue
+		}
+		delay:=time.Duration(rule.DelayMs)*time.Millisecond
+		if rule.JitterMs>0{
+			delay+=time.Duration(inj.rnd.Int63n(rule.JitterMs*int64(time.Millisecond)))
+		}
+		return rule,delay
+	}
+	return nil,0
+}
+
+func messageMethod(fullMethod string) (string,bool){
+	if !strings.HasPrefix(fullMethod,servicePrefix){
+		return "",false
+	}
+	method:=strings.TrimPrefix(fullMethod,servicePrefix)
+	return method,!exempt[method]
+}
+
+func apply(ctx context.Context,rule *pb.FaultRule,delay time.Duration,call func(ctx context.Context) error) error{
+	if rule==nil{
+		return call(ctx)
+	}
+	switch rule.Action{
+	case ActionDrop:
+		if _,ok:=ctx.Deadline();!ok{
+			return status.Error(codes.Unavailable,"chaos: dropped")
+		}
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	case ActionDelay:
+		select{
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	case ActionDuplicate:
+		call(ctx)
+	case ActionFail:
+		return status.Error(codes.Unavailable,"chaos: injected failure")
+	}
+	return call(ctx)
+}
+
+// UnaryClientInterceptor faults the calls this node makes, matching Peer
+// against the address dialed.
+func (inj *Injector)UnaryClientInterceptor(ctx context.Context,fullMethod string,req,reply interface{},cc *grpc.ClientConn,invoker grpc.UnaryInvoker,opts ...grpc.CallOption) error{
+	method,ok:=messageMethod(fullMethod)
+	if !ok{
+		return invoker(ctx,fullMethod,req,reply,cc,opts...)
+	}
+	ctx=metadata.AppendToOutgoingContext(ctx,fromKey,inj.self)
+	rule,delay:=inj.match(DirectionOut,cc.Target(),method)
+	return apply(ctx,rule,delay,func(ctx context.Context) error{
+		return invoker(ctx,fullMethod,req,reply,cc,opts...)
+	})
+}
+
+// UnaryServerInterceptor faults the calls this node serves, matching Peer
+// against the address the calling node put in the metadata.
+func (inj *Injector)UnaryServerInterceptor(ctx context.Context,req interface{},info *grpc.UnaryServerInfo,handler grpc.UnaryHandler) (interface{},error){
+	method,ok:=messageMethod(info.FullMethod)
+	if !ok{
+		return handler(ctx,req)
+	}
+	from:=""
+	if md,ok:=metadata.FromIncomingContext(ctx);ok{
+		if v:=md.Get(fromKey);len(v)>0{
+			from=v[0]
+		}
+	}
+	rule,delay:=inj.match(DirectionIn,from,method)
+	var res interface{}
+	err:=apply(ctx,rule,delay,func(ctx context.Context) error{
+		var err error
+		res,err=handler(ctx,req)
+		return err
+	})
+	return res,err
+}
@@ -0,0 +1,157 @@
+package chaos
+
+import(
+	"net"
+	"time"
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/credentials/insecure"
+	pb "neweraft/raftpb"
+)
+
+const peer = "10.0.0.2:7000"
+
+func TestServerInterceptor(t *testing.T){
+	cases:=[]struct{
+		name string
+		rules []*pb.FaultRule
+		method string
+		deadline bool
+		calls int
+		code codes.Code
+		wait time.Duration
+	}{
+		{name:"no rules",method:"AppendEntry",calls:1,code:codes.OK},
+		{name:"drop",rules:[]*pb.FaultRule{{Action:ActionDrop}},method:"AppendEntry",deadline:true,calls:0,code:codes.DeadlineExceeded},
+		{name:"drop without deadline",rules:[]*pb.FaultRule{{Action:ActionDrop}},method:"AppendEntry",calls:0,code:codes.Unavailable},
+		{name:"delay",rules:[]*pb.FaultRule{{Action:ActionDelay,DelayMs:50}},method:"AppendEntry",calls:1,code:codes.OK,wait:50*time.Millisecond},
+		{name:"delay past deadline",rules:[]*pb.FaultRule{{Action:ActionDelay,DelayMs:5000}},method:"AppendEntry",deadline:true,calls:0,code:codes.DeadlineExceeded},
+		{name:"duplicate",rules:[]*pb.FaultRule{{Action:ActionDuplicate}},method:"AppendEntry",calls:2,code:codes.OK},
+		{name:"fail",rules:[]*pb.FaultRule{{Action:ActionFail}},method:"AppendEntry",calls:0,code:codes.Unavailable},
+		{name:"peer matches",rules:[]*pb.FaultRule{{Action:ActionFail,Peer:peer}},method:"AppendEntry",calls:0,code:codes.Unavailable},
+		{name:"other peer",rules:[]*pb.FaultRule{{Action:ActionFail,Peer:"10.0.0.3:7000"}},method:"AppendEntry",calls:1,code:codes.OK},
+		{name:"other method",rules:[]*pb.FaultRule{{Action:ActionFail,Method:"RequestVote"}},method:"AppendEntry",calls:1,code:codes.OK},
+		{name:"outbound rule",rules:[]*pb.FaultRule{{Action:ActionFail,Direction:DirectionOut}},method:"AppendEntry",calls:1,code:codes.OK},
+		{name:"inbound rule",rules:[]*pb.FaultRule{{Action:ActionFail,Direction:DirectionIn}},method:"AppendEntry",calls:0,code:codes.Unavailable},
+		{name:"first match decides",rules:[]*pb.FaultRule{{Action:ActionDuplicate,Method:"AppendEntry"},{Action:ActionFail}},method:"AppendEntry",calls:2,code:codes.OK},
+		{name:"status exempt",rules:[]*pb.FaultRule{{Action:ActionFail}},method:"Status",calls:1,code:codes.OK},
+		{name:"set faults exempt",rules:[]*pb.FaultRule{{Action:ActionDrop}},method:"SetFaults",calls:1,code:codes.OK},
+	}
+	for _,c:=range cases{
+		inj:=MakeInjector("10.0.0.1:7000")
+		if _,err:=inj.SetRules(c.rules,false);err!=nil{
+			t.Fatalf("%s: %v",c.name,err)
+		}
+		ctx:=metadata.NewIncomingContext(context.Background(),metadata.Pairs(fromKey,peer))
+		if c.deadline{
+			var cancel context.CancelFunc
+			ctx,cancel=context.WithTimeout(ctx,30*time.Millisecond)
+			defer cancel()
+		}
+		calls:=0
+		start:=time.Now()
+		_,err:=inj.UnaryServerInterceptor(ctx,nil,&grpc.UnaryServerInfo{FullMethod:servicePrefix+c.method},func(ctx context.Context,req interface{}) (interface{},error){
+			calls++
+			return nil,nil
+		})
+		if calls!=c.calls || status.Code(err)!=c.code{
+			t.Errorf("%s: %d calls, %v; want %d calls, %v",c.name,calls,err,c.calls,c.code)
+		}
+		if time.Since(start)<c.wait{
+			t.Errorf("%s: returned after %v, want at least %v",c.name,time.Since(start),c.wait)
+		}
+	}
+}
+
+func TestClientInterceptor(t *testing.T){
+	cc,err:=grpc.NewClient(peer,grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err!=nil{
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	inj:=MakeInjector("10.0.0.1:7000")
+	inj.SetRules([]*pb.FaultRule{{Action:ActionFail,Peer:peer,Direction:DirectionOut,Method:"RequestVote"}},false)
+
+	var from []string
+	invoker:=func(ctx context.Context,method string,req,reply interface{},cc *grpc.ClientConn,opts ...grpc.CallOption) error{
+		md,_:=metadata.FromOutgoingContext(ctx)
+		from=md.Get(fromKey)
+		return nil
+	}
+	if err:=inj.UnaryClientInterceptor(context.Background(),servicePrefix+"RequestVote",nil,nil,cc,invoker);status.Code(err)!=codes.Unavailable{
+		t.Fatalf("faulted call: %v",err)
+	}
+	if err:=inj.UnaryClientInterceptor(context.Background(),servicePrefix+"AppendEntry",nil,nil,cc,invoker);err!=nil{
+		t.Fatal(err)
+	}
+	// the callee matches inbound rules on the address the caller sends
+	if len(from)!=1 || from[0]!="10.0.0.1:7000"{
+		t.Fatalf("caller address %v",from)
+	}
+	from=nil
+	if err:=inj.UnaryClientInterceptor(context.Background(),"/raftpb.ShardKVService/Command",nil,nil,cc,invoker);err!=nil || from!=nil{
+		t.Fatalf("call outside MessageService: %v %v",err,from)
+	}
+}
+
+type faultsServer struct{
+	pb.UnimplementedMessageServiceServer
+	inj *Injector
+}
+
+func (s *faultsServer)SetFaults(ctx context.Context,req *pb.SetFaultsRequest) (*pb.SetFaultsResponse,error){
+	return s.inj.SetFaults(ctx,req)
+}
+
+func TestSetFaults(t *testing.T){
+	lis,err:=net.Listen("tcp","127.0.0.1:0")
+	if err!=nil{
+		t.Fatal(err)
+	}
+	inj:=MakeInjector(lis.Addr().String())
+	s:=grpc.NewServer(grpc.UnaryInterceptor(inj.UnaryServerInterceptor))
+	pb.RegisterMessageServiceServer(s,&faultsServer{inj:inj})
+	go s.Serve(lis)
+	defer s.Stop()
+	cc,err:=grpc.NewClient(lis.Addr().String(),grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err!=nil{
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	cli:=pb.NewMessageServiceClient(cc)
+	ctx,cancel:=context.WithTimeout(context.Background(),5*time.Second)
+	defer cancel()
+
+	if _,err:=cli.SetFaults(ctx,&pb.SetFaultsRequest{Rules:[]*pb.FaultRule{{Action:"explode"}}});status.Code(err)!=codes.InvalidArgument{
+		t.Fatalf("unknown action: %v",err)
+	}
+	if _,err:=cli.SetFaults(ctx,&pb.SetFaultsRequest{Rules:[]*pb.FaultRule{{Action:ActionFail,Probability:2}}});status.Code(err)!=codes.InvalidArgument{
+		t.Fatalf("probability out of range: %v",err)
+	}
+	// a node cut off from everything can still be healed
+	res,err:=cli.SetFaults(ctx,&pb.SetFaultsRequest{Rules:[]*pb.FaultRule{{Action:ActionDrop}}})
+	if err!=nil || len(res.Rules)!=1{
+		t.Fatalf("set: %v %v",res,err)
+	}
+	short,cancelShort:=context.WithTimeout(ctx,100*time.Millisecond)
+	defer cancelShort()
+	if _,err:=cli.Heartbeat(short,&pb.HeartbeatRequest{});status.Code(err)!=codes.DeadlineExceeded{
+		t.Fatalf("dropped call: %v",err)
+	}
+	res,err=cli.SetFaults(ctx,&pb.SetFaultsRequest{Rules:[]*pb.FaultRule{{Action:ActionDelay,DelayMs:1}},Append:true})
+	if err!=nil || len(res.Rules)!=2{
+		t.Fatalf("append: %v %v",res,err)
+	}
+	res,err=cli.SetFaults(ctx,&pb.SetFaultsRequest{})
+	if err!=nil || len(res.Rules)!=0 || len(inj.Rules())!=0{
+		t.Fatalf("clear: %v %v",res,err)
+	}
+	if _,err:=cli.Heartbeat(ctx,&pb.HeartbeatRequest{});status.Code(err)!=codes.Unimplemented{
+		t.Fatalf("call after clearing: %v",err)
+	}
+}
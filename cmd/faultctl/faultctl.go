@@ -0,0 +1,95 @@
+package main
+
+import(
+	"os"
+	"fmt"
+	"flag"
+	"time"
+	"context"
+	"strings"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"neweraft/chaos"
+	pb "neweraft/raftpb"
+)
+
+const usage = `usage: faultctl -addrs addr[,addr...] command
+  list                  print the rules of each node
+  clear                 drop all rules
+  set rules.json        replace the rules with those of the file
+  add [flags]           add one rule:
+      -peer addr -method name -dir in|out -action drop|delay|duplicate|fail
+      -prob p -delay d -jitter d
+The nodes run with SHARDKV_CHAOS=1.`
+
+func main(){
+	addrs:=flag.String("addrs","","nodes to change, by gRPC address")
+	flag.Usage=func(){ fmt.Fprintln(os.Stderr,usage) }
+	flag.Parse()
+	if *addrs=="" || flag.NArg()==0{
+		flag.Usage()
+		os.Exit(2)
+	}
+	req:=&pb.SetFaultsRequest{}
+	switch flag.Arg(0){
+	case "list":
+		req.Append=true
+	case "clear":
+	case "set":
+		if flag.NArg()<2{
+			flag.Usage()
+			os.Exit(2)
+		}
+		rules,err:=chaos.LoadRules(flag.Arg(1))
+		if err!=nil{
+			fmt.Fprintln(os.Stderr,err)
+			os.Exit(1)
+		}
+		req.Rules=rules
+	case "add":
+		fs:=flag.NewFlagSet("add",flag.ExitOnError)
+		rule:=&pb.FaultRule{}
+		fs.StringVar(&rule.Peer,"peer","","peer address, every peer when empty")
+		fs.StringVar(&rule.Method,"method","","MessageService method, every method when empty")
+		fs.StringVar(&rule.Direction,"dir","","in, out, or both when empty")
+		fs.StringVar(&rule.Action,"action",chaos.ActionDrop,"drop, delay, duplicate or fail")
+		fs.Float64Var(&rule.Probability,"prob",0,"chance the rule applies, 0 for always")
+		delay:=fs.Duration("delay",0,"delay of the delay action")
+		jitter:=fs.Duration("jitter",0,"random extra delay up to this")
+		fs.Parse(flag.Args()[1:])
+		rule.DelayMs,rule.JitterMs=delay.Milliseconds(),jitter.Milliseconds()
+		req.Rules,req.Append=[]*pb.FaultRule{rule},true
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	failed:=false
+	for _,addr:=range strings.Split(*addrs,","){
+		conn,err:=grpc.NewClient(addr,grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err!=nil{
+			fmt.Fprintf(os.Stderr,"%s: %v\n",addr,err)
+			failed=true
+			continue
+		}
+		ctx,cancel:=context.WithTimeout(context.Background(),2*time.Second)
+		res,err:=pb.NewMessageServiceClient(conn).SetFaults(ctx,req)
+		cancel()
+		conn.Close()
+		if err!=nil{
+			fmt.Fprintf(os.Stderr,"%s: %v\n",addr,err)
+			failed=true
+			continue
+		}
+		if res.Rules==nil{
+			res.Rules=[]*pb.FaultRule{}
+		}
+		rules,_:=json.Marshal(res.Rules)
+		fmt.Printf("%s %s\n",addr,rules)
+	}
+	if failed{
+		os.Exit(1)
+	}
+}
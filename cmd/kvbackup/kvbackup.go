@@ -0,0 +1,143 @@
+package main
+
+import(
+	"os"
+	"fmt"
+	"flag"
+	"time"
+	"context"
+	"strings"
+	"path/filepath"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	pb "neweraft/raftpb"
+	"neweraft/shardkvserver"
+	"neweraft/storage"
+)
+
+const usage = `usage: kvbackup command [flags]
+  save -addr a [-group g] -out file
+        snapshot of the group at the node's applied index, with its raft
+        metadata; the manifest goes to file.manifest.json
+  archive -addr a [-group g] -from i -out file
+        log segment of the committed entries from i to the applied index
+  verify file
+        check file against its manifest and print the manifest
+  restore -in file [-segments f,f] [-to i] -addrs a,a -dir dir
+        seed a new cluster of the given nodes, one or more, from a save and
+        the segments after it, up to index i; start node n in dir/n<n> with
+        shardsvr <n> <addrs>`
+
+func fatal(err error){
+	fmt.Fprintln(os.Stderr,err)
+	os.Exit(1)
+}
+
+func fetch(args []string,archive bool){
+	fs:=flag.NewFlagSet(args[0],flag.ExitOnError)
+	addr:=fs.String("addr","","node to back up from, by gRPC address")
+	gid:=fs.Int64("group",0,"group, needed when the node runs more than one")
+	out:=fs.String("out","","file to write")
+	from:=fs.Int64("from",0,"first index of the segment")
+	timeout:=fs.Duration("timeout",10*time.Minute,"give up after this")
+	fs.Parse(args[1:])
+	if *addr=="" || *out=="" || (archive && *from<=0){
+		fmt.Fprintln(os.Stderr,usage)
+		os.Exit(2)
+	}
+	req:=&pb.BackupRequest{GroupId:*gid}
+	if archive{
+		req.SkipData,req.LogStart=true,*from
+	}
+	conn,err:=grpc.NewClient(*addr,grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err!=nil{
+		fatal(err)
+	}
+	defer conn.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),*timeout)
+	defer cancel()
+	manifest,err:=shardkvserver.FetchBackup(ctx,pb.NewMessageServiceClient(conn),req,*out)
+	if err!=nil{
+		fatal(err)
+	}
+	if archive{
+		fmt.Printf("group %d entries %d..%d, %d bytes to %s\n",manifest.Gid,manifest.LogStart,manifest.LogEnd,manifest.Bytes,*out)
+		return
+	}
+	fmt.Printf("group %d at index %d term %d, %d pairs, %d bytes to %s\n",manifest.Gid,manifest.AppliedIndex,manifest.AppliedTerm,manifest.Pairs,manifest.Bytes,*out)
+}
+
+func verify(args []string){
+	if len(args)!=2{
+		fmt.Fprintln(os.Stderr,usage)
+		os.Exit(2)
+	}
+	manifest,err:=shardkvserver.ReadBackup(args[1],nil)
+	if err!=nil{
+		fatal(err)
+	}
+	manifestData,_:=json.MarshalIndent(manifest,"","  ")
+	fmt.Println(string(manifestData))
+}
+
+func restore(args []string){
+	fs:=flag.NewFlagSet("restore",flag.ExitOnError)
+	in:=fs.String("in","","backup made by save")
+	segments:=fs.String("segments","","segments made by archive, comma separated, in order")
+	to:=fs.Int64("to",0,"last index to restore, 0 for the end of the segments")
+	addrs:=fs.String("addrs","","gRPC addresses of the new nodes")
+	dir:=fs.String("dir","./out/restore","directory of the new nodes")
+	fs.Parse(args[1:])
+	if *in=="" || *addrs==""{
+		fmt.Fprintln(os.Stderr,usage)
+		os.Exit(2)
+	}
+	opts:=shardkvserver.RestoreOptions{Backup:*in,TargetIndex:*to,Peers:strings.Split(*addrs,",")}
+	if *segments!=""{
+		opts.Segments=strings.Split(*segments,",")
+	}
+	for i:=range opts.Peers{
+		nodeDir:=filepath.Join(*dir,fmt.Sprintf("n%d",i))
+		dbPath:=filepath.Join(nodeDir,"out","data","db",fmt.Sprintf("%d_db",i))
+		if _,err:=os.Stat(dbPath);err==nil{
+			fatal(fmt.Errorf("%s exists, restore wants new nodes",dbPath))
+		}
+		db,err:=storage.MakeLevelDBKvStore(dbPath)
+		if err!=nil{
+			fatal(err)
+		}
+		config,lastIdx,err:=shardkvserver.RestoreBackup(db,opts)
+		db.Close()
+		if err!=nil{
+			os.RemoveAll(dbPath)
+			fatal(err)
+		}
+		configData,_:=json.Marshal([]*shardkvserver.Config{config})
+		if err:=os.WriteFile(filepath.Join(nodeDir,"out","config.json"),configData,0644);err!=nil{
+			fatal(err)
+		}
+		fmt.Printf("n%d %s seeded in %s, log up to %d\n",i,opts.Peers[i],nodeDir,lastIdx)
+	}
+}
+
+func main(){
+	if len(os.Args)<2{
+		fmt.Fprintln(os.Stderr,usage)
+		os.Exit(2)
+	}
+	switch os.Args[1]{
+	case "save":
+		fetch(os.Args[1:],false)
+	case "archive":
+		fetch(os.Args[1:],true)
+	case "verify":
+		verify(os.Args[1:])
+	case "restore":
+		restore(os.Args[1:])
+	default:
+		fmt.Fprintln(os.Stderr,usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import(
+	"math"
+	"time"
+	"math/bits"
+)
+
+// subBits sets the histogram's precision: values are kept in buckets no
+// wider than 1/2^(subBits-1) of their magnitude, under 1% for 8.
+const subBits = 8
+
+const subCount = 1<<subBits
+
+// histogram records latencies in nanoseconds over log-linear buckets, the
+// way HdrHistogram does: exact below subCount, then subCount/2 buckets per
+// power of two.
+type histogram struct{
+	counts []int64
+	total int64
+	min int64
+	max int64
+	sum float64
+}
+
+func newHistogram() *histogram{
+	return &histogram{counts:make([]int64,subCount+(64-subBits)*subCount/2),min:math.MaxInt64}
+}
+
+func bucketOf(v int64) int{
+	if v<subCount{
+		return int(v)
+	}
+	shift:=bits.Len64(uint64(v))-subBits
+	return subCount+(shift-1)*subCount/2+int(v>>uint(shift))-subCount/2
+}
+
+// upperOf is the highest value that lands in bucket i.
+func upperOf(i int) int64{
+	if i<subCount{
+		return int64(i)
+	}
+	shift:=(i-subCount)/(subCount/2)+1
+	m:=int64((i-subCount)%(subCount/2)+subCount/2)
+	return (m+1)<<uint(shift)-1
+}
+
+func (h *histogram)record(d time.Duration){
+	v:=int64(d)
+	if v<0{
+		v=0
+	}
+	h.counts[bucketOf(v)]++
+	h.total++
+	h.sum+=float64(v)
+	if v<h.min{
+		h.min=v
+	}
+	if v>h.max{
+		h.max=v
+	}
+}
+
+func (h *histogram)merge(o *histogram){
+	for i,c:=range o.counts{
+		h.counts[i]+=c
+	}
+	h.total+=o.total
+	h.sum+=o.sum
+	if o.min<h.min{
+		h.min=o.min
+	}
+	if o.max>h.max{
+		h.max=o.max
+	}
+}
+
+// percentile returns the value at or below which q percent of the records
+// fall, as the upper edge of its bucket.
+func (h *histogram)percentile(q float64) time.Duration{
+	if h.total==0{
+		return 0
+	}
+	rank:=int64(math.Ceil(q/100*float64(h.total)))
+	if rank<1{
+		rank=1
+	}
+	var cum int64
+	for i,c:=range h.counts{
+		cum+=c
+		if cum>=rank{
+			v:=upperOf(i)
+			if v>h.max{
+				v=h.max
+			}
+			return time.Duration(v)
+		}
+	}
+	return time.Duration(h.max)
+}
+
+func (h *histogram)mean() time.Duration{
+	if h.total==0{
+		return 0
+	}
+	return time.Duration(h.sum/float64(h.total))
+}
@@ -0,0 +1,298 @@
+package main
+
+import(
+	"os"
+	"fmt"
+	"flag"
+	"sync"
+	"time"
+	"errors"
+	"context"
+	"strings"
+	"math/rand"
+	"encoding/json"
+
+	"neweraft/shardkvclient"
+)
+
+type benchConfig struct{
+	Addrs []string
+	Duration time.Duration
+	Requests int64
+	Concurrency int
+	Rate float64
+	Keys int64
+	Distribution string
+	ZipfS float64
+	ValueSize int
+	ReadRatio float64
+	Prefix string
+	Preload bool
+	Timeout time.Duration
+}
+
+// opStats are the results of one kind of operation.
+type opStats struct{
+	Count int64
+	Errors int64
+	Throughput float64
+	Mean time.Duration
+	Min time.Duration
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	P999 time.Duration
+	P9999 time.Duration
+	Max time.Duration
+}
+
+type benchReport struct{
+	Config *benchConfig
+	Elapsed time.Duration
+	All *opStats
+	Reads *opStats
+	Writes *opStats
+}
+
+// worker keeps its own histograms so recording takes no lock.
+type worker struct{
+	reads *histogram
+	writes *histogram
+	readErrs int64
+	writeErrs int64
+}
+
+func makeStats(h *histogram,errs int64,elapsed time.Duration) *opStats{
+	st:=&opStats{
+		Count:h.total,
+		Errors:errs,
+		Mean:h.mean(),
+		P50:h.percentile(50),
+		P90:h.percentile(90),
+		P99:h.percentile(99),
+		P999:h.percentile(99.9),
+		P9999:h.percentile(99.99),
+		Max:time.Duration(h.max),
+	}
+	if h.total>0{
+		st.Min=time.Duration(h.min)
+	}
+	if elapsed>0{
+		st.Throughput=float64(h.total)/elapsed.Seconds()
+	}
+	return st
+}
+
+// keyChooser picks key indexes from [0,keys) after the configured
+// distribution; zipf puts the weight on the low indexes.
+func keyChooser(cfg *benchConfig,rnd *rand.Rand) func() int64{
+	if cfg.Distribution=="zipf"{
+		zipf:=rand.NewZipf(rnd,cfg.ZipfS,1,uint64(cfg.Keys-1))
+		return func() int64{ return int64(zipf.Uint64()) }
+	}
+	return func() int64{ return rnd.Int63n(cfg.Keys) }
+}
+
+func randomValue(rnd *rand.Rand,size int) string{
+	const letters="abcdefghijklmnopqrstuvwxyz0123456789"
+	b:=make([]byte,size)
+	for i:=range b{
+		b[i]=letters[rnd.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+func preload(cfg *benchConfig){
+	var wg sync.WaitGroup
+	for w:=0;w<cfg.Concurrency;w++{
+		wg.Add(1)
+		go func(w int){
+			defer wg.Done()
+			cli:=shardkvclient.MakeClient(cfg.Addrs)
+			defer cli.Close()
+			rnd:=rand.New(rand.NewSource(int64(w)))
+			for k:=int64(w);k<cfg.Keys;k+=int64(cfg.Concurrency){
+				ctx,cancel:=context.WithTimeout(context.Background(),cfg.Timeout)
+				cli.Put(ctx,fmt.Sprintf("%s%d",cfg.Prefix,k),randomValue(rnd,cfg.ValueSize))
+				cancel()
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// run drives the cluster. With a rate the operations are scheduled at fixed
+// intervals and latency counts from when one was due, not from when a worker
+// got to it, so a stall shows in the tail instead of slowing the load.
+func run(cfg *benchConfig) *benchReport{
+	ctx,cancel:=context.WithTimeout(context.Background(),cfg.Duration)
+	defer cancel()
+	var issued int64
+	var issuedMu sync.Mutex
+	next:=func() bool{
+		issuedMu.Lock()
+		defer issuedMu.Unlock()
+		if cfg.Requests>0 && issued>=cfg.Requests{
+			return false
+		}
+		issued++
+		return true
+	}
+
+	var schedule chan time.Time
+	if cfg.Rate>0{
+		schedule=make(chan time.Time,cfg.Concurrency)
+		go func(){
+			defer close(schedule)
+			interval:=time.Duration(float64(time.Second)/cfg.Rate)
+			due:=time.Now()
+			for next(){
+				if wait:=time.Until(due);wait>0{
+					select{
+					case <-time.After(wait):
+					case <-ctx.Done():
+						return
+					}
+				}
+				select{
+				case schedule<-due:
+				case <-ctx.Done():
+					return
+				}
+				due=due.Add(interval)
+			}
+		}()
+	}
+
+	workers:=make([]*worker,cfg.Concurrency)
+	start:=time.Now()
+	var wg sync.WaitGroup
+	for w:=range workers{
+		workers[w]=&worker{reads:newHistogram(),writes:newHistogram()}
+		wg.Add(1)
+		go func(wk *worker,seed int64){
+			defer wg.Done()
+			cli:=shardkvclient.MakeClient(cfg.Addrs)
+			defer cli.Close()
+			rnd:=rand.New(rand.NewSource(seed))
+			chooseKey:=keyChooser(cfg,rnd)
+			value:=randomValue(rnd,cfg.ValueSize)
+			for ctx.Err()==nil{
+				due:=time.Now()
+				if schedule!=nil{
+					var ok bool
+					if due,ok=<-schedule;!ok{
+						return
+					}
+				} else if !next(){
+					return
+				}
+				key:=fmt.Sprintf("%s%d",cfg.Prefix,chooseKey())
+				opCtx,opCancel:=context.WithTimeout(context.Background(),cfg.Timeout)
+				var err error
+				read:=rnd.Float64()<cfg.ReadRatio
+				if read{
+					if _,err=cli.Get(opCtx,key);errors.Is(err,shardkvclient.ErrNoKey){
+						err=nil
+					}
+				} else {
+					_,err=cli.Put(opCtx,key,value)
+				}
+				opCancel()
+				latency:=time.Since(due)
+				switch{
+				case read && err!=nil:
+					wk.readErrs++
+				case read:
+					wk.reads.record(latency)
+				case err!=nil:
+					wk.writeErrs++
+				default:
+					wk.writes.record(latency)
+				}
+			}
+		}(workers[w],time.Now().UnixNano()+int64(w))
+	}
+	wg.Wait()
+	elapsed:=time.Since(start)
+
+	reads,writes,all:=newHistogram(),newHistogram(),newHistogram()
+	var readErrs,writeErrs int64
+	for _,wk:=range workers{
+		reads.merge(wk.reads)
+		writes.merge(wk.writes)
+		readErrs+=wk.readErrs
+		writeErrs+=wk.writeErrs
+	}
+	all.merge(reads)
+	all.merge(writes)
+	return &benchReport{
+		Config:cfg,
+		Elapsed:elapsed,
+		All:makeStats(all,readErrs+writeErrs,elapsed),
+		Reads:makeStats(reads,readErrs,elapsed),
+		Writes:makeStats(writes,writeErrs,elapsed),
+	}
+}
+
+func printText(r *benchReport){
+	cfg:=r.Config
+	fmt.Printf("%d workers, %d keys (%s), %dB values, %.0f%% reads, elapsed %v\n",
+		cfg.Concurrency,cfg.Keys,cfg.Distribution,cfg.ValueSize,cfg.ReadRatio*100,r.Elapsed.Round(time.Millisecond))
+	fmt.Printf("%-7s %9s %7s %10s %10s %10s %10s %10s %10s %10s %10s\n","op","count","errors","ops/s","mean","p50","p90","p99","p99.9","p99.99","max")
+	for _,row:=range []struct{
+		name string
+		st *opStats
+	}{{"read",r.Reads},{"write",r.Writes},{"all",r.All}}{
+		st:=row.st
+		fmt.Printf("%-7s %9d %7d %10.1f %10v %10v %10v %10v %10v %10v %10v\n",row.name,st.Count,st.Errors,st.Throughput,
+			st.Mean.Round(time.Microsecond),st.P50.Round(time.Microsecond),st.P90.Round(time.Microsecond),st.P99.Round(time.Microsecond),
+			st.P999.Round(time.Microsecond),st.P9999.Round(time.Microsecond),st.Max.Round(time.Microsecond))
+	}
+}
+
+func main(){
+	cfg:=&benchConfig{}
+	addrs:=flag.String("addrs","127.0.0.1:8088,127.0.0.1:8089,127.0.0.1:8090","servers, by gRPC address")
+	flag.DurationVar(&cfg.Duration,"duration",10*time.Second,"how long to run")
+	flag.Int64Var(&cfg.Requests,"requests",0,"stop after this many operations, 0 for no limit")
+	flag.IntVar(&cfg.Concurrency,"concurrency",16,"workers, each with its own client")
+	flag.Float64Var(&cfg.Rate,"rate",0,"operations per second over all workers, 0 for as fast as they go")
+	flag.Int64Var(&cfg.Keys,"keys",10000,"number of distinct keys")
+	flag.StringVar(&cfg.Distribution,"dist","uniform","key distribution, uniform or zipf")
+	flag.Float64Var(&cfg.ZipfS,"zipf-s",1.1,"zipf exponent, above 1")
+	flag.IntVar(&cfg.ValueSize,"value-size",128,"bytes per written value")
+	flag.Float64Var(&cfg.ReadRatio,"reads",0.5,"fraction of operations that are reads")
+	flag.StringVar(&cfg.Prefix,"prefix","bench/","key prefix")
+	flag.BoolVar(&cfg.Preload,"preload",false,"write every key once before the run")
+	flag.DurationVar(&cfg.Timeout,"timeout",5*time.Second,"per operation timeout, retries included")
+	jsonOut:=flag.Bool("json",false,"print the report as JSON")
+	flag.Parse()
+	cfg.Addrs=strings.Split(*addrs,",")
+
+	switch{
+	case cfg.Distribution!="uniform" && cfg.Distribution!="zipf":
+		fmt.Fprintf(os.Stderr,"unknown distribution %q\n",cfg.Distribution)
+		os.Exit(2)
+	case cfg.Distribution=="zipf" && cfg.ZipfS<=1:
+		fmt.Fprintln(os.Stderr,"zipf-s has to be above 1")
+		os.Exit(2)
+	case cfg.Keys<2 || cfg.Concurrency<1 || cfg.ReadRatio<0 || cfg.ReadRatio>1:
+		fmt.Fprintln(os.Stderr,"need keys>=2, concurrency>=1 and reads in [0,1]")
+		os.Exit(2)
+	}
+
+	if cfg.Preload{
+		start:=time.Now()
+		preload(cfg)
+		fmt.Fprintf(os.Stderr,"preloaded %d keys in %v\n",cfg.Keys,time.Since(start).Round(time.Millisecond))
+	}
+	report:=run(cfg)
+	if *jsonOut{
+		enc:=json.NewEncoder(os.Stdout)
+		enc.SetIndent("","  ")
+		enc.Encode(report)
+		return
+	}
+	printText(report)
+}
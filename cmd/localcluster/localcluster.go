@@ -0,0 +1,199 @@
+package main
+
+import(
+	"os"
+	"fmt"
+	"flag"
+	"time"
+	"bufio"
+	"context"
+	"strings"
+	"strconv"
+	"os/signal"
+	"sync/atomic"
+
+	"neweraft/localcluster"
+	pb "neweraft/raftpb"
+)
+
+const help = `commands:
+  status                     role and term of every node
+  leader                     wait for the group's leader
+  kill <n>                   kill node n
+  start <n>                  start node n
+  restart <n>                kill and start node n
+  pause <n> / resume <n>     freeze and thaw node n
+  partition <n,n> [<n,n>..]  cut the given sides off each other and the rest
+  heal                       drop all fault rules
+  quiet / verbose            stop and resume printing node output
+  quit                       stop every node and exit`
+
+func parseIds(s string) ([]int,error){
+	var ids []int
+	for _,part:=range strings.Split(s,","){
+		id,err:=strconv.Atoi(part)
+		if err!=nil{
+			return nil,fmt.Errorf("bad node %q",part)
+		}
+		ids=append(ids,id)
+	}
+	return ids,nil
+}
+
+func printStatus(c *localcluster.Cluster){
+	for _,st:=range c.Status(){
+		state:="up"
+		switch{
+		case !st.Running:
+			state="down"
+		case st.Paused:
+			state="paused"
+		case st.Err!=nil:
+			state="unreachable"
+		}
+		fmt.Printf("n%d %s %s",st.Id,st.Addr,state)
+		for _,rs:=range st.Groups{
+			fmt.Printf("  group %d %s term %d commit %d applied %d",rs.GroupId,rs.Role,rs.Term,rs.CommitIndex,rs.AppliedIndex)
+		}
+		fmt.Println()
+	}
+}
+
+// switchWriter lets the prompt turn node output on and off.
+type switchWriter struct{
+	on atomic.Bool
+}
+
+func (sw *switchWriter)Write(p []byte) (int,error){
+	if sw.on.Load(){
+		return os.Stdout.Write(p)
+	}
+	return len(p),nil
+}
+
+func main(){
+	nodes:=flag.Int("n",3,"number of nodes")
+	dir:=flag.String("dir","./out/localcluster","directory of the nodes")
+	basePort:=flag.Int("port",8088,"gRPC port of node 0, the others following; 0 picks free ports")
+	bin:=flag.String("bin","","shardsvr binary, built from ./cmd/shardsvr when empty")
+	fresh:=flag.Bool("fresh",false,"remove the nodes' data first")
+	quiet:=flag.Bool("quiet",false,"start without printing node output")
+	flag.Parse()
+
+	out:=&switchWriter{}
+	out.on.Store(!*quiet)
+	c,err:=localcluster.Start(localcluster.Options{Nodes:*nodes,Dir:*dir,BasePort:*basePort,Binary:*bin,Fresh:*fresh,Log:out})
+	if err!=nil{
+		fmt.Fprintln(os.Stderr,err)
+		os.Exit(1)
+	}
+	fmt.Printf("nodes %s, logs in %s\n",strings.Join(c.Addrs(),","),*dir)
+	ctx,cancel:=context.WithTimeout(context.Background(),30*time.Second)
+	if id,rs,err:=c.WaitLeader(ctx,1);err!=nil{
+		fmt.Println("no leader yet:",err)
+	} else {
+		fmt.Printf("leader n%d term %d\n",id,rs.Term)
+	}
+	cancel()
+
+	sigs:=make(chan os.Signal,1)
+	signal.Notify(sigs,os.Interrupt)
+	lines:=make(chan string)
+	go func(){
+		scanner:=bufio.NewScanner(os.Stdin)
+		for scanner.Scan(){
+			lines<-scanner.Text()
+		}
+		close(lines)
+	}()
+	defer c.Stop()
+	for{
+		fmt.Print("> ")
+		var line string
+		var ok bool
+		select{
+		case <-sigs:
+			fmt.Println()
+			return
+		case line,ok=<-lines:
+			if !ok{
+				return
+			}
+		}
+		args:=strings.Fields(line)
+		if len(args)==0{
+			continue
+		}
+		var err error
+		node:=func() int{
+			if len(args)<2{
+				err=fmt.Errorf("%s wants a node",args[0])
+				return -1
+			}
+			id,e:=strconv.Atoi(args[1])
+			if e!=nil{
+				err=fmt.Errorf("bad node %q",args[1])
+			}
+			return id
+		}
+		switch args[0]{
+		case "status":
+			printStatus(c)
+		case "leader":
+			ctx,cancel:=context.WithTimeout(context.Background(),10*time.Second)
+			var id int
+			var rs *pb.RaftStatus
+			if id,rs,err=c.WaitLeader(ctx,1);err==nil{
+				fmt.Printf("leader n%d term %d\n",id,rs.Term)
+			}
+			cancel()
+		case "kill":
+			if id:=node();err==nil{
+				err=c.Kill(id)
+			}
+		case "start":
+			if id:=node();err==nil{
+				err=c.StartNode(id)
+			}
+		case "restart":
+			if id:=node();err==nil{
+				err=c.Restart(id)
+			}
+		case "pause":
+			if id:=node();err==nil{
+				err=c.Pause(id)
+			}
+		case "resume":
+			if id:=node();err==nil{
+				err=c.Resume(id)
+			}
+		case "partition":
+			var sides [][]int
+			for _,arg:=range args[1:]{
+				var side []int
+				if side,err=parseIds(arg);err!=nil{
+					break
+				}
+				sides=append(sides,side)
+			}
+			if err==nil{
+				err=c.Partition(sides...)
+			}
+		case "heal":
+			err=c.Heal()
+		case "quiet":
+			out.on.Store(false)
+		case "verbose":
+			out.on.Store(true)
+		case "quit","exit":
+			return
+		case "help":
+			fmt.Println(help)
+		default:
+			err=fmt.Errorf("unknown command %q, try help",args[0])
+		}
+		if err!=nil{
+			fmt.Println(err)
+		}
+	}
+}
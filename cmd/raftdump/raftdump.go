@@ -0,0 +1,415 @@
+package main
+
+import(
+	"os"
+	"fmt"
+	"flag"
+	"sort"
+	"bytes"
+	"errors"
+	"strconv"
+	"encoding/gob"
+	"encoding/binary"
+
+	"neweraft/raftcore"
+	pb "neweraft/raftpb"
+	"neweraft/shardkvserver"
+	"neweraft/storage"
+)
+
+const usage = `usage: raftdump -db dir [-group gid] command
+  groups                      list the groups kept in the store
+  state                       persistent state, log bounds and snapshot metadata
+  entries [-from i] [-to j] [-limit n]
+                              entries with type, term, index and a payload preview
+  check                       check the log indexes are continuous
+  truncate -force index       drop the entries after index
+  reset-vote -force           move to the next term with no vote cast
+The node has to be stopped; only truncate and reset-vote write, and both
+refuse without -force.`
+
+// rangeGroup mirrors the range registry entries of shardkvserver.
+type rangeGroup struct{
+	SelfId int64
+	Peers []string
+	Removed bool
+}
+
+type groupInfo struct{
+	gid int64
+	hasLog bool
+	hasData bool
+	registry *rangeGroup
+}
+
+func fatal(format string,args ...any){
+	fmt.Fprintf(os.Stderr,format+"\n",args...)
+	os.Exit(1)
+}
+
+// listGroups finds the groups by their key prefixes, jumping from one
+// group space to the next instead of walking every key.
+func listGroups(db storage.KvStore) []*groupInfo{
+	groups:=make(map[int64]*groupInfo)
+	get:=func(gid int64) *groupInfo{
+		if groups[gid]==nil{
+			groups[gid]=&groupInfo{gid:gid}
+		}
+		return groups[gid]
+	}
+	start,end:=[]byte{'g'},storage.PrefixEnd([]byte{'g'})
+	for{
+		it:=db.NewIterator(start,end,false)
+		ok:=it.Next()
+		key:=append([]byte{},it.Key()...)
+		it.Release()
+		if !ok{
+			break
+		}
+		if len(key)<10{
+			start=append(key,0)
+			continue
+		}
+		gid:=int64(binary.BigEndian.Uint64(key[1:9]))
+		switch key[9]{
+		case shardkvserver.GroupLogSpace:
+			get(gid).hasLog=true
+		case shardkvserver.GroupDataSpace:
+			get(gid).hasData=true
+		}
+		start=storage.PrefixEnd(key[:10])
+	}
+	if registryStr,err:=db.Get(shardkvserver.RangeGroupsKey);err==nil{
+		registry:=make(map[int64]*rangeGroup)
+		gob.NewDecoder(bytes.NewBufferString(registryStr)).Decode(&registry)
+		for gid,meta:=range registry{
+			get(gid).registry=meta
+		}
+	}
+	list:=make([]*groupInfo,0,len(groups))
+	for _,g:=range groups{
+		list=append(list,g)
+	}
+	sort.Slice(list,func(i,j int) bool{ return list[i].gid<list[j].gid })
+	return list
+}
+
+func logKey(idx int64) []byte{
+	key:=make([]byte,8)
+	binary.BigEndian.PutUint64(key,uint64(idx))
+	return append(append([]byte{},raftcore.RaftLogPrefix...),key...)
+}
+
+// forEntries calls fn with the index each log key names and its entry, over
+// [from,to]; a negative to means to the end. Entries that do not decode come
+// with err.
+func forEntries(eng storage.KvStore,from int64,to int64,fn func(idx int64,entry *pb.Entry,size int,err error) bool){
+	start,end:=logKey(from),storage.PrefixEnd(raftcore.RaftLogPrefix)
+	if to>=0{
+		end=logKey(to+1)
+	}
+	it:=eng.NewIterator(start,end,false)
+	defer it.Release()
+	for it.Next(){
+		key:=it.Key()
+		if len(key)!=len(raftcore.RaftLogPrefix)+8{
+			continue
+		}
+		idx:=int64(binary.BigEndian.Uint64(key[len(raftcore.RaftLogPrefix):]))
+		entry:=&pb.Entry{}
+		err:=gob.NewDecoder(bytes.NewReader(it.Value())).Decode(entry)
+		if !fn(idx,entry,len(it.Value()),err){
+			return
+		}
+	}
+}
+
+func preview(b []byte,n int) string{
+	if len(b)>n{
+		return strconv.Quote(string(b[:n]))+"..."
+	}
+	return strconv.Quote(string(b))
+}
+
+// describe tells what an entry carries, as far as it is a shardkv command.
+func describe(entry *pb.Entry) string{
+	if len(entry.Date)==0{
+		return "-"
+	}
+	cmd,err:=shardkvserver.DecodeCommand(entry.Date)
+	if err!=nil{
+		return fmt.Sprintf("%dB %s",len(entry.Date),preview(entry.Date,32))
+	}
+	desc:=cmd.Type.String()
+	switch{
+	case cmd.Request!=nil:
+		desc+=fmt.Sprintf(" %s key=%s",cmd.Request.Op,preview([]byte(cmd.Request.Key),32))
+		if cmd.Request.Value!=""{
+			desc+=" value="+preview([]byte(cmd.Request.Value),32)
+		}
+		desc+=fmt.Sprintf(" client=%d cmd=%d",cmd.Request.ClientId,cmd.Request.CommandId)
+	case cmd.Config!=nil:
+		desc+=fmt.Sprintf(" num=%d",cmd.Config.Num)
+	case cmd.ShardsReq!=nil:
+		desc+=fmt.Sprintf(" shards=%v config=%d",cmd.ShardsReq.ShardIds,cmd.ShardsReq.ConfigNum)
+	case cmd.ShardsResp!=nil:
+		desc+=fmt.Sprintf(" config=%d",cmd.ShardsResp.ConfigNum)
+	}
+	return desc
+}
+
+func printGroups(db storage.KvStore){
+	for _,g:=range listGroups(db){
+		fmt.Printf("group %d",g.gid)
+		if g.hasLog{
+			eng:=storage.MakePrefixKvStore(db,shardkvserver.GroupKeyPrefix(g.gid,shardkvserver.GroupLogSpace))
+			state,_:=raftcore.ReadPersistState(eng)
+			rflog:=raftcore.MakeRaftLog(eng)
+			fmt.Printf(" term %d applied %d log %d..%d",state.CurTerm,state.AppliedIdx,rflog.GetFirstIdx(),rflog.GetLastIdx())
+		} else {
+			fmt.Print(" no log")
+		}
+		if !g.hasData{
+			fmt.Print(" no data")
+		}
+		if g.registry!=nil{
+			fmt.Printf(" range member %d of %v",g.registry.SelfId,g.registry.Peers)
+			if g.registry.Removed{
+				fmt.Print(" removed")
+			}
+		}
+		fmt.Println()
+	}
+}
+
+func printState(db storage.KvStore,eng storage.KvStore,gid int64){
+	state,err:=raftcore.ReadPersistState(eng)
+	if err!=nil{
+		fmt.Printf("raft state does not decode: %v\n",err)
+	}
+	rflog:=raftcore.MakeRaftLog(eng)
+	firstIdx,lastIdx:=rflog.GetFirstIdx(),rflog.GetLastIdx()
+	fmt.Printf("group %d\n",gid)
+	fmt.Printf("term      %d\n",state.CurTerm)
+	fmt.Printf("vote      %d\n",state.VoteFor)
+	fmt.Printf("applied   %d\n",state.AppliedIdx)
+	fmt.Printf("log       %d..%d, last term %d\n",firstIdx,lastIdx,rflog.GetLastTerm())
+	// the log is never compacted, so the snapshot is the entry it starts at
+	first:=rflog.GetEntry(firstIdx)
+	fmt.Printf("snapshot  index %d term %d",firstIdx,first.CurTerm)
+	if snap,err:=eng.GetByte(raftcore.SnapshotStateKey);err==nil{
+		fmt.Printf(", %dB of snapshot state",len(snap))
+	}
+	fmt.Println()
+	dataEng:=storage.MakePrefixKvStore(db,shardkvserver.GroupKeyPrefix(gid,shardkvserver.GroupDataSpace))
+	if meta,err:=dataEng.Get(shardkvserver.ShardMetaKey);err==nil{
+		fmt.Printf("shard meta %dB\n",len(meta))
+	} else {
+		fmt.Println("shard meta none")
+	}
+}
+
+func printEntries(eng storage.KvStore,args []string){
+	fs:=flag.NewFlagSet("entries",flag.ExitOnError)
+	from:=fs.Int64("from",0,"first index")
+	to:=fs.Int64("to",-1,"last index, -1 for the end of the log")
+	limit:=fs.Int("limit",100,"print at most this many, 0 for all")
+	fs.Parse(args)
+	n:=0
+	forEntries(eng,*from,*to,func(idx int64,entry *pb.Entry,size int,err error) bool{
+		if *limit>0 && n>=*limit{
+			fmt.Println("...")
+			return false
+		}
+		n++
+		if err!=nil{
+			fmt.Printf("%8d  does not decode: %v\n",idx,err)
+			return true
+		}
+		fmt.Printf("%8d  term %-4d %-11s %5dB  %s\n",idx,entry.CurTerm,entry.EntryType,size,describe(entry))
+		return true
+	})
+}
+
+// checkLog reports what is wrong with the log: gaps, entries naming another
+// index, terms going back and an applied index past the end. The entry at
+// the first index stands for the snapshot and need not be there.
+func checkLog(eng storage.KvStore) []string{
+	var problems []string
+	rflog:=raftcore.MakeRaftLog(eng)
+	firstIdx,lastIdx:=rflog.GetFirstIdx(),rflog.GetLastIdx()
+	next,term:=firstIdx+1,int64(0)
+	beyond:=0
+	forEntries(eng,0,-1,func(idx int64,entry *pb.Entry,size int,err error) bool{
+		switch{
+		case idx<firstIdx:
+			problems=append(problems,fmt.Sprintf("entry %d before the first index %d",idx,firstIdx))
+			return true
+		case idx>lastIdx:
+			beyond++
+			return true
+		case idx==firstIdx:
+			term=entry.CurTerm
+			return true
+		case idx>next:
+			problems=append(problems,fmt.Sprintf("entries %d..%d missing",next,idx-1))
+		}
+		next=idx+1
+		if err!=nil{
+			problems=append(problems,fmt.Sprintf("entry %d does not decode: %v",idx,err))
+			return true
+		}
+		if entry.Index!=idx{
+			problems=append(problems,fmt.Sprintf("entry %d says it is %d",idx,entry.Index))
+		}
+		if entry.CurTerm<term{
+			problems=append(problems,fmt.Sprintf("entry %d has term %d after term %d",idx,entry.CurTerm,term))
+		}
+		term=entry.CurTerm
+		return true
+	})
+	if next<=lastIdx{
+		problems=append(problems,fmt.Sprintf("entries %d..%d missing",next,lastIdx))
+	}
+	if beyond>0{
+		problems=append(problems,fmt.Sprintf("%d entries past the last index %d",beyond,lastIdx))
+	}
+	if state,err:=raftcore.ReadPersistState(eng);err!=nil{
+		problems=append(problems,fmt.Sprintf("raft state does not decode: %v",err))
+	} else if state.AppliedIdx>lastIdx{
+		problems=append(problems,fmt.Sprintf("applied index %d past the last index %d",state.AppliedIdx,lastIdx))
+	}
+	return problems
+}
+
+// truncate drops the tail of the log. The entries past index may be
+// committed, and their writes acknowledged, even though this node has not
+// applied them; they survive only if a majority of the other nodes hold them.
+func truncate(eng storage.KvStore,args []string) error{
+	fs:=flag.NewFlagSet("truncate",flag.ExitOnError)
+	force:=fs.Bool("force",false,"drop the entries even though they may be committed")
+	fs.Parse(args)
+	if fs.NArg()!=1{
+		return errors.New("truncate wants the index to keep up to")
+	}
+	idx,err:=strconv.ParseInt(fs.Arg(0),10,64)
+	if err!=nil{
+		return fmt.Errorf("bad index %q",fs.Arg(0))
+	}
+	state,err:=raftcore.ReadPersistState(eng)
+	if err!=nil{
+		return err
+	}
+	rflog:=raftcore.MakeRaftLog(eng)
+	firstIdx,lastIdx:=rflog.GetFirstIdx(),rflog.GetLastIdx()
+	switch{
+	case idx<firstIdx:
+		return fmt.Errorf("index %d is before the first index %d",idx,firstIdx)
+	case idx<state.AppliedIdx:
+		return fmt.Errorf("entries up to %d are applied, they cannot go",state.AppliedIdx)
+	case idx>=lastIdx:
+		fmt.Printf("log ends at %d, nothing to drop\n",lastIdx)
+		return nil
+	}
+	fmt.Printf("entries %d..%d may be committed and acknowledged to clients; unless a majority\n",idx+1,lastIdx)
+	fmt.Println("of the other nodes still holds them, dropping them loses those writes.")
+	if !*force{
+		return errors.New("refusing to truncate without -force")
+	}
+	if err:=rflog.EraseAfter(idx+1);err!=nil{
+		return err
+	}
+	fmt.Printf("dropped entries %d..%d\n",idx+1,lastIdx)
+	return nil
+}
+
+// resetVote moves the node to the next term with no vote cast. Clearing the
+// vote in the current term would let the node vote twice in it and elect two
+// leaders; nobody has a vote from it in a term it never reached.
+func resetVote(eng storage.KvStore,args []string) error{
+	fs:=flag.NewFlagSet("reset-vote",flag.ExitOnError)
+	force:=fs.Bool("force",false,"write the new term and vote")
+	fs.Parse(args)
+	state,err:=raftcore.ReadPersistState(eng)
+	if err!=nil{
+		return err
+	}
+	fmt.Printf("term %d vote %d -> term %d vote -1\n",state.CurTerm,state.VoteFor,state.CurTerm+1)
+	if !*force{
+		return errors.New("refusing to reset the vote without -force")
+	}
+	state.CurTerm++
+	state.VoteFor=-1
+	return raftcore.WritePersistState(eng,state)
+}
+
+func main(){
+	dbPath:=flag.String("db","","the node's store, ./out/data/db/<id>_db")
+	gid:=flag.Int64("group",0,"group to inspect, needed when the store holds more than one")
+	flag.Usage=func(){ fmt.Fprintln(os.Stderr,usage) }
+	flag.Parse()
+	if *dbPath=="" || flag.NArg()==0{
+		flag.Usage()
+		os.Exit(2)
+	}
+	command:=flag.Arg(0)
+	var db storage.KvStore
+	var err error
+	switch command{
+	case "truncate","reset-vote":
+		db,err=storage.MakeLevelDBKvStore(*dbPath)
+	case "groups","state","entries","check":
+		db,err=storage.MakeReadOnlyLevelDBKvStore(*dbPath)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err!=nil{
+		fatal("open %s: %v (is the node still running?)",*dbPath,err)
+	}
+	defer db.Close()
+
+	if command=="groups"{
+		printGroups(db)
+		return
+	}
+	if *gid==0{
+		var withLog []int64
+		for _,g:=range listGroups(db){
+			if g.hasLog{
+				withLog=append(withLog,g.gid)
+			}
+		}
+		if len(withLog)!=1{
+			db.Close()
+			fatal("the store holds the logs of groups %v, pick one with -group",withLog)
+		}
+		*gid=withLog[0]
+	}
+	eng:=storage.MakePrefixKvStore(db,shardkvserver.GroupKeyPrefix(*gid,shardkvserver.GroupLogSpace))
+
+	switch command{
+	case "state":
+		printState(db,eng,*gid)
+	case "entries":
+		printEntries(eng,flag.Args()[1:])
+	case "check":
+		problems:=checkLog(eng)
+		for _,p:=range problems{
+			fmt.Println(p)
+		}
+		if len(problems)>0{
+			db.Close()
+			os.Exit(1)
+		}
+		fmt.Println("ok")
+	case "truncate":
+		err=truncate(eng,flag.Args()[1:])
+	case "reset-vote":
+		err=resetVote(eng,flag.Args()[1:])
+	}
+	if err!=nil{
+		db.Close()
+		fatal("%v",err)
+	}
+}
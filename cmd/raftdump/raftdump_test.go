@@ -0,0 +1,72 @@
+package main
+
+import(
+	"testing"
+
+	"neweraft/raftcore"
+	pb "neweraft/raftpb"
+	"neweraft/shardkvserver"
+	"neweraft/storage"
+)
+
+// testLog makes a store for group 1 with entries 1..6 in term 2, voted for
+// node 1 in term 3 and applied up to applied.
+func testLog(t *testing.T,applied int64) storage.KvStore{
+	db,err:=storage.MakeLevelDBKvStore(t.TempDir())
+	if err!=nil{
+		t.Fatal(err)
+	}
+	t.Cleanup(func(){ db.Close() })
+	eng:=storage.MakePrefixKvStore(db,shardkvserver.GroupKeyPrefix(1,shardkvserver.GroupLogSpace))
+	var entries []*pb.Entry
+	for idx:=int64(1);idx<=6;idx++{
+		entries=append(entries,&pb.Entry{Index:idx,CurTerm:2})
+	}
+	raftcore.MakeRaftLog(eng).AppendLogEntries(entries)
+	if err:=raftcore.WritePersistState(eng,&raftcore.RaftPersistentState{CurTerm:3,VoteFor:1,AppliedIdx:applied});err!=nil{
+		t.Fatal(err)
+	}
+	return eng
+}
+
+func TestTruncate(t *testing.T){
+	eng:=testLog(t,4)
+	lastIdx:=func() int64{ return raftcore.MakeRaftLog(eng).GetLastIdx() }
+
+	if err:=truncate(eng,[]string{"-force","3"});err==nil || lastIdx()!=6{
+		t.Fatalf("truncate below the applied index: %v, log ends at %d",err,lastIdx())
+	}
+	if err:=truncate(eng,[]string{"5"});err==nil || lastIdx()!=6{
+		t.Fatalf("truncate without -force: %v, log ends at %d",err,lastIdx())
+	}
+	if err:=truncate(eng,[]string{"-force","5"});err!=nil{
+		t.Fatal(err)
+	}
+	if lastIdx()!=5{
+		t.Fatalf("log ends at %d after truncating to 5",lastIdx())
+	}
+	if problems:=checkLog(eng);len(problems)>0{
+		t.Fatalf("log after truncating: %v",problems)
+	}
+	// at the applied index nothing applied goes
+	if err:=truncate(eng,[]string{"-force","4"});err!=nil || lastIdx()!=4{
+		t.Fatalf("truncate to the applied index: %v, log ends at %d",err,lastIdx())
+	}
+}
+
+func TestResetVote(t *testing.T){
+	eng:=testLog(t,4)
+	if err:=resetVote(eng,nil);err==nil{
+		t.Fatal("reset-vote without -force")
+	}
+	if state,_:=raftcore.ReadPersistState(eng);state.CurTerm!=3 || state.VoteFor!=1{
+		t.Fatalf("state written without -force: %+v",state)
+	}
+	if err:=resetVote(eng,[]string{"-force"});err!=nil{
+		t.Fatal(err)
+	}
+	// the node moves on to a term it never voted in, keeping what it applied
+	if state,_:=raftcore.ReadPersistState(eng);state.CurTerm!=4 || state.VoteFor!=-1 || state.AppliedIdx!=4{
+		t.Fatalf("state after reset-vote: %+v",state)
+	}
+}
@@ -2,45 +2,137 @@ package main
 
 import(
 	"os"
-	"log"
 	"strings"
 	"strconv"
 	"net"
+	"net/http"
     
 	"google.golang.org/grpc"
+	"neweraft/chaos"
+	"neweraft/metrics"
+	"neweraft/raftcore"
 	"neweraft/shardkvserver"
+	"neweraft/tracing"
 	pb "neweraft/raftpb"
 )
 
+func fatal(logger raftcore.Logger,msg string,err error){
+	logger.Error(msg,"err",err)
+	os.Exit(1)
+}
+
 func main(){
+	// SHARDKV_LOG_LEVEL is one of debug, info, warn, error; SHARDKV_LOG_FORMAT=json
+	// writes one JSON object per record
+	if err:=raftcore.ConfigureLogging(os.Stderr,os.Getenv("SHARDKV_LOG_LEVEL"),os.Getenv("SHARDKV_LOG_FORMAT")=="json");err!=nil{
+		fatal(raftcore.GetLogger(),"bad SHARDKV_LOG_LEVEL",err)
+	}
 	if len(os.Args) < 3 {
-		log.Println("输入格式:[id] [addr,addr,addr]")
+		raftcore.GetLogger().Error("输入格式:[id] [addr,addr,addr] [config.json]")
 		return
 	}
 
 	idStr:=os.Args[1]
 	id,iderr:=strconv.Atoi(idStr)
 	if iderr!=nil{
-		log.Println("id atoi err")
+		fatal(raftcore.GetLogger(),"id atoi err",iderr)
 	}
+	logger:=raftcore.GetLogger().With("node",id)
 
 	addrs:=strings.Split(os.Args[2],",")
 	peersAddrsMap:=make(map[int]string)
 	for i,addr:=range addrs{
 		peersAddrsMap[i]=addr
 	}
+	configPath:="./out/config.json"
+	if len(os.Args)>3{
+		configPath=os.Args[3]
+	}
 
 	lis,liserr:=net.Listen("tcp",peersAddrsMap[id])
 	if liserr!=nil{
-		log.Fatalf("tcpcon: %v", liserr)
-		return 
+		fatal(logger,"tcpcon",liserr)
+	}
+
+	// SHARDKV_TRACE_FILE appends spans to a file as JSON lines, SHARDKV_TRACE_MEMORY=n
+	// keeps the last n for /debug/traces on the metrics address
+	var traceMem *tracing.MemoryExporter
+	if traceFile:=os.Getenv("SHARDKV_TRACE_FILE");traceFile!=""{
+		exporter,err:=tracing.NewFileExporter(traceFile)
+		if err!=nil{
+			fatal(logger,"trace file",err)
+		}
+		tracing.SetExporter(exporter)
+	} else if n,err:=strconv.Atoi(os.Getenv("SHARDKV_TRACE_MEMORY"));err==nil && n>0{
+		traceMem=tracing.NewMemoryExporter(n)
+		tracing.SetExporter(traceMem)
+	}
+	if rate,err:=strconv.ParseFloat(os.Getenv("SHARDKV_TRACE_SAMPLE"),64);err==nil{
+		tracing.SetSampleRate(rate)
 	}
 
-	s:=grpc.NewServer()
-	srdSvr:=shardkvserver.MakeShardServer(peersAddrsMap,int64(id))
+	// SHARDKV_CHAOS=1 turns on fault injection between nodes, with the rules of
+	// SHARDKV_FAULTS_FILE if given, changed later through SetFaults
+	interceptors:=[]grpc.UnaryServerInterceptor{tracing.UnaryServerInterceptor}
+	var faults *chaos.Injector
+	faultsFile:=os.Getenv("SHARDKV_FAULTS_FILE")
+	if os.Getenv("SHARDKV_CHAOS")=="1" || faultsFile!=""{
+		faults=chaos.MakeInjector(peersAddrsMap[id])
+		if faultsFile!=""{
+			rules,err:=chaos.LoadRules(faultsFile)
+			if err!=nil{
+				fatal(logger,"faults file",err)
+			}
+			faults.SetRules(rules,false)
+		}
+		interceptors=append(interceptors,faults.UnaryServerInterceptor)
+		raftcore.SharedConnOptions=append(raftcore.SharedConnOptions,grpc.WithChainUnaryInterceptor(faults.UnaryClientInterceptor))
+		logger.Warn("fault injection on","rules",len(faults.Rules()))
+	}
+
+	s:=grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	srdSvr:=shardkvserver.MakeShardServer(peersAddrsMap[id],int64(id),shardkvserver.MakeFileCtrlerClient(configPath))
+	srdSvr.SetFaultInjector(faults)
+	if cdcDir:=os.Getenv("SHARDKV_CDC_DIR");cdcDir!=""{
+		if _,err:=shardkvserver.StartCdcFileSink(srdSvr,cdcDir);err!=nil{
+			fatal(logger,"cdc sink",err)
+		}
+	}
+	if respAddrs:=os.Getenv("SHARDKV_RESP_ADDRS");respAddrs!=""{
+		// one RESP address per node, in the order of the gRPC addresses
+		respAddrOf:=make(map[string]string)
+		for i,addr:=range strings.Split(respAddrs,","){
+			respAddrOf[peersAddrsMap[i]]=addr
+		}
+		advertise:=func(addr string) string{ return respAddrOf[addr] }
+		if err:=shardkvserver.ServeResp(srdSvr,respAddrOf[peersAddrsMap[id]],advertise);err!=nil{
+			fatal(logger,"resp listen",err)
+		}
+	}
+	if httpAddrs:=os.Getenv("SHARDKV_HTTP_ADDRS");httpAddrs!=""{
+		httpAddrOf:=make(map[string]string)
+		for i,addr:=range strings.Split(httpAddrs,","){
+			httpAddrOf[peersAddrsMap[i]]=addr
+		}
+		advertise:=func(addr string) string{ return httpAddrOf[addr] }
+		if err:=shardkvserver.ServeHttpGateway(srdSvr,httpAddrOf[peersAddrsMap[id]],advertise);err!=nil{
+			fatal(logger,"http listen",err)
+		}
+	}
+	if metricsAddr:=os.Getenv("SHARDKV_METRICS_ADDR");metricsAddr!=""{
+		mux:=http.NewServeMux()
+		mux.Handle("/metrics",metrics.Handler())
+		if traceMem!=nil{
+			mux.Handle("/debug/traces",traceMem)
+		}
+		go func(){
+			logger.Error("metrics","err",http.ListenAndServe(metricsAddr,mux))
+		}()
+	}
 	pb.RegisterMessageServiceServer(s,srdSvr)
+	pb.RegisterShardKVServiceServer(s,srdSvr)
 	serverr:=s.Serve(lis)
 	if serverr!=nil {
-		log.Println("serve err")
+		logger.Error("serve err","err",serverr)
 	}
 }
\ No newline at end of file
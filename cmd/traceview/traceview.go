@@ -0,0 +1,36 @@
+package main
+
+import(
+	"os"
+	"fmt"
+	"flag"
+
+	"neweraft/tracing"
+)
+
+// traceview merges the span files of several nodes and prints each trace as a
+// tree, or only the one given with -trace.
+func main(){
+	traceId:=flag.String("trace","","only print this trace")
+	flag.Parse()
+	if flag.NArg()==0{
+		fmt.Fprintln(os.Stderr,"usage: traceview [-trace id] spans.json...")
+		os.Exit(2)
+	}
+	var spans []*tracing.Span
+	for _,path:=range flag.Args(){
+		f,err:=os.Open(path)
+		if err!=nil{
+			fmt.Fprintln(os.Stderr,err)
+			os.Exit(1)
+		}
+		read,err:=tracing.ReadSpans(f,*traceId)
+		f.Close()
+		if err!=nil{
+			fmt.Fprintf(os.Stderr,"%s: %v\n",path,err)
+			os.Exit(1)
+		}
+		spans=append(spans,read...)
+	}
+	tracing.WriteTree(os.Stdout,spans)
+}
@@ -0,0 +1,280 @@
+// Package linearizability checks recorded histories of concurrent operations
+// against a sequential model, in the manner of Wing and Gong's search with the
+// memoization of Lowe and Porcupine.
+package linearizability
+
+import(
+	"sort"
+	"sync"
+	"time"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// Operation is one call as a client saw it: issued at Call, answered at
+// Return. An operation whose outcome is unknown, e.g. a write that timed out,
+// gets Return Pending and may then take effect at any point after Call, or
+// never; the model has to accept it with whatever Output it carries.
+type Operation struct{
+	ClientId int
+	Input interface{}
+	Output interface{}
+	Call int64
+	Return int64
+}
+
+// Pending is the Return of an operation whose outcome is unknown.
+const Pending = math.MaxInt64
+
+// Model is the sequential specification histories are checked against.
+type Model struct{
+	// Partition splits a history into independent ones, e.g. one per key; nil
+	// checks it whole
+	Partition func(history []Operation) [][]Operation
+	Init func() interface{}
+	// Step applies input to state and tells whether output is what the
+	// specification allows
+	Step func(state interface{},input interface{},output interface{}) (bool,interface{})
+	Equal func(a,b interface{}) bool
+}
+
+type CheckResult int
+
+const(
+	Ok CheckResult=iota
+	Illegal
+	Unknown
+)
+
+func (res CheckResult)String() string{
+	switch res{
+	case Ok:
+		return "ok"
+	case Illegal:
+		return "illegal"
+	}
+	return "unknown"
+}
+
+type bitset []uint64
+
+func newBitset(n int) bitset{
+	return make(bitset,(n+63)/64)
+}
+
+func (b bitset)clone() bitset{
+	return append(bitset{},b...)
+}
+
+func (b bitset)set(i int){
+	b[i/64]|=1<<uint(i%64)
+}
+
+func (b bitset)clear(i int){
+	b[i/64]&^=1<<uint(i%64)
+}
+
+func (b bitset)hash() uint64{
+	h:=uint64(len(b))
+	for _,w:=range b{
+		h=bits.RotateLeft64(h,13)^w
+		h*=0x9e3779b97f4a7c15
+	}
+	return h
+}
+
+func (b bitset)equals(o bitset) bool{
+	for i:=range b{
+		if b[i]!=o[i]{
+			return false
+		}
+	}
+	return true
+}
+
+// node is a call or return in the doubly linked list the search lifts
+// linearized operations out of. A call points at its return through match.
+type node struct{
+	value interface{}
+	match *node
+	id int
+	prev *node
+	next *node
+}
+
+func makeList(history []Operation) *node{
+	type event struct{
+		time int64
+		call bool
+		id int
+	}
+	events:=make([]event,0,2*len(history))
+	for i,op:=range history{
+		events=append(events,event{op.Call,true,i},event{op.Return,false,i})
+	}
+	// calls go before returns at the same time, leaving such operations
+	// concurrent
+	sort.SliceStable(events,func(i,j int) bool{
+		if events[i].time!=events[j].time{
+			return events[i].time<events[j].time
+		}
+		return events[i].call && !events[j].call
+	})
+	calls:=make([]*node,len(history))
+	returns:=make([]*node,len(history))
+	head:=&node{id:-1}
+	last:=head
+	for _,ev:=range events{
+		n:=&node{id:ev.id,prev:last}
+		if ev.call{
+			n.value=history[ev.id].Input
+			calls[ev.id]=n
+		} else {
+			n.value=history[ev.id].Output
+			returns[ev.id]=n
+		}
+		last.next=n
+		last=n
+	}
+	for id,call:=range calls{
+		call.match=returns[id]
+	}
+	return head
+}
+
+func lift(call *node){
+	call.prev.next=call.next
+	call.next.prev=call.prev
+	ret:=call.match
+	ret.prev.next=ret.next
+	if ret.next!=nil{
+		ret.next.prev=ret.prev
+	}
+}
+
+func unlift(call *node){
+	ret:=call.match
+	ret.prev.next=ret
+	if ret.next!=nil{
+		ret.next.prev=ret
+	}
+	call.prev.next=call
+	call.next.prev=call
+}
+
+type cacheEntry struct{
+	linearized bitset
+	state interface{}
+}
+
+type callFrame struct{
+	call *node
+	state interface{}
+}
+
+// checkSingle searches for a linearization of one partition, giving up when
+// stop is set.
+func checkSingle(model Model,history []Operation,stop *int32) bool{
+	linearized:=newBitset(len(history))
+	cache:=make(map[uint64][]cacheEntry)
+	var calls []callFrame
+	state:=model.Init()
+	head:=makeList(history)
+	n:=head.next
+	for head.next!=nil{
+		if atomic.LoadInt32(stop)!=0{
+			return false
+		}
+		if n.match!=nil{
+			ok,newState:=model.Step(state,n.value,n.match.value)
+			if ok{
+				newLinearized:=linearized.clone()
+				newLinearized.set(n.id)
+				hash:=newLinearized.hash()
+				seen:=false
+				for _,ce:=range cache[hash]{
+					if ce.linearized.equals(newLinearized) && model.Equal(ce.state,newState){
+						seen=true
+						break
+					}
+				}
+				if !seen{
+					cache[hash]=append(cache[hash],cacheEntry{newLinearized,newState})
+					calls=append(calls,callFrame{n,state})
+					state=newState
+					linearized.set(n.id)
+					lift(n)
+					n=head.next
+					continue
+				}
+			}
+			n=n.next
+			continue
+		}
+		// a return whose call cannot go next: undo the last choice
+		if len(calls)==0{
+			return false
+		}
+		top:=calls[len(calls)-1]
+		calls=calls[:len(calls)-1]
+		n,state=top.call,top.state
+		linearized.clear(n.id)
+		unlift(n)
+		n=n.next
+	}
+	return true
+}
+
+// Check reports whether history is linearizable under model, Unknown when
+// timeout (0 for none) ran out first. For an illegal history it also returns
+// the partition that has no linearization.
+func Check(model Model,history []Operation,timeout time.Duration) (CheckResult,[]Operation){
+	partitions:=[][]Operation{history}
+	if model.Partition!=nil{
+		partitions=model.Partition(history)
+	}
+	var stop int32
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var illegal []Operation
+	found:=false
+	for _,part:=range partitions{
+		wg.Add(1)
+		go func(part []Operation){
+			defer wg.Done()
+			if !checkSingle(model,part,&stop) && atomic.LoadInt32(&stop)==0{
+				mu.Lock()
+				if !found{
+					found,illegal=true,part
+				}
+				mu.Unlock()
+				atomic.StoreInt32(&stop,1)
+			}
+		}(part)
+	}
+	done:=make(chan struct{})
+	go func(){
+		wg.Wait()
+		close(done)
+	}()
+	var timer <-chan time.Time
+	if timeout>0{
+		timer=time.After(timeout)
+	}
+	select{
+	case <-done:
+	case <-timer:
+		atomic.StoreInt32(&stop,1)
+		<-done
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if found{
+		return Illegal,illegal
+	}
+	if atomic.LoadInt32(&stop)!=0{
+		return Unknown,nil
+	}
+	return Ok,nil
+}
@@ -0,0 +1,106 @@
+package linearizability
+
+import(
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type KvOp int
+
+const(
+	KvGet KvOp=iota
+	KvPut
+	KvAppend
+	KvDelete
+)
+
+func (op KvOp)String() string{
+	switch op{
+	case KvGet:
+		return "get"
+	case KvPut:
+		return "put"
+	case KvAppend:
+		return "append"
+	case KvDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+type KvInput struct{
+	Op KvOp
+	Key string
+	Value string
+}
+
+// KvOutput is what a get returned; a missing key reads as "". Writes carry
+// none.
+type KvOutput struct{
+	Value string
+}
+
+// KvModel is a map of string keys, checked key by key.
+var KvModel = Model{
+	Partition:partitionByKey,
+	Init:func() interface{}{ return "" },
+	Step:func(state interface{},input interface{},output interface{}) (bool,interface{}){
+		in:=input.(KvInput)
+		value:=state.(string)
+		switch in.Op{
+		case KvGet:
+			out,ok:=output.(KvOutput)
+			return ok && out.Value==value,value
+		case KvPut:
+			return true,in.Value
+		case KvAppend:
+			return true,value+in.Value
+		case KvDelete:
+			return true,""
+		}
+		return false,value
+	},
+	Equal:func(a,b interface{}) bool{ return a.(string)==b.(string) },
+}
+
+func partitionByKey(history []Operation) [][]Operation{
+	byKey:=make(map[string][]Operation)
+	for _,op:=range history{
+		key:=op.Input.(KvInput).Key
+		byKey[key]=append(byKey[key],op)
+	}
+	keys:=make([]string,0,len(byKey))
+	for key:=range byKey{
+		keys=append(keys,key)
+	}
+	sort.Strings(keys)
+	partitions:=make([][]Operation,0,len(keys))
+	for _,key:=range keys{
+		partitions=append(partitions,byKey[key])
+	}
+	return partitions
+}
+
+// DescribeKv prints a KV history one operation per line, by call time, for
+// reading a failed check.
+func DescribeKv(history []Operation) string{
+	ops:=append([]Operation{},history...)
+	sort.Slice(ops,func(i,j int) bool{ return ops[i].Call<ops[j].Call })
+	var sb strings.Builder
+	for _,op:=range ops{
+		in:=op.Input.(KvInput)
+		ret:="pending"
+		if op.Return!=Pending{
+			ret=fmt.Sprint(op.Return)
+		}
+		fmt.Fprintf(&sb,"client %d [%d,%s] %s %q",op.ClientId,op.Call,ret,in.Op,in.Key)
+		if in.Op!=KvGet{
+			fmt.Fprintf(&sb," %q",in.Value)
+		} else if out,ok:=op.Output.(KvOutput);ok{
+			fmt.Fprintf(&sb," -> %q",out.Value)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
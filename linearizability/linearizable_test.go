@@ -0,0 +1,211 @@
+package linearizability
+
+import(
+	"os"
+	"fmt"
+	"sync"
+	"time"
+	"errors"
+	"context"
+	"testing"
+	"math/rand"
+
+	"neweraft/localcluster"
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+)
+
+func TestLinearizableChecker(t *testing.T){
+	put:=func(client int,call,ret int64,key,value string) Operation{
+		return Operation{ClientId:client,Input:KvInput{Op:KvPut,Key:key,Value:value},Call:call,Return:ret}
+	}
+	get:=func(client int,call,ret int64,key,value string) Operation{
+		return Operation{ClientId:client,Input:KvInput{Op:KvGet,Key:key},Output:KvOutput{Value:value},Call:call,Return:ret}
+	}
+	cases:=[]struct{
+		name string
+		history []Operation
+		want CheckResult
+	}{
+		{"sequential",[]Operation{put(0,0,1,"x","a"),get(1,2,3,"x","a")},Ok},
+		{"stale read",[]Operation{put(0,0,1,"x","a"),put(0,2,3,"x","b"),get(1,4,5,"x","a")},Illegal},
+		{"concurrent either way",[]Operation{put(0,0,10,"x","a"),get(1,1,2,"x",""),get(2,3,4,"x","a")},Ok},
+		{"read goes back",[]Operation{put(0,0,10,"x","a"),get(1,1,2,"x","a"),get(2,3,4,"x","")},Illegal},
+		{"pending write seen",[]Operation{put(0,0,Pending,"x","a"),get(1,5,6,"x","a"),get(1,7,8,"x","a")},Ok},
+		{"pending write never seen",[]Operation{put(0,0,Pending,"x","a"),get(1,5,6,"x","")},Ok},
+		{"keys are independent",[]Operation{put(0,0,1,"x","a"),put(0,2,3,"y","b"),get(1,4,5,"x","a"),get(1,6,7,"y","")},Illegal},
+		{"appends in order",[]Operation{
+			{ClientId:0,Input:KvInput{Op:KvAppend,Key:"x",Value:"1"},Call:0,Return:3},
+			{ClientId:1,Input:KvInput{Op:KvAppend,Key:"x",Value:"2"},Call:1,Return:4},
+			get(2,5,6,"x","21"),
+			get(2,7,8,"x","12"),
+		},Illegal},
+	}
+	for _,c:=range cases{
+		if got,_:=Check(KvModel,c.history,time.Second);got!=c.want{
+			t.Errorf("%s: got %v, want %v",c.name,got,c.want)
+		}
+	}
+}
+
+func waitReady(t *testing.T,c *localcluster.Cluster){
+	cli:=shardkvclient.MakeClient(c.Addrs())
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),30*time.Second)
+	defer cancel()
+	for{
+		callCtx,callCancel:=context.WithTimeout(ctx,2*time.Second)
+		_,err:=cli.Put(callCtx,"__ready","1")
+		callCancel()
+		if err==nil{
+			return
+		}
+		if ctx.Err()!=nil{
+			t.Fatalf("cluster not ready: %v",err)
+		}
+	}
+}
+
+// TestLinearizableCluster runs clients doing gets, puts and appends on a few
+// keys against a three node cluster while nodes crash, restart and get cut
+// off, then checks the recorded history. LINEARIZABLE_DURATION sets how long
+// the clients run.
+func TestLinearizableCluster(t *testing.T){
+	if testing.Short(){
+		t.Skip("starts a cluster")
+	}
+	duration:=10*time.Second
+	if s:=os.Getenv("LINEARIZABLE_DURATION");s!=""{
+		d,err:=time.ParseDuration(s)
+		if err!=nil{
+			t.Fatal(err)
+		}
+		duration=d
+	}
+	seed:=time.Now().UnixNano()
+	t.Logf("seed %d",seed)
+	rnd:=rand.New(rand.NewSource(seed))
+
+	cluster,err:=localcluster.Start(localcluster.Options{Nodes:3,Dir:t.TempDir(),Env:[]string{"SHARDKV_LOG_LEVEL=warn"}})
+	if err!=nil{
+		t.Fatal(err)
+	}
+	defer cluster.Stop()
+	waitReady(t,cluster)
+
+	const clients=5
+	keys:=[]string{"lin/a","lin/b","lin/c","lin/d"}
+	start:=time.Now()
+	now:=func() int64{ return int64(time.Since(start)) }
+	var mu sync.Mutex
+	var history []Operation
+	var okWrites,pendingWrites int
+
+	done:=make(chan struct{})
+	var wg sync.WaitGroup
+	for c:=0;c<clients;c++{
+		wg.Add(1)
+		go func(c int,seed int64){
+			defer wg.Done()
+			rnd:=rand.New(rand.NewSource(seed))
+			cli:=shardkvclient.MakeClient(cluster.Addrs())
+			defer cli.Close()
+			for n:=0;;n++{
+				select{
+				case <-done:
+					return
+				default:
+				}
+				in:=KvInput{Key:keys[rnd.Intn(len(keys))],Value:fmt.Sprintf("%d.%d ",c,n)}
+				switch r:=rnd.Intn(10);{
+				case r<5:
+					in.Op,in.Value=KvGet,""
+				case r<7:
+					in.Op=KvPut
+				case r<9:
+					in.Op=KvAppend
+				default:
+					in.Op,in.Value=KvDelete,""
+				}
+				op:=Operation{ClientId:c,Input:in,Call:now()}
+				ctx,cancel:=context.WithTimeout(context.Background(),2*time.Second)
+				var err error
+				switch in.Op{
+				case KvGet:
+					var kv *pb.KeyValue
+					kv,err=cli.Get(ctx,in.Key)
+					if errors.Is(err,shardkvclient.ErrNoKey){
+						op.Output,err=KvOutput{},nil
+					} else if err==nil{
+						op.Output=KvOutput{Value:kv.Value}
+					}
+				case KvPut:
+					_,err=cli.Put(ctx,in.Key,in.Value)
+				case KvAppend:
+					_,err=cli.Append(ctx,in.Key,in.Value)
+				case KvDelete:
+					if err=cli.Delete(ctx,in.Key);errors.Is(err,shardkvclient.ErrNoKey){
+						err=nil
+					}
+				}
+				cancel()
+				op.Return=now()
+				mu.Lock()
+				switch{
+				case err==nil:
+					history=append(history,op)
+					if in.Op!=KvGet{
+						okWrites++
+					}
+				case in.Op!=KvGet:
+					// the write may still apply at any later point
+					op.Return=Pending
+					history=append(history,op)
+					pendingWrites++
+				}
+				mu.Unlock()
+			}
+		}(c,rnd.Int63())
+	}
+
+	for time.Since(start)<duration{
+		time.Sleep(time.Duration(500+rnd.Intn(1000))*time.Millisecond)
+		i:=rnd.Intn(cluster.Size())
+		down:=time.Duration(300+rnd.Intn(1500))*time.Millisecond
+		switch rnd.Intn(3){
+		case 0:
+			t.Logf("%v crash node %d for %v",time.Since(start).Round(time.Millisecond),i,down)
+			cluster.Kill(i)
+			time.Sleep(down)
+			err=cluster.StartNode(i)
+		case 1:
+			t.Logf("%v pause node %d for %v",time.Since(start).Round(time.Millisecond),i,down)
+			cluster.Pause(i)
+			time.Sleep(down)
+			err=cluster.Resume(i)
+		case 2:
+			t.Logf("%v partition node %d for %v",time.Since(start).Round(time.Millisecond),i,down)
+			if err=cluster.Partition([]int{i});err==nil{
+				time.Sleep(down)
+				err=cluster.Heal()
+			}
+		}
+		if err!=nil{
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	t.Logf("%d operations, %d writes acknowledged, %d writes with unknown outcome",len(history),okWrites,pendingWrites)
+	if okWrites==0{
+		t.Fatal("no write succeeded")
+	}
+	res,illegal:=Check(KvModel,history,time.Minute)
+	switch res{
+	case Illegal:
+		t.Fatalf("history is not linearizable, operations on the failing key:\n%s",DescribeKv(illegal))
+	case Unknown:
+		t.Log("checker timed out, result unknown")
+	}
+}
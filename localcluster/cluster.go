@@ -0,0 +1,418 @@
+// Package localcluster runs shardsvr processes of one range group on this
+// machine, each in its own directory, for development and integration tests.
+// Nodes can be killed, restarted, paused and cut off from each other; the
+// last goes through the fault injector, which the nodes always run with.
+package localcluster
+
+import(
+	"io"
+	"os"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+	"bufio"
+	"errors"
+	"context"
+	"os/exec"
+	"strings"
+	"syscall"
+	"path/filepath"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"neweraft/chaos"
+	pb "neweraft/raftpb"
+	"neweraft/shardkvserver"
+)
+
+type Options struct{
+	Nodes int
+	// Dir holds node n's working directory n<n>, its log n<n>.log and the
+	// built binary
+	Dir string
+	// BasePort is the gRPC port of node 0, the others following it; 0 picks
+	// free ports
+	BasePort int
+	// Binary is the shardsvr to run; empty builds ./cmd/shardsvr of the
+	// module the caller runs in
+	Binary string
+	// Fresh removes the nodes' data before the first start
+	Fresh bool
+	// Env is added to the environment of every node
+	Env []string
+	// Log receives the nodes' output, each line prefixed with "[n<n>] ";
+	// nil keeps it in the log files only
+	Log io.Writer
+}
+
+type node struct{
+	id int
+	addr string
+	dir string
+	cmd *exec.Cmd
+	exited chan struct{}
+	paused bool
+	conn *grpc.ClientConn
+}
+
+type Cluster struct{
+	opts Options
+	bin string
+
+	mu sync.Mutex
+	logMu sync.Mutex
+	nodes []*node
+}
+
+func freeAddr() (string,error){
+	lis,err:=net.Listen("tcp","127.0.0.1:0")
+	if err!=nil{
+		return "",err
+	}
+	defer lis.Close()
+	return lis.Addr().String(),nil
+}
+
+// Start writes the configs and starts every node.
+func Start(opts Options) (*Cluster,error){
+	if opts.Nodes<=0{
+		return nil,errors.New("localcluster: no nodes")
+	}
+	dir,err:=filepath.Abs(opts.Dir)
+	if err!=nil{
+		return nil,err
+	}
+	if err:=os.MkdirAll(dir,0755);err!=nil{
+		return nil,err
+	}
+	c:=&Cluster{opts:opts,bin:opts.Binary}
+	c.opts.Dir=dir
+	if c.bin==""{
+		c.bin=filepath.Join(dir,"shardsvr")
+		if out,err:=exec.Command("go","build","-o",c.bin,"neweraft/cmd/shardsvr").CombinedOutput();err!=nil{
+			return nil,fmt.Errorf("localcluster: build shardsvr: %v\n%s",err,out)
+		}
+	}
+	for i:=0;i<opts.Nodes;i++{
+		addr:=fmt.Sprintf("127.0.0.1:%d",opts.BasePort+i)
+		if opts.BasePort==0{
+			if addr,err=freeAddr();err!=nil{
+				return nil,err
+			}
+		}
+		n:=&node{id:i,addr:addr,dir:filepath.Join(dir,fmt.Sprintf("n%d",i))}
+		if n.conn,err=grpc.NewClient(addr,grpc.WithTransportCredentials(insecure.NewCredentials()));err!=nil{
+			return nil,err
+		}
+		c.nodes=append(c.nodes,n)
+	}
+	configs:=[]*shardkvserver.Config{{
+		Num:1,
+		Groups:map[int64][]string{1:c.Addrs()},
+		Ranges:[]*shardkvserver.KeyRange{{Gid:1}},
+	}}
+	config,_:=json.Marshal(configs)
+	for _,n:=range c.nodes{
+		if opts.Fresh{
+			if err:=os.RemoveAll(filepath.Join(n.dir,"out","data"));err!=nil{
+				return nil,err
+			}
+		}
+		if err:=os.MkdirAll(filepath.Join(n.dir,"out"),0755);err!=nil{
+			return nil,err
+		}
+		if err:=os.WriteFile(filepath.Join(n.dir,"out","config.json"),config,0644);err!=nil{
+			return nil,err
+		}
+	}
+	for i:=range c.nodes{
+		if err:=c.StartNode(i);err!=nil{
+			c.Stop()
+			return nil,err
+		}
+	}
+	return c,nil
+}
+
+func (c *Cluster)Addrs() []string{
+	addrs:=make([]string,0,len(c.nodes))
+	for _,n:=range c.nodes{
+		addrs=append(addrs,n.addr)
+	}
+	return addrs
+}
+
+func (c *Cluster)Size() int{
+	return len(c.nodes)
+}
+
+func (c *Cluster)node(i int) (*node,error){
+	if i<0 || i>=len(c.nodes){
+		return nil,fmt.Errorf("localcluster: no node %d",i)
+	}
+	return c.nodes[i],nil
+}
+
+// copyLog writes the lines of r to the node's log file and, prefixed, to
+// Options.Log.
+func (c *Cluster)copyLog(n *node,r io.Reader,file *os.File){
+	scanner:=bufio.NewScanner(r)
+	scanner.Buffer(make([]byte,64*1024),1<<20)
+	for scanner.Scan(){
+		line:=scanner.Text()
+		fmt.Fprintln(file,line)
+		if c.opts.Log!=nil{
+			c.logMu.Lock()
+			fmt.Fprintf(c.opts.Log,"[n%d] %s\n",n.id,line)
+			c.logMu.Unlock()
+		}
+	}
+}
+
+// StartNode starts node i unless it runs.
+func (c *Cluster)StartNode(i int) error{
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n,err:=c.node(i)
+	if err!=nil{
+		return err
+	}
+	if n.running(){
+		return nil
+	}
+	file,err:=os.OpenFile(filepath.Join(c.opts.Dir,fmt.Sprintf("n%d.log",i)),os.O_CREATE|os.O_WRONLY|os.O_APPEND,0644)
+	if err!=nil{
+		return err
+	}
+	pr,pw:=io.Pipe()
+	cmd:=exec.Command(c.bin,fmt.Sprint(i),strings.Join(c.Addrs(),","))
+	cmd.Dir=n.dir
+	cmd.Env=append(append(os.Environ(),"SHARDKV_CHAOS=1"),c.opts.Env...)
+	cmd.Stdout,cmd.Stderr=pw,pw
+	if err:=cmd.Start();err!=nil{
+		file.Close()
+		return err
+	}
+	n.cmd,n.paused,n.exited=cmd,false,make(chan struct{})
+	go c.copyLog(n,pr,file)
+	go func(exited chan struct{}){
+		cmd.Wait()
+		pw.Close()
+		file.Close()
+		close(exited)
+	}(n.exited)
+	return nil
+}
+
+func (n *node)running() bool{
+	if n.exited==nil{
+		return false
+	}
+	select{
+	case <-n.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// Kill stops node i at once, as a crash would.
+func (c *Cluster)Kill(i int) error{
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n,err:=c.node(i)
+	if err!=nil{
+		return err
+	}
+	if !n.running(){
+		return nil
+	}
+	n.cmd.Process.Signal(syscall.SIGCONT)
+	n.cmd.Process.Kill()
+	<-n.exited
+	return nil
+}
+
+func (c *Cluster)Restart(i int) error{
+	if err:=c.Kill(i);err!=nil{
+		return err
+	}
+	return c.StartNode(i)
+}
+
+// Pause freezes node i, which to the others looks like a partition that
+// stops its clock as well.
+func (c *Cluster)Pause(i int) error{
+	return c.signal(i,syscall.SIGSTOP,true)
+}
+
+func (c *Cluster)Resume(i int) error{
+	return c.signal(i,syscall.SIGCONT,false)
+}
+
+func (c *Cluster)signal(i int,sig syscall.Signal,paused bool) error{
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n,err:=c.node(i)
+	if err!=nil{
+		return err
+	}
+	if !n.running(){
+		return fmt.Errorf("localcluster: node %d is down",i)
+	}
+	n.paused=paused
+	return n.cmd.Process.Signal(sig)
+}
+
+func (c *Cluster)setFaults(i int,rules []*pb.FaultRule) error{
+	c.mu.Lock()
+	n:=c.nodes[i]
+	running,paused:=n.running(),n.paused
+	c.mu.Unlock()
+	if !running{
+		return fmt.Errorf("localcluster: node %d is down",i)
+	}
+	if paused{
+		return fmt.Errorf("localcluster: node %d is paused",i)
+	}
+	ctx,cancel:=context.WithTimeout(context.Background(),2*time.Second)
+	defer cancel()
+	_,err:=pb.NewMessageServiceClient(c.nodes[i].conn).SetFaults(ctx,&pb.SetFaultsRequest{Rules:rules})
+	return err
+}
+
+// Partition cuts the cluster into sides that do not hear each other: the
+// given ones, plus one side of the nodes named in none. A node that is down
+// or paused misses its rules; the others still drop its calls.
+func (c *Cluster)Partition(sides ...[]int) error{
+	sideOf:=make([]int,len(c.nodes))
+	for s,side:=range sides{
+		for _,i:=range side{
+			if _,err:=c.node(i);err!=nil{
+				return err
+			}
+			sideOf[i]=s+1
+		}
+	}
+	var errs []error
+	for i:=range c.nodes{
+		var rules []*pb.FaultRule
+		for j,peer:=range c.nodes{
+			if sideOf[j]!=sideOf[i]{
+				rules=append(rules,&pb.FaultRule{Peer:peer.addr,Action:chaos.ActionDrop})
+			}
+		}
+		if err:=c.setFaults(i,rules);err!=nil{
+			errs=append(errs,fmt.Errorf("node %d: %v",i,err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Heal drops the fault rules of every node.
+func (c *Cluster)Heal() error{
+	var errs []error
+	for i:=range c.nodes{
+		if err:=c.setFaults(i,nil);err!=nil{
+			errs=append(errs,fmt.Errorf("node %d: %v",i,err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NodeStatus is what node Id answered to Status, Err when it did not.
+type NodeStatus struct{
+	Id int
+	Addr string
+	Running bool
+	Paused bool
+	Groups []*pb.RaftStatus
+	Err error
+}
+
+func (c *Cluster)Status() []*NodeStatus{
+	c.mu.Lock()
+	statuses:=make([]*NodeStatus,len(c.nodes))
+	for i,n:=range c.nodes{
+		statuses[i]=&NodeStatus{Id:i,Addr:n.addr,Running:n.running(),Paused:n.paused}
+	}
+	c.mu.Unlock()
+	var wg sync.WaitGroup
+	for i,st:=range statuses{
+		if !st.Running || st.Paused{
+			continue
+		}
+		wg.Add(1)
+		go func(i int,st *NodeStatus){
+			defer wg.Done()
+			ctx,cancel:=context.WithTimeout(context.Background(),time.Second)
+			defer cancel()
+			res,err:=pb.NewMessageServiceClient(c.nodes[i].conn).Status(ctx,&pb.StatusRequest{})
+			if err!=nil{
+				st.Err=err
+				return
+			}
+			st.Groups=res.Groups
+		}(i,st)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// Leader returns the node leading gid in the highest term any node reports,
+// once another node, if any, follows it in that term.
+func (c *Cluster)Leader(gid int64) (int,*pb.RaftStatus,bool){
+	var leader *pb.RaftStatus
+	var term int64
+	followers:=0
+	statuses:=c.Status()
+	for _,st:=range statuses{
+		for _,rs:=range st.Groups{
+			if rs.GroupId==gid && rs.Term>term{
+				term=rs.Term
+			}
+		}
+	}
+	for _,st:=range statuses{
+		for _,rs:=range st.Groups{
+			if rs.GroupId!=gid || rs.Term!=term{
+				continue
+			}
+			if rs.Role=="leader"{
+				leader=rs
+			} else if rs.LeaderId>=0{
+				followers++
+			}
+		}
+	}
+	if leader==nil || (followers==0 && len(c.nodes)>1){
+		return -1,nil,false
+	}
+	return int(leader.Id),leader,true
+}
+
+// WaitLeader polls until gid has a leader or ctx ends.
+func (c *Cluster)WaitLeader(ctx context.Context,gid int64) (int,*pb.RaftStatus,error){
+	for{
+		if id,rs,ok:=c.Leader(gid);ok{
+			return id,rs,nil
+		}
+		select{
+		case <-ctx.Done():
+			return -1,nil,ctx.Err()
+		case <-time.After(100*time.Millisecond):
+		}
+	}
+}
+
+// Stop kills every node.
+func (c *Cluster)Stop(){
+	for i:=range c.nodes{
+		c.Kill(i)
+	}
+	for _,n:=range c.nodes{
+		n.conn.Close()
+	}
+}
@@ -0,0 +1,325 @@
+// Package metrics keeps counters, gauges and histograms in memory and writes
+// them in the Prometheus text exposition format.
+package metrics
+
+import(
+	"io"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"bufio"
+	"strings"
+	"net/http"
+	"sync/atomic"
+)
+
+var(
+	// LatencyBuckets are upper bounds in seconds, from half a millisecond up.
+	LatencyBuckets = []float64{.0005,.001,.0025,.005,.01,.025,.05,.1,.25,.5,1,2.5,5}
+	// SizeBuckets are upper bounds in bytes, from 64B to 64MB.
+	SizeBuckets = []float64{64,256,1024,4096,16384,65536,262144,1<<20,4<<20,16<<20,64<<20}
+)
+
+type metricKind string
+
+const(
+	kindCounter metricKind="counter"
+	kindGauge metricKind="gauge"
+	kindHistogram metricKind="histogram"
+)
+
+// family is one metric name with its children, one per set of label values.
+type family struct{
+	name string
+	help string
+	kind metricKind
+	labels []string
+	buckets []float64
+
+	mu sync.RWMutex
+	children map[string]interface{}
+	values map[string][]string
+}
+
+// Registry holds metric families and the hooks run before every scrape, which
+// is where gauges mirroring some other state get refreshed.
+type Registry struct{
+	mu sync.Mutex
+	families map[string]*family
+	hooks []func()
+}
+
+func NewRegistry() *Registry{
+	return &Registry{families:make(map[string]*family)}
+}
+
+var Default = NewRegistry()
+
+func (reg *Registry)register(name string,help string,kind metricKind,labels []string,buckets []float64) *family{
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if f,ok:=reg.families[name];ok{
+		if f.kind!=kind || len(f.labels)!=len(labels){
+			panic("metrics: "+name+" registered twice with different kinds or labels")
+		}
+		return f
+	}
+	f:=&family{
+		name:name,
+		help:help,
+		kind:kind,
+		labels:labels,
+		buckets:buckets,
+		children:make(map[string]interface{}),
+		values:make(map[string][]string),
+	}
+	reg.families[name]=f
+	return f
+}
+
+// OnScrape adds fn to the hooks run before the registry is written out.
+func (reg *Registry)OnScrape(fn func()){
+	reg.mu.Lock()
+	reg.hooks=append(reg.hooks,fn)
+	reg.mu.Unlock()
+}
+
+func OnScrape(fn func()){
+	Default.OnScrape(fn)
+}
+
+func (f *family)child(values []string,make func() interface{}) interface{}{
+	if len(values)!=len(f.labels){
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d",f.name,len(f.labels),len(values)))
+	}
+	key:=strings.Join(values,"\xff")
+	f.mu.RLock()
+	c,ok:=f.children[key]
+	f.mu.RUnlock()
+	if ok{
+		return c
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c,ok:=f.children[key];ok{
+		return c
+	}
+	c=make()
+	f.children[key]=c
+	f.values[key]=append([]string{},values...)
+	return c
+}
+
+// delete drops the child with the given label values, e.g. of a group that
+// left the node.
+func (f *family)delete(values []string){
+	key:=strings.Join(values,"\xff")
+	f.mu.Lock()
+	delete(f.children,key)
+	delete(f.values,key)
+	f.mu.Unlock()
+}
+
+type Counter struct{
+	bits uint64
+}
+
+func (c *Counter)Add(v float64){
+	for{
+		old:=atomic.LoadUint64(&c.bits)
+		if atomic.CompareAndSwapUint64(&c.bits,old,math.Float64bits(math.Float64frombits(old)+v)){
+			return
+		}
+	}
+}
+
+func (c *Counter)Inc(){
+	c.Add(1)
+}
+
+func (c *Counter)value() float64{
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+type Gauge struct{
+	Counter
+}
+
+func (g *Gauge)Set(v float64){
+	atomic.StoreUint64(&g.bits,math.Float64bits(v))
+}
+
+type Histogram struct{
+	mu sync.Mutex
+	upper []float64
+	counts []uint64
+	count uint64
+	sum float64
+}
+
+func (h *Histogram)Observe(v float64){
+	i:=sort.SearchFloat64s(h.upper,v)
+	h.mu.Lock()
+	if i<len(h.counts){
+		h.counts[i]++
+	}
+	h.count++
+	h.sum+=v
+	h.mu.Unlock()
+}
+
+type CounterVec struct{ f *family }
+type GaugeVec struct{ f *family }
+type HistogramVec struct{ f *family }
+
+func (reg *Registry)NewCounter(name string,help string,labels ...string) *CounterVec{
+	return &CounterVec{reg.register(name,help,kindCounter,labels,nil)}
+}
+
+func (reg *Registry)NewGauge(name string,help string,labels ...string) *GaugeVec{
+	return &GaugeVec{reg.register(name,help,kindGauge,labels,nil)}
+}
+
+func (reg *Registry)NewHistogram(name string,help string,buckets []float64,labels ...string) *HistogramVec{
+	return &HistogramVec{reg.register(name,help,kindHistogram,labels,buckets)}
+}
+
+func NewCounter(name string,help string,labels ...string) *CounterVec{
+	return Default.NewCounter(name,help,labels...)
+}
+
+func NewGauge(name string,help string,labels ...string) *GaugeVec{
+	return Default.NewGauge(name,help,labels...)
+}
+
+func NewHistogram(name string,help string,buckets []float64,labels ...string) *HistogramVec{
+	return Default.NewHistogram(name,help,buckets,labels...)
+}
+
+func (cv *CounterVec)With(values ...string) *Counter{
+	return cv.f.child(values,func() interface{}{ return &Counter{} }).(*Counter)
+}
+
+func (cv *CounterVec)Delete(values ...string){
+	cv.f.delete(values)
+}
+
+func (gv *GaugeVec)With(values ...string) *Gauge{
+	return gv.f.child(values,func() interface{}{ return &Gauge{} }).(*Gauge)
+}
+
+func (gv *GaugeVec)Delete(values ...string){
+	gv.f.delete(values)
+}
+
+func (hv *HistogramVec)With(values ...string) *Histogram{
+	return hv.f.child(values,func() interface{}{
+		return &Histogram{upper:hv.f.buckets,counts:make([]uint64,len(hv.f.buckets))}
+	}).(*Histogram)
+}
+
+func (hv *HistogramVec)Delete(values ...string){
+	hv.f.delete(values)
+}
+
+var labelEscaper = strings.NewReplacer("\\",`\\`,"\n",`\n`,"\"",`\"`)
+
+func formatLabels(names []string,values []string,extra ...string) string{
+	if len(names)==0 && len(extra)==0{
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i,name:=range names{
+		if i>0{
+			sb.WriteString(",")
+		}
+		sb.WriteString(name+"=\""+labelEscaper.Replace(values[i])+"\"")
+	}
+	for i:=0;i+1<len(extra);i+=2{
+		if len(names)>0 || i>0{
+			sb.WriteString(",")
+		}
+		sb.WriteString(extra[i]+"=\""+extra[i+1]+"\"")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func formatFloat(v float64) string{
+	switch{
+	case math.IsInf(v,1):
+		return "+Inf"
+	case math.IsInf(v,-1):
+		return "-Inf"
+	}
+	return fmt.Sprint(v)
+}
+
+func (f *family)write(w *bufio.Writer){
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if len(f.children)==0{
+		return
+	}
+	fmt.Fprintf(w,"# HELP %s %s\n# TYPE %s %s\n",f.name,strings.ReplaceAll(f.help,"\n"," "),f.name,f.kind)
+	keys:=make([]string,0,len(f.children))
+	for key:=range f.children{
+		keys=append(keys,key)
+	}
+	sort.Strings(keys)
+	for _,key:=range keys{
+		values:=f.values[key]
+		switch c:=f.children[key].(type){
+		case *Counter:
+			fmt.Fprintf(w,"%s%s %s\n",f.name,formatLabels(f.labels,values),formatFloat(c.value()))
+		case *Gauge:
+			fmt.Fprintf(w,"%s%s %s\n",f.name,formatLabels(f.labels,values),formatFloat(c.value()))
+		case *Histogram:
+			c.mu.Lock()
+			var cum uint64
+			for i,upper:=range c.upper{
+				cum+=c.counts[i]
+				fmt.Fprintf(w,"%s_bucket%s %d\n",f.name,formatLabels(f.labels,values,"le",formatFloat(upper)),cum)
+			}
+			fmt.Fprintf(w,"%s_bucket%s %d\n",f.name,formatLabels(f.labels,values,"le","+Inf"),c.count)
+			fmt.Fprintf(w,"%s_sum%s %s\n",f.name,formatLabels(f.labels,values),formatFloat(c.sum))
+			fmt.Fprintf(w,"%s_count%s %d\n",f.name,formatLabels(f.labels,values),c.count)
+			c.mu.Unlock()
+		}
+	}
+}
+
+// WriteText runs the scrape hooks and writes every family with at least one
+// child, sorted by name.
+func (reg *Registry)WriteText(out io.Writer) error{
+	reg.mu.Lock()
+	hooks:=append([]func(){},reg.hooks...)
+	reg.mu.Unlock()
+	for _,hook:=range hooks{
+		hook()
+	}
+	reg.mu.Lock()
+	families:=make([]*family,0,len(reg.families))
+	for _,f:=range reg.families{
+		families=append(families,f)
+	}
+	reg.mu.Unlock()
+	sort.Slice(families,func(i,j int) bool{ return families[i].name<families[j].name })
+	w:=bufio.NewWriter(out)
+	for _,f:=range families{
+		f.write(w)
+	}
+	return w.Flush()
+}
+
+func (reg *Registry)ServeHTTP(w http.ResponseWriter,r *http.Request){
+	w.Header().Set("Content-Type","text/plain; version=0.0.4; charset=utf-8")
+	reg.WriteText(w)
+}
+
+// Handler serves the default registry, for mounting at /metrics.
+func Handler() http.Handler{
+	return Default
+}
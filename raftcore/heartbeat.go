@@ -0,0 +1,197 @@
+package raftcore
+
+import(
+	"sync"
+	"time"
+	"context"
+
+	pb "neweraft/raftpb"
+)
+
+// Heartbeater batches the idle heartbeats of every raft group on this node
+// that are headed to the same peer address into one Heartbeat rpc. Its loop
+// only runs while some live raft is registered, so killing the rafts of a
+// node stops it too.
+type Heartbeater struct{
+	mu sync.Mutex
+	running bool
+
+	rafts map[int64]*Raft
+	pending map[string]map[int64]*pb.HeartbeatMsg
+	flushTime time.Duration
+}
+
+func MakeHeartbeater(flushTime time.Duration) *Heartbeater{
+	hb:=&Heartbeater{
+		rafts:make(map[int64]*Raft),
+		pending:make(map[string]map[int64]*pb.HeartbeatMsg),
+		flushTime:flushTime,
+	}
+	return hb
+}
+
+func (hb *Heartbeater) Register(raft *Raft){
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	hb.rafts[raft.groupId]=raft
+	if !hb.running{
+		hb.running=true
+		go hb.Tick()
+	}
+}
+
+func (hb *Heartbeater) Unregister(groupId int64){
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	delete(hb.rafts,groupId)
+}
+
+func (hb *Heartbeater) Add(addr string,msg *pb.HeartbeatMsg){
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	if _,ok:=hb.pending[addr];!ok{
+		hb.pending[addr]=make(map[int64]*pb.HeartbeatMsg)
+	}
+	hb.pending[addr][msg.GroupId]=msg
+}
+
+func (hb *Heartbeater) Tick(){
+	for{
+		time.Sleep(hb.flushTime)
+		if !hb.alive(){
+			return
+		}
+		hb.flush()
+	}
+}
+
+// alive drops the killed rafts and reports whether any is left; when none
+// is, the loop is marked stopped under the same lock so Register restarts it.
+// Rafts call Add holding their own lock, so isKill runs outside hb.mu.
+func (hb *Heartbeater) alive() bool{
+	hb.mu.Lock()
+	rafts:=make([]*Raft,0,len(hb.rafts))
+	for _,raft:=range hb.rafts{
+		rafts=append(rafts,raft)
+	}
+	hb.mu.Unlock()
+	var dead []*Raft
+	for _,raft:=range rafts{
+		if raft.isKill(){
+			dead=append(dead,raft)
+		}
+	}
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	for _,raft:=range dead{
+		if hb.rafts[raft.groupId]==raft{
+			delete(hb.rafts,raft.groupId)
+		}
+	}
+	if len(hb.rafts)==0{
+		hb.running=false
+		hb.pending=make(map[string]map[int64]*pb.HeartbeatMsg)
+		return false
+	}
+	return true
+}
+
+func (hb *Heartbeater) flush(){
+	hb.mu.Lock()
+	pending:=hb.pending
+	hb.pending=make(map[string]map[int64]*pb.HeartbeatMsg)
+	hb.mu.Unlock()
+
+	for addr,msgs:=range pending{
+		req:=&pb.HeartbeatRequest{Heartbeats:make([]*pb.HeartbeatMsg,0,len(msgs))}
+		for _,msg:=range msgs{
+			req.Heartbeats=append(req.Heartbeats,msg)
+		}
+		go hb.send(addr,req)
+	}
+}
+
+func (hb *Heartbeater) send(addr string,req *pb.HeartbeatRequest){
+	conn,err:=GetSharedConn(addr)
+	if err!=nil{
+		return
+	}
+	ctx,cancel:=context.WithTimeout(context.Background(),200*time.Millisecond)
+	defer cancel()
+	res,err:=pb.NewMessageServiceClient(conn).Heartbeat(ctx,req)
+	if err!=nil{
+		return
+	}
+	for _,result:=range res.Results{
+		hb.mu.Lock()
+		raft,ok:=hb.rafts[result.GroupId]
+		hb.mu.Unlock()
+		if ok{
+			raft.handleHeartbeatResult(result)
+		}
+	}
+}
+
+func (raft *Raft) makeHeartbeatMsg() *pb.HeartbeatMsg{
+	lastIdx:=raft.rflog.GetLastIdx()
+	return &pb.HeartbeatMsg{
+		GroupId:raft.groupId,
+		CurTerm:raft.curTerm,
+		LeaderId:raft.id,
+		CommitIndex:raft.commitIndex,
+		LastLogIndex:lastIdx,
+		LastLogTerm:raft.rflog.GetEntry(lastIdx).CurTerm,
+	}
+}
+
+func (raft *Raft) HandleHeartbeat(msg *pb.HeartbeatMsg,res *pb.HeartbeatResult){
+	raft.mu.Lock()
+	defer raft.mu.Unlock()
+	res.GroupId=raft.groupId
+	res.PeerId=raft.id
+	res.Term=raft.curTerm
+
+	if msg.CurTerm<raft.curTerm{
+		res.Success=false
+		return
+	}
+	if msg.CurTerm>raft.curTerm{
+		raft.curTerm=msg.CurTerm
+		raft.voteFor=-1
+		raft.MakePersistState()
+	}
+	raft.switchRole(RaftFollower)
+	raft.leaderId=msg.LeaderId
+	res.Term=raft.curTerm
+
+	raft.electionTimer.Reset(raft.electionTime)
+
+	if msg.LastLogIndex>raft.rflog.GetLastIdx() || raft.rflog.GetEntry(msg.LastLogIndex).CurTerm!=msg.LastLogTerm{
+		res.Success=false
+		return
+	}
+	newCommitIdx:=msg.CommitIndex
+	if newCommitIdx>msg.LastLogIndex{
+		newCommitIdx=msg.LastLogIndex
+	}
+	raft.commitTo(newCommitIdx)
+	res.Success=true
+}
+
+func (raft *Raft) handleHeartbeatResult(result *pb.HeartbeatResult){
+	raft.mu.Lock()
+	defer raft.mu.Unlock()
+	if raft.role!=RaftLeader{
+		return
+	}
+	if result.Term>raft.curTerm{
+		raft.curTerm=result.Term
+		raft.voteFor=-1
+		raft.switchRole(RaftFollower)
+		raft.MakePersistState()
+		return
+	}
+	if !result.Success && result.PeerId>=0 && result.PeerId<int64(len(raft.peers)){
+		go raft.replicateOneround(raft.peers[result.PeerId])
+	}
+}
@@ -0,0 +1,78 @@
+package raftcore
+
+import(
+	"io"
+	"time"
+	"testing"
+
+	pb "neweraft/raftpb"
+	"neweraft/storage"
+)
+
+func heartbeaterRunning(hb *Heartbeater) bool{
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	return hb.running
+}
+
+func TestHeartbeaterStopsWithItsRafts(t *testing.T){
+	defer SetLogger(GetLogger())
+	ConfigureLogging(io.Discard,"error",false)
+	hb:=MakeHeartbeater(5*time.Millisecond)
+	if heartbeaterRunning(hb){
+		t.Fatal("loop running before any raft registered")
+	}
+	start:=func(gid int64) *Raft{
+		eng:=storage.Engineerfactory("leveldb",t.TempDir())
+		t.Cleanup(func(){ eng.Close() })
+		peers:=[]*RaftClient{MakeRaftClient("127.0.0.1:1",0),MakeRaftClient("127.0.0.1:2",1)}
+		return MakeRaft(1,gid,peers,eng,make(chan *ApplyMsg,16),hb)
+	}
+	waitStopped:=func(){
+		deadline:=time.Now().Add(time.Second)
+		for heartbeaterRunning(hb){
+			if time.Now().After(deadline){
+				t.Fatal("loop still running after its rafts were killed")
+			}
+			time.Sleep(5*time.Millisecond)
+		}
+	}
+
+	r1:=start(1)
+	if !heartbeaterRunning(hb){
+		t.Fatal("loop not started by Register")
+	}
+	r1.Kill()
+	waitStopped()
+
+	r2:=start(2)
+	if !heartbeaterRunning(hb){
+		t.Fatal("loop not restarted by a later Register")
+	}
+	r2.Kill()
+	waitStopped()
+}
+
+func TestHeartbeatMarksCommitted(t *testing.T){
+	defer SetLogger(GetLogger())
+	ConfigureLogging(io.Discard,"error",false)
+	eng:=storage.Engineerfactory("leveldb",t.TempDir())
+	defer eng.Close()
+	peers:=[]*RaftClient{MakeRaftClient("127.0.0.1:1",0),MakeRaftClient("127.0.0.1:2",1)}
+	raft:=MakeRaft(1,1,peers,eng,make(chan *ApplyMsg,16),MakeHeartbeater(time.Hour))
+	defer raft.Kill()
+
+	raft.mu.Lock()
+	raft.rflog.AppendLogEntries([]*pb.Entry{{CurTerm:1,Index:1}})
+	raft.mu.Unlock()
+	res:=&pb.HeartbeatResult{}
+	raft.HandleHeartbeat(&pb.HeartbeatMsg{CurTerm:1,LeaderId:0,CommitIndex:1,LastLogIndex:1,LastLogTerm:1},res)
+	if !res.Success{
+		t.Fatal("heartbeat rejected")
+	}
+	raft.mu.Lock()
+	defer raft.mu.Unlock()
+	if _,ok:=raft.commitTimes[1];raft.commitIndex!=1 || !ok{
+		t.Fatalf("commit index %d, commit time recorded %v",raft.commitIndex,ok)
+	}
+}
@@ -0,0 +1,88 @@
+package raftcore
+
+import(
+	"io"
+	"os"
+	"strings"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Logger is what raftcore and the servers on top of it log through. Args are
+// alternating keys and values, as with log/slog.
+type Logger interface{
+	Debug(msg string,args ...any)
+	Info(msg string,args ...any)
+	Warn(msg string,args ...any)
+	Error(msg string,args ...any)
+	With(args ...any) Logger
+}
+
+type slogLogger struct{
+	l *slog.Logger
+}
+
+func NewSlogLogger(l *slog.Logger) Logger{
+	return &slogLogger{l:l}
+}
+
+func (sl *slogLogger)Debug(msg string,args ...any){ sl.l.Debug(msg,args...) }
+func (sl *slogLogger)Info(msg string,args ...any){ sl.l.Info(msg,args...) }
+func (sl *slogLogger)Warn(msg string,args ...any){ sl.l.Warn(msg,args...) }
+func (sl *slogLogger)Error(msg string,args ...any){ sl.l.Error(msg,args...) }
+
+func (sl *slogLogger)With(args ...any) Logger{
+	return &slogLogger{l:sl.l.With(args...)}
+}
+
+type loggerHolder struct{
+	logger Logger
+}
+
+var processLogger atomic.Value
+
+func init(){
+	processLogger.Store(&loggerHolder{NewSlogLogger(slog.New(slog.NewTextHandler(os.Stderr,nil)))})
+}
+
+// SetLogger replaces the process logger. Records already carrying context
+// pick it up on their next call, since the context is added per call.
+func SetLogger(l Logger){
+	processLogger.Store(&loggerHolder{l})
+}
+
+func GetLogger() Logger{
+	return processLogger.Load().(*loggerHolder).logger
+}
+
+// ConfigureLogging makes the process logger slog writing to w at level
+// (debug, info, warn or error), one JSON object per record when json is set.
+func ConfigureLogging(w io.Writer,level string,json bool) error{
+	var lvl slog.Level
+	if level!=""{
+		if err:=lvl.UnmarshalText([]byte(strings.ToUpper(level)));err!=nil{
+			return err
+		}
+	}
+	opts:=&slog.HandlerOptions{Level:lvl}
+	var handler slog.Handler=slog.NewTextHandler(w,opts)
+	if json{
+		handler=slog.NewJSONHandler(w,opts)
+	}
+	SetLogger(NewSlogLogger(slog.New(handler)))
+	return nil
+}
+
+// logger carries the node's identity and current term and role. The caller
+// holds raft.mu.
+func (raft *Raft)logger() Logger{
+	return GetLogger().With("node",raft.id,"group",raft.groupId,"term",raft.curTerm,"role",raft.role.String())
+}
+
+// Logger is logger for callers not holding the raft lock, such as the state
+// machine on top.
+func (raft *Raft) Logger() Logger{
+	raft.mu.RLock()
+	defer raft.mu.RUnlock()
+	return raft.logger()
+}
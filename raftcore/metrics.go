@@ -0,0 +1,120 @@
+package raftcore
+
+import(
+	"sync"
+	"time"
+	"strconv"
+
+	"neweraft/metrics"
+)
+
+var(
+	electionsStarted = metrics.NewCounter("raft_elections_started_total","Elections this node started as candidate.","group")
+	electionsWon = metrics.NewCounter("raft_elections_won_total","Elections this node won.","group")
+	termChanges = metrics.NewCounter("raft_term_changes_total","Times this node moved to a new term.","group")
+	entriesAppended = metrics.NewCounter("raft_entries_appended_total","Entries appended to the local log, proposed here or received from the leader.","group")
+	appendSeconds = metrics.NewHistogram("raft_append_entries_seconds","Latency of AppendEntry RPCs sent by the leader.",metrics.LatencyBuckets,"group","peer")
+	appendFailures = metrics.NewCounter("raft_append_entries_failures_total","AppendEntry RPCs sent by the leader that got no answer.","group","peer")
+	commitToApply = metrics.NewHistogram("raft_commit_to_apply_seconds","Time from the commit index advancing to the entries up to it being handed to the state machine.",metrics.LatencyBuckets,"group")
+
+	termGauge = metrics.NewGauge("raft_term","Current term.","group")
+	leaderGauge = metrics.NewGauge("raft_is_leader","1 when this node leads the group.","group")
+	commitGauge = metrics.NewGauge("raft_commit_index","Highest log index known committed.","group")
+	appliedGauge = metrics.NewGauge("raft_applied_index","Highest log index handed to the state machine.","group")
+	lastIndexGauge = metrics.NewGauge("raft_last_log_index","Index of the last entry in the local log.","group")
+	peerLagGauge = metrics.NewGauge("raft_peer_lag_entries","Entries the peer is behind the leader's log, set on the leader only.","group","peer")
+)
+
+// raftMetrics caches the children of one Raft so the hot paths skip the label
+// lookups.
+type raftMetrics struct{
+	group string
+	electionsStarted *metrics.Counter
+	electionsWon *metrics.Counter
+	termChanges *metrics.Counter
+	entriesAppended *metrics.Counter
+	commitToApply *metrics.Histogram
+	appendSeconds map[int64]*metrics.Histogram
+	appendFailures map[int64]*metrics.Counter
+}
+
+func makeRaftMetrics(id int64,groupId int64,peers []*RaftClient) *raftMetrics{
+	group:=strconv.FormatInt(groupId,10)
+	m:=&raftMetrics{
+		group:group,
+		electionsStarted:electionsStarted.With(group),
+		electionsWon:electionsWon.With(group),
+		termChanges:termChanges.With(group),
+		entriesAppended:entriesAppended.With(group),
+		commitToApply:commitToApply.With(group),
+		appendSeconds:make(map[int64]*metrics.Histogram),
+		appendFailures:make(map[int64]*metrics.Counter),
+	}
+	for _,peer:=range peers{
+		if peer.id==id{
+			continue
+		}
+		peerId:=strconv.FormatInt(peer.id,10)
+		m.appendSeconds[peer.id]=appendSeconds.With(group,peerId)
+		m.appendFailures[peer.id]=appendFailures.With(group,peerId)
+	}
+	return m
+}
+
+// liveRafts are the instances whose state the scrape hook mirrors in gauges.
+var liveRafts sync.Map
+
+func init(){
+	metrics.OnScrape(func(){
+		liveRafts.Range(func(key,_ interface{}) bool{
+			raft:=key.(*Raft)
+			status:=raft.Status()
+			group:=raft.metrics.group
+			termGauge.With(group).Set(float64(status.Term))
+			leader:=0.0
+			if status.Role==RaftLeader.String(){
+				leader=1
+			}
+			leaderGauge.With(group).Set(leader)
+			commitGauge.With(group).Set(float64(status.CommitIndex))
+			appliedGauge.With(group).Set(float64(status.AppliedIndex))
+			lastIndexGauge.With(group).Set(float64(status.LastIndex))
+			for _,peer:=range status.Peers{
+				peerId:=strconv.FormatInt(peer.Id,10)
+				if leader==1{
+					peerLagGauge.With(group,peerId).Set(float64(peer.Lag))
+				} else {
+					peerLagGauge.Delete(group,peerId)
+				}
+			}
+			return true
+		})
+	})
+}
+
+func (raft *Raft)forgetMetrics(){
+	liveRafts.Delete(raft)
+	group:=raft.metrics.group
+	for _,gauge:=range []*metrics.GaugeVec{termGauge,leaderGauge,commitGauge,appliedGauge,lastIndexGauge}{
+		gauge.Delete(group)
+	}
+	for _,peer:=range raft.peers{
+		peerLagGauge.Delete(group,strconv.FormatInt(peer.id,10))
+	}
+}
+
+// markCommitted notes when the commit index reached idx, for the
+// commit-to-apply latency.
+func (raft *Raft)markCommitted(idx int64){
+	raft.commitTimes[idx]=time.Now()
+}
+
+func (raft *Raft)observeApplied(idx int64){
+	now:=time.Now()
+	for committed,at:=range raft.commitTimes{
+		if committed<=idx{
+			raft.metrics.commitToApply.Observe(now.Sub(at).Seconds())
+			delete(raft.commitTimes,committed)
+		}
+	}
+}
@@ -1,16 +1,14 @@
 package raftcore
 
 import(
-	"log"
 	"sync"
 	"time"
 	"context"
 	"math/rand"
-	"bytes"
-	"encoding/gob"
 
 	pb "neweraft/raftpb"
 	"neweraft/storage"
+	"neweraft/tracing"
 )
 
 type RaftRole int
@@ -21,6 +19,15 @@ const(
 	RaftLeader
 )
 
+type ApplyMsg struct{
+	CommandValid bool
+	Command []byte
+	CommandTerm int64
+	CommandIndex int64
+	// Trace is the span the apply of a traced entry hangs under
+	Trace tracing.SpanContext
+}
+
 type Raft struct {
 	mu sync.RWMutex
 
@@ -30,10 +37,18 @@ type Raft struct {
 	matchIndexs []int64
 	commitIndex int64
 	appliedIndex int64
+	// dispatchedIndex is the last entry handed to applyCh; appliedIndex
+	// follows it once the state machine calls Applied
+	dispatchedIndex int64
+
+	applyCh chan *ApplyMsg
+	applyCond *sync.Cond
 
 	id int64
+	groupId int64
 	leaderId int64
 	deadIf bool
+	stopCh chan struct{}
 	role RaftRole
 	curTerm int64
 	peers []*RaftClient
@@ -45,14 +60,21 @@ type Raft struct {
 
 	heartTimer *time.Timer
 	heartTime time.Duration
+	heartbeater *Heartbeater
+
+	metrics *raftMetrics
+	commitTimes map[int64]time.Time
+	persistedTerm int64
+	traces map[int64]entryTrace
 }
 
-func MakeRaft(id int64,peers []*RaftClient,logeng storage.KvStore) *Raft{
+func MakeRaft(id int64,groupId int64,peers []*RaftClient,logeng storage.KvStore,applyCh chan *ApplyMsg,heartbeater *Heartbeater) *Raft{
 	electionTime:=time.Duration(500 + rand.Intn(150)) * time.Millisecond
 	heartTime:=100*time.Millisecond
 	lenSize:=int64(len(peers))
 	raft:=&Raft{
 		id:id,
+		groupId:groupId,
 		role:RaftFollower,
 		countVote:0,
 		voteFor:-1,
@@ -60,6 +82,7 @@ func MakeRaft(id int64,peers []*RaftClient,logeng storage.KvStore) *Raft{
 		electionTimer:time.NewTimer(electionTime),
 		peers:peers,
 		deadIf:false,
+		stopCh:make(chan struct{}),
 		curTerm:0,
 		electionTime: electionTime,
 		heartTime: heartTime,
@@ -68,16 +91,30 @@ func MakeRaft(id int64,peers []*RaftClient,logeng storage.KvStore) *Raft{
 		matchIndexs:make([]int64, lenSize),
 		nextIndexs:make([]int64, lenSize),
 		commitIndex:0,
-		leaderId:id,
+		leaderId:-1,
+		applyCh:applyCh,
+		heartbeater:heartbeater,
+		metrics:makeRaftMetrics(id,groupId,peers),
+		commitTimes:make(map[int64]time.Time),
+		traces:make(map[int64]entryTrace),
 	}
+	raft.applyCond=sync.NewCond(&raft.mu)
 	newRaftPersistentState:=raft.GetPersistState()
 	raft.curTerm=newRaftPersistentState.CurTerm
+	raft.persistedTerm=raft.curTerm
 	raft.voteFor=newRaftPersistentState.VoteFor
 	raft.appliedIndex=newRaftPersistentState.AppliedIdx
-	
+	raft.commitIndex=raft.appliedIndex
+	raft.dispatchedIndex=raft.appliedIndex
+
 	raft.heartTimer.Stop()
 	raft.electionTimer.Reset(raft.electionTime)
-	go raft.Tick() 
+	if raft.heartbeater!=nil{
+		raft.heartbeater.Register(raft)
+	}
+	liveRafts.Store(raft,struct{}{})
+	go raft.Tick()
+	go raft.Applier()
 
 	return raft
 }
@@ -86,46 +123,199 @@ func(raft *Raft) Tick(){
 	for !raft.isKill() {
 		select{
 		case <-raft.electionTimer.C:
+			raft.mu.Lock()
 			if(raft.role==RaftLeader){
+				raft.mu.Unlock()
 				break
 			}
 			raft.switchRole(RaftCandidate)
-			raft.electionTimer.Reset(raft.electionTime)
+			raft.election()
+			if raft.role!=RaftLeader{
+				raft.electionTimer.Reset(raft.electionTime)
+			}
+			raft.mu.Unlock()
 		case <-raft.heartTimer.C:
-			raft.broadcastHeart()
-			raft.heartTimer.Reset(raft.heartTime)
+			raft.mu.Lock()
+			if raft.role==RaftLeader{
+				raft.broadcastIdleHeart()
+				raft.heartTimer.Reset(raft.heartTime)
+			}
+			raft.mu.Unlock()
+		case <-raft.stopCh:
+			return
 		}
 	}
 }
 
+func (role RaftRole) String() string{
+	switch role{
+	case RaftFollower:
+		return "follower"
+	case RaftCandidate:
+		return "candidate"
+	case RaftLeader:
+		return "leader"
+	}
+	return "unknown"
+}
+
 func (raft *Raft)switchRole(newRole RaftRole) {
-	raft.mu.Lock()
 	if raft.role==newRole{
-		raft.mu.Unlock()
 		return
 	}
-	log.Printf("Node %d state change: %d -> %d (Term %d)", raft.id, raft.role, newRole, raft.curTerm)
+	raft.logger().Info("state change","to",newRole.String())
 	raft.role=newRole
-	raft.mu.Unlock()
 
 	switch newRole{
-	case RaftCandidate:
-		raft.election()
 	case RaftFollower:
-		raft.heartTimer.Stop()	
+		raft.heartTimer.Stop()
+		raft.electionTimer.Reset(raft.electionTime)
 	case RaftLeader:
-		raft.electionTimer.Stop()	
+		raft.metrics.electionsWon.Inc()
+		raft.leaderId=raft.id
+		lastIdx:=raft.rflog.GetLastIdx()
+		for i:=range raft.peers{
+			raft.nextIndexs[i]=lastIdx+1
+			raft.matchIndexs[i]=0
+		}
+		raft.matchIndexs[raft.id]=lastIdx
+		raft.electionTimer.Stop()
 		raft.heartTimer.Reset(raft.heartTime)
 	}
-
-	raft.MakePersistState()
-	return
-} 
+}
 
 func(raft *Raft) isKill() bool{
+	raft.mu.RLock()
+	defer raft.mu.RUnlock()
 	return raft.deadIf
 }
 
+func (raft *Raft) Killed() bool{
+	return raft.isKill()
+}
+
+func (raft *Raft) Kill(){
+	raft.mu.Lock()
+	if raft.deadIf{
+		raft.mu.Unlock()
+		return
+	}
+	raft.deadIf=true
+	raft.electionTimer.Stop()
+	raft.heartTimer.Stop()
+	close(raft.stopCh)
+	raft.applyCond.Broadcast()
+	raft.mu.Unlock()
+	if raft.heartbeater!=nil{
+		raft.heartbeater.Unregister(raft.groupId)
+	}
+	raft.forgetMetrics()
+}
+
+func (raft *Raft) GetState() (int64,bool){
+	raft.mu.RLock()
+	defer raft.mu.RUnlock()
+	return raft.curTerm,raft.role==RaftLeader
+}
+
+func (raft *Raft) GetGroupId() int64{
+	return raft.groupId
+}
+
+func (raft *Raft) GetLeaderId() int64{
+	raft.mu.RLock()
+	defer raft.mu.RUnlock()
+	return raft.leaderId
+}
+
+// Status is a consistent snapshot of the node's view of its group.
+// NextIndex, MatchIndex and Lag are only tracked, and set, on the leader.
+func (raft *Raft) Status() *pb.RaftStatus{
+	raft.mu.RLock()
+	defer raft.mu.RUnlock()
+	firstIdx,lastIdx:=raft.rflog.GetFirstIdx(),raft.rflog.GetLastIdx()
+	status:=&pb.RaftStatus{
+		GroupId:raft.groupId,
+		Id:raft.id,
+		Role:raft.role.String(),
+		Term:raft.curTerm,
+		VoteFor:raft.voteFor,
+		LeaderId:raft.leaderId,
+		CommitIndex:raft.commitIndex,
+		AppliedIndex:raft.appliedIndex,
+		FirstIndex:firstIdx,
+		LastIndex:lastIdx,
+	}
+	for i,peer:=range raft.peers{
+		ps:=&pb.PeerStatus{Id:peer.GetId(),Addr:peer.GetAddr()}
+		if raft.role==RaftLeader{
+			ps.NextIndex,ps.MatchIndex=raft.nextIndexs[i],raft.matchIndexs[i]
+			ps.Lag=lastIdx-raft.matchIndexs[i]
+			if int64(i)==raft.id{
+				ps.MatchIndex,ps.Lag=lastIdx,0
+			}
+		}
+		status.Peers=append(status.Peers,ps)
+	}
+	return status
+}
+
+// GetCommittedEntries returns the committed log entries in [fIdx,lIdx],
+// clipped to what the log still holds.
+func (raft *Raft) GetCommittedEntries(fIdx int64,lIdx int64) []*pb.Entry{
+	raft.mu.RLock()
+	defer raft.mu.RUnlock()
+	if firstIdx:=raft.rflog.GetFirstIdx();fIdx<firstIdx{
+		fIdx=firstIdx
+	}
+	if lIdx>raft.commitIndex{
+		lIdx=raft.commitIndex
+	}
+	if fIdx>lIdx{
+		return nil
+	}
+	return raft.rflog.GetEntries(fIdx,lIdx)
+}
+
+func (raft *Raft) HasLogInCurrentTerm() bool{
+	raft.mu.RLock()
+	defer raft.mu.RUnlock()
+	return raft.rflog.GetLastTerm()==raft.curTerm
+}
+
+func (raft *Raft) Propose(data []byte) (int64,int64,bool){
+	return raft.ProposeContext(context.Background(),data)
+}
+
+// ProposeContext is Propose tracing the entry under the span in ctx: the
+// leader's append, its replication to each peer, the followers' appends and
+// every node's apply.
+func (raft *Raft) ProposeContext(ctx context.Context,data []byte) (int64,int64,bool){
+	raft.mu.Lock()
+	defer raft.mu.Unlock()
+	if raft.role!=RaftLeader{
+		return -1,-1,false
+	}
+	_,span:=tracing.StartSpan(ctx,"raft.propose","node",raft.id,"group",raft.groupId,"term",raft.curTerm)
+	newEntry:=&pb.Entry{
+		EntryType:pb.Entrytype_EntryNormal,
+		CurTerm:raft.curTerm,
+		Index:raft.rflog.GetLastIdx()+1,
+		Date:data,
+	}
+	raft.rflog.AppendLogEntries([]*pb.Entry{newEntry})
+	raft.metrics.entriesAppended.Inc()
+	raft.matchIndexs[raft.id]=newEntry.Index
+	raft.nextIndexs[raft.id]=newEntry.Index+1
+	span.SetAttr("index",newEntry.Index)
+	span.End()
+	if span!=nil{
+		raft.traces[newEntry.Index]=entryTrace{term:newEntry.CurTerm,span:span.Context()}
+	}
+	raft.advanceCommitIndex()
+	raft.broadcastHeart()
+	return newEntry.Index,newEntry.CurTerm,true
+}
 
 func (raft *Raft)broadcastHeart(){
 	for _, peer:=range raft.peers{
@@ -137,58 +327,148 @@ func (raft *Raft)broadcastHeart(){
 	}
 }
 
+func (raft *Raft)broadcastIdleHeart(){
+	if raft.heartbeater==nil{
+		raft.broadcastHeart()
+		return
+	}
+	lastIdx:=raft.rflog.GetLastIdx()
+	msg:=raft.makeHeartbeatMsg()
+	for _, peer:=range raft.peers{
+		if(peer.id==raft.id){
+			continue
+		}
+		if raft.matchIndexs[peer.id]==lastIdx{
+			raft.heartbeater.Add(peer.addr,msg)
+		} else {
+			go raft.replicateOneround(peer)
+		}
+	}
+}
+
 func (raft *Raft)replicateOneround(peer *RaftClient) {
+	raft.mu.RLock()
 	if raft.role!=RaftLeader{
+		raft.mu.RUnlock()
 		return
 	}
-
-
-
+	preLogIndex:=raft.nextIndexs[peer.id]-1
 	appendEntryRequest:=&pb.AppendEntryRequest{
 		CurTerm:raft.curTerm,
-		LeaderId:raft.leaderId,
-		PreLogIndex:raft.nextIndexs[peer.id]-1,
-		PreLogTerm:raft.rflog.GetEntry(raft.nextIndexs[peer.id]-1).CurTerm,
-		Entries:raft.rflog.GetEntries(raft.nextIndexs[peer.id],raft.rflog.GetLastIdx()),
+		LeaderId:raft.id,
+		PreLogIndex:preLogIndex,
+		PreLogTerm:raft.rflog.GetEntry(preLogIndex).CurTerm,
+		CommitIndex:raft.commitIndex,
+		Entries:raft.rflog.GetEntries(preLogIndex+1,raft.rflog.GetLastIdx()),
+		GroupId:raft.groupId,
 	}
-	ctx,cancel:=context.WithTimeout(context.Background(),200 * time.Millisecond)
+	traceCtx,spans:=raft.startReplicateSpans(context.Background(),peer.id,appendEntryRequest.Entries)
+	raft.mu.RUnlock()
+
+	ctx,cancel:=context.WithTimeout(traceCtx,200 * time.Millisecond)
 	defer cancel()
+	start:=time.Now()
 	appendEntryResponse,err:=peer.MessageServiceClient.AppendEntry(ctx,appendEntryRequest)
+	endReplicateSpans(spans,appendEntryResponse,err)
 	if err!=nil {
-		peer=MakeRaftClient(peer.addr,peer.id)
-		raft.peers[peer.id]=peer
-		appendEntryResponse,_=peer.MessageServiceClient.AppendEntry(ctx,appendEntryRequest)
-		// log.Printf("AppendEntryResponse %d error: %v",peer.id, err)
+		raft.metrics.appendFailures[peer.id].Inc()
+		raft.Logger().Debug("append entry failed","peer",peer.id,"err",err)
+		return
+	}
+	raft.metrics.appendSeconds[peer.id].Observe(time.Since(start).Seconds())
+
+	raft.mu.Lock()
+	defer raft.mu.Unlock()
+	if raft.role!=RaftLeader || raft.curTerm!=appendEntryRequest.CurTerm{
+		return
+	}
+	if appendEntryResponse.Success{
+		newMatchIdx:=appendEntryRequest.PreLogIndex+int64(len(appendEntryRequest.Entries))
+		if newMatchIdx>raft.matchIndexs[peer.id]{
+			raft.matchIndexs[peer.id]=newMatchIdx
+		}
+		raft.nextIndexs[peer.id]=raft.matchIndexs[peer.id]+1
+
+		//commit通知
+		raft.advanceCommitIndex()
 	} else {
-		if appendEntryResponse.Success{
-			raft.matchIndexs[peer.id]=appendEntryRequest.PreLogIndex
-			raft.nextIndexs[peer.id]=raft.matchIndexs[peer.id]+1
-			
-			//commit通知
+		if appendEntryResponse.Term>raft.curTerm{
+			raft.curTerm=appendEntryResponse.Term
+			raft.voteFor=-1
+			raft.switchRole(RaftFollower)
+			raft.MakePersistState()
+		} else if appendEntryResponse.ConflictIndex>0 && appendEntryResponse.ConflictIndex<=preLogIndex{
+			raft.nextIndexs[peer.id]=appendEntryResponse.ConflictIndex
+			go raft.replicateOneround(peer)
+		}
+	}
+}
 
-		} else {
-			if appendEntryResponse.Term<raft.curTerm && appendEntryResponse.ConflictIndex<raft.rflog.GetFirstIdx() {
-				raft.nextIndexs[peer.id]=appendEntryResponse.ConflictIndex
-				raft.replicateOneround(peer)
-			} else if appendEntryResponse.Term>raft.curTerm{
-				raft.switchRole(RaftFollower)
+func (raft *Raft)advanceCommitIndex(){
+	for n:=raft.rflog.GetLastIdx();n>raft.commitIndex;n--{
+		if raft.rflog.GetEntry(n).CurTerm!=raft.curTerm{
+			break
+		}
+		count:=0
+		for i:=range raft.peers{
+			if raft.matchIndexs[i]>=n{
+				count++
 			}
 		}
-	}	
+		if count>len(raft.peers)/2{
+			raft.commitTo(n)
+			break
+		}
+	}
+}
+
+// commitTo raises the commit index to idx and wakes the applier. Every path
+// that commits goes through it so the commit-to-apply latency sees them all.
+func (raft *Raft)commitTo(idx int64){
+	if idx>raft.commitIndex{
+		raft.commitIndex=idx
+		raft.markCommitted(idx)
+		raft.applyCond.Broadcast()
+	}
+}
+
+func (raft *Raft)isLogUpToDate(lastLogTerm int64,lastLogIndex int64) bool{
+	myLastTerm:=raft.rflog.GetLastTerm()
+	return lastLogTerm>myLastTerm || (lastLogTerm==myLastTerm && lastLogIndex>=raft.rflog.GetLastIdx())
 }
 
 func (raft *Raft)HandleRequestVote(req *pb.VoteRequest,res *pb.VoteResponse){
-	if(raft.curTerm<req.CurTerm){
+	raft.mu.Lock()
+	defer raft.mu.Unlock()
+	defer raft.MakePersistState()
+
+	if req.CurTerm<raft.curTerm || (req.CurTerm==raft.curTerm && raft.voteFor!=-1 && raft.voteFor!=req.SefId){
+		res.CurTerm=raft.curTerm
+		res.VoteGranted=false
+		return
+	}
+	if req.CurTerm>raft.curTerm{
 		raft.switchRole(RaftFollower)
-		res.VoteGranted=true
-	} else {
-		res.VoteGranted=false   
+		raft.curTerm=req.CurTerm
+		raft.voteFor=-1
 	}
+	res.CurTerm=raft.curTerm
+	if !raft.isLogUpToDate(req.LastLogTerm,req.LastLogIndex){
+		res.VoteGranted=false
+		return
+	}
+	raft.voteFor=req.SefId
+	res.VoteGranted=true
 
 	raft.electionTimer.Reset(raft.electionTime)
 }
 
-func (raft *Raft)HandleAppendEntry(req *pb.AppendEntryRequest,res *pb.AppendEntryResponse){
+func (raft *Raft)HandleAppendEntry(ctx context.Context,req *pb.AppendEntryRequest,res *pb.AppendEntryResponse){
+	traces:=incomingEntryTraces(ctx)
+	start:=time.Now()
+	raft.mu.Lock()
+	defer raft.mu.Unlock()
+	defer raft.traceAppended(traces,req,res,start)
 	res.Term=raft.curTerm
 
 	if(req.CurTerm<raft.curTerm){
@@ -199,32 +479,66 @@ func (raft *Raft)HandleAppendEntry(req *pb.AppendEntryRequest,res *pb.AppendEntr
 	if req.CurTerm > raft.curTerm {
 		raft.curTerm = req.CurTerm
 		raft.voteFor = -1
-		raft.switchRole(RaftFollower)
+		raft.MakePersistState()
 	}
+	raft.switchRole(RaftFollower)
+	raft.leaderId=req.LeaderId
+	res.Term=raft.curTerm
 
 	raft.electionTimer.Reset(raft.electionTime)
 
-	if req.PreLogIndex==raft.rflog.GetLastIdx() && req.PreLogTerm==raft.rflog.GetLastTerm(){
-		raft.rflog.AppendLogEntries(req.Entries)
-		res.Success=true
-		res.Term=req.CurTerm
-		raft.curTerm=req.CurTerm
-	} else {
+	lastIdx:=raft.rflog.GetLastIdx()
+	if req.PreLogIndex>lastIdx{
 		res.Success=false
-		res.ConflictIndex=raft.rflog.GetLastIdx()
+		res.ConflictIndex=lastIdx+1
 		res.ConflictTerm=raft.rflog.GetLastTerm()
+		return
+	}
+	if preLogTerm:=raft.rflog.GetEntry(req.PreLogIndex).CurTerm;preLogTerm!=req.PreLogTerm{
+		res.Success=false
+		res.ConflictTerm=preLogTerm
+		res.ConflictIndex=req.PreLogIndex
+		for res.ConflictIndex>raft.rflog.GetFirstIdx()+1 && raft.rflog.GetEntry(res.ConflictIndex-1).CurTerm==preLogTerm{
+			res.ConflictIndex--
+		}
+		return
+	}
+
+	for i,entry:=range req.Entries{
+		if entry.Index>lastIdx || raft.rflog.GetEntry(entry.Index).CurTerm!=entry.CurTerm{
+			raft.rflog.EraseAfter(entry.Index)
+			raft.rflog.AppendLogEntries(req.Entries[i:])
+			raft.metrics.entriesAppended.Add(float64(len(req.Entries)-i))
+			break
+		}
 	}
+
+	newCommitIdx:=req.CommitIndex
+	if lastNewIdx:=req.PreLogIndex+int64(len(req.Entries));lastNewIdx<newCommitIdx{
+		newCommitIdx=lastNewIdx
+	}
+	raft.commitTo(newCommitIdx)
+	res.Success=true
 }
 
 func(raft *Raft) election(){
-	raft.mu.Lock()
-	
 	raft.curTerm++
+	raft.metrics.electionsStarted.Inc()
 	raft.voteFor = raft.id
 	raft.countVote=1
+	raft.MakePersistState()
 
-	raft.mu.Unlock()
-
+	voteRequest:=&pb.VoteRequest{
+		CurTerm:raft.curTerm,
+		SefId:raft.id,
+		LastLogIndex:raft.rflog.GetLastIdx(),
+		LastLogTerm:raft.rflog.GetLastTerm(),
+		GroupId:raft.groupId,
+	}
+	if raft.countVote>int64((len(raft.peers))/2) {
+		raft.switchRole(RaftLeader)
+		return
+	}
 
 	for _,peer:=range raft.peers{
 		if(peer.id==raft.id){
@@ -232,53 +546,101 @@ func(raft *Raft) election(){
 		}
 
 		go func(p *RaftClient){
-			voteRequest:=&pb.VoteRequest{
-				CurTerm:raft.curTerm,
-				SefId:raft.id,
-			}
-
 			ctx,cancel :=context.WithTimeout(context.Background(),200 * time.Millisecond)
 			defer cancel()
 			voteResponse,err:=p.MessageServiceClient.RequestVote(ctx,voteRequest)
 			if err!=nil {
-				log.Printf("voteResponse %d error: %v",p.id, err)
+				raft.Logger().Warn("request vote failed","peer",p.id,"err",err)
 				return
 			}
 
+			raft.mu.Lock()
+			defer raft.mu.Unlock()
+			if raft.role!=RaftCandidate || raft.curTerm!=voteRequest.CurTerm{
+				return
+			}
+			if voteResponse.CurTerm>raft.curTerm{
+				raft.curTerm=voteResponse.CurTerm
+				raft.voteFor=-1
+				raft.switchRole(RaftFollower)
+				raft.MakePersistState()
+				return
+			}
 			if voteResponse.VoteGranted {
-				raft.mu.Lock()
 				raft.countVote++
-				raft.mu.Unlock()
 			}
 
 			if raft.countVote>int64((len(raft.peers))/2) {
 				raft.switchRole(RaftLeader)
+				raft.broadcastHeart()
 			}
 		}(peer)
 	}
 }
 
+func (raft *Raft) Applier(){
+	for !raft.isKill(){
+		raft.mu.Lock()
+		for raft.dispatchedIndex>=raft.commitIndex && !raft.deadIf{
+			raft.applyCond.Wait()
+		}
+		if raft.deadIf{
+			raft.mu.Unlock()
+			return
+		}
+		dispatchedIdx,commitIdx:=raft.dispatchedIndex,raft.commitIndex
+		entries:=raft.rflog.GetEntries(dispatchedIdx+1,commitIdx)
+		traces:=raft.takeTraces(entries)
+		raft.mu.Unlock()
+
+		for i,entry:=range entries{
+			msg:=&ApplyMsg{
+				CommandValid:true,
+				Command:entry.Date,
+				CommandTerm:entry.CurTerm,
+				CommandIndex:entry.Index,
+			}
+			if traces!=nil{
+				msg.Trace=traces[i]
+			}
+			raft.applyCh<-msg
+		}
+
+		raft.mu.Lock()
+		if commitIdx>raft.dispatchedIndex{
+			raft.dispatchedIndex=commitIdx
+		}
+		raft.mu.Unlock()
+	}
+}
+
+// Applied tells raft the state machine has written the entries up to idx.
+// Only then is the applied index persisted, so entries a crash caught half
+// applied are handed out again on restart.
+func (raft *Raft) Applied(idx int64){
+	raft.mu.Lock()
+	defer raft.mu.Unlock()
+	if idx<=raft.appliedIndex{
+		return
+	}
+	raft.appliedIndex=idx
+	raft.MakePersistState()
+	raft.observeApplied(idx)
+}
+
 func (raft *Raft) MakePersistState() error{
-	newPesistState:=&RaftPersistentState{
+	if raft.curTerm!=raft.persistedTerm{
+		raft.metrics.termChanges.Inc()
+		raft.persistedTerm=raft.curTerm
+	}
+	return WritePersistState(raft.logEng,&RaftPersistentState{
 		CurTerm:raft.curTerm,
 		VoteFor:raft.voteFor,
 		AppliedIdx:raft.appliedIndex,
-	}
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	err := enc.Encode(newPesistState)
-	if err != nil{
-		return err
-	}
-	raft.logEng.PutByte(RaftStateKey,buf.Bytes())
-	return nil
+	})
 }
 
 func (raft *Raft) GetPersistState() *RaftPersistentState{
-	RaftPersistentStateByte,_ := raft.logEng.GetByte(RaftStateKey)
-	buf := bytes.NewBuffer(RaftPersistentStateByte)
-	dec := gob.NewDecoder(buf)
-	newRaftPersistentState:=&RaftPersistentState{}
-	dec.Decode(newRaftPersistentState)
-	return newRaftPersistentState
-}
\ No newline at end of file
+	state,_:=ReadPersistState(raft.logEng)
+	return state
+}
\ No newline at end of file
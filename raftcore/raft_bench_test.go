@@ -0,0 +1,83 @@
+package raftcore
+
+import(
+	"io"
+	"fmt"
+	"testing"
+	"context"
+
+	pb "neweraft/raftpb"
+	"neweraft/storage"
+)
+
+var benchBatches = []int{1,16,128}
+
+func benchEntries(first int64,n int,payload []byte) []*pb.Entry{
+	entries:=make([]*pb.Entry,n)
+	for i:=range entries{
+		entries[i]=&pb.Entry{EntryType:pb.Entrytype_EntryNormal,CurTerm:1,Index:first+int64(i),Date:payload}
+	}
+	return entries
+}
+
+func BenchmarkRaftLogAppend(b *testing.B){
+	payload:=make([]byte,128)
+	for _,batch:=range benchBatches{
+		b.Run(fmt.Sprintf("batch=%d",batch),func(b *testing.B){
+			eng:=storage.Engineerfactory("leveldb",b.TempDir())
+			defer eng.Close()
+			rflog:=MakeRaftLog(eng)
+			b.SetBytes(int64(batch*len(payload)))
+			b.ResetTimer()
+			for i:=0;i<b.N;i++{
+				if err:=rflog.AppendLogEntries(benchEntries(rflog.GetLastIdx()+1,batch,payload));err!=nil{
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkHandleAppendEntry feeds a follower the requests a leader of term 1
+// would send, committing everything but the batch at hand.
+func BenchmarkHandleAppendEntry(b *testing.B){
+	defer SetLogger(GetLogger())
+	ConfigureLogging(io.Discard,"error",false)
+	payload:=make([]byte,128)
+	for _,batch:=range benchBatches{
+		b.Run(fmt.Sprintf("batch=%d",batch),func(b *testing.B){
+			eng:=storage.Engineerfactory("leveldb",b.TempDir())
+			defer eng.Close()
+			peers:=[]*RaftClient{MakeRaftClient("127.0.0.1:1",0),MakeRaftClient("127.0.0.1:2",1),MakeRaftClient("127.0.0.1:3",2)}
+			applyCh:=make(chan *ApplyMsg,1024)
+			raft:=MakeRaft(1,1,peers,eng,applyCh,nil)
+			defer raft.Kill()
+			go func(){
+				for range applyCh{
+				}
+			}()
+			prevIdx:=raft.rflog.GetLastIdx()
+			prevTerm:=raft.rflog.GetLastTerm()
+			b.SetBytes(int64(batch*len(payload)))
+			b.ResetTimer()
+			for i:=0;i<b.N;i++{
+				req:=&pb.AppendEntryRequest{
+					CurTerm:1,
+					LeaderId:0,
+					PreLogIndex:prevIdx,
+					PreLogTerm:prevTerm,
+					CommitIndex:prevIdx,
+					Entries:benchEntries(prevIdx+1,batch,payload),
+					GroupId:1,
+				}
+				res:=&pb.AppendEntryResponse{}
+				raft.HandleAppendEntry(context.Background(),req,res)
+				if !res.Success{
+					b.Fatalf("append at %d rejected: %+v",prevIdx+1,res)
+				}
+				prevIdx+=int64(batch)
+				prevTerm=1
+			}
+		})
+	}
+}
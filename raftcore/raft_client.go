@@ -1,13 +1,37 @@
 package raftcore
 
 import(
-	"log"
+	"sync"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	pb "neweraft/raftpb"
 )
 
+var connPool = struct{
+	mu sync.Mutex
+	conns map[string]*grpc.ClientConn
+}{conns:make(map[string]*grpc.ClientConn)}
+
+// SharedConnOptions are added to the dial options of the shared conns, e.g.
+// interceptors. They are set before the first conn is made.
+var SharedConnOptions []grpc.DialOption
+
+func GetSharedConn(addr string) (*grpc.ClientConn,error){
+	connPool.mu.Lock()
+	defer connPool.mu.Unlock()
+	if conn,ok:=connPool.conns[addr];ok{
+		return conn,nil
+	}
+	opts:=append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},SharedConnOptions...)
+	conn,err:=grpc.NewClient(addr,opts...)
+	if err!=nil{
+		return nil,err
+	}
+	connPool.conns[addr]=conn
+	return conn,nil
+}
+
 type RaftClient struct{
 	id int64
 	addr string
@@ -19,14 +43,18 @@ func (raftcli *RaftClient) GetId() int64 {
 	return raftcli.id
 }
 
+func (raftcli *RaftClient) GetAddr() string {
+	return raftcli.addr
+}
+
 func (raftCli *RaftClient) GetMessageService() pb.MessageServiceClient{
 	return raftCli.MessageServiceClient
 }
 
 func MakeRaftClient(addrMe string,idMe int64) *RaftClient{
-	connMe,err:=grpc.NewClient(addrMe,grpc.WithTransportCredentials(insecure.NewCredentials()))
+	connMe,err:=GetSharedConn(addrMe)
 	if err!=nil{
-		log.Println("RaftClient conn err")
+		GetLogger().Error("raft client conn failed","peer",idMe,"addr",addrMe,"err",err)
 	}
 	messageServiceClientMe:=pb.NewMessageServiceClient(connMe)
 	return &RaftClient{
@@ -35,4 +63,4 @@ func MakeRaftClient(addrMe string,idMe int64) *RaftClient{
 		conn:connMe,
 		MessageServiceClient:messageServiceClientMe,
 	}
-}
\ No newline at end of file
+}
@@ -26,6 +26,28 @@ type RaftPersistentState struct{
 	AppliedIdx int64
 }
 
+// ReadPersistState decodes the term, vote and applied index kept in eng,
+// the zero state voting for no one when there is none.
+func ReadPersistState(eng storage.KvStore) (*RaftPersistentState,error){
+	stateByte,err:=eng.GetByte(RaftStateKey)
+	if err!=nil{
+		return &RaftPersistentState{VoteFor:-1},nil
+	}
+	state:=&RaftPersistentState{}
+	if err:=gob.NewDecoder(bytes.NewBuffer(stateByte)).Decode(state);err!=nil{
+		return state,err
+	}
+	return state,nil
+}
+
+func WritePersistState(eng storage.KvStore,state *RaftPersistentState) error{
+	var buf bytes.Buffer
+	if err:=gob.NewEncoder(&buf).Encode(state);err!=nil{
+		return err
+	}
+	return eng.PutByte(RaftStateKey,buf.Bytes())
+}
+
 func MakeRaftLog(newdbeng storage.KvStore) *RaftLog{
 	newRaftLog:=&RaftLog{
 		firstIndex:0,
@@ -132,7 +154,7 @@ func (rflog *RaftLog)GetLastTerm() int64{
 	if LIBerr!=nil{
 		return 0
 	}
-	newEntryByte,NEBerr := rflog.dbeng.GetByte(lastIdxByte)
+	newEntryByte,NEBerr := rflog.dbeng.GetByte(append(RaftLogPrefix,lastIdxByte...))
 	if NEBerr !=nil{
 		return 0
 	}
@@ -179,5 +201,21 @@ func (rflog *RaftLog)AppendLogEntries(newEntries []*pb.Entry) error{
 		rflog.lastIndex = newEntry.Index
 	}
 
+	return nil
+}
+
+func (rflog *RaftLog)EraseAfter(idx int64) error{
+	lastIdx:=rflog.GetLastIdx()
+	for i:=idx;i<=lastIdx;i++{
+		err:=rflog.dbeng.DelByte(append(RaftLogPrefix,rflog.Int64toBytes(i)...))
+		if err!=nil{
+			return err
+		}
+	}
+	rflog.dbeng.PutByte(append(RaftLogPrefix,LastIdxKey...),rflog.Int64toBytes(idx-1))
+	if idx-rflog.firstIndex>=0 && idx-rflog.firstIndex<int64(len(rflog.entries)){
+		rflog.entries=rflog.entries[:idx-rflog.firstIndex]
+	}
+	rflog.lastIndex=idx-1
 	return nil
 }
\ No newline at end of file
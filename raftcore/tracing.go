@@ -0,0 +1,122 @@
+package raftcore
+
+import(
+	"time"
+	"context"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	pb "neweraft/raftpb"
+	"neweraft/tracing"
+)
+
+// entryTraceKey carries "index:span context" of each traced entry in an
+// AppendEntry, so followers can hang their steps under the proposal's trace.
+const entryTraceKey = "raft-entry-trace"
+
+// entryTrace is the span the next steps of the entry at some index hang
+// under, as long as the entry there still has term.
+type entryTrace struct{
+	term int64
+	span tracing.SpanContext
+}
+
+// startReplicateSpans opens a span for each traced entry sent to peer and
+// returns them with ctx carrying their contexts. The caller holds raft.mu.
+func (raft *Raft)startReplicateSpans(ctx context.Context,peer int64,entries []*pb.Entry) (context.Context,[]*tracing.Span){
+	if len(raft.traces)==0{
+		return ctx,nil
+	}
+	var spans []*tracing.Span
+	for _,entry:=range entries{
+		tr,ok:=raft.traces[entry.Index]
+		if !ok || tr.term!=entry.CurTerm{
+			continue
+		}
+		span:=tracing.Start(tr.span,"raft.replicate","node",raft.id,"group",raft.groupId,"peer",peer,"index",entry.Index,"batch",len(entries))
+		if span==nil{
+			continue
+		}
+		spans=append(spans,span)
+		ctx=metadata.AppendToOutgoingContext(ctx,entryTraceKey,strconv.FormatInt(entry.Index,10)+":"+span.Context().String())
+	}
+	return ctx,spans
+}
+
+func endReplicateSpans(spans []*tracing.Span,res *pb.AppendEntryResponse,err error){
+	for _,span:=range spans{
+		if err!=nil{
+			span.SetAttr("err",err)
+		} else {
+			span.SetAttr("success",res.Success)
+		}
+		span.End()
+	}
+}
+
+// incomingEntryTraces reads the entry span contexts the leader sent along.
+func incomingEntryTraces(ctx context.Context) map[int64]tracing.SpanContext{
+	md,ok:=metadata.FromIncomingContext(ctx)
+	if !ok{
+		return nil
+	}
+	var traces map[int64]tracing.SpanContext
+	for _,v:=range md.Get(entryTraceKey){
+		idxStr,scStr,_:=strings.Cut(v,":")
+		idx,err:=strconv.ParseInt(idxStr,10,64)
+		sc,ok:=tracing.ParseSpanContext(scStr)
+		if err!=nil || !ok{
+			continue
+		}
+		if traces==nil{
+			traces=make(map[int64]tracing.SpanContext)
+		}
+		traces[idx]=sc
+	}
+	return traces
+}
+
+// traceAppended records the follower's handling of the traced entries of req,
+// and keeps the spans for their apply. The caller holds raft.mu.
+func (raft *Raft)traceAppended(traces map[int64]tracing.SpanContext,req *pb.AppendEntryRequest,res *pb.AppendEntryResponse,start time.Time){
+	if len(traces)==0{
+		return
+	}
+	for _,entry:=range req.Entries{
+		sc,ok:=traces[entry.Index]
+		if !ok{
+			continue
+		}
+		span:=tracing.Start(sc,"raft.follower_append","node",raft.id,"group",raft.groupId,"index",entry.Index,"success",res.Success)
+		if span==nil{
+			continue
+		}
+		span.Start=start
+		span.End()
+		if res.Success{
+			raft.traces[entry.Index]=entryTrace{term:entry.CurTerm,span:span.Context()}
+		}
+	}
+}
+
+// takeTraces returns the spans the applies of entries hang under, dropping
+// the kept ones up to the last of them. The caller holds raft.mu.
+func (raft *Raft)takeTraces(entries []*pb.Entry) []tracing.SpanContext{
+	if len(raft.traces)==0 || len(entries)==0{
+		return nil
+	}
+	scs:=make([]tracing.SpanContext,len(entries))
+	for i,entry:=range entries{
+		if tr,ok:=raft.traces[entry.Index];ok && tr.term==entry.CurTerm{
+			scs[i]=tr.span
+		}
+	}
+	last:=entries[len(entries)-1].Index
+	for idx:=range raft.traces{
+		if idx<=last{
+			delete(raft.traces,idx)
+		}
+	}
+	return scs
+}
@@ -71,6 +71,9 @@ type VoteRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	CurTerm       int64                  `protobuf:"varint,1,opt,name=CurTerm,proto3" json:"CurTerm,omitempty"`
 	SefId         int64                  `protobuf:"varint,2,opt,name=SefId,proto3" json:"SefId,omitempty"`
+	LastLogIndex  int64                  `protobuf:"varint,3,opt,name=LastLogIndex,proto3" json:"LastLogIndex,omitempty"`
+	LastLogTerm   int64                  `protobuf:"varint,4,opt,name=LastLogTerm,proto3" json:"LastLogTerm,omitempty"`
+	GroupId       int64                  `protobuf:"varint,5,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -119,6 +122,27 @@ func (x *VoteRequest) GetSefId() int64 {
 	return 0
 }
 
+func (x *VoteRequest) GetLastLogIndex() int64 {
+	if x != nil {
+		return x.LastLogIndex
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetLastLogTerm() int64 {
+	if x != nil {
+		return x.LastLogTerm
+	}
+	return 0
+}
+
+func (x *VoteRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
 type VoteResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	CurTerm       int64                  `protobuf:"varint,1,opt,name=CurTerm,proto3" json:"CurTerm,omitempty"`
@@ -179,6 +203,7 @@ type AppendEntryRequest struct {
 	PreLogTerm    int64                  `protobuf:"varint,4,opt,name=PreLogTerm,proto3" json:"PreLogTerm,omitempty"`
 	CommitIndex   int64                  `protobuf:"varint,5,opt,name=CommitIndex,proto3" json:"CommitIndex,omitempty"`
 	Entries       []*Entry               `protobuf:"bytes,6,rep,name=Entries,proto3" json:"Entries,omitempty"`
+	GroupId       int64                  `protobuf:"varint,7,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -255,6 +280,13 @@ func (x *AppendEntryRequest) GetEntries() []*Entry {
 	return nil
 }
 
+func (x *AppendEntryRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
 type AppendEntryResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Term          int64                  `protobuf:"varint,1,opt,name=Term,proto3" json:"Term,omitempty"`
@@ -391,42 +423,1147 @@ func (x *Entry) GetDate() []byte {
 	return nil
 }
 
-var File_raftbasic_proto protoreflect.FileDescriptor
+type HeartbeatMsg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	CurTerm       int64                  `protobuf:"varint,2,opt,name=CurTerm,proto3" json:"CurTerm,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,3,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	CommitIndex   int64                  `protobuf:"varint,4,opt,name=CommitIndex,proto3" json:"CommitIndex,omitempty"`
+	LastLogIndex  int64                  `protobuf:"varint,5,opt,name=LastLogIndex,proto3" json:"LastLogIndex,omitempty"`
+	LastLogTerm   int64                  `protobuf:"varint,6,opt,name=LastLogTerm,proto3" json:"LastLogTerm,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_raftbasic_proto_rawDesc = "" +
-	"\n" +
-	"\x0fraftbasic.proto\x12\x06raftpb\"=\n" +
-	"\vVoteRequest\x12\x18\n" +
-	"\aCurTerm\x18\x01 \x01(\x03R\aCurTerm\x12\x14\n" +
-	"\x05SefId\x18\x02 \x01(\x03R\x05SefId\"J\n" +
-	"\fVoteResponse\x12\x18\n" +
-	"\aCurTerm\x18\x01 \x01(\x03R\aCurTerm\x12 \n" +
-	"\vVoteGranted\x18\x02 \x01(\bR\vVoteGranted\"\xd7\x01\n" +
-	"\x12AppendEntryRequest\x12\x18\n" +
-	"\aCurTerm\x18\x01 \x01(\x03R\aCurTerm\x12\x1a\n" +
-	"\bLeaderId\x18\x02 \x01(\x03R\bLeaderId\x12 \n" +
-	"\vPreLogIndex\x18\x03 \x01(\x03R\vPreLogIndex\x12\x1e\n" +
-	"\n" +
-	"PreLogTerm\x18\x04 \x01(\x03R\n" +
-	"PreLogTerm\x12 \n" +
-	"\vCommitIndex\x18\x05 \x01(\x03R\vCommitIndex\x12'\n" +
-	"\aEntries\x18\x06 \x03(\v2\r.raftpb.EntryR\aEntries\"\x8d\x01\n" +
-	"\x13AppendEntryResponse\x12\x12\n" +
-	"\x04Term\x18\x01 \x01(\x03R\x04Term\x12\x18\n" +
-	"\aSuccess\x18\x02 \x01(\bR\aSuccess\x12$\n" +
-	"\rConflictIndex\x18\x03 \x01(\x03R\rConflictIndex\x12\"\n" +
-	"\fConflictTerm\x18\x04 \x01(\x03R\fConflictTerm\"|\n" +
-	"\x05Entry\x12/\n" +
-	"\tEntryType\x18\x01 \x01(\x0e2\x11.raftpb.EntrytypeR\tEntryType\x12\x18\n" +
-	"\aCurTerm\x18\x02 \x01(\x03R\aCurTerm\x12\x14\n" +
-	"\x05Index\x18\x03 \x01(\x03R\x05Index\x12\x12\n" +
-	"\x04date\x18\x04 \x01(\fR\x04date*-\n" +
+func (x *HeartbeatMsg) Reset() {
+	*x = HeartbeatMsg{}
+	mi := &file_raftbasic_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatMsg) ProtoMessage() {}
+
+func (x *HeartbeatMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatMsg.ProtoReflect.Descriptor instead.
+func (*HeartbeatMsg) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *HeartbeatMsg) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *HeartbeatMsg) GetCurTerm() int64 {
+	if x != nil {
+		return x.CurTerm
+	}
+	return 0
+}
+
+func (x *HeartbeatMsg) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *HeartbeatMsg) GetCommitIndex() int64 {
+	if x != nil {
+		return x.CommitIndex
+	}
+	return 0
+}
+
+func (x *HeartbeatMsg) GetLastLogIndex() int64 {
+	if x != nil {
+		return x.LastLogIndex
+	}
+	return 0
+}
+
+func (x *HeartbeatMsg) GetLastLogTerm() int64 {
+	if x != nil {
+		return x.LastLogTerm
+	}
+	return 0
+}
+
+type HeartbeatResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	PeerId        int64                  `protobuf:"varint,2,opt,name=PeerId,proto3" json:"PeerId,omitempty"`
+	Term          int64                  `protobuf:"varint,3,opt,name=Term,proto3" json:"Term,omitempty"`
+	Success       bool                   `protobuf:"varint,4,opt,name=Success,proto3" json:"Success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatResult) Reset() {
+	*x = HeartbeatResult{}
+	mi := &file_raftbasic_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatResult) ProtoMessage() {}
+
+func (x *HeartbeatResult) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatResult.ProtoReflect.Descriptor instead.
+func (*HeartbeatResult) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *HeartbeatResult) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *HeartbeatResult) GetPeerId() int64 {
+	if x != nil {
+		return x.PeerId
+	}
+	return 0
+}
+
+func (x *HeartbeatResult) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *HeartbeatResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type HeartbeatRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Heartbeats    []*HeartbeatMsg        `protobuf:"bytes,1,rep,name=Heartbeats,proto3" json:"Heartbeats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	mi := &file_raftbasic_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HeartbeatRequest) GetHeartbeats() []*HeartbeatMsg {
+	if x != nil {
+		return x.Heartbeats
+	}
+	return nil
+}
+
+type HeartbeatResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*HeartbeatResult     `protobuf:"bytes,1,rep,name=Results,proto3" json:"Results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatResponse) Reset() {
+	*x = HeartbeatResponse{}
+	mi := &file_raftbasic_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatResponse) ProtoMessage() {}
+
+func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
+func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HeartbeatResponse) GetResults() []*HeartbeatResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type PeerStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Addr          string                 `protobuf:"bytes,2,opt,name=Addr,proto3" json:"Addr,omitempty"`
+	NextIndex     int64                  `protobuf:"varint,3,opt,name=NextIndex,proto3" json:"NextIndex,omitempty"`
+	MatchIndex    int64                  `protobuf:"varint,4,opt,name=MatchIndex,proto3" json:"MatchIndex,omitempty"`
+	Lag           int64                  `protobuf:"varint,5,opt,name=Lag,proto3" json:"Lag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerStatus) Reset() {
+	*x = PeerStatus{}
+	mi := &file_raftbasic_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerStatus) ProtoMessage() {}
+
+func (x *PeerStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerStatus.ProtoReflect.Descriptor instead.
+func (*PeerStatus) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PeerStatus) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PeerStatus) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *PeerStatus) GetNextIndex() int64 {
+	if x != nil {
+		return x.NextIndex
+	}
+	return 0
+}
+
+func (x *PeerStatus) GetMatchIndex() int64 {
+	if x != nil {
+		return x.MatchIndex
+	}
+	return 0
+}
+
+func (x *PeerStatus) GetLag() int64 {
+	if x != nil {
+		return x.Lag
+	}
+	return 0
+}
+
+type RaftStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	Id            int64                  `protobuf:"varint,2,opt,name=Id,proto3" json:"Id,omitempty"`
+	Role          string                 `protobuf:"bytes,3,opt,name=Role,proto3" json:"Role,omitempty"`
+	Term          int64                  `protobuf:"varint,4,opt,name=Term,proto3" json:"Term,omitempty"`
+	VoteFor       int64                  `protobuf:"varint,5,opt,name=VoteFor,proto3" json:"VoteFor,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,6,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	CommitIndex   int64                  `protobuf:"varint,7,opt,name=CommitIndex,proto3" json:"CommitIndex,omitempty"`
+	AppliedIndex  int64                  `protobuf:"varint,8,opt,name=AppliedIndex,proto3" json:"AppliedIndex,omitempty"`
+	FirstIndex    int64                  `protobuf:"varint,9,opt,name=FirstIndex,proto3" json:"FirstIndex,omitempty"`
+	LastIndex     int64                  `protobuf:"varint,10,opt,name=LastIndex,proto3" json:"LastIndex,omitempty"`
+	Peers         []*PeerStatus          `protobuf:"bytes,12,rep,name=Peers,proto3" json:"Peers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaftStatus) Reset() {
+	*x = RaftStatus{}
+	mi := &file_raftbasic_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaftStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftStatus) ProtoMessage() {}
+
+func (x *RaftStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftStatus.ProtoReflect.Descriptor instead.
+func (*RaftStatus) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RaftStatus) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *RaftStatus) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RaftStatus) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *RaftStatus) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftStatus) GetVoteFor() int64 {
+	if x != nil {
+		return x.VoteFor
+	}
+	return 0
+}
+
+func (x *RaftStatus) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *RaftStatus) GetCommitIndex() int64 {
+	if x != nil {
+		return x.CommitIndex
+	}
+	return 0
+}
+
+func (x *RaftStatus) GetAppliedIndex() int64 {
+	if x != nil {
+		return x.AppliedIndex
+	}
+	return 0
+}
+
+func (x *RaftStatus) GetFirstIndex() int64 {
+	if x != nil {
+		return x.FirstIndex
+	}
+	return 0
+}
+
+func (x *RaftStatus) GetLastIndex() int64 {
+	if x != nil {
+		return x.LastIndex
+	}
+	return 0
+}
+
+func (x *RaftStatus) GetPeers() []*PeerStatus {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_raftbasic_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StatusRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        int64                  `protobuf:"varint,1,opt,name=NodeId,proto3" json:"NodeId,omitempty"`
+	Addr          string                 `protobuf:"bytes,2,opt,name=Addr,proto3" json:"Addr,omitempty"`
+	Groups        []*RaftStatus          `protobuf:"bytes,3,rep,name=Groups,proto3" json:"Groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_raftbasic_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StatusResponse) GetNodeId() int64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetGroups() []*RaftStatus {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type FaultRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peer          string                 `protobuf:"bytes,1,opt,name=Peer,proto3" json:"Peer,omitempty"`
+	Method        string                 `protobuf:"bytes,2,opt,name=Method,proto3" json:"Method,omitempty"`
+	Direction     string                 `protobuf:"bytes,3,opt,name=Direction,proto3" json:"Direction,omitempty"`
+	Action        string                 `protobuf:"bytes,4,opt,name=Action,proto3" json:"Action,omitempty"`
+	Probability   float64                `protobuf:"fixed64,5,opt,name=Probability,proto3" json:"Probability,omitempty"`
+	DelayMs       int64                  `protobuf:"varint,6,opt,name=DelayMs,proto3" json:"DelayMs,omitempty"`
+	JitterMs      int64                  `protobuf:"varint,7,opt,name=JitterMs,proto3" json:"JitterMs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FaultRule) Reset() {
+	*x = FaultRule{}
+	mi := &file_raftbasic_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FaultRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FaultRule) ProtoMessage() {}
+
+func (x *FaultRule) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FaultRule.ProtoReflect.Descriptor instead.
+func (*FaultRule) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *FaultRule) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *FaultRule) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *FaultRule) GetDirection() string {
+	if x != nil {
+		return x.Direction
+	}
+	return ""
+}
+
+func (x *FaultRule) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *FaultRule) GetProbability() float64 {
+	if x != nil {
+		return x.Probability
+	}
+	return 0
+}
+
+func (x *FaultRule) GetDelayMs() int64 {
+	if x != nil {
+		return x.DelayMs
+	}
+	return 0
+}
+
+func (x *FaultRule) GetJitterMs() int64 {
+	if x != nil {
+		return x.JitterMs
+	}
+	return 0
+}
+
+type SetFaultsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rules         []*FaultRule           `protobuf:"bytes,1,rep,name=Rules,proto3" json:"Rules,omitempty"`
+	Append        bool                   `protobuf:"varint,2,opt,name=Append,proto3" json:"Append,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetFaultsRequest) Reset() {
+	*x = SetFaultsRequest{}
+	mi := &file_raftbasic_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetFaultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetFaultsRequest) ProtoMessage() {}
+
+func (x *SetFaultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetFaultsRequest.ProtoReflect.Descriptor instead.
+func (*SetFaultsRequest) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SetFaultsRequest) GetRules() []*FaultRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *SetFaultsRequest) GetAppend() bool {
+	if x != nil {
+		return x.Append
+	}
+	return false
+}
+
+type SetFaultsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rules         []*FaultRule           `protobuf:"bytes,1,rep,name=Rules,proto3" json:"Rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetFaultsResponse) Reset() {
+	*x = SetFaultsResponse{}
+	mi := &file_raftbasic_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetFaultsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetFaultsResponse) ProtoMessage() {}
+
+func (x *SetFaultsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetFaultsResponse.ProtoReflect.Descriptor instead.
+func (*SetFaultsResponse) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SetFaultsResponse) GetRules() []*FaultRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+type BackupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	SkipData      bool                   `protobuf:"varint,2,opt,name=SkipData,proto3" json:"SkipData,omitempty"`
+	LogStart      int64                  `protobuf:"varint,3,opt,name=LogStart,proto3" json:"LogStart,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupRequest) Reset() {
+	*x = BackupRequest{}
+	mi := &file_raftbasic_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupRequest) ProtoMessage() {}
+
+func (x *BackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupRequest.ProtoReflect.Descriptor instead.
+func (*BackupRequest) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BackupRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *BackupRequest) GetSkipData() bool {
+	if x != nil {
+		return x.SkipData
+	}
+	return false
+}
+
+func (x *BackupRequest) GetLogStart() int64 {
+	if x != nil {
+		return x.LogStart
+	}
+	return 0
+}
+
+type BackupMeta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	NodeId        int64                  `protobuf:"varint,2,opt,name=NodeId,proto3" json:"NodeId,omitempty"`
+	AppliedIndex  int64                  `protobuf:"varint,3,opt,name=AppliedIndex,proto3" json:"AppliedIndex,omitempty"`
+	AppliedTerm   int64                  `protobuf:"varint,4,opt,name=AppliedTerm,proto3" json:"AppliedTerm,omitempty"`
+	Term          int64                  `protobuf:"varint,5,opt,name=Term,proto3" json:"Term,omitempty"`
+	Peers         []string               `protobuf:"bytes,6,rep,name=Peers,proto3" json:"Peers,omitempty"`
+	HasData       bool                   `protobuf:"varint,7,opt,name=HasData,proto3" json:"HasData,omitempty"`
+	LogStart      int64                  `protobuf:"varint,8,opt,name=LogStart,proto3" json:"LogStart,omitempty"`
+	LogEnd        int64                  `protobuf:"varint,9,opt,name=LogEnd,proto3" json:"LogEnd,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupMeta) Reset() {
+	*x = BackupMeta{}
+	mi := &file_raftbasic_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupMeta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupMeta) ProtoMessage() {}
+
+func (x *BackupMeta) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupMeta.ProtoReflect.Descriptor instead.
+func (*BackupMeta) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BackupMeta) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *BackupMeta) GetNodeId() int64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *BackupMeta) GetAppliedIndex() int64 {
+	if x != nil {
+		return x.AppliedIndex
+	}
+	return 0
+}
+
+func (x *BackupMeta) GetAppliedTerm() int64 {
+	if x != nil {
+		return x.AppliedTerm
+	}
+	return 0
+}
+
+func (x *BackupMeta) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *BackupMeta) GetPeers() []string {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *BackupMeta) GetHasData() bool {
+	if x != nil {
+		return x.HasData
+	}
+	return false
+}
+
+func (x *BackupMeta) GetLogStart() int64 {
+	if x != nil {
+		return x.LogStart
+	}
+	return 0
+}
+
+func (x *BackupMeta) GetLogEnd() int64 {
+	if x != nil {
+		return x.LogEnd
+	}
+	return 0
+}
+
+type BackupPair struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           []byte                 `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=Value,proto3" json:"Value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupPair) Reset() {
+	*x = BackupPair{}
+	mi := &file_raftbasic_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupPair) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupPair) ProtoMessage() {}
+
+func (x *BackupPair) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupPair.ProtoReflect.Descriptor instead.
+func (*BackupPair) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BackupPair) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *BackupPair) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type BackupChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Meta          *BackupMeta            `protobuf:"bytes,1,opt,name=Meta,proto3" json:"Meta,omitempty"`
+	Pairs         []*BackupPair          `protobuf:"bytes,2,rep,name=Pairs,proto3" json:"Pairs,omitempty"`
+	Entries       []*Entry               `protobuf:"bytes,3,rep,name=Entries,proto3" json:"Entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupChunk) Reset() {
+	*x = BackupChunk{}
+	mi := &file_raftbasic_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupChunk) ProtoMessage() {}
+
+func (x *BackupChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_raftbasic_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupChunk.ProtoReflect.Descriptor instead.
+func (*BackupChunk) Descriptor() ([]byte, []int) {
+	return file_raftbasic_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BackupChunk) GetMeta() *BackupMeta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+func (x *BackupChunk) GetPairs() []*BackupPair {
+	if x != nil {
+		return x.Pairs
+	}
+	return nil
+}
+
+func (x *BackupChunk) GetEntries() []*Entry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+var File_raftbasic_proto protoreflect.FileDescriptor
+
+const file_raftbasic_proto_rawDesc = "" +
+	"\n" +
+	"\x0fraftbasic.proto\x12\x06raftpb\"\x9d\x01\n" +
+	"\vVoteRequest\x12\x18\n" +
+	"\aCurTerm\x18\x01 \x01(\x03R\aCurTerm\x12\x14\n" +
+	"\x05SefId\x18\x02 \x01(\x03R\x05SefId\x12\"\n" +
+	"\fLastLogIndex\x18\x03 \x01(\x03R\fLastLogIndex\x12 \n" +
+	"\vLastLogTerm\x18\x04 \x01(\x03R\vLastLogTerm\x12\x18\n" +
+	"\aGroupId\x18\x05 \x01(\x03R\aGroupId\"J\n" +
+	"\fVoteResponse\x12\x18\n" +
+	"\aCurTerm\x18\x01 \x01(\x03R\aCurTerm\x12 \n" +
+	"\vVoteGranted\x18\x02 \x01(\bR\vVoteGranted\"\xf1\x01\n" +
+	"\x12AppendEntryRequest\x12\x18\n" +
+	"\aCurTerm\x18\x01 \x01(\x03R\aCurTerm\x12\x1a\n" +
+	"\bLeaderId\x18\x02 \x01(\x03R\bLeaderId\x12 \n" +
+	"\vPreLogIndex\x18\x03 \x01(\x03R\vPreLogIndex\x12\x1e\n" +
+	"\n" +
+	"PreLogTerm\x18\x04 \x01(\x03R\n" +
+	"PreLogTerm\x12 \n" +
+	"\vCommitIndex\x18\x05 \x01(\x03R\vCommitIndex\x12'\n" +
+	"\aEntries\x18\x06 \x03(\v2\r.raftpb.EntryR\aEntries\x12\x18\n" +
+	"\aGroupId\x18\a \x01(\x03R\aGroupId\"\x8d\x01\n" +
+	"\x13AppendEntryResponse\x12\x12\n" +
+	"\x04Term\x18\x01 \x01(\x03R\x04Term\x12\x18\n" +
+	"\aSuccess\x18\x02 \x01(\bR\aSuccess\x12$\n" +
+	"\rConflictIndex\x18\x03 \x01(\x03R\rConflictIndex\x12\"\n" +
+	"\fConflictTerm\x18\x04 \x01(\x03R\fConflictTerm\"|\n" +
+	"\x05Entry\x12/\n" +
+	"\tEntryType\x18\x01 \x01(\x0e2\x11.raftpb.EntrytypeR\tEntryType\x12\x18\n" +
+	"\aCurTerm\x18\x02 \x01(\x03R\aCurTerm\x12\x14\n" +
+	"\x05Index\x18\x03 \x01(\x03R\x05Index\x12\x12\n" +
+	"\x04date\x18\x04 \x01(\fR\x04date\"\xc6\x01\n" +
+	"\fHeartbeatMsg\x12\x18\n" +
+	"\aGroupId\x18\x01 \x01(\x03R\aGroupId\x12\x18\n" +
+	"\aCurTerm\x18\x02 \x01(\x03R\aCurTerm\x12\x1a\n" +
+	"\bLeaderId\x18\x03 \x01(\x03R\bLeaderId\x12 \n" +
+	"\vCommitIndex\x18\x04 \x01(\x03R\vCommitIndex\x12\"\n" +
+	"\fLastLogIndex\x18\x05 \x01(\x03R\fLastLogIndex\x12 \n" +
+	"\vLastLogTerm\x18\x06 \x01(\x03R\vLastLogTerm\"q\n" +
+	"\x0fHeartbeatResult\x12\x18\n" +
+	"\aGroupId\x18\x01 \x01(\x03R\aGroupId\x12\x16\n" +
+	"\x06PeerId\x18\x02 \x01(\x03R\x06PeerId\x12\x12\n" +
+	"\x04Term\x18\x03 \x01(\x03R\x04Term\x12\x18\n" +
+	"\aSuccess\x18\x04 \x01(\bR\aSuccess\"H\n" +
+	"\x10HeartbeatRequest\x124\n" +
+	"\n" +
+	"Heartbeats\x18\x01 \x03(\v2\x14.raftpb.HeartbeatMsgR\n" +
+	"Heartbeats\"F\n" +
+	"\x11HeartbeatResponse\x121\n" +
+	"\aResults\x18\x01 \x03(\v2\x17.raftpb.HeartbeatResultR\aResults\"\x80\x01\n" +
+	"\n" +
+	"PeerStatus\x12\x0e\n" +
+	"\x02Id\x18\x01 \x01(\x03R\x02Id\x12\x12\n" +
+	"\x04Addr\x18\x02 \x01(\tR\x04Addr\x12\x1c\n" +
+	"\tNextIndex\x18\x03 \x01(\x03R\tNextIndex\x12\x1e\n" +
+	"\n" +
+	"MatchIndex\x18\x04 \x01(\x03R\n" +
+	"MatchIndex\x12\x10\n" +
+	"\x03Lag\x18\x05 \x01(\x03R\x03Lag\"\xc8\x02\n" +
+	"\n" +
+	"RaftStatus\x12\x18\n" +
+	"\aGroupId\x18\x01 \x01(\x03R\aGroupId\x12\x0e\n" +
+	"\x02Id\x18\x02 \x01(\x03R\x02Id\x12\x12\n" +
+	"\x04Role\x18\x03 \x01(\tR\x04Role\x12\x12\n" +
+	"\x04Term\x18\x04 \x01(\x03R\x04Term\x12\x18\n" +
+	"\aVoteFor\x18\x05 \x01(\x03R\aVoteFor\x12\x1a\n" +
+	"\bLeaderId\x18\x06 \x01(\x03R\bLeaderId\x12 \n" +
+	"\vCommitIndex\x18\a \x01(\x03R\vCommitIndex\x12\"\n" +
+	"\fAppliedIndex\x18\b \x01(\x03R\fAppliedIndex\x12\x1e\n" +
+	"\n" +
+	"FirstIndex\x18\t \x01(\x03R\n" +
+	"FirstIndex\x12\x1c\n" +
+	"\tLastIndex\x18\n" +
+	" \x01(\x03R\tLastIndex\x12(\n" +
+	"\x05Peers\x18\f \x03(\v2\x12.raftpb.PeerStatusR\x05PeersJ\x04\b\v\x10\f\")\n" +
+	"\rStatusRequest\x12\x18\n" +
+	"\aGroupId\x18\x01 \x01(\x03R\aGroupId\"h\n" +
+	"\x0eStatusResponse\x12\x16\n" +
+	"\x06NodeId\x18\x01 \x01(\x03R\x06NodeId\x12\x12\n" +
+	"\x04Addr\x18\x02 \x01(\tR\x04Addr\x12*\n" +
+	"\x06Groups\x18\x03 \x03(\v2\x12.raftpb.RaftStatusR\x06Groups\"\xc5\x01\n" +
+	"\tFaultRule\x12\x12\n" +
+	"\x04Peer\x18\x01 \x01(\tR\x04Peer\x12\x16\n" +
+	"\x06Method\x18\x02 \x01(\tR\x06Method\x12\x1c\n" +
+	"\tDirection\x18\x03 \x01(\tR\tDirection\x12\x16\n" +
+	"\x06Action\x18\x04 \x01(\tR\x06Action\x12 \n" +
+	"\vProbability\x18\x05 \x01(\x01R\vProbability\x12\x18\n" +
+	"\aDelayMs\x18\x06 \x01(\x03R\aDelayMs\x12\x1a\n" +
+	"\bJitterMs\x18\a \x01(\x03R\bJitterMs\"S\n" +
+	"\x10SetFaultsRequest\x12'\n" +
+	"\x05Rules\x18\x01 \x03(\v2\x11.raftpb.FaultRuleR\x05Rules\x12\x16\n" +
+	"\x06Append\x18\x02 \x01(\bR\x06Append\"<\n" +
+	"\x11SetFaultsResponse\x12'\n" +
+	"\x05Rules\x18\x01 \x03(\v2\x11.raftpb.FaultRuleR\x05Rules\"a\n" +
+	"\rBackupRequest\x12\x18\n" +
+	"\aGroupId\x18\x01 \x01(\x03R\aGroupId\x12\x1a\n" +
+	"\bSkipData\x18\x02 \x01(\bR\bSkipData\x12\x1a\n" +
+	"\bLogStart\x18\x03 \x01(\x03R\bLogStart\"\xfc\x01\n" +
+	"\n" +
+	"BackupMeta\x12\x18\n" +
+	"\aGroupId\x18\x01 \x01(\x03R\aGroupId\x12\x16\n" +
+	"\x06NodeId\x18\x02 \x01(\x03R\x06NodeId\x12\"\n" +
+	"\fAppliedIndex\x18\x03 \x01(\x03R\fAppliedIndex\x12 \n" +
+	"\vAppliedTerm\x18\x04 \x01(\x03R\vAppliedTerm\x12\x12\n" +
+	"\x04Term\x18\x05 \x01(\x03R\x04Term\x12\x14\n" +
+	"\x05Peers\x18\x06 \x03(\tR\x05Peers\x12\x18\n" +
+	"\aHasData\x18\a \x01(\bR\aHasData\x12\x1a\n" +
+	"\bLogStart\x18\b \x01(\x03R\bLogStart\x12\x16\n" +
+	"\x06LogEnd\x18\t \x01(\x03R\x06LogEnd\"4\n" +
+	"\n" +
+	"BackupPair\x12\x10\n" +
+	"\x03Key\x18\x01 \x01(\fR\x03Key\x12\x14\n" +
+	"\x05Value\x18\x02 \x01(\fR\x05Value\"\x88\x01\n" +
+	"\vBackupChunk\x12&\n" +
+	"\x04Meta\x18\x01 \x01(\v2\x12.raftpb.BackupMetaR\x04Meta\x12(\n" +
+	"\x05Pairs\x18\x02 \x03(\v2\x12.raftpb.BackupPairR\x05Pairs\x12'\n" +
+	"\aEntries\x18\x03 \x03(\v2\r.raftpb.EntryR\aEntries*-\n" +
 	"\tEntrytype\x12\x0f\n" +
 	"\vEntryNormal\x10\x00\x12\x0f\n" +
-	"\vEntryConfig\x10\x012\x92\x01\n" +
+	"\vEntryConfig\x10\x012\x87\x03\n" +
 	"\x0eMessageService\x128\n" +
 	"\vRequestVote\x12\x13.raftpb.VoteRequest\x1a\x14.raftpb.VoteResponse\x12F\n" +
-	"\vAppendEntry\x12\x1a.raftpb.AppendEntryRequest\x1a\x1b.raftpb.AppendEntryResponseB\vZ\t../raftpbb\x06proto3"
+	"\vAppendEntry\x12\x1a.raftpb.AppendEntryRequest\x1a\x1b.raftpb.AppendEntryResponse\x12@\n" +
+	"\tHeartbeat\x12\x18.raftpb.HeartbeatRequest\x1a\x19.raftpb.HeartbeatResponse\x127\n" +
+	"\x06Status\x12\x15.raftpb.StatusRequest\x1a\x16.raftpb.StatusResponse\x12@\n" +
+	"\tSetFaults\x12\x18.raftpb.SetFaultsRequest\x1a\x19.raftpb.SetFaultsResponse\x126\n" +
+	"\x06Backup\x12\x15.raftpb.BackupRequest\x1a\x13.raftpb.BackupChunk0\x01B\vZ\t../raftpbb\x06proto3"
 
 var (
 	file_raftbasic_proto_rawDescOnce sync.Once
@@ -441,7 +1578,7 @@ func file_raftbasic_proto_rawDescGZIP() []byte {
 }
 
 var file_raftbasic_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_raftbasic_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_raftbasic_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_raftbasic_proto_goTypes = []any{
 	(Entrytype)(0),              // 0: raftpb.Entrytype
 	(*VoteRequest)(nil),         // 1: raftpb.VoteRequest
@@ -449,19 +1586,51 @@ var file_raftbasic_proto_goTypes = []any{
 	(*AppendEntryRequest)(nil),  // 3: raftpb.AppendEntryRequest
 	(*AppendEntryResponse)(nil), // 4: raftpb.AppendEntryResponse
 	(*Entry)(nil),               // 5: raftpb.Entry
+	(*HeartbeatMsg)(nil),        // 6: raftpb.HeartbeatMsg
+	(*HeartbeatResult)(nil),     // 7: raftpb.HeartbeatResult
+	(*HeartbeatRequest)(nil),    // 8: raftpb.HeartbeatRequest
+	(*HeartbeatResponse)(nil),   // 9: raftpb.HeartbeatResponse
+	(*PeerStatus)(nil),          // 10: raftpb.PeerStatus
+	(*RaftStatus)(nil),          // 11: raftpb.RaftStatus
+	(*StatusRequest)(nil),       // 12: raftpb.StatusRequest
+	(*StatusResponse)(nil),      // 13: raftpb.StatusResponse
+	(*FaultRule)(nil),           // 14: raftpb.FaultRule
+	(*SetFaultsRequest)(nil),    // 15: raftpb.SetFaultsRequest
+	(*SetFaultsResponse)(nil),   // 16: raftpb.SetFaultsResponse
+	(*BackupRequest)(nil),       // 17: raftpb.BackupRequest
+	(*BackupMeta)(nil),          // 18: raftpb.BackupMeta
+	(*BackupPair)(nil),          // 19: raftpb.BackupPair
+	(*BackupChunk)(nil),         // 20: raftpb.BackupChunk
 }
 var file_raftbasic_proto_depIdxs = []int32{
-	5, // 0: raftpb.AppendEntryRequest.Entries:type_name -> raftpb.Entry
-	0, // 1: raftpb.Entry.EntryType:type_name -> raftpb.Entrytype
-	1, // 2: raftpb.MessageService.RequestVote:input_type -> raftpb.VoteRequest
-	3, // 3: raftpb.MessageService.AppendEntry:input_type -> raftpb.AppendEntryRequest
-	2, // 4: raftpb.MessageService.RequestVote:output_type -> raftpb.VoteResponse
-	4, // 5: raftpb.MessageService.AppendEntry:output_type -> raftpb.AppendEntryResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	5,  // 0: raftpb.AppendEntryRequest.Entries:type_name -> raftpb.Entry
+	0,  // 1: raftpb.Entry.EntryType:type_name -> raftpb.Entrytype
+	6,  // 2: raftpb.HeartbeatRequest.Heartbeats:type_name -> raftpb.HeartbeatMsg
+	7,  // 3: raftpb.HeartbeatResponse.Results:type_name -> raftpb.HeartbeatResult
+	10, // 4: raftpb.RaftStatus.Peers:type_name -> raftpb.PeerStatus
+	11, // 5: raftpb.StatusResponse.Groups:type_name -> raftpb.RaftStatus
+	14, // 6: raftpb.SetFaultsRequest.Rules:type_name -> raftpb.FaultRule
+	14, // 7: raftpb.SetFaultsResponse.Rules:type_name -> raftpb.FaultRule
+	18, // 8: raftpb.BackupChunk.Meta:type_name -> raftpb.BackupMeta
+	19, // 9: raftpb.BackupChunk.Pairs:type_name -> raftpb.BackupPair
+	5,  // 10: raftpb.BackupChunk.Entries:type_name -> raftpb.Entry
+	1,  // 11: raftpb.MessageService.RequestVote:input_type -> raftpb.VoteRequest
+	3,  // 12: raftpb.MessageService.AppendEntry:input_type -> raftpb.AppendEntryRequest
+	8,  // 13: raftpb.MessageService.Heartbeat:input_type -> raftpb.HeartbeatRequest
+	12, // 14: raftpb.MessageService.Status:input_type -> raftpb.StatusRequest
+	15, // 15: raftpb.MessageService.SetFaults:input_type -> raftpb.SetFaultsRequest
+	17, // 16: raftpb.MessageService.Backup:input_type -> raftpb.BackupRequest
+	2,  // 17: raftpb.MessageService.RequestVote:output_type -> raftpb.VoteResponse
+	4,  // 18: raftpb.MessageService.AppendEntry:output_type -> raftpb.AppendEntryResponse
+	9,  // 19: raftpb.MessageService.Heartbeat:output_type -> raftpb.HeartbeatResponse
+	13, // 20: raftpb.MessageService.Status:output_type -> raftpb.StatusResponse
+	16, // 21: raftpb.MessageService.SetFaults:output_type -> raftpb.SetFaultsResponse
+	20, // 22: raftpb.MessageService.Backup:output_type -> raftpb.BackupChunk
+	17, // [17:23] is the sub-list for method output_type
+	11, // [11:17] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_raftbasic_proto_init() }
@@ -475,7 +1644,7 @@ func file_raftbasic_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_raftbasic_proto_rawDesc), len(file_raftbasic_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   5,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
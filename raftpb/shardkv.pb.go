@@ -0,0 +1,3434 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v3.6.1
+// source: shardkv.proto
+
+package raftpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type OpType int32
+
+const (
+	OpType_OpPut            OpType = 0
+	OpType_OpAppend         OpType = 1
+	OpType_OpGet            OpType = 2
+	OpType_OpDel            OpType = 3
+	OpType_OpCas            OpType = 4
+	OpType_OpPutIfAbsent    OpType = 5
+	OpType_OpDeleteIfEquals OpType = 6
+)
+
+// Enum value maps for OpType.
+var (
+	OpType_name = map[int32]string{
+		0: "OpPut",
+		1: "OpAppend",
+		2: "OpGet",
+		3: "OpDel",
+		4: "OpCas",
+		5: "OpPutIfAbsent",
+		6: "OpDeleteIfEquals",
+	}
+	OpType_value = map[string]int32{
+		"OpPut":            0,
+		"OpAppend":         1,
+		"OpGet":            2,
+		"OpDel":            3,
+		"OpCas":            4,
+		"OpPutIfAbsent":    5,
+		"OpDeleteIfEquals": 6,
+	}
+)
+
+func (x OpType) Enum() *OpType {
+	p := new(OpType)
+	*p = x
+	return p
+}
+
+func (x OpType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OpType) Descriptor() protoreflect.EnumDescriptor {
+	return file_shardkv_proto_enumTypes[0].Descriptor()
+}
+
+func (OpType) Type() protoreflect.EnumType {
+	return &file_shardkv_proto_enumTypes[0]
+}
+
+func (x OpType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OpType.Descriptor instead.
+func (OpType) EnumDescriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{0}
+}
+
+type ErrCode int32
+
+const (
+	ErrCode_ErrOK            ErrCode = 0
+	ErrCode_ErrNoKey         ErrCode = 1
+	ErrCode_ErrWrongLeader   ErrCode = 2
+	ErrCode_ErrWrongGroup    ErrCode = 3
+	ErrCode_ErrTimeout       ErrCode = 4
+	ErrCode_ErrNotReady      ErrCode = 5
+	ErrCode_ErrOutDated      ErrCode = 6
+	ErrCode_ErrCompareFailed ErrCode = 7
+	ErrCode_ErrBadRequest    ErrCode = 8
+	ErrCode_ErrLocked        ErrCode = 9
+	ErrCode_ErrLeaseNotFound ErrCode = 10
+	ErrCode_ErrCompacted     ErrCode = 11
+)
+
+// Enum value maps for ErrCode.
+var (
+	ErrCode_name = map[int32]string{
+		0:  "ErrOK",
+		1:  "ErrNoKey",
+		2:  "ErrWrongLeader",
+		3:  "ErrWrongGroup",
+		4:  "ErrTimeout",
+		5:  "ErrNotReady",
+		6:  "ErrOutDated",
+		7:  "ErrCompareFailed",
+		8:  "ErrBadRequest",
+		9:  "ErrLocked",
+		10: "ErrLeaseNotFound",
+		11: "ErrCompacted",
+	}
+	ErrCode_value = map[string]int32{
+		"ErrOK":            0,
+		"ErrNoKey":         1,
+		"ErrWrongLeader":   2,
+		"ErrWrongGroup":    3,
+		"ErrTimeout":       4,
+		"ErrNotReady":      5,
+		"ErrOutDated":      6,
+		"ErrCompareFailed": 7,
+		"ErrBadRequest":    8,
+		"ErrLocked":        9,
+		"ErrLeaseNotFound": 10,
+		"ErrCompacted":     11,
+	}
+)
+
+func (x ErrCode) Enum() *ErrCode {
+	p := new(ErrCode)
+	*p = x
+	return p
+}
+
+func (x ErrCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_shardkv_proto_enumTypes[1].Descriptor()
+}
+
+func (ErrCode) Type() protoreflect.EnumType {
+	return &file_shardkv_proto_enumTypes[1]
+}
+
+func (x ErrCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrCode.Descriptor instead.
+func (ErrCode) EnumDescriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{1}
+}
+
+type CompareTarget int32
+
+const (
+	CompareTarget_CmpValue   CompareTarget = 0
+	CompareTarget_CmpVersion CompareTarget = 1
+)
+
+// Enum value maps for CompareTarget.
+var (
+	CompareTarget_name = map[int32]string{
+		0: "CmpValue",
+		1: "CmpVersion",
+	}
+	CompareTarget_value = map[string]int32{
+		"CmpValue":   0,
+		"CmpVersion": 1,
+	}
+)
+
+func (x CompareTarget) Enum() *CompareTarget {
+	p := new(CompareTarget)
+	*p = x
+	return p
+}
+
+func (x CompareTarget) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CompareTarget) Descriptor() protoreflect.EnumDescriptor {
+	return file_shardkv_proto_enumTypes[2].Descriptor()
+}
+
+func (CompareTarget) Type() protoreflect.EnumType {
+	return &file_shardkv_proto_enumTypes[2]
+}
+
+func (x CompareTarget) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CompareTarget.Descriptor instead.
+func (CompareTarget) EnumDescriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{2}
+}
+
+type CompareResult int32
+
+const (
+	CompareResult_CmpEqual    CompareResult = 0
+	CompareResult_CmpNotEqual CompareResult = 1
+	CompareResult_CmpGreater  CompareResult = 2
+	CompareResult_CmpLess     CompareResult = 3
+)
+
+// Enum value maps for CompareResult.
+var (
+	CompareResult_name = map[int32]string{
+		0: "CmpEqual",
+		1: "CmpNotEqual",
+		2: "CmpGreater",
+		3: "CmpLess",
+	}
+	CompareResult_value = map[string]int32{
+		"CmpEqual":    0,
+		"CmpNotEqual": 1,
+		"CmpGreater":  2,
+		"CmpLess":     3,
+	}
+)
+
+func (x CompareResult) Enum() *CompareResult {
+	p := new(CompareResult)
+	*p = x
+	return p
+}
+
+func (x CompareResult) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CompareResult) Descriptor() protoreflect.EnumDescriptor {
+	return file_shardkv_proto_enumTypes[3].Descriptor()
+}
+
+func (CompareResult) Type() protoreflect.EnumType {
+	return &file_shardkv_proto_enumTypes[3]
+}
+
+func (x CompareResult) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CompareResult.Descriptor instead.
+func (CompareResult) EnumDescriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{3}
+}
+
+type TxnState int32
+
+const (
+	TxnState_TxnPending   TxnState = 0
+	TxnState_TxnCommitted TxnState = 1
+	TxnState_TxnAborted   TxnState = 2
+	TxnState_TxnUnknown   TxnState = 3
+)
+
+// Enum value maps for TxnState.
+var (
+	TxnState_name = map[int32]string{
+		0: "TxnPending",
+		1: "TxnCommitted",
+		2: "TxnAborted",
+		3: "TxnUnknown",
+	}
+	TxnState_value = map[string]int32{
+		"TxnPending":   0,
+		"TxnCommitted": 1,
+		"TxnAborted":   2,
+		"TxnUnknown":   3,
+	}
+)
+
+func (x TxnState) Enum() *TxnState {
+	p := new(TxnState)
+	*p = x
+	return p
+}
+
+func (x TxnState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TxnState) Descriptor() protoreflect.EnumDescriptor {
+	return file_shardkv_proto_enumTypes[4].Descriptor()
+}
+
+func (TxnState) Type() protoreflect.EnumType {
+	return &file_shardkv_proto_enumTypes[4]
+}
+
+func (x TxnState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TxnState.Descriptor instead.
+func (TxnState) EnumDescriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{4}
+}
+
+type EventType int32
+
+const (
+	EventType_EventPut    EventType = 0
+	EventType_EventDelete EventType = 1
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EventPut",
+		1: "EventDelete",
+	}
+	EventType_value = map[string]int32{
+		"EventPut":    0,
+		"EventDelete": 1,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_shardkv_proto_enumTypes[5].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_shardkv_proto_enumTypes[5]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{5}
+}
+
+type CommandRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Key             string                 `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Value           string                 `protobuf:"bytes,2,opt,name=Value,proto3" json:"Value,omitempty"`
+	Op              OpType                 `protobuf:"varint,3,opt,name=Op,proto3,enum=raftpb.OpType" json:"Op,omitempty"`
+	ClientId        int64                  `protobuf:"varint,4,opt,name=ClientId,proto3" json:"ClientId,omitempty"`
+	CommandId       int64                  `protobuf:"varint,5,opt,name=CommandId,proto3" json:"CommandId,omitempty"`
+	ExpectedValue   string                 `protobuf:"bytes,6,opt,name=ExpectedValue,proto3" json:"ExpectedValue,omitempty"`
+	ExpectedVersion int64                  `protobuf:"varint,7,opt,name=ExpectedVersion,proto3" json:"ExpectedVersion,omitempty"`
+	ReadIndex       int64                  `protobuf:"varint,8,opt,name=ReadIndex,proto3" json:"ReadIndex,omitempty"`
+	LeaseId         int64                  `protobuf:"varint,9,opt,name=LeaseId,proto3" json:"LeaseId,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CommandRequest) Reset() {
+	*x = CommandRequest{}
+	mi := &file_shardkv_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandRequest) ProtoMessage() {}
+
+func (x *CommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandRequest.ProtoReflect.Descriptor instead.
+func (*CommandRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CommandRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CommandRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *CommandRequest) GetOp() OpType {
+	if x != nil {
+		return x.Op
+	}
+	return OpType_OpPut
+}
+
+func (x *CommandRequest) GetClientId() int64 {
+	if x != nil {
+		return x.ClientId
+	}
+	return 0
+}
+
+func (x *CommandRequest) GetCommandId() int64 {
+	if x != nil {
+		return x.CommandId
+	}
+	return 0
+}
+
+func (x *CommandRequest) GetExpectedValue() string {
+	if x != nil {
+		return x.ExpectedValue
+	}
+	return ""
+}
+
+func (x *CommandRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *CommandRequest) GetReadIndex() int64 {
+	if x != nil {
+		return x.ReadIndex
+	}
+	return 0
+}
+
+func (x *CommandRequest) GetLeaseId() int64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+type CommandResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         string                 `protobuf:"bytes,1,opt,name=Value,proto3" json:"Value,omitempty"`
+	Err           ErrCode                `protobuf:"varint,2,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,3,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	Version       int64                  `protobuf:"varint,4,opt,name=Version,proto3" json:"Version,omitempty"`
+	Txn           *TxnResponse           `protobuf:"bytes,5,opt,name=Txn,proto3" json:"Txn,omitempty"`
+	ModIndex      int64                  `protobuf:"varint,6,opt,name=ModIndex,proto3" json:"ModIndex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandResponse) Reset() {
+	*x = CommandResponse{}
+	mi := &file_shardkv_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResponse) ProtoMessage() {}
+
+func (x *CommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResponse.ProtoReflect.Descriptor instead.
+func (*CommandResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CommandResponse) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *CommandResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *CommandResponse) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *CommandResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *CommandResponse) GetTxn() *TxnResponse {
+	if x != nil {
+		return x.Txn
+	}
+	return nil
+}
+
+func (x *CommandResponse) GetModIndex() int64 {
+	if x != nil {
+		return x.ModIndex
+	}
+	return 0
+}
+
+type OperationContext struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	MaxAppliedCommandId int64                  `protobuf:"varint,1,opt,name=MaxAppliedCommandId,proto3" json:"MaxAppliedCommandId,omitempty"`
+	LastResponse        *CommandResponse       `protobuf:"bytes,2,opt,name=LastResponse,proto3" json:"LastResponse,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *OperationContext) Reset() {
+	*x = OperationContext{}
+	mi := &file_shardkv_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OperationContext) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OperationContext) ProtoMessage() {}
+
+func (x *OperationContext) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OperationContext.ProtoReflect.Descriptor instead.
+func (*OperationContext) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OperationContext) GetMaxAppliedCommandId() int64 {
+	if x != nil {
+		return x.MaxAppliedCommandId
+	}
+	return 0
+}
+
+func (x *OperationContext) GetLastResponse() *CommandResponse {
+	if x != nil {
+		return x.LastResponse
+	}
+	return nil
+}
+
+type ShardData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kvs           map[string]string      `protobuf:"bytes,1,rep,name=Kvs,proto3" json:"Kvs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardData) Reset() {
+	*x = ShardData{}
+	mi := &file_shardkv_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardData) ProtoMessage() {}
+
+func (x *ShardData) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardData.ProtoReflect.Descriptor instead.
+func (*ShardData) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ShardData) GetKvs() map[string]string {
+	if x != nil {
+		return x.Kvs
+	}
+	return nil
+}
+
+type LeaseInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaseId       int64                  `protobuf:"varint,1,opt,name=LeaseId,proto3" json:"LeaseId,omitempty"`
+	TTL           int64                  `protobuf:"varint,2,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	Keys          []string               `protobuf:"bytes,3,rep,name=Keys,proto3" json:"Keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseInfo) Reset() {
+	*x = LeaseInfo{}
+	mi := &file_shardkv_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseInfo) ProtoMessage() {}
+
+func (x *LeaseInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseInfo.ProtoReflect.Descriptor instead.
+func (*LeaseInfo) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LeaseInfo) GetLeaseId() int64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+func (x *LeaseInfo) GetTTL() int64 {
+	if x != nil {
+		return x.TTL
+	}
+	return 0
+}
+
+func (x *LeaseInfo) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type ShardOperationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConfigNum     int64                  `protobuf:"varint,1,opt,name=ConfigNum,proto3" json:"ConfigNum,omitempty"`
+	ShardIds      []int64                `protobuf:"varint,2,rep,packed,name=ShardIds,proto3" json:"ShardIds,omitempty"`
+	GroupId       int64                  `protobuf:"varint,3,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardOperationRequest) Reset() {
+	*x = ShardOperationRequest{}
+	mi := &file_shardkv_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardOperationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardOperationRequest) ProtoMessage() {}
+
+func (x *ShardOperationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardOperationRequest.ProtoReflect.Descriptor instead.
+func (*ShardOperationRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ShardOperationRequest) GetConfigNum() int64 {
+	if x != nil {
+		return x.ConfigNum
+	}
+	return 0
+}
+
+func (x *ShardOperationRequest) GetShardIds() []int64 {
+	if x != nil {
+		return x.ShardIds
+	}
+	return nil
+}
+
+func (x *ShardOperationRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+type ShardOperationResponse struct {
+	state          protoimpl.MessageState      `protogen:"open.v1"`
+	Err            ErrCode                     `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	ConfigNum      int64                       `protobuf:"varint,2,opt,name=ConfigNum,proto3" json:"ConfigNum,omitempty"`
+	Shards         map[int64]*ShardData        `protobuf:"bytes,3,rep,name=Shards,proto3" json:"Shards,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	LastOperations map[int64]*OperationContext `protobuf:"bytes,4,rep,name=LastOperations,proto3" json:"LastOperations,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Leases         []*LeaseInfo                `protobuf:"bytes,5,rep,name=Leases,proto3" json:"Leases,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ShardOperationResponse) Reset() {
+	*x = ShardOperationResponse{}
+	mi := &file_shardkv_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardOperationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardOperationResponse) ProtoMessage() {}
+
+func (x *ShardOperationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardOperationResponse.ProtoReflect.Descriptor instead.
+func (*ShardOperationResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ShardOperationResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *ShardOperationResponse) GetConfigNum() int64 {
+	if x != nil {
+		return x.ConfigNum
+	}
+	return 0
+}
+
+func (x *ShardOperationResponse) GetShards() map[int64]*ShardData {
+	if x != nil {
+		return x.Shards
+	}
+	return nil
+}
+
+func (x *ShardOperationResponse) GetLastOperations() map[int64]*OperationContext {
+	if x != nil {
+		return x.LastOperations
+	}
+	return nil
+}
+
+func (x *ShardOperationResponse) GetLeases() []*LeaseInfo {
+	if x != nil {
+		return x.Leases
+	}
+	return nil
+}
+
+type RangeInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Gid           int64                  `protobuf:"varint,1,opt,name=Gid,proto3" json:"Gid,omitempty"`
+	StartKey      string                 `protobuf:"bytes,2,opt,name=StartKey,proto3" json:"StartKey,omitempty"`
+	EndKey        string                 `protobuf:"bytes,3,opt,name=EndKey,proto3" json:"EndKey,omitempty"`
+	Epoch         int64                  `protobuf:"varint,4,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
+	Peers         []string               `protobuf:"bytes,5,rep,name=Peers,proto3" json:"Peers,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,6,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeInfo) Reset() {
+	*x = RangeInfo{}
+	mi := &file_shardkv_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeInfo) ProtoMessage() {}
+
+func (x *RangeInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeInfo.ProtoReflect.Descriptor instead.
+func (*RangeInfo) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RangeInfo) GetGid() int64 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+func (x *RangeInfo) GetStartKey() string {
+	if x != nil {
+		return x.StartKey
+	}
+	return ""
+}
+
+func (x *RangeInfo) GetEndKey() string {
+	if x != nil {
+		return x.EndKey
+	}
+	return ""
+}
+
+func (x *RangeInfo) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *RangeInfo) GetPeers() []string {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *RangeInfo) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+type GetRangesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRangesRequest) Reset() {
+	*x = GetRangesRequest{}
+	mi := &file_shardkv_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRangesRequest) ProtoMessage() {}
+
+func (x *GetRangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRangesRequest.ProtoReflect.Descriptor instead.
+func (*GetRangesRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{8}
+}
+
+type GetRangesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ranges        []*RangeInfo           `protobuf:"bytes,1,rep,name=Ranges,proto3" json:"Ranges,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRangesResponse) Reset() {
+	*x = GetRangesResponse{}
+	mi := &file_shardkv_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRangesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRangesResponse) ProtoMessage() {}
+
+func (x *GetRangesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRangesResponse.ProtoReflect.Descriptor instead.
+func (*GetRangesResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetRangesResponse) GetRanges() []*RangeInfo {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+type RangeOperationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	Epoch         int64                  `protobuf:"varint,2,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
+	TargetGid     int64                  `protobuf:"varint,3,opt,name=TargetGid,proto3" json:"TargetGid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeOperationRequest) Reset() {
+	*x = RangeOperationRequest{}
+	mi := &file_shardkv_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeOperationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeOperationRequest) ProtoMessage() {}
+
+func (x *RangeOperationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeOperationRequest.ProtoReflect.Descriptor instead.
+func (*RangeOperationRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RangeOperationRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *RangeOperationRequest) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *RangeOperationRequest) GetTargetGid() int64 {
+	if x != nil {
+		return x.TargetGid
+	}
+	return 0
+}
+
+type RangeOperationResponse struct {
+	state          protoimpl.MessageState      `protogen:"open.v1"`
+	Err            ErrCode                     `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	Range          *RangeInfo                  `protobuf:"bytes,2,opt,name=Range,proto3" json:"Range,omitempty"`
+	Data           *ShardData                  `protobuf:"bytes,3,opt,name=Data,proto3" json:"Data,omitempty"`
+	LastOperations map[int64]*OperationContext `protobuf:"bytes,4,rep,name=LastOperations,proto3" json:"LastOperations,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Leases         []*LeaseInfo                `protobuf:"bytes,5,rep,name=Leases,proto3" json:"Leases,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RangeOperationResponse) Reset() {
+	*x = RangeOperationResponse{}
+	mi := &file_shardkv_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeOperationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeOperationResponse) ProtoMessage() {}
+
+func (x *RangeOperationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeOperationResponse.ProtoReflect.Descriptor instead.
+func (*RangeOperationResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RangeOperationResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *RangeOperationResponse) GetRange() *RangeInfo {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+func (x *RangeOperationResponse) GetData() *ShardData {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *RangeOperationResponse) GetLastOperations() map[int64]*OperationContext {
+	if x != nil {
+		return x.LastOperations
+	}
+	return nil
+}
+
+func (x *RangeOperationResponse) GetLeases() []*LeaseInfo {
+	if x != nil {
+		return x.Leases
+	}
+	return nil
+}
+
+type KeyValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=Value,proto3" json:"Value,omitempty"`
+	Version       int64                  `protobuf:"varint,3,opt,name=Version,proto3" json:"Version,omitempty"`
+	ModIndex      int64                  `protobuf:"varint,4,opt,name=ModIndex,proto3" json:"ModIndex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValue) Reset() {
+	*x = KeyValue{}
+	mi := &file_shardkv_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValue) ProtoMessage() {}
+
+func (x *KeyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
+func (*KeyValue) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *KeyValue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *KeyValue) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *KeyValue) GetModIndex() int64 {
+	if x != nil {
+		return x.ModIndex
+	}
+	return 0
+}
+
+type ScanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartKey      string                 `protobuf:"bytes,1,opt,name=StartKey,proto3" json:"StartKey,omitempty"`
+	EndKey        string                 `protobuf:"bytes,2,opt,name=EndKey,proto3" json:"EndKey,omitempty"`
+	Limit         int64                  `protobuf:"varint,3,opt,name=Limit,proto3" json:"Limit,omitempty"`
+	Reverse       bool                   `protobuf:"varint,4,opt,name=Reverse,proto3" json:"Reverse,omitempty"`
+	AppliedIndex  int64                  `protobuf:"varint,5,opt,name=AppliedIndex,proto3" json:"AppliedIndex,omitempty"`
+	PageToken     []byte                 `protobuf:"bytes,6,opt,name=PageToken,proto3" json:"PageToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_shardkv_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ScanRequest) GetStartKey() string {
+	if x != nil {
+		return x.StartKey
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetEndKey() string {
+	if x != nil {
+		return x.EndKey
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetReverse() bool {
+	if x != nil {
+		return x.Reverse
+	}
+	return false
+}
+
+func (x *ScanRequest) GetAppliedIndex() int64 {
+	if x != nil {
+		return x.AppliedIndex
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetPageToken() []byte {
+	if x != nil {
+		return x.PageToken
+	}
+	return nil
+}
+
+type ScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kvs           []*KeyValue            `protobuf:"bytes,1,rep,name=Kvs,proto3" json:"Kvs,omitempty"`
+	Err           ErrCode                `protobuf:"varint,2,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,3,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	AppliedIndex  int64                  `protobuf:"varint,4,opt,name=AppliedIndex,proto3" json:"AppliedIndex,omitempty"`
+	NextPageToken []byte                 `protobuf:"bytes,5,opt,name=NextPageToken,proto3" json:"NextPageToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_shardkv_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ScanResponse) GetKvs() []*KeyValue {
+	if x != nil {
+		return x.Kvs
+	}
+	return nil
+}
+
+func (x *ScanResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *ScanResponse) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *ScanResponse) GetAppliedIndex() int64 {
+	if x != nil {
+		return x.AppliedIndex
+	}
+	return 0
+}
+
+func (x *ScanResponse) GetNextPageToken() []byte {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return nil
+}
+
+type Compare struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Target        CompareTarget          `protobuf:"varint,2,opt,name=Target,proto3,enum=raftpb.CompareTarget" json:"Target,omitempty"`
+	Result        CompareResult          `protobuf:"varint,3,opt,name=Result,proto3,enum=raftpb.CompareResult" json:"Result,omitempty"`
+	Value         string                 `protobuf:"bytes,4,opt,name=Value,proto3" json:"Value,omitempty"`
+	Version       int64                  `protobuf:"varint,5,opt,name=Version,proto3" json:"Version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Compare) Reset() {
+	*x = Compare{}
+	mi := &file_shardkv_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Compare) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Compare) ProtoMessage() {}
+
+func (x *Compare) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Compare.ProtoReflect.Descriptor instead.
+func (*Compare) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Compare) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Compare) GetTarget() CompareTarget {
+	if x != nil {
+		return x.Target
+	}
+	return CompareTarget_CmpValue
+}
+
+func (x *Compare) GetResult() CompareResult {
+	if x != nil {
+		return x.Result
+	}
+	return CompareResult_CmpEqual
+}
+
+func (x *Compare) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Compare) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type TxnOp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Op            OpType                 `protobuf:"varint,1,opt,name=Op,proto3,enum=raftpb.OpType" json:"Op,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=Key,proto3" json:"Key,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=Value,proto3" json:"Value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnOp) Reset() {
+	*x = TxnOp{}
+	mi := &file_shardkv_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnOp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnOp) ProtoMessage() {}
+
+func (x *TxnOp) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnOp.ProtoReflect.Descriptor instead.
+func (*TxnOp) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *TxnOp) GetOp() OpType {
+	if x != nil {
+		return x.Op
+	}
+	return OpType_OpPut
+}
+
+func (x *TxnOp) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TxnOp) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type TxnOpResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Err           ErrCode                `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=Value,proto3" json:"Value,omitempty"`
+	Version       int64                  `protobuf:"varint,3,opt,name=Version,proto3" json:"Version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnOpResult) Reset() {
+	*x = TxnOpResult{}
+	mi := &file_shardkv_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnOpResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnOpResult) ProtoMessage() {}
+
+func (x *TxnOpResult) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnOpResult.ProtoReflect.Descriptor instead.
+func (*TxnOpResult) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *TxnOpResult) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *TxnOpResult) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *TxnOpResult) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type TxnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Compares      []*Compare             `protobuf:"bytes,1,rep,name=Compares,proto3" json:"Compares,omitempty"`
+	Success       []*TxnOp               `protobuf:"bytes,2,rep,name=Success,proto3" json:"Success,omitempty"`
+	Failure       []*TxnOp               `protobuf:"bytes,3,rep,name=Failure,proto3" json:"Failure,omitempty"`
+	ClientId      int64                  `protobuf:"varint,4,opt,name=ClientId,proto3" json:"ClientId,omitempty"`
+	CommandId     int64                  `protobuf:"varint,5,opt,name=CommandId,proto3" json:"CommandId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnRequest) Reset() {
+	*x = TxnRequest{}
+	mi := &file_shardkv_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnRequest) ProtoMessage() {}
+
+func (x *TxnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnRequest.ProtoReflect.Descriptor instead.
+func (*TxnRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TxnRequest) GetCompares() []*Compare {
+	if x != nil {
+		return x.Compares
+	}
+	return nil
+}
+
+func (x *TxnRequest) GetSuccess() []*TxnOp {
+	if x != nil {
+		return x.Success
+	}
+	return nil
+}
+
+func (x *TxnRequest) GetFailure() []*TxnOp {
+	if x != nil {
+		return x.Failure
+	}
+	return nil
+}
+
+func (x *TxnRequest) GetClientId() int64 {
+	if x != nil {
+		return x.ClientId
+	}
+	return 0
+}
+
+func (x *TxnRequest) GetCommandId() int64 {
+	if x != nil {
+		return x.CommandId
+	}
+	return 0
+}
+
+type TxnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Err           ErrCode                `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,2,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	Succeeded     bool                   `protobuf:"varint,3,opt,name=Succeeded,proto3" json:"Succeeded,omitempty"`
+	Results       []*TxnOpResult         `protobuf:"bytes,4,rep,name=Results,proto3" json:"Results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnResponse) Reset() {
+	*x = TxnResponse{}
+	mi := &file_shardkv_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnResponse) ProtoMessage() {}
+
+func (x *TxnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnResponse.ProtoReflect.Descriptor instead.
+func (*TxnResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *TxnResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *TxnResponse) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *TxnResponse) GetSucceeded() bool {
+	if x != nil {
+		return x.Succeeded
+	}
+	return false
+}
+
+func (x *TxnResponse) GetResults() []*TxnOpResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type DistTxnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Compares      []*Compare             `protobuf:"bytes,1,rep,name=Compares,proto3" json:"Compares,omitempty"`
+	Ops           []*TxnOp               `protobuf:"bytes,2,rep,name=Ops,proto3" json:"Ops,omitempty"`
+	ClientId      int64                  `protobuf:"varint,3,opt,name=ClientId,proto3" json:"ClientId,omitempty"`
+	CommandId     int64                  `protobuf:"varint,4,opt,name=CommandId,proto3" json:"CommandId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DistTxnRequest) Reset() {
+	*x = DistTxnRequest{}
+	mi := &file_shardkv_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistTxnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistTxnRequest) ProtoMessage() {}
+
+func (x *DistTxnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistTxnRequest.ProtoReflect.Descriptor instead.
+func (*DistTxnRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DistTxnRequest) GetCompares() []*Compare {
+	if x != nil {
+		return x.Compares
+	}
+	return nil
+}
+
+func (x *DistTxnRequest) GetOps() []*TxnOp {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+func (x *DistTxnRequest) GetClientId() int64 {
+	if x != nil {
+		return x.ClientId
+	}
+	return 0
+}
+
+func (x *DistTxnRequest) GetCommandId() int64 {
+	if x != nil {
+		return x.CommandId
+	}
+	return 0
+}
+
+type DistTxnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Err           ErrCode                `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,2,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	Committed     bool                   `protobuf:"varint,3,opt,name=Committed,proto3" json:"Committed,omitempty"`
+	TxnId         int64                  `protobuf:"varint,4,opt,name=TxnId,proto3" json:"TxnId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DistTxnResponse) Reset() {
+	*x = DistTxnResponse{}
+	mi := &file_shardkv_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistTxnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistTxnResponse) ProtoMessage() {}
+
+func (x *DistTxnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistTxnResponse.ProtoReflect.Descriptor instead.
+func (*DistTxnResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DistTxnResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *DistTxnResponse) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *DistTxnResponse) GetCommitted() bool {
+	if x != nil {
+		return x.Committed
+	}
+	return false
+}
+
+func (x *DistTxnResponse) GetTxnId() int64 {
+	if x != nil {
+		return x.TxnId
+	}
+	return 0
+}
+
+type TxnPrepareRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	GroupId          int64                  `protobuf:"varint,1,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	TxnId            int64                  `protobuf:"varint,2,opt,name=TxnId,proto3" json:"TxnId,omitempty"`
+	CoordinatorGid   int64                  `protobuf:"varint,3,opt,name=CoordinatorGid,proto3" json:"CoordinatorGid,omitempty"`
+	CoordinatorPeers []string               `protobuf:"bytes,4,rep,name=CoordinatorPeers,proto3" json:"CoordinatorPeers,omitempty"`
+	Compares         []*Compare             `protobuf:"bytes,5,rep,name=Compares,proto3" json:"Compares,omitempty"`
+	Ops              []*TxnOp               `protobuf:"bytes,6,rep,name=Ops,proto3" json:"Ops,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *TxnPrepareRequest) Reset() {
+	*x = TxnPrepareRequest{}
+	mi := &file_shardkv_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnPrepareRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnPrepareRequest) ProtoMessage() {}
+
+func (x *TxnPrepareRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnPrepareRequest.ProtoReflect.Descriptor instead.
+func (*TxnPrepareRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *TxnPrepareRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *TxnPrepareRequest) GetTxnId() int64 {
+	if x != nil {
+		return x.TxnId
+	}
+	return 0
+}
+
+func (x *TxnPrepareRequest) GetCoordinatorGid() int64 {
+	if x != nil {
+		return x.CoordinatorGid
+	}
+	return 0
+}
+
+func (x *TxnPrepareRequest) GetCoordinatorPeers() []string {
+	if x != nil {
+		return x.CoordinatorPeers
+	}
+	return nil
+}
+
+func (x *TxnPrepareRequest) GetCompares() []*Compare {
+	if x != nil {
+		return x.Compares
+	}
+	return nil
+}
+
+func (x *TxnPrepareRequest) GetOps() []*TxnOp {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+type TxnPrepareResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Err           ErrCode                `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	Prepared      bool                   `protobuf:"varint,2,opt,name=Prepared,proto3" json:"Prepared,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnPrepareResponse) Reset() {
+	*x = TxnPrepareResponse{}
+	mi := &file_shardkv_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnPrepareResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnPrepareResponse) ProtoMessage() {}
+
+func (x *TxnPrepareResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnPrepareResponse.ProtoReflect.Descriptor instead.
+func (*TxnPrepareResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *TxnPrepareResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *TxnPrepareResponse) GetPrepared() bool {
+	if x != nil {
+		return x.Prepared
+	}
+	return false
+}
+
+type TxnResolveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	TxnId         int64                  `protobuf:"varint,2,opt,name=TxnId,proto3" json:"TxnId,omitempty"`
+	Commit        bool                   `protobuf:"varint,3,opt,name=Commit,proto3" json:"Commit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnResolveRequest) Reset() {
+	*x = TxnResolveRequest{}
+	mi := &file_shardkv_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnResolveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnResolveRequest) ProtoMessage() {}
+
+func (x *TxnResolveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnResolveRequest.ProtoReflect.Descriptor instead.
+func (*TxnResolveRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *TxnResolveRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *TxnResolveRequest) GetTxnId() int64 {
+	if x != nil {
+		return x.TxnId
+	}
+	return 0
+}
+
+func (x *TxnResolveRequest) GetCommit() bool {
+	if x != nil {
+		return x.Commit
+	}
+	return false
+}
+
+type TxnResolveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Err           ErrCode                `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnResolveResponse) Reset() {
+	*x = TxnResolveResponse{}
+	mi := &file_shardkv_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnResolveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnResolveResponse) ProtoMessage() {}
+
+func (x *TxnResolveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnResolveResponse.ProtoReflect.Descriptor instead.
+func (*TxnResolveResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *TxnResolveResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+type TxnStatusRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	GroupId        int64                  `protobuf:"varint,1,opt,name=GroupId,proto3" json:"GroupId,omitempty"`
+	TxnId          int64                  `protobuf:"varint,2,opt,name=TxnId,proto3" json:"TxnId,omitempty"`
+	AbortIfPending bool                   `protobuf:"varint,3,opt,name=AbortIfPending,proto3" json:"AbortIfPending,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TxnStatusRequest) Reset() {
+	*x = TxnStatusRequest{}
+	mi := &file_shardkv_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnStatusRequest) ProtoMessage() {}
+
+func (x *TxnStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnStatusRequest.ProtoReflect.Descriptor instead.
+func (*TxnStatusRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *TxnStatusRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *TxnStatusRequest) GetTxnId() int64 {
+	if x != nil {
+		return x.TxnId
+	}
+	return 0
+}
+
+func (x *TxnStatusRequest) GetAbortIfPending() bool {
+	if x != nil {
+		return x.AbortIfPending
+	}
+	return false
+}
+
+type TxnStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Err           ErrCode                `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	State         TxnState               `protobuf:"varint,2,opt,name=State,proto3,enum=raftpb.TxnState" json:"State,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnStatusResponse) Reset() {
+	*x = TxnStatusResponse{}
+	mi := &file_shardkv_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnStatusResponse) ProtoMessage() {}
+
+func (x *TxnStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnStatusResponse.ProtoReflect.Descriptor instead.
+func (*TxnStatusResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *TxnStatusResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *TxnStatusResponse) GetState() TxnState {
+	if x != nil {
+		return x.State
+	}
+	return TxnState_TxnPending
+}
+
+type LeaseGrantRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	TTL           int64                  `protobuf:"varint,2,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	LeaseId       int64                  `protobuf:"varint,3,opt,name=LeaseId,proto3" json:"LeaseId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseGrantRequest) Reset() {
+	*x = LeaseGrantRequest{}
+	mi := &file_shardkv_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseGrantRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseGrantRequest) ProtoMessage() {}
+
+func (x *LeaseGrantRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseGrantRequest.ProtoReflect.Descriptor instead.
+func (*LeaseGrantRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *LeaseGrantRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *LeaseGrantRequest) GetTTL() int64 {
+	if x != nil {
+		return x.TTL
+	}
+	return 0
+}
+
+func (x *LeaseGrantRequest) GetLeaseId() int64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+type LeaseGrantResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Err           ErrCode                `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,2,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	LeaseId       int64                  `protobuf:"varint,3,opt,name=LeaseId,proto3" json:"LeaseId,omitempty"`
+	TTL           int64                  `protobuf:"varint,4,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseGrantResponse) Reset() {
+	*x = LeaseGrantResponse{}
+	mi := &file_shardkv_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseGrantResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseGrantResponse) ProtoMessage() {}
+
+func (x *LeaseGrantResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseGrantResponse.ProtoReflect.Descriptor instead.
+func (*LeaseGrantResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *LeaseGrantResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *LeaseGrantResponse) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *LeaseGrantResponse) GetLeaseId() int64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+func (x *LeaseGrantResponse) GetTTL() int64 {
+	if x != nil {
+		return x.TTL
+	}
+	return 0
+}
+
+type LeaseRevokeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaseId       int64                  `protobuf:"varint,1,opt,name=LeaseId,proto3" json:"LeaseId,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=Key,proto3" json:"Key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseRevokeRequest) Reset() {
+	*x = LeaseRevokeRequest{}
+	mi := &file_shardkv_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseRevokeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseRevokeRequest) ProtoMessage() {}
+
+func (x *LeaseRevokeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseRevokeRequest.ProtoReflect.Descriptor instead.
+func (*LeaseRevokeRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *LeaseRevokeRequest) GetLeaseId() int64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+func (x *LeaseRevokeRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type LeaseRevokeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Err           ErrCode                `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,2,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseRevokeResponse) Reset() {
+	*x = LeaseRevokeResponse{}
+	mi := &file_shardkv_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseRevokeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseRevokeResponse) ProtoMessage() {}
+
+func (x *LeaseRevokeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseRevokeResponse.ProtoReflect.Descriptor instead.
+func (*LeaseRevokeResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *LeaseRevokeResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *LeaseRevokeResponse) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+type LeaseKeepAliveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaseId       int64                  `protobuf:"varint,1,opt,name=LeaseId,proto3" json:"LeaseId,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=Key,proto3" json:"Key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseKeepAliveRequest) Reset() {
+	*x = LeaseKeepAliveRequest{}
+	mi := &file_shardkv_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseKeepAliveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseKeepAliveRequest) ProtoMessage() {}
+
+func (x *LeaseKeepAliveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseKeepAliveRequest.ProtoReflect.Descriptor instead.
+func (*LeaseKeepAliveRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *LeaseKeepAliveRequest) GetLeaseId() int64 {
+	if x != nil {
+		return x.LeaseId
+	}
+	return 0
+}
+
+func (x *LeaseKeepAliveRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type LeaseKeepAliveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Err           ErrCode                `protobuf:"varint,1,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,2,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	TTL           int64                  `protobuf:"varint,3,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseKeepAliveResponse) Reset() {
+	*x = LeaseKeepAliveResponse{}
+	mi := &file_shardkv_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseKeepAliveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseKeepAliveResponse) ProtoMessage() {}
+
+func (x *LeaseKeepAliveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseKeepAliveResponse.ProtoReflect.Descriptor instead.
+func (*LeaseKeepAliveResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *LeaseKeepAliveResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *LeaseKeepAliveResponse) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *LeaseKeepAliveResponse) GetTTL() int64 {
+	if x != nil {
+		return x.TTL
+	}
+	return 0
+}
+
+type WatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          EventType              `protobuf:"varint,1,opt,name=Type,proto3,enum=raftpb.EventType" json:"Type,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=Key,proto3" json:"Key,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=Value,proto3" json:"Value,omitempty"`
+	Version       int64                  `protobuf:"varint,4,opt,name=Version,proto3" json:"Version,omitempty"`
+	ModIndex      int64                  `protobuf:"varint,5,opt,name=ModIndex,proto3" json:"ModIndex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_shardkv_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *WatchEvent) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EventPut
+}
+
+func (x *WatchEvent) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *WatchEvent) GetModIndex() int64 {
+	if x != nil {
+		return x.ModIndex
+	}
+	return 0
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Prefix        bool                   `protobuf:"varint,2,opt,name=Prefix,proto3" json:"Prefix,omitempty"`
+	StartIndex    int64                  `protobuf:"varint,3,opt,name=StartIndex,proto3" json:"StartIndex,omitempty"`
+	Gid           int64                  `protobuf:"varint,4,opt,name=Gid,proto3" json:"Gid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_shardkv_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *WatchRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetPrefix() bool {
+	if x != nil {
+		return x.Prefix
+	}
+	return false
+}
+
+func (x *WatchRequest) GetStartIndex() int64 {
+	if x != nil {
+		return x.StartIndex
+	}
+	return 0
+}
+
+func (x *WatchRequest) GetGid() int64 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+type WatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*WatchEvent          `protobuf:"bytes,1,rep,name=Events,proto3" json:"Events,omitempty"`
+	Err           ErrCode                `protobuf:"varint,2,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	LeaderId      int64                  `protobuf:"varint,3,opt,name=LeaderId,proto3" json:"LeaderId,omitempty"`
+	Gid           int64                  `protobuf:"varint,4,opt,name=Gid,proto3" json:"Gid,omitempty"`
+	CompactIndex  int64                  `protobuf:"varint,5,opt,name=CompactIndex,proto3" json:"CompactIndex,omitempty"`
+	NextIndex     int64                  `protobuf:"varint,6,opt,name=NextIndex,proto3" json:"NextIndex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchResponse) Reset() {
+	*x = WatchResponse{}
+	mi := &file_shardkv_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResponse) ProtoMessage() {}
+
+func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *WatchResponse) GetEvents() []*WatchEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *WatchResponse) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *WatchResponse) GetGid() int64 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+func (x *WatchResponse) GetCompactIndex() int64 {
+	if x != nil {
+		return x.CompactIndex
+	}
+	return 0
+}
+
+func (x *WatchResponse) GetNextIndex() int64 {
+	if x != nil {
+		return x.NextIndex
+	}
+	return 0
+}
+
+type CdcRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Gid           int64                  `protobuf:"varint,1,opt,name=Gid,proto3" json:"Gid,omitempty"`
+	Index         int64                  `protobuf:"varint,2,opt,name=Index,proto3" json:"Index,omitempty"`
+	Term          int64                  `protobuf:"varint,3,opt,name=Term,proto3" json:"Term,omitempty"`
+	Type          string                 `protobuf:"bytes,4,opt,name=Type,proto3" json:"Type,omitempty"`
+	Mutations     []*WatchEvent          `protobuf:"bytes,5,rep,name=Mutations,proto3" json:"Mutations,omitempty"`
+	Snapshot      bool                   `protobuf:"varint,6,opt,name=Snapshot,proto3" json:"Snapshot,omitempty"`
+	More          bool                   `protobuf:"varint,7,opt,name=More,proto3" json:"More,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CdcRecord) Reset() {
+	*x = CdcRecord{}
+	mi := &file_shardkv_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CdcRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CdcRecord) ProtoMessage() {}
+
+func (x *CdcRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CdcRecord.ProtoReflect.Descriptor instead.
+func (*CdcRecord) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *CdcRecord) GetGid() int64 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+func (x *CdcRecord) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *CdcRecord) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *CdcRecord) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CdcRecord) GetMutations() []*WatchEvent {
+	if x != nil {
+		return x.Mutations
+	}
+	return nil
+}
+
+func (x *CdcRecord) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *CdcRecord) GetMore() bool {
+	if x != nil {
+		return x.More
+	}
+	return false
+}
+
+type CdcRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Gid           int64                  `protobuf:"varint,1,opt,name=Gid,proto3" json:"Gid,omitempty"`
+	StartIndex    int64                  `protobuf:"varint,2,opt,name=StartIndex,proto3" json:"StartIndex,omitempty"`
+	Snapshot      bool                   `protobuf:"varint,3,opt,name=Snapshot,proto3" json:"Snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CdcRequest) Reset() {
+	*x = CdcRequest{}
+	mi := &file_shardkv_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CdcRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CdcRequest) ProtoMessage() {}
+
+func (x *CdcRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CdcRequest.ProtoReflect.Descriptor instead.
+func (*CdcRequest) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CdcRequest) GetGid() int64 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+func (x *CdcRequest) GetStartIndex() int64 {
+	if x != nil {
+		return x.StartIndex
+	}
+	return 0
+}
+
+func (x *CdcRequest) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+type CdcResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*CdcRecord           `protobuf:"bytes,1,rep,name=Records,proto3" json:"Records,omitempty"`
+	Err           ErrCode                `protobuf:"varint,2,opt,name=Err,proto3,enum=raftpb.ErrCode" json:"Err,omitempty"`
+	CompactIndex  int64                  `protobuf:"varint,3,opt,name=CompactIndex,proto3" json:"CompactIndex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CdcResponse) Reset() {
+	*x = CdcResponse{}
+	mi := &file_shardkv_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CdcResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CdcResponse) ProtoMessage() {}
+
+func (x *CdcResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shardkv_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CdcResponse.ProtoReflect.Descriptor instead.
+func (*CdcResponse) Descriptor() ([]byte, []int) {
+	return file_shardkv_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *CdcResponse) GetRecords() []*CdcRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *CdcResponse) GetErr() ErrCode {
+	if x != nil {
+		return x.Err
+	}
+	return ErrCode_ErrOK
+}
+
+func (x *CdcResponse) GetCompactIndex() int64 {
+	if x != nil {
+		return x.CompactIndex
+	}
+	return 0
+}
+
+var File_shardkv_proto protoreflect.FileDescriptor
+
+const file_shardkv_proto_rawDesc = "" +
+	"\n" +
+	"\rshardkv.proto\x12\x06raftpb\"\x9a\x02\n" +
+	"\x0eCommandRequest\x12\x10\n" +
+	"\x03Key\x18\x01 \x01(\tR\x03Key\x12\x14\n" +
+	"\x05Value\x18\x02 \x01(\tR\x05Value\x12\x1e\n" +
+	"\x02Op\x18\x03 \x01(\x0e2\x0e.raftpb.OpTypeR\x02Op\x12\x1a\n" +
+	"\bClientId\x18\x04 \x01(\x03R\bClientId\x12\x1c\n" +
+	"\tCommandId\x18\x05 \x01(\x03R\tCommandId\x12$\n" +
+	"\rExpectedValue\x18\x06 \x01(\tR\rExpectedValue\x12(\n" +
+	"\x0fExpectedVersion\x18\a \x01(\x03R\x0fExpectedVersion\x12\x1c\n" +
+	"\tReadIndex\x18\b \x01(\x03R\tReadIndex\x12\x18\n" +
+	"\aLeaseId\x18\t \x01(\x03R\aLeaseId\"\xc3\x01\n" +
+	"\x0fCommandResponse\x12\x14\n" +
+	"\x05Value\x18\x01 \x01(\tR\x05Value\x12!\n" +
+	"\x03Err\x18\x02 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x1a\n" +
+	"\bLeaderId\x18\x03 \x01(\x03R\bLeaderId\x12\x18\n" +
+	"\aVersion\x18\x04 \x01(\x03R\aVersion\x12%\n" +
+	"\x03Txn\x18\x05 \x01(\v2\x13.raftpb.TxnResponseR\x03Txn\x12\x1a\n" +
+	"\bModIndex\x18\x06 \x01(\x03R\bModIndex\"\x81\x01\n" +
+	"\x10OperationContext\x120\n" +
+	"\x13MaxAppliedCommandId\x18\x01 \x01(\x03R\x13MaxAppliedCommandId\x12;\n" +
+	"\fLastResponse\x18\x02 \x01(\v2\x17.raftpb.CommandResponseR\fLastResponse\"q\n" +
+	"\tShardData\x12,\n" +
+	"\x03Kvs\x18\x01 \x03(\v2\x1a.raftpb.ShardData.KvsEntryR\x03Kvs\x1a6\n" +
+	"\bKvsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"K\n" +
+	"\tLeaseInfo\x12\x18\n" +
+	"\aLeaseId\x18\x01 \x01(\x03R\aLeaseId\x12\x10\n" +
+	"\x03TTL\x18\x02 \x01(\x03R\x03TTL\x12\x12\n" +
+	"\x04Keys\x18\x03 \x03(\tR\x04Keys\"k\n" +
+	"\x15ShardOperationRequest\x12\x1c\n" +
+	"\tConfigNum\x18\x01 \x01(\x03R\tConfigNum\x12\x1a\n" +
+	"\bShardIds\x18\x02 \x03(\x03R\bShardIds\x12\x18\n" +
+	"\aGroupId\x18\x03 \x01(\x03R\aGroupId\"\xcf\x03\n" +
+	"\x16ShardOperationResponse\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x1c\n" +
+	"\tConfigNum\x18\x02 \x01(\x03R\tConfigNum\x12B\n" +
+	"\x06Shards\x18\x03 \x03(\v2*.raftpb.ShardOperationResponse.ShardsEntryR\x06Shards\x12Z\n" +
+	"\x0eLastOperations\x18\x04 \x03(\v22.raftpb.ShardOperationResponse.LastOperationsEntryR\x0eLastOperations\x12)\n" +
+	"\x06Leases\x18\x05 \x03(\v2\x11.raftpb.LeaseInfoR\x06Leases\x1aL\n" +
+	"\vShardsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12'\n" +
+	"\x05value\x18\x02 \x01(\v2\x11.raftpb.ShardDataR\x05value:\x028\x01\x1a[\n" +
+	"\x13LastOperationsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12.\n" +
+	"\x05value\x18\x02 \x01(\v2\x18.raftpb.OperationContextR\x05value:\x028\x01\"\x99\x01\n" +
+	"\tRangeInfo\x12\x10\n" +
+	"\x03Gid\x18\x01 \x01(\x03R\x03Gid\x12\x1a\n" +
+	"\bStartKey\x18\x02 \x01(\tR\bStartKey\x12\x16\n" +
+	"\x06EndKey\x18\x03 \x01(\tR\x06EndKey\x12\x14\n" +
+	"\x05Epoch\x18\x04 \x01(\x03R\x05Epoch\x12\x14\n" +
+	"\x05Peers\x18\x05 \x03(\tR\x05Peers\x12\x1a\n" +
+	"\bLeaderId\x18\x06 \x01(\x03R\bLeaderId\"\x12\n" +
+	"\x10GetRangesRequest\">\n" +
+	"\x11GetRangesResponse\x12)\n" +
+	"\x06Ranges\x18\x01 \x03(\v2\x11.raftpb.RangeInfoR\x06Ranges\"e\n" +
+	"\x15RangeOperationRequest\x12\x18\n" +
+	"\aGroupId\x18\x01 \x01(\x03R\aGroupId\x12\x14\n" +
+	"\x05Epoch\x18\x02 \x01(\x03R\x05Epoch\x12\x1c\n" +
+	"\tTargetGid\x18\x03 \x01(\x03R\tTargetGid\"\xef\x02\n" +
+	"\x16RangeOperationResponse\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12'\n" +
+	"\x05Range\x18\x02 \x01(\v2\x11.raftpb.RangeInfoR\x05Range\x12%\n" +
+	"\x04Data\x18\x03 \x01(\v2\x11.raftpb.ShardDataR\x04Data\x12Z\n" +
+	"\x0eLastOperations\x18\x04 \x03(\v22.raftpb.RangeOperationResponse.LastOperationsEntryR\x0eLastOperations\x12)\n" +
+	"\x06Leases\x18\x05 \x03(\v2\x11.raftpb.LeaseInfoR\x06Leases\x1a[\n" +
+	"\x13LastOperationsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12.\n" +
+	"\x05value\x18\x02 \x01(\v2\x18.raftpb.OperationContextR\x05value:\x028\x01\"h\n" +
+	"\bKeyValue\x12\x10\n" +
+	"\x03Key\x18\x01 \x01(\tR\x03Key\x12\x14\n" +
+	"\x05Value\x18\x02 \x01(\tR\x05Value\x12\x18\n" +
+	"\aVersion\x18\x03 \x01(\x03R\aVersion\x12\x1a\n" +
+	"\bModIndex\x18\x04 \x01(\x03R\bModIndex\"\xb3\x01\n" +
+	"\vScanRequest\x12\x1a\n" +
+	"\bStartKey\x18\x01 \x01(\tR\bStartKey\x12\x16\n" +
+	"\x06EndKey\x18\x02 \x01(\tR\x06EndKey\x12\x14\n" +
+	"\x05Limit\x18\x03 \x01(\x03R\x05Limit\x12\x18\n" +
+	"\aReverse\x18\x04 \x01(\bR\aReverse\x12\"\n" +
+	"\fAppliedIndex\x18\x05 \x01(\x03R\fAppliedIndex\x12\x1c\n" +
+	"\tPageToken\x18\x06 \x01(\fR\tPageToken\"\xbb\x01\n" +
+	"\fScanResponse\x12\"\n" +
+	"\x03Kvs\x18\x01 \x03(\v2\x10.raftpb.KeyValueR\x03Kvs\x12!\n" +
+	"\x03Err\x18\x02 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x1a\n" +
+	"\bLeaderId\x18\x03 \x01(\x03R\bLeaderId\x12\"\n" +
+	"\fAppliedIndex\x18\x04 \x01(\x03R\fAppliedIndex\x12$\n" +
+	"\rNextPageToken\x18\x05 \x01(\fR\rNextPageToken\"\xa9\x01\n" +
+	"\aCompare\x12\x10\n" +
+	"\x03Key\x18\x01 \x01(\tR\x03Key\x12-\n" +
+	"\x06Target\x18\x02 \x01(\x0e2\x15.raftpb.CompareTargetR\x06Target\x12-\n" +
+	"\x06Result\x18\x03 \x01(\x0e2\x15.raftpb.CompareResultR\x06Result\x12\x14\n" +
+	"\x05Value\x18\x04 \x01(\tR\x05Value\x12\x18\n" +
+	"\aVersion\x18\x05 \x01(\x03R\aVersion\"O\n" +
+	"\x05TxnOp\x12\x1e\n" +
+	"\x02Op\x18\x01 \x01(\x0e2\x0e.raftpb.OpTypeR\x02Op\x12\x10\n" +
+	"\x03Key\x18\x02 \x01(\tR\x03Key\x12\x14\n" +
+	"\x05Value\x18\x03 \x01(\tR\x05Value\"`\n" +
+	"\vTxnOpResult\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x14\n" +
+	"\x05Value\x18\x02 \x01(\tR\x05Value\x12\x18\n" +
+	"\aVersion\x18\x03 \x01(\x03R\aVersion\"\xc5\x01\n" +
+	"\n" +
+	"TxnRequest\x12+\n" +
+	"\bCompares\x18\x01 \x03(\v2\x0f.raftpb.CompareR\bCompares\x12'\n" +
+	"\aSuccess\x18\x02 \x03(\v2\r.raftpb.TxnOpR\aSuccess\x12'\n" +
+	"\aFailure\x18\x03 \x03(\v2\r.raftpb.TxnOpR\aFailure\x12\x1a\n" +
+	"\bClientId\x18\x04 \x01(\x03R\bClientId\x12\x1c\n" +
+	"\tCommandId\x18\x05 \x01(\x03R\tCommandId\"\x99\x01\n" +
+	"\vTxnResponse\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x1a\n" +
+	"\bLeaderId\x18\x02 \x01(\x03R\bLeaderId\x12\x1c\n" +
+	"\tSucceeded\x18\x03 \x01(\bR\tSucceeded\x12-\n" +
+	"\aResults\x18\x04 \x03(\v2\x13.raftpb.TxnOpResultR\aResults\"\x98\x01\n" +
+	"\x0eDistTxnRequest\x12+\n" +
+	"\bCompares\x18\x01 \x03(\v2\x0f.raftpb.CompareR\bCompares\x12\x1f\n" +
+	"\x03Ops\x18\x02 \x03(\v2\r.raftpb.TxnOpR\x03Ops\x12\x1a\n" +
+	"\bClientId\x18\x03 \x01(\x03R\bClientId\x12\x1c\n" +
+	"\tCommandId\x18\x04 \x01(\x03R\tCommandId\"\x84\x01\n" +
+	"\x0fDistTxnResponse\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x1a\n" +
+	"\bLeaderId\x18\x02 \x01(\x03R\bLeaderId\x12\x1c\n" +
+	"\tCommitted\x18\x03 \x01(\bR\tCommitted\x12\x14\n" +
+	"\x05TxnId\x18\x04 \x01(\x03R\x05TxnId\"\xe5\x01\n" +
+	"\x11TxnPrepareRequest\x12\x18\n" +
+	"\aGroupId\x18\x01 \x01(\x03R\aGroupId\x12\x14\n" +
+	"\x05TxnId\x18\x02 \x01(\x03R\x05TxnId\x12&\n" +
+	"\x0eCoordinatorGid\x18\x03 \x01(\x03R\x0eCoordinatorGid\x12*\n" +
+	"\x10CoordinatorPeers\x18\x04 \x03(\tR\x10CoordinatorPeers\x12+\n" +
+	"\bCompares\x18\x05 \x03(\v2\x0f.raftpb.CompareR\bCompares\x12\x1f\n" +
+	"\x03Ops\x18\x06 \x03(\v2\r.raftpb.TxnOpR\x03Ops\"S\n" +
+	"\x12TxnPrepareResponse\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x1a\n" +
+	"\bPrepared\x18\x02 \x01(\bR\bPrepared\"[\n" +
+	"\x11TxnResolveRequest\x12\x18\n" +
+	"\aGroupId\x18\x01 \x01(\x03R\aGroupId\x12\x14\n" +
+	"\x05TxnId\x18\x02 \x01(\x03R\x05TxnId\x12\x16\n" +
+	"\x06Commit\x18\x03 \x01(\bR\x06Commit\"7\n" +
+	"\x12TxnResolveResponse\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\"j\n" +
+	"\x10TxnStatusRequest\x12\x18\n" +
+	"\aGroupId\x18\x01 \x01(\x03R\aGroupId\x12\x14\n" +
+	"\x05TxnId\x18\x02 \x01(\x03R\x05TxnId\x12&\n" +
+	"\x0eAbortIfPending\x18\x03 \x01(\bR\x0eAbortIfPending\"^\n" +
+	"\x11TxnStatusResponse\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12&\n" +
+	"\x05State\x18\x02 \x01(\x0e2\x10.raftpb.TxnStateR\x05State\"Q\n" +
+	"\x11LeaseGrantRequest\x12\x10\n" +
+	"\x03Key\x18\x01 \x01(\tR\x03Key\x12\x10\n" +
+	"\x03TTL\x18\x02 \x01(\x03R\x03TTL\x12\x18\n" +
+	"\aLeaseId\x18\x03 \x01(\x03R\aLeaseId\"\x7f\n" +
+	"\x12LeaseGrantResponse\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x1a\n" +
+	"\bLeaderId\x18\x02 \x01(\x03R\bLeaderId\x12\x18\n" +
+	"\aLeaseId\x18\x03 \x01(\x03R\aLeaseId\x12\x10\n" +
+	"\x03TTL\x18\x04 \x01(\x03R\x03TTL\"@\n" +
+	"\x12LeaseRevokeRequest\x12\x18\n" +
+	"\aLeaseId\x18\x01 \x01(\x03R\aLeaseId\x12\x10\n" +
+	"\x03Key\x18\x02 \x01(\tR\x03Key\"T\n" +
+	"\x13LeaseRevokeResponse\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x1a\n" +
+	"\bLeaderId\x18\x02 \x01(\x03R\bLeaderId\"C\n" +
+	"\x15LeaseKeepAliveRequest\x12\x18\n" +
+	"\aLeaseId\x18\x01 \x01(\x03R\aLeaseId\x12\x10\n" +
+	"\x03Key\x18\x02 \x01(\tR\x03Key\"i\n" +
+	"\x16LeaseKeepAliveResponse\x12!\n" +
+	"\x03Err\x18\x01 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x1a\n" +
+	"\bLeaderId\x18\x02 \x01(\x03R\bLeaderId\x12\x10\n" +
+	"\x03TTL\x18\x03 \x01(\x03R\x03TTL\"\x91\x01\n" +
+	"\n" +
+	"WatchEvent\x12%\n" +
+	"\x04Type\x18\x01 \x01(\x0e2\x11.raftpb.EventTypeR\x04Type\x12\x10\n" +
+	"\x03Key\x18\x02 \x01(\tR\x03Key\x12\x14\n" +
+	"\x05Value\x18\x03 \x01(\tR\x05Value\x12\x18\n" +
+	"\aVersion\x18\x04 \x01(\x03R\aVersion\x12\x1a\n" +
+	"\bModIndex\x18\x05 \x01(\x03R\bModIndex\"j\n" +
+	"\fWatchRequest\x12\x10\n" +
+	"\x03Key\x18\x01 \x01(\tR\x03Key\x12\x16\n" +
+	"\x06Prefix\x18\x02 \x01(\bR\x06Prefix\x12\x1e\n" +
+	"\n" +
+	"StartIndex\x18\x03 \x01(\x03R\n" +
+	"StartIndex\x12\x10\n" +
+	"\x03Gid\x18\x04 \x01(\x03R\x03Gid\"\xce\x01\n" +
+	"\rWatchResponse\x12*\n" +
+	"\x06Events\x18\x01 \x03(\v2\x12.raftpb.WatchEventR\x06Events\x12!\n" +
+	"\x03Err\x18\x02 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\x1a\n" +
+	"\bLeaderId\x18\x03 \x01(\x03R\bLeaderId\x12\x10\n" +
+	"\x03Gid\x18\x04 \x01(\x03R\x03Gid\x12\"\n" +
+	"\fCompactIndex\x18\x05 \x01(\x03R\fCompactIndex\x12\x1c\n" +
+	"\tNextIndex\x18\x06 \x01(\x03R\tNextIndex\"\xbd\x01\n" +
+	"\tCdcRecord\x12\x10\n" +
+	"\x03Gid\x18\x01 \x01(\x03R\x03Gid\x12\x14\n" +
+	"\x05Index\x18\x02 \x01(\x03R\x05Index\x12\x12\n" +
+	"\x04Term\x18\x03 \x01(\x03R\x04Term\x12\x12\n" +
+	"\x04Type\x18\x04 \x01(\tR\x04Type\x120\n" +
+	"\tMutations\x18\x05 \x03(\v2\x12.raftpb.WatchEventR\tMutations\x12\x1a\n" +
+	"\bSnapshot\x18\x06 \x01(\bR\bSnapshot\x12\x12\n" +
+	"\x04More\x18\a \x01(\bR\x04More\"Z\n" +
+	"\n" +
+	"CdcRequest\x12\x10\n" +
+	"\x03Gid\x18\x01 \x01(\x03R\x03Gid\x12\x1e\n" +
+	"\n" +
+	"StartIndex\x18\x02 \x01(\x03R\n" +
+	"StartIndex\x12\x1a\n" +
+	"\bSnapshot\x18\x03 \x01(\bR\bSnapshot\"\x81\x01\n" +
+	"\vCdcResponse\x12+\n" +
+	"\aRecords\x18\x01 \x03(\v2\x11.raftpb.CdcRecordR\aRecords\x12!\n" +
+	"\x03Err\x18\x02 \x01(\x0e2\x0f.raftpb.ErrCodeR\x03Err\x12\"\n" +
+	"\fCompactIndex\x18\x03 \x01(\x03R\fCompactIndex*k\n" +
+	"\x06OpType\x12\t\n" +
+	"\x05OpPut\x10\x00\x12\f\n" +
+	"\bOpAppend\x10\x01\x12\t\n" +
+	"\x05OpGet\x10\x02\x12\t\n" +
+	"\x05OpDel\x10\x03\x12\t\n" +
+	"\x05OpCas\x10\x04\x12\x11\n" +
+	"\rOpPutIfAbsent\x10\x05\x12\x14\n" +
+	"\x10OpDeleteIfEquals\x10\x06*\xdb\x01\n" +
+	"\aErrCode\x12\t\n" +
+	"\x05ErrOK\x10\x00\x12\f\n" +
+	"\bErrNoKey\x10\x01\x12\x12\n" +
+	"\x0eErrWrongLeader\x10\x02\x12\x11\n" +
+	"\rErrWrongGroup\x10\x03\x12\x0e\n" +
+	"\n" +
+	"ErrTimeout\x10\x04\x12\x0f\n" +
+	"\vErrNotReady\x10\x05\x12\x0f\n" +
+	"\vErrOutDated\x10\x06\x12\x14\n" +
+	"\x10ErrCompareFailed\x10\a\x12\x11\n" +
+	"\rErrBadRequest\x10\b\x12\r\n" +
+	"\tErrLocked\x10\t\x12\x14\n" +
+	"\x10ErrLeaseNotFound\x10\n" +
+	"\x12\x10\n" +
+	"\fErrCompacted\x10\v*-\n" +
+	"\rCompareTarget\x12\f\n" +
+	"\bCmpValue\x10\x00\x12\x0e\n" +
+	"\n" +
+	"CmpVersion\x10\x01*K\n" +
+	"\rCompareResult\x12\f\n" +
+	"\bCmpEqual\x10\x00\x12\x0f\n" +
+	"\vCmpNotEqual\x10\x01\x12\x0e\n" +
+	"\n" +
+	"CmpGreater\x10\x02\x12\v\n" +
+	"\aCmpLess\x10\x03*L\n" +
+	"\bTxnState\x12\x0e\n" +
+	"\n" +
+	"TxnPending\x10\x00\x12\x10\n" +
+	"\fTxnCommitted\x10\x01\x12\x0e\n" +
+	"\n" +
+	"TxnAborted\x10\x02\x12\x0e\n" +
+	"\n" +
+	"TxnUnknown\x10\x03**\n" +
+	"\tEventType\x12\f\n" +
+	"\bEventPut\x10\x00\x12\x0f\n" +
+	"\vEventDelete\x10\x012\xb4\b\n" +
+	"\x0eShardKVService\x12:\n" +
+	"\aCommand\x12\x16.raftpb.CommandRequest\x1a\x17.raftpb.CommandResponse\x12J\n" +
+	"\tPullShard\x12\x1d.raftpb.ShardOperationRequest\x1a\x1e.raftpb.ShardOperationResponse\x12L\n" +
+	"\vDeleteShard\x12\x1d.raftpb.ShardOperationRequest\x1a\x1e.raftpb.ShardOperationResponse\x12@\n" +
+	"\tGetRanges\x12\x18.raftpb.GetRangesRequest\x1a\x19.raftpb.GetRangesResponse\x12L\n" +
+	"\vFreezeRange\x12\x1d.raftpb.RangeOperationRequest\x1a\x1e.raftpb.RangeOperationResponse\x123\n" +
+	"\x04Scan\x12\x13.raftpb.ScanRequest\x1a\x14.raftpb.ScanResponse0\x01\x12.\n" +
+	"\x03Txn\x12\x12.raftpb.TxnRequest\x1a\x13.raftpb.TxnResponse\x12:\n" +
+	"\aDistTxn\x12\x16.raftpb.DistTxnRequest\x1a\x17.raftpb.DistTxnResponse\x12C\n" +
+	"\n" +
+	"TxnPrepare\x12\x19.raftpb.TxnPrepareRequest\x1a\x1a.raftpb.TxnPrepareResponse\x12C\n" +
+	"\n" +
+	"TxnResolve\x12\x19.raftpb.TxnResolveRequest\x1a\x1a.raftpb.TxnResolveResponse\x12@\n" +
+	"\tTxnStatus\x12\x18.raftpb.TxnStatusRequest\x1a\x19.raftpb.TxnStatusResponse\x12C\n" +
+	"\n" +
+	"LeaseGrant\x12\x19.raftpb.LeaseGrantRequest\x1a\x1a.raftpb.LeaseGrantResponse\x12F\n" +
+	"\vLeaseRevoke\x12\x1a.raftpb.LeaseRevokeRequest\x1a\x1b.raftpb.LeaseRevokeResponse\x12O\n" +
+	"\x0eLeaseKeepAlive\x12\x1d.raftpb.LeaseKeepAliveRequest\x1a\x1e.raftpb.LeaseKeepAliveResponse\x126\n" +
+	"\x05Watch\x12\x14.raftpb.WatchRequest\x1a\x15.raftpb.WatchResponse0\x01\x129\n" +
+	"\fCdcSubscribe\x12\x12.raftpb.CdcRequest\x1a\x13.raftpb.CdcResponse0\x01B\vZ\t../raftpbb\x06proto3"
+
+var (
+	file_shardkv_proto_rawDescOnce sync.Once
+	file_shardkv_proto_rawDescData []byte
+)
+
+func file_shardkv_proto_rawDescGZIP() []byte {
+	file_shardkv_proto_rawDescOnce.Do(func() {
+		file_shardkv_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_shardkv_proto_rawDesc), len(file_shardkv_proto_rawDesc)))
+	})
+	return file_shardkv_proto_rawDescData
+}
+
+var file_shardkv_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_shardkv_proto_msgTypes = make([]protoimpl.MessageInfo, 44)
+var file_shardkv_proto_goTypes = []any{
+	(OpType)(0),                    // 0: raftpb.OpType
+	(ErrCode)(0),                   // 1: raftpb.ErrCode
+	(CompareTarget)(0),             // 2: raftpb.CompareTarget
+	(CompareResult)(0),             // 3: raftpb.CompareResult
+	(TxnState)(0),                  // 4: raftpb.TxnState
+	(EventType)(0),                 // 5: raftpb.EventType
+	(*CommandRequest)(nil),         // 6: raftpb.CommandRequest
+	(*CommandResponse)(nil),        // 7: raftpb.CommandResponse
+	(*OperationContext)(nil),       // 8: raftpb.OperationContext
+	(*ShardData)(nil),              // 9: raftpb.ShardData
+	(*LeaseInfo)(nil),              // 10: raftpb.LeaseInfo
+	(*ShardOperationRequest)(nil),  // 11: raftpb.ShardOperationRequest
+	(*ShardOperationResponse)(nil), // 12: raftpb.ShardOperationResponse
+	(*RangeInfo)(nil),              // 13: raftpb.RangeInfo
+	(*GetRangesRequest)(nil),       // 14: raftpb.GetRangesRequest
+	(*GetRangesResponse)(nil),      // 15: raftpb.GetRangesResponse
+	(*RangeOperationRequest)(nil),  // 16: raftpb.RangeOperationRequest
+	(*RangeOperationResponse)(nil), // 17: raftpb.RangeOperationResponse
+	(*KeyValue)(nil),               // 18: raftpb.KeyValue
+	(*ScanRequest)(nil),            // 19: raftpb.ScanRequest
+	(*ScanResponse)(nil),           // 20: raftpb.ScanResponse
+	(*Compare)(nil),                // 21: raftpb.Compare
+	(*TxnOp)(nil),                  // 22: raftpb.TxnOp
+	(*TxnOpResult)(nil),            // 23: raftpb.TxnOpResult
+	(*TxnRequest)(nil),             // 24: raftpb.TxnRequest
+	(*TxnResponse)(nil),            // 25: raftpb.TxnResponse
+	(*DistTxnRequest)(nil),         // 26: raftpb.DistTxnRequest
+	(*DistTxnResponse)(nil),        // 27: raftpb.DistTxnResponse
+	(*TxnPrepareRequest)(nil),      // 28: raftpb.TxnPrepareRequest
+	(*TxnPrepareResponse)(nil),     // 29: raftpb.TxnPrepareResponse
+	(*TxnResolveRequest)(nil),      // 30: raftpb.TxnResolveRequest
+	(*TxnResolveResponse)(nil),     // 31: raftpb.TxnResolveResponse
+	(*TxnStatusRequest)(nil),       // 32: raftpb.TxnStatusRequest
+	(*TxnStatusResponse)(nil),      // 33: raftpb.TxnStatusResponse
+	(*LeaseGrantRequest)(nil),      // 34: raftpb.LeaseGrantRequest
+	(*LeaseGrantResponse)(nil),     // 35: raftpb.LeaseGrantResponse
+	(*LeaseRevokeRequest)(nil),     // 36: raftpb.LeaseRevokeRequest
+	(*LeaseRevokeResponse)(nil),    // 37: raftpb.LeaseRevokeResponse
+	(*LeaseKeepAliveRequest)(nil),  // 38: raftpb.LeaseKeepAliveRequest
+	(*LeaseKeepAliveResponse)(nil), // 39: raftpb.LeaseKeepAliveResponse
+	(*WatchEvent)(nil),             // 40: raftpb.WatchEvent
+	(*WatchRequest)(nil),           // 41: raftpb.WatchRequest
+	(*WatchResponse)(nil),          // 42: raftpb.WatchResponse
+	(*CdcRecord)(nil),              // 43: raftpb.CdcRecord
+	(*CdcRequest)(nil),             // 44: raftpb.CdcRequest
+	(*CdcResponse)(nil),            // 45: raftpb.CdcResponse
+	nil,                            // 46: raftpb.ShardData.KvsEntry
+	nil,                            // 47: raftpb.ShardOperationResponse.ShardsEntry
+	nil,                            // 48: raftpb.ShardOperationResponse.LastOperationsEntry
+	nil,                            // 49: raftpb.RangeOperationResponse.LastOperationsEntry
+}
+var file_shardkv_proto_depIdxs = []int32{
+	0,  // 0: raftpb.CommandRequest.Op:type_name -> raftpb.OpType
+	1,  // 1: raftpb.CommandResponse.Err:type_name -> raftpb.ErrCode
+	25, // 2: raftpb.CommandResponse.Txn:type_name -> raftpb.TxnResponse
+	7,  // 3: raftpb.OperationContext.LastResponse:type_name -> raftpb.CommandResponse
+	46, // 4: raftpb.ShardData.Kvs:type_name -> raftpb.ShardData.KvsEntry
+	1,  // 5: raftpb.ShardOperationResponse.Err:type_name -> raftpb.ErrCode
+	47, // 6: raftpb.ShardOperationResponse.Shards:type_name -> raftpb.ShardOperationResponse.ShardsEntry
+	48, // 7: raftpb.ShardOperationResponse.LastOperations:type_name -> raftpb.ShardOperationResponse.LastOperationsEntry
+	10, // 8: raftpb.ShardOperationResponse.Leases:type_name -> raftpb.LeaseInfo
+	13, // 9: raftpb.GetRangesResponse.Ranges:type_name -> raftpb.RangeInfo
+	1,  // 10: raftpb.RangeOperationResponse.Err:type_name -> raftpb.ErrCode
+	13, // 11: raftpb.RangeOperationResponse.Range:type_name -> raftpb.RangeInfo
+	9,  // 12: raftpb.RangeOperationResponse.Data:type_name -> raftpb.ShardData
+	49, // 13: raftpb.RangeOperationResponse.LastOperations:type_name -> raftpb.RangeOperationResponse.LastOperationsEntry
+	10, // 14: raftpb.RangeOperationResponse.Leases:type_name -> raftpb.LeaseInfo
+	18, // 15: raftpb.ScanResponse.Kvs:type_name -> raftpb.KeyValue
+	1,  // 16: raftpb.ScanResponse.Err:type_name -> raftpb.ErrCode
+	2,  // 17: raftpb.Compare.Target:type_name -> raftpb.CompareTarget
+	3,  // 18: raftpb.Compare.Result:type_name -> raftpb.CompareResult
+	0,  // 19: raftpb.TxnOp.Op:type_name -> raftpb.OpType
+	1,  // 20: raftpb.TxnOpResult.Err:type_name -> raftpb.ErrCode
+	21, // 21: raftpb.TxnRequest.Compares:type_name -> raftpb.Compare
+	22, // 22: raftpb.TxnRequest.Success:type_name -> raftpb.TxnOp
+	22, // 23: raftpb.TxnRequest.Failure:type_name -> raftpb.TxnOp
+	1,  // 24: raftpb.TxnResponse.Err:type_name -> raftpb.ErrCode
+	23, // 25: raftpb.TxnResponse.Results:type_name -> raftpb.TxnOpResult
+	21, // 26: raftpb.DistTxnRequest.Compares:type_name -> raftpb.Compare
+	22, // 27: raftpb.DistTxnRequest.Ops:type_name -> raftpb.TxnOp
+	1,  // 28: raftpb.DistTxnResponse.Err:type_name -> raftpb.ErrCode
+	21, // 29: raftpb.TxnPrepareRequest.Compares:type_name -> raftpb.Compare
+	22, // 30: raftpb.TxnPrepareRequest.Ops:type_name -> raftpb.TxnOp
+	1,  // 31: raftpb.TxnPrepareResponse.Err:type_name -> raftpb.ErrCode
+	1,  // 32: raftpb.TxnResolveResponse.Err:type_name -> raftpb.ErrCode
+	1,  // 33: raftpb.TxnStatusResponse.Err:type_name -> raftpb.ErrCode
+	4,  // 34: raftpb.TxnStatusResponse.State:type_name -> raftpb.TxnState
+	1,  // 35: raftpb.LeaseGrantResponse.Err:type_name -> raftpb.ErrCode
+	1,  // 36: raftpb.LeaseRevokeResponse.Err:type_name -> raftpb.ErrCode
+	1,  // 37: raftpb.LeaseKeepAliveResponse.Err:type_name -> raftpb.ErrCode
+	5,  // 38: raftpb.WatchEvent.Type:type_name -> raftpb.EventType
+	40, // 39: raftpb.WatchResponse.Events:type_name -> raftpb.WatchEvent
+	1,  // 40: raftpb.WatchResponse.Err:type_name -> raftpb.ErrCode
+	40, // 41: raftpb.CdcRecord.Mutations:type_name -> raftpb.WatchEvent
+	43, // 42: raftpb.CdcResponse.Records:type_name -> raftpb.CdcRecord
+	1,  // 43: raftpb.CdcResponse.Err:type_name -> raftpb.ErrCode
+	9,  // 44: raftpb.ShardOperationResponse.ShardsEntry.value:type_name -> raftpb.ShardData
+	8,  // 45: raftpb.ShardOperationResponse.LastOperationsEntry.value:type_name -> raftpb.OperationContext
+	8,  // 46: raftpb.RangeOperationResponse.LastOperationsEntry.value:type_name -> raftpb.OperationContext
+	6,  // 47: raftpb.ShardKVService.Command:input_type -> raftpb.CommandRequest
+	11, // 48: raftpb.ShardKVService.PullShard:input_type -> raftpb.ShardOperationRequest
+	11, // 49: raftpb.ShardKVService.DeleteShard:input_type -> raftpb.ShardOperationRequest
+	14, // 50: raftpb.ShardKVService.GetRanges:input_type -> raftpb.GetRangesRequest
+	16, // 51: raftpb.ShardKVService.FreezeRange:input_type -> raftpb.RangeOperationRequest
+	19, // 52: raftpb.ShardKVService.Scan:input_type -> raftpb.ScanRequest
+	24, // 53: raftpb.ShardKVService.Txn:input_type -> raftpb.TxnRequest
+	26, // 54: raftpb.ShardKVService.DistTxn:input_type -> raftpb.DistTxnRequest
+	28, // 55: raftpb.ShardKVService.TxnPrepare:input_type -> raftpb.TxnPrepareRequest
+	30, // 56: raftpb.ShardKVService.TxnResolve:input_type -> raftpb.TxnResolveRequest
+	32, // 57: raftpb.ShardKVService.TxnStatus:input_type -> raftpb.TxnStatusRequest
+	34, // 58: raftpb.ShardKVService.LeaseGrant:input_type -> raftpb.LeaseGrantRequest
+	36, // 59: raftpb.ShardKVService.LeaseRevoke:input_type -> raftpb.LeaseRevokeRequest
+	38, // 60: raftpb.ShardKVService.LeaseKeepAlive:input_type -> raftpb.LeaseKeepAliveRequest
+	41, // 61: raftpb.ShardKVService.Watch:input_type -> raftpb.WatchRequest
+	44, // 62: raftpb.ShardKVService.CdcSubscribe:input_type -> raftpb.CdcRequest
+	7,  // 63: raftpb.ShardKVService.Command:output_type -> raftpb.CommandResponse
+	12, // 64: raftpb.ShardKVService.PullShard:output_type -> raftpb.ShardOperationResponse
+	12, // 65: raftpb.ShardKVService.DeleteShard:output_type -> raftpb.ShardOperationResponse
+	15, // 66: raftpb.ShardKVService.GetRanges:output_type -> raftpb.GetRangesResponse
+	17, // 67: raftpb.ShardKVService.FreezeRange:output_type -> raftpb.RangeOperationResponse
+	20, // 68: raftpb.ShardKVService.Scan:output_type -> raftpb.ScanResponse
+	25, // 69: raftpb.ShardKVService.Txn:output_type -> raftpb.TxnResponse
+	27, // 70: raftpb.ShardKVService.DistTxn:output_type -> raftpb.DistTxnResponse
+	29, // 71: raftpb.ShardKVService.TxnPrepare:output_type -> raftpb.TxnPrepareResponse
+	31, // 72: raftpb.ShardKVService.TxnResolve:output_type -> raftpb.TxnResolveResponse
+	33, // 73: raftpb.ShardKVService.TxnStatus:output_type -> raftpb.TxnStatusResponse
+	35, // 74: raftpb.ShardKVService.LeaseGrant:output_type -> raftpb.LeaseGrantResponse
+	37, // 75: raftpb.ShardKVService.LeaseRevoke:output_type -> raftpb.LeaseRevokeResponse
+	39, // 76: raftpb.ShardKVService.LeaseKeepAlive:output_type -> raftpb.LeaseKeepAliveResponse
+	42, // 77: raftpb.ShardKVService.Watch:output_type -> raftpb.WatchResponse
+	45, // 78: raftpb.ShardKVService.CdcSubscribe:output_type -> raftpb.CdcResponse
+	63, // [63:79] is the sub-list for method output_type
+	47, // [47:63] is the sub-list for method input_type
+	47, // [47:47] is the sub-list for extension type_name
+	47, // [47:47] is the sub-list for extension extendee
+	0,  // [0:47] is the sub-list for field type_name
+}
+
+func init() { file_shardkv_proto_init() }
+func file_shardkv_proto_init() {
+	if File_shardkv_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_shardkv_proto_rawDesc), len(file_shardkv_proto_rawDesc)),
+			NumEnums:      6,
+			NumMessages:   44,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_shardkv_proto_goTypes,
+		DependencyIndexes: file_shardkv_proto_depIdxs,
+		EnumInfos:         file_shardkv_proto_enumTypes,
+		MessageInfos:      file_shardkv_proto_msgTypes,
+	}.Build()
+	File_shardkv_proto = out.File
+	file_shardkv_proto_goTypes = nil
+	file_shardkv_proto_depIdxs = nil
+}
@@ -0,0 +1,701 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             v3.6.1
+// source: shardkv.proto
+
+package raftpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ShardKVService_Command_FullMethodName        = "/raftpb.ShardKVService/Command"
+	ShardKVService_PullShard_FullMethodName      = "/raftpb.ShardKVService/PullShard"
+	ShardKVService_DeleteShard_FullMethodName    = "/raftpb.ShardKVService/DeleteShard"
+	ShardKVService_GetRanges_FullMethodName      = "/raftpb.ShardKVService/GetRanges"
+	ShardKVService_FreezeRange_FullMethodName    = "/raftpb.ShardKVService/FreezeRange"
+	ShardKVService_Scan_FullMethodName           = "/raftpb.ShardKVService/Scan"
+	ShardKVService_Txn_FullMethodName            = "/raftpb.ShardKVService/Txn"
+	ShardKVService_DistTxn_FullMethodName        = "/raftpb.ShardKVService/DistTxn"
+	ShardKVService_TxnPrepare_FullMethodName     = "/raftpb.ShardKVService/TxnPrepare"
+	ShardKVService_TxnResolve_FullMethodName     = "/raftpb.ShardKVService/TxnResolve"
+	ShardKVService_TxnStatus_FullMethodName      = "/raftpb.ShardKVService/TxnStatus"
+	ShardKVService_LeaseGrant_FullMethodName     = "/raftpb.ShardKVService/LeaseGrant"
+	ShardKVService_LeaseRevoke_FullMethodName    = "/raftpb.ShardKVService/LeaseRevoke"
+	ShardKVService_LeaseKeepAlive_FullMethodName = "/raftpb.ShardKVService/LeaseKeepAlive"
+	ShardKVService_Watch_FullMethodName          = "/raftpb.ShardKVService/Watch"
+	ShardKVService_CdcSubscribe_FullMethodName   = "/raftpb.ShardKVService/CdcSubscribe"
+)
+
+// ShardKVServiceClient is the client API for ShardKVService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ShardKVServiceClient interface {
+	Command(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	PullShard(ctx context.Context, in *ShardOperationRequest, opts ...grpc.CallOption) (*ShardOperationResponse, error)
+	DeleteShard(ctx context.Context, in *ShardOperationRequest, opts ...grpc.CallOption) (*ShardOperationResponse, error)
+	GetRanges(ctx context.Context, in *GetRangesRequest, opts ...grpc.CallOption) (*GetRangesResponse, error)
+	FreezeRange(ctx context.Context, in *RangeOperationRequest, opts ...grpc.CallOption) (*RangeOperationResponse, error)
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScanResponse], error)
+	Txn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*TxnResponse, error)
+	DistTxn(ctx context.Context, in *DistTxnRequest, opts ...grpc.CallOption) (*DistTxnResponse, error)
+	TxnPrepare(ctx context.Context, in *TxnPrepareRequest, opts ...grpc.CallOption) (*TxnPrepareResponse, error)
+	TxnResolve(ctx context.Context, in *TxnResolveRequest, opts ...grpc.CallOption) (*TxnResolveResponse, error)
+	TxnStatus(ctx context.Context, in *TxnStatusRequest, opts ...grpc.CallOption) (*TxnStatusResponse, error)
+	LeaseGrant(ctx context.Context, in *LeaseGrantRequest, opts ...grpc.CallOption) (*LeaseGrantResponse, error)
+	LeaseRevoke(ctx context.Context, in *LeaseRevokeRequest, opts ...grpc.CallOption) (*LeaseRevokeResponse, error)
+	LeaseKeepAlive(ctx context.Context, in *LeaseKeepAliveRequest, opts ...grpc.CallOption) (*LeaseKeepAliveResponse, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error)
+	CdcSubscribe(ctx context.Context, in *CdcRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CdcResponse], error)
+}
+
+type shardKVServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewShardKVServiceClient(cc grpc.ClientConnInterface) ShardKVServiceClient {
+	return &shardKVServiceClient{cc}
+}
+
+func (c *shardKVServiceClient) Command(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_Command_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) PullShard(ctx context.Context, in *ShardOperationRequest, opts ...grpc.CallOption) (*ShardOperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShardOperationResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_PullShard_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) DeleteShard(ctx context.Context, in *ShardOperationRequest, opts ...grpc.CallOption) (*ShardOperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShardOperationResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_DeleteShard_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) GetRanges(ctx context.Context, in *GetRangesRequest, opts ...grpc.CallOption) (*GetRangesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRangesResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_GetRanges_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) FreezeRange(ctx context.Context, in *RangeOperationRequest, opts ...grpc.CallOption) (*RangeOperationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RangeOperationResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_FreezeRange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScanResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ShardKVService_ServiceDesc.Streams[0], ShardKVService_Scan_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ScanRequest, ScanResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ShardKVService_ScanClient = grpc.ServerStreamingClient[ScanResponse]
+
+func (c *shardKVServiceClient) Txn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*TxnResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TxnResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_Txn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) DistTxn(ctx context.Context, in *DistTxnRequest, opts ...grpc.CallOption) (*DistTxnResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DistTxnResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_DistTxn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) TxnPrepare(ctx context.Context, in *TxnPrepareRequest, opts ...grpc.CallOption) (*TxnPrepareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TxnPrepareResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_TxnPrepare_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) TxnResolve(ctx context.Context, in *TxnResolveRequest, opts ...grpc.CallOption) (*TxnResolveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TxnResolveResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_TxnResolve_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) TxnStatus(ctx context.Context, in *TxnStatusRequest, opts ...grpc.CallOption) (*TxnStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TxnStatusResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_TxnStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) LeaseGrant(ctx context.Context, in *LeaseGrantRequest, opts ...grpc.CallOption) (*LeaseGrantResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LeaseGrantResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_LeaseGrant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) LeaseRevoke(ctx context.Context, in *LeaseRevokeRequest, opts ...grpc.CallOption) (*LeaseRevokeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LeaseRevokeResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_LeaseRevoke_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) LeaseKeepAlive(ctx context.Context, in *LeaseKeepAliveRequest, opts ...grpc.CallOption) (*LeaseKeepAliveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LeaseKeepAliveResponse)
+	err := c.cc.Invoke(ctx, ShardKVService_LeaseKeepAlive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardKVServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ShardKVService_ServiceDesc.Streams[1], ShardKVService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ShardKVService_WatchClient = grpc.ServerStreamingClient[WatchResponse]
+
+func (c *shardKVServiceClient) CdcSubscribe(ctx context.Context, in *CdcRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CdcResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ShardKVService_ServiceDesc.Streams[2], ShardKVService_CdcSubscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CdcRequest, CdcResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ShardKVService_CdcSubscribeClient = grpc.ServerStreamingClient[CdcResponse]
+
+// ShardKVServiceServer is the server API for ShardKVService service.
+// All implementations must embed UnimplementedShardKVServiceServer
+// for forward compatibility.
+type ShardKVServiceServer interface {
+	Command(context.Context, *CommandRequest) (*CommandResponse, error)
+	PullShard(context.Context, *ShardOperationRequest) (*ShardOperationResponse, error)
+	DeleteShard(context.Context, *ShardOperationRequest) (*ShardOperationResponse, error)
+	GetRanges(context.Context, *GetRangesRequest) (*GetRangesResponse, error)
+	FreezeRange(context.Context, *RangeOperationRequest) (*RangeOperationResponse, error)
+	Scan(*ScanRequest, grpc.ServerStreamingServer[ScanResponse]) error
+	Txn(context.Context, *TxnRequest) (*TxnResponse, error)
+	DistTxn(context.Context, *DistTxnRequest) (*DistTxnResponse, error)
+	TxnPrepare(context.Context, *TxnPrepareRequest) (*TxnPrepareResponse, error)
+	TxnResolve(context.Context, *TxnResolveRequest) (*TxnResolveResponse, error)
+	TxnStatus(context.Context, *TxnStatusRequest) (*TxnStatusResponse, error)
+	LeaseGrant(context.Context, *LeaseGrantRequest) (*LeaseGrantResponse, error)
+	LeaseRevoke(context.Context, *LeaseRevokeRequest) (*LeaseRevokeResponse, error)
+	LeaseKeepAlive(context.Context, *LeaseKeepAliveRequest) (*LeaseKeepAliveResponse, error)
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error
+	CdcSubscribe(*CdcRequest, grpc.ServerStreamingServer[CdcResponse]) error
+	mustEmbedUnimplementedShardKVServiceServer()
+}
+
+// UnimplementedShardKVServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedShardKVServiceServer struct{}
+
+func (UnimplementedShardKVServiceServer) Command(context.Context, *CommandRequest) (*CommandResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Command not implemented")
+}
+func (UnimplementedShardKVServiceServer) PullShard(context.Context, *ShardOperationRequest) (*ShardOperationResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PullShard not implemented")
+}
+func (UnimplementedShardKVServiceServer) DeleteShard(context.Context, *ShardOperationRequest) (*ShardOperationResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteShard not implemented")
+}
+func (UnimplementedShardKVServiceServer) GetRanges(context.Context, *GetRangesRequest) (*GetRangesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetRanges not implemented")
+}
+func (UnimplementedShardKVServiceServer) FreezeRange(context.Context, *RangeOperationRequest) (*RangeOperationResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method FreezeRange not implemented")
+}
+func (UnimplementedShardKVServiceServer) Scan(*ScanRequest, grpc.ServerStreamingServer[ScanResponse]) error {
+	return status.Error(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedShardKVServiceServer) Txn(context.Context, *TxnRequest) (*TxnResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Txn not implemented")
+}
+func (UnimplementedShardKVServiceServer) DistTxn(context.Context, *DistTxnRequest) (*DistTxnResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DistTxn not implemented")
+}
+func (UnimplementedShardKVServiceServer) TxnPrepare(context.Context, *TxnPrepareRequest) (*TxnPrepareResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method TxnPrepare not implemented")
+}
+func (UnimplementedShardKVServiceServer) TxnResolve(context.Context, *TxnResolveRequest) (*TxnResolveResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method TxnResolve not implemented")
+}
+func (UnimplementedShardKVServiceServer) TxnStatus(context.Context, *TxnStatusRequest) (*TxnStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method TxnStatus not implemented")
+}
+func (UnimplementedShardKVServiceServer) LeaseGrant(context.Context, *LeaseGrantRequest) (*LeaseGrantResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method LeaseGrant not implemented")
+}
+func (UnimplementedShardKVServiceServer) LeaseRevoke(context.Context, *LeaseRevokeRequest) (*LeaseRevokeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method LeaseRevoke not implemented")
+}
+func (UnimplementedShardKVServiceServer) LeaseKeepAlive(context.Context, *LeaseKeepAliveRequest) (*LeaseKeepAliveResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method LeaseKeepAlive not implemented")
+}
+func (UnimplementedShardKVServiceServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedShardKVServiceServer) CdcSubscribe(*CdcRequest, grpc.ServerStreamingServer[CdcResponse]) error {
+	return status.Error(codes.Unimplemented, "method CdcSubscribe not implemented")
+}
+func (UnimplementedShardKVServiceServer) mustEmbedUnimplementedShardKVServiceServer() {}
+func (UnimplementedShardKVServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeShardKVServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ShardKVServiceServer will
+// result in compilation errors.
+type UnsafeShardKVServiceServer interface {
+	mustEmbedUnimplementedShardKVServiceServer()
+}
+
+func RegisterShardKVServiceServer(s grpc.ServiceRegistrar, srv ShardKVServiceServer) {
+	// If the following call panics, it indicates UnimplementedShardKVServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ShardKVService_ServiceDesc, srv)
+}
+
+func _ShardKVService_Command_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).Command(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_Command_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).Command(ctx, req.(*CommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_PullShard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShardOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).PullShard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_PullShard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).PullShard(ctx, req.(*ShardOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_DeleteShard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShardOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).DeleteShard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_DeleteShard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).DeleteShard(ctx, req.(*ShardOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_GetRanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).GetRanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_GetRanges_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).GetRanges(ctx, req.(*GetRangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_FreezeRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RangeOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).FreezeRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_FreezeRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).FreezeRange(ctx, req.(*RangeOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_Scan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShardKVServiceServer).Scan(m, &grpc.GenericServerStream[ScanRequest, ScanResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ShardKVService_ScanServer = grpc.ServerStreamingServer[ScanResponse]
+
+func _ShardKVService_Txn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).Txn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_Txn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).Txn(ctx, req.(*TxnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_DistTxn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DistTxnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).DistTxn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_DistTxn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).DistTxn(ctx, req.(*DistTxnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_TxnPrepare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnPrepareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).TxnPrepare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_TxnPrepare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).TxnPrepare(ctx, req.(*TxnPrepareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_TxnResolve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnResolveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).TxnResolve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_TxnResolve_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).TxnResolve(ctx, req.(*TxnResolveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_TxnStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).TxnStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_TxnStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).TxnStatus(ctx, req.(*TxnStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_LeaseGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaseGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).LeaseGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_LeaseGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).LeaseGrant(ctx, req.(*LeaseGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_LeaseRevoke_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaseRevokeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).LeaseRevoke(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_LeaseRevoke_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).LeaseRevoke(ctx, req.(*LeaseRevokeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_LeaseKeepAlive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaseKeepAliveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardKVServiceServer).LeaseKeepAlive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardKVService_LeaseKeepAlive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardKVServiceServer).LeaseKeepAlive(ctx, req.(*LeaseKeepAliveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardKVService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShardKVServiceServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ShardKVService_WatchServer = grpc.ServerStreamingServer[WatchResponse]
+
+func _ShardKVService_CdcSubscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CdcRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShardKVServiceServer).CdcSubscribe(m, &grpc.GenericServerStream[CdcRequest, CdcResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ShardKVService_CdcSubscribeServer = grpc.ServerStreamingServer[CdcResponse]
+
+// ShardKVService_ServiceDesc is the grpc.ServiceDesc for ShardKVService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ShardKVService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "raftpb.ShardKVService",
+	HandlerType: (*ShardKVServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Command",
+			Handler:    _ShardKVService_Command_Handler,
+		},
+		{
+			MethodName: "PullShard",
+			Handler:    _ShardKVService_PullShard_Handler,
+		},
+		{
+			MethodName: "DeleteShard",
+			Handler:    _ShardKVService_DeleteShard_Handler,
+		},
+		{
+			MethodName: "GetRanges",
+			Handler:    _ShardKVService_GetRanges_Handler,
+		},
+		{
+			MethodName: "FreezeRange",
+			Handler:    _ShardKVService_FreezeRange_Handler,
+		},
+		{
+			MethodName: "Txn",
+			Handler:    _ShardKVService_Txn_Handler,
+		},
+		{
+			MethodName: "DistTxn",
+			Handler:    _ShardKVService_DistTxn_Handler,
+		},
+		{
+			MethodName: "TxnPrepare",
+			Handler:    _ShardKVService_TxnPrepare_Handler,
+		},
+		{
+			MethodName: "TxnResolve",
+			Handler:    _ShardKVService_TxnResolve_Handler,
+		},
+		{
+			MethodName: "TxnStatus",
+			Handler:    _ShardKVService_TxnStatus_Handler,
+		},
+		{
+			MethodName: "LeaseGrant",
+			Handler:    _ShardKVService_LeaseGrant_Handler,
+		},
+		{
+			MethodName: "LeaseRevoke",
+			Handler:    _ShardKVService_LeaseRevoke_Handler,
+		},
+		{
+			MethodName: "LeaseKeepAlive",
+			Handler:    _ShardKVService_LeaseKeepAlive_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Scan",
+			Handler:       _ShardKVService_Scan_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _ShardKVService_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CdcSubscribe",
+			Handler:       _ShardKVService_CdcSubscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "shardkv.proto",
+}
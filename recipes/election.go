@@ -0,0 +1,147 @@
+package recipes
+
+import(
+	"context"
+
+	"neweraft/shardkvclient"
+	pb "neweraft/raftpb"
+)
+
+// Election elects one session at a time as leader of key. The key holds the
+// leader's owner id followed by the value it proclaims, and is attached to
+// its session lease, so leadership ends with the session at the latest.
+type Election struct{
+	s *Session
+	key string
+	index int64
+	version int64
+	value string
+}
+
+func NewElection(s *Session,key string) *Election{
+	return &Election{s:s,key:key}
+}
+
+func (e *Election)encode(value string) string{
+	return e.s.owner()+value
+}
+
+func decodeLeader(raw string) string{
+	if len(raw)<16{
+		return raw
+	}
+	return raw[16:]
+}
+
+// Campaign waits until this session is leader and then proclaims value.
+func (e *Election)Campaign(ctx context.Context,value string) error{
+	if err:=e.s.attach(ctx,e.key);err!=nil{
+		return err
+	}
+	for{
+		res,err:=e.s.client.PutIfAbsent(ctx,e.key,e.encode(value),e.s.leaseId)
+		if err==nil{
+			e.index,e.version,e.value=res.ModIndex,res.Version,value
+			return nil
+		}
+		if err!=shardkvclient.ErrCompareFailed{
+			return err
+		}
+		if res.Value==e.encode(value){
+			kv,err:=e.s.client.Get(ctx,e.key)
+			if err==nil && kv.Value==e.encode(value){
+				e.index,e.version,e.value=kv.ModIndex,kv.Version,value
+				return nil
+			}
+			continue
+		}
+		if err:=waitChange(ctx,e.s.client,[]string{e.key});err!=nil{
+			return err
+		}
+	}
+}
+
+// Index is the raft index at which this session became leader, usable as a
+// fencing token like Mutex.Token.
+func (e *Election)Index() int64{
+	return e.index
+}
+
+// Proclaim changes the leader value without giving up leadership.
+func (e *Election)Proclaim(ctx context.Context,value string) error{
+	if e.index==0{
+		return ErrNotHeld
+	}
+	res,err:=e.s.client.Cas(ctx,e.key,e.version,e.encode(value))
+	if err==shardkvclient.ErrCompareFailed || err==shardkvclient.ErrNoKey{
+		return ErrNotHeld
+	}
+	if err!=nil{
+		return err
+	}
+	e.version,e.value=res.Version,value
+	return nil
+}
+
+// Resign gives up leadership, letting the next campaigner in.
+func (e *Election)Resign(ctx context.Context) error{
+	if e.index==0{
+		return ErrNotHeld
+	}
+	_,err:=e.s.client.DeleteIfEquals(ctx,e.key,e.encode(e.value),e.version)
+	e.index,e.version=0,0
+	if err==shardkvclient.ErrCompareFailed || err==shardkvclient.ErrNoKey{
+		return ErrNotHeld
+	}
+	return err
+}
+
+// Leader returns the value proclaimed by the current leader.
+func (e *Election)Leader(ctx context.Context) (string,error){
+	kv,err:=e.s.client.Get(ctx,e.key)
+	if err==shardkvclient.ErrNoKey{
+		return "",ErrNoLeader
+	}
+	if err!=nil{
+		return "",err
+	}
+	return decodeLeader(kv.Value),nil
+}
+
+// Observe sends the current leader value and every later one until ctx ends.
+func (e *Election)Observe(ctx context.Context) <-chan string{
+	ch:=make(chan string)
+	go func(){
+		defer close(ch)
+		send:=func(value string) bool{
+			select{
+			case ch<-value:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for ctx.Err()==nil{
+			watch:=e.s.client.Watch(ctx,e.key,false,0)
+			if _,ok:=<-watch;!ok{
+				return
+			}
+			// the watch may sit on a replica behind the read; skip what it saw
+			var seen int64
+			if kv,err:=e.s.client.Get(ctx,e.key);err==nil{
+				seen=kv.ModIndex
+				if !send(decodeLeader(kv.Value)){
+					return
+				}
+			}
+			for res:=range watch{
+				for _,ev:=range res.Events{
+					if ev.Type==pb.EventType_EventPut && ev.ModIndex>seen && !send(decodeLeader(ev.Value)){
+						return
+					}
+				}
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,86 @@
+package recipes
+
+import(
+	"context"
+
+	"neweraft/shardkvclient"
+)
+
+// Mutex is held by the session whose owner id is stored in key. The key is
+// attached to the session lease, so a crashed holder releases it when the
+// lease expires. Token is the raft index that created the key: it only grows
+// from one holder to the next, so a resource can reject writes carrying an
+// older token than one it has seen.
+type Mutex struct{
+	s *Session
+	key string
+	token int64
+	version int64
+}
+
+func NewMutex(s *Session,key string) *Mutex{
+	return &Mutex{s:s,key:key}
+}
+
+func (m *Mutex)Key() string{
+	return m.key
+}
+
+// Token is the fencing token of the current hold, 0 when not held.
+func (m *Mutex)Token() int64{
+	return m.token
+}
+
+// TryLock takes the mutex or returns ErrLocked without waiting.
+func (m *Mutex)TryLock(ctx context.Context) error{
+	if err:=m.s.attach(ctx,m.key);err!=nil{
+		return err
+	}
+	res,err:=m.s.client.PutIfAbsent(ctx,m.key,m.s.owner(),m.s.leaseId)
+	if err==nil{
+		m.token,m.version=res.ModIndex,res.Version
+		return nil
+	}
+	if err!=shardkvclient.ErrCompareFailed{
+		return err
+	}
+	if res.Value!=m.s.owner(){
+		return ErrLocked
+	}
+	// an earlier attempt of ours got through; read back its index
+	kv,err:=m.s.client.Get(ctx,m.key)
+	if err!=nil{
+		return err
+	}
+	if kv.Value!=m.s.owner(){
+		return ErrLocked
+	}
+	m.token,m.version=kv.ModIndex,kv.Version
+	return nil
+}
+
+// Lock waits until the mutex is free and takes it.
+func (m *Mutex)Lock(ctx context.Context) error{
+	for{
+		err:=m.TryLock(ctx)
+		if err!=ErrLocked{
+			return err
+		}
+		if err:=waitChange(ctx,m.s.client,[]string{m.key});err!=nil{
+			return err
+		}
+	}
+}
+
+// Unlock deletes the key if this session still holds it.
+func (m *Mutex)Unlock(ctx context.Context) error{
+	if m.token==0{
+		return ErrNotHeld
+	}
+	_,err:=m.s.client.DeleteIfEquals(ctx,m.key,m.s.owner(),m.version)
+	m.token,m.version=0,0
+	if err==shardkvclient.ErrCompareFailed || err==shardkvclient.ErrNoKey{
+		return ErrNotHeld
+	}
+	return err
+}
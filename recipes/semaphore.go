@@ -0,0 +1,85 @@
+package recipes
+
+import(
+	"fmt"
+	"context"
+
+	"neweraft/shardkvclient"
+)
+
+// Semaphore lets up to n sessions in at once. Each holder owns one of the slot
+// keys name/0 .. name/n-1, attached to its session lease like a Mutex.
+type Semaphore struct{
+	s *Session
+	name string
+	n int
+	slot string
+	token int64
+	version int64
+}
+
+func NewSemaphore(s *Session,name string,n int) *Semaphore{
+	return &Semaphore{s:s,name:name,n:n}
+}
+
+func (sem *Semaphore)slots() []string{
+	keys:=make([]string,sem.n)
+	for i:=range keys{
+		keys[i]=fmt.Sprintf("%s/%d",sem.name,i)
+	}
+	return keys
+}
+
+// Token is the raft index that created the held slot, 0 when not held.
+func (sem *Semaphore)Token() int64{
+	return sem.token
+}
+
+// TryAcquire takes a free slot or returns ErrLocked when all are held.
+func (sem *Semaphore)TryAcquire(ctx context.Context) error{
+	for _,slot:=range sem.slots(){
+		if err:=sem.s.attach(ctx,slot);err!=nil{
+			return err
+		}
+		res,err:=sem.s.client.PutIfAbsent(ctx,slot,sem.s.owner(),sem.s.leaseId)
+		if err==nil{
+			sem.slot,sem.token,sem.version=slot,res.ModIndex,res.Version
+			return nil
+		}
+		if err!=shardkvclient.ErrCompareFailed{
+			return err
+		}
+		if res.Value==sem.s.owner(){
+			if kv,err:=sem.s.client.Get(ctx,slot);err==nil && kv.Value==sem.s.owner(){
+				sem.slot,sem.token,sem.version=slot,kv.ModIndex,kv.Version
+				return nil
+			}
+		}
+	}
+	return ErrLocked
+}
+
+// Acquire waits for a free slot and takes it.
+func (sem *Semaphore)Acquire(ctx context.Context) error{
+	for{
+		err:=sem.TryAcquire(ctx)
+		if err!=ErrLocked{
+			return err
+		}
+		if err:=waitChange(ctx,sem.s.client,sem.slots());err!=nil{
+			return err
+		}
+	}
+}
+
+func (sem *Semaphore)Release(ctx context.Context) error{
+	if sem.slot==""{
+		return ErrNotHeld
+	}
+	_,err:=sem.s.client.DeleteIfEquals(ctx,sem.slot,sem.s.owner(),sem.version)
+	sem.slot,sem.token,sem.version="",0,0
+	if err==shardkvclient.ErrCompareFailed || err==shardkvclient.ErrNoKey{
+		return ErrNotHeld
+	}
+	return err
+}
@@ -0,0 +1,193 @@
+package recipes
+
+import(
+	"fmt"
+	"sync"
+	"time"
+	"errors"
+	"context"
+	"math/rand"
+
+	"neweraft/shardkvclient"
+	pb "neweraft/raftpb"
+)
+
+var(
+	ErrSessionExpired = errors.New("recipes: session expired")
+	ErrLocked = errors.New("recipes: held by another session")
+	ErrNotHeld = errors.New("recipes: not held by this session")
+	ErrNoLeader = errors.New("recipes: no leader")
+)
+
+// Session is a lease renewed in the background. Every key a recipe creates is
+// attached to it, so when the process stops renewing, the leader of the
+// owning group expires the lease and the keys go with it; no client clock is
+// trusted. Leases live per group, so the session grants its lease id in each
+// group it puts keys into.
+type Session struct{
+	mu sync.Mutex
+	client *shardkvclient.Client
+	ttl int64
+	leaseId int64
+	keys map[string]bool
+	cancel context.CancelFunc
+	done chan struct{}
+	closed bool
+}
+
+func NewSession(client *shardkvclient.Client,ttl int64) *Session{
+	ctx,cancel:=context.WithCancel(context.Background())
+	s:=&Session{
+		client:client,
+		ttl:ttl,
+		leaseId:rand.Int63(),
+		keys:make(map[string]bool),
+		cancel:cancel,
+		done:make(chan struct{}),
+	}
+	go s.keepAlive(ctx)
+	return s
+}
+
+func (s *Session)LeaseId() int64{
+	return s.leaseId
+}
+
+// owner is what recipes store in their keys to recognize their own.
+func (s *Session)owner() string{
+	return fmt.Sprintf("%016x",uint64(s.leaseId))
+}
+
+// Done is closed once the lease is found expired; whatever the session held
+// is gone by then.
+func (s *Session)Done() <-chan struct{}{
+	return s.done
+}
+
+func (s *Session)expire(){
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed{
+		s.closed=true
+		close(s.done)
+	}
+}
+
+// attach makes sure the group owning key holds the session lease.
+func (s *Session)attach(ctx context.Context,key string) error{
+	s.mu.Lock()
+	if s.closed{
+		s.mu.Unlock()
+		return ErrSessionExpired
+	}
+	if s.keys[key]{
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+	if _,err:=s.client.LeaseGrant(ctx,key,s.ttl,s.leaseId);err!=nil{
+		return err
+	}
+	s.mu.Lock()
+	s.keys[key]=true
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Session)hintKeys() []string{
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys:=make([]string,0,len(s.keys))
+	for key:=range s.keys{
+		keys=append(keys,key)
+	}
+	return keys
+}
+
+func (s *Session)keepAlive(ctx context.Context){
+	interval:=time.Duration(s.ttl)*time.Second/3
+	if interval<100*time.Millisecond{
+		interval=100*time.Millisecond
+	}
+	for{
+		select{
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		for _,key:=range s.hintKeys(){
+			callCtx,cancel:=context.WithTimeout(ctx,interval)
+			_,err:=s.client.LeaseKeepAlive(callCtx,key,s.leaseId)
+			cancel()
+			if err==shardkvclient.ErrLeaseNotFound{
+				s.expire()
+				return
+			}
+		}
+	}
+}
+
+// Close stops renewing and revokes the lease, releasing everything held.
+func (s *Session)Close(ctx context.Context) error{
+	s.cancel()
+	var firstErr error
+	for _,key:=range s.hintKeys(){
+		if err:=s.client.LeaseRevoke(ctx,key,s.leaseId);err!=nil && err!=shardkvclient.ErrLeaseNotFound && firstErr==nil{
+			firstErr=err
+		}
+	}
+	s.expire()
+	return firstErr
+}
+
+// waitChange returns once one of keys may have been deleted: right away if one
+// is absent after the watches are in place, else on the first delete event or
+// when a watch ends.
+func waitChange(ctx context.Context,client *shardkvclient.Client,keys []string) error{
+	ctx,cancel:=context.WithCancel(ctx)
+	defer cancel()
+	deleted:=make(chan struct{},len(keys))
+	for _,key:=range keys{
+		ready:=make(chan struct{})
+		go func(key string){
+			first:=true
+			for res:=range client.Watch(ctx,key,false,0){
+				if first{
+					first=false
+					close(ready)
+				}
+				for _,ev:=range res.Events{
+					if ev.Type==pb.EventType_EventDelete{
+						deleted<-struct{}{}
+						return
+					}
+				}
+				if res.Err!=pb.ErrCode_ErrOK{
+					break
+				}
+			}
+			if first{
+				close(ready)
+			}
+			deleted<-struct{}{}
+		}(key)
+		select{
+		case <-ready:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for _,key:=range keys{
+		if _,err:=client.Get(ctx,key);err==shardkvclient.ErrNoKey{
+			return nil
+		} else if err!=nil{
+			return err
+		}
+	}
+	select{
+	case <-deleted:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
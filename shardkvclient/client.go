@@ -0,0 +1,322 @@
+package shardkvclient
+
+import(
+	"io"
+	"fmt"
+	"sync"
+	"time"
+	"errors"
+	"context"
+	"math/rand"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	pb "neweraft/raftpb"
+	"neweraft/tracing"
+)
+
+var(
+	RequestTimeout = time.Second
+	RetryInterval = 50*time.Millisecond
+)
+
+var(
+	ErrNoKey = errors.New("shardkv: key not found")
+	ErrCompareFailed = errors.New("shardkv: compare failed")
+	ErrLeaseNotFound = errors.New("shardkv: lease not found")
+	ErrCompacted = errors.New("shardkv: history compacted")
+	ErrOutDated = errors.New("shardkv: read index compacted")
+	ErrBadRequest = errors.New("shardkv: bad request")
+)
+
+func codeErr(code pb.ErrCode) error{
+	switch code{
+	case pb.ErrCode_ErrOK:
+		return nil
+	case pb.ErrCode_ErrNoKey:
+		return ErrNoKey
+	case pb.ErrCode_ErrCompareFailed:
+		return ErrCompareFailed
+	case pb.ErrCode_ErrLeaseNotFound:
+		return ErrLeaseNotFound
+	case pb.ErrCode_ErrCompacted:
+		return ErrCompacted
+	case pb.ErrCode_ErrOutDated:
+		return ErrOutDated
+	case pb.ErrCode_ErrBadRequest:
+		return ErrBadRequest
+	}
+	return fmt.Errorf("shardkv: %v",code)
+}
+
+// retryable codes mean the request did not take effect here and another
+// server, or the same one a little later, may take it.
+func retryable(code pb.ErrCode) bool{
+	switch code{
+	case pb.ErrCode_ErrWrongLeader,pb.ErrCode_ErrWrongGroup,pb.ErrCode_ErrTimeout,pb.ErrCode_ErrNotReady,pb.ErrCode_ErrLocked:
+		return true
+	}
+	return false
+}
+
+// Client talks to any server of the cluster and retries until the group owning
+// a key answers. Writes carry a client id and command id so a retried write is
+// applied once; a Client therefore issues one write at a time, and callers
+// wanting parallel writes use several Clients.
+type Client struct{
+	mu sync.Mutex
+	writeMu sync.Mutex
+	servers []string
+	conns map[string]*grpc.ClientConn
+	next int
+
+	clientId int64
+	commandId int64
+}
+
+func MakeClient(servers []string) *Client{
+	return &Client{
+		servers:append([]string{},servers...),
+		conns:make(map[string]*grpc.ClientConn),
+		clientId:rand.Int63(),
+		commandId:time.Now().UnixNano(),
+	}
+}
+
+func (c *Client)Close(){
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for addr,conn:=range c.conns{
+		conn.Close()
+		delete(c.conns,addr)
+	}
+}
+
+func (c *Client)svrClient(i int) (pb.ShardKVServiceClient,error){
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addr:=c.servers[i%len(c.servers)]
+	conn,ok:=c.conns[addr]
+	if !ok{
+		var err error
+		if conn,err=grpc.NewClient(addr,grpc.WithTransportCredentials(insecure.NewCredentials()),grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor));err!=nil{
+			return nil,err
+		}
+		c.conns[addr]=conn
+	}
+	return pb.NewShardKVServiceClient(conn),nil
+}
+
+// do runs fn against the servers, starting from the last one that answered,
+// until it returns a code that is not retryable or ctx ends.
+func (c *Client)do(ctx context.Context,fn func(ctx context.Context,cli pb.ShardKVServiceClient) (pb.ErrCode,error)) (pb.ErrCode,error){
+	c.mu.Lock()
+	start:=c.next
+	c.mu.Unlock()
+	for try:=0;;try++{
+		i:=start+try
+		cli,err:=c.svrClient(i)
+		if err!=nil{
+			return pb.ErrCode_ErrOK,err
+		}
+		callCtx,cancel:=context.WithTimeout(ctx,RequestTimeout)
+		code,err:=fn(callCtx,cli)
+		cancel()
+		if err==nil && !retryable(code){
+			c.mu.Lock()
+			c.next=i%len(c.servers)
+			c.mu.Unlock()
+			return code,nil
+		}
+		if ctx.Err()!=nil{
+			return code,ctx.Err()
+		}
+		if (try+1)%len(c.servers)==0{
+			time.Sleep(RetryInterval)
+		}
+	}
+}
+
+// Command sends req as is except for the client and command ids, which are
+// filled in for writes. A failed compare returns the response as well, so the
+// caller can see the current value and version.
+func (c *Client)Command(ctx context.Context,req *pb.CommandRequest) (*pb.CommandResponse,error){
+	if req.Op!=pb.OpType_OpGet{
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		c.commandId++
+		req.ClientId,req.CommandId=c.clientId,c.commandId
+	}
+	var res *pb.CommandResponse
+	code,err:=c.do(ctx,func(ctx context.Context,cli pb.ShardKVServiceClient) (pb.ErrCode,error){
+		var err error
+		if res,err=cli.Command(ctx,req);err!=nil{
+			return pb.ErrCode_ErrTimeout,err
+		}
+		return res.Err,nil
+	})
+	if err!=nil{
+		return nil,err
+	}
+	return res,codeErr(code)
+}
+
+func (c *Client)Get(ctx context.Context,key string) (*pb.KeyValue,error){
+	return c.GetAt(ctx,key,0)
+}
+
+// GetAt reads key as of a raft index of its group; 0 reads the latest value.
+func (c *Client)GetAt(ctx context.Context,key string,index int64) (*pb.KeyValue,error){
+	res,err:=c.Command(ctx,&pb.CommandRequest{Key:key,Op:pb.OpType_OpGet,ReadIndex:index})
+	if err!=nil{
+		return nil,err
+	}
+	return &pb.KeyValue{Key:key,Value:res.Value,Version:res.Version,ModIndex:res.ModIndex},nil
+}
+
+func (c *Client)Put(ctx context.Context,key string,value string) (*pb.CommandResponse,error){
+	return c.Command(ctx,&pb.CommandRequest{Key:key,Value:value,Op:pb.OpType_OpPut})
+}
+
+// PutWithLease writes key and attaches it to leaseId, so it is deleted when
+// the lease expires or is revoked. Leases are per group: leaseId has to be
+// granted on a key of the same group, or the put fails with ErrLeaseNotFound.
+func (c *Client)PutWithLease(ctx context.Context,key string,value string,leaseId int64) (*pb.CommandResponse,error){
+	return c.Command(ctx,&pb.CommandRequest{Key:key,Value:value,Op:pb.OpType_OpPut,LeaseId:leaseId})
+}
+
+func (c *Client)Append(ctx context.Context,key string,value string) (*pb.CommandResponse,error){
+	return c.Command(ctx,&pb.CommandRequest{Key:key,Value:value,Op:pb.OpType_OpAppend})
+}
+
+func (c *Client)Delete(ctx context.Context,key string) error{
+	_,err:=c.Command(ctx,&pb.CommandRequest{Key:key,Op:pb.OpType_OpDel})
+	return err
+}
+
+func (c *Client)Cas(ctx context.Context,key string,expectedVersion int64,value string) (*pb.CommandResponse,error){
+	return c.Command(ctx,&pb.CommandRequest{Key:key,Value:value,Op:pb.OpType_OpCas,ExpectedVersion:expectedVersion})
+}
+
+func (c *Client)PutIfAbsent(ctx context.Context,key string,value string,leaseId int64) (*pb.CommandResponse,error){
+	return c.Command(ctx,&pb.CommandRequest{Key:key,Value:value,Op:pb.OpType_OpPutIfAbsent,LeaseId:leaseId})
+}
+
+// DeleteIfEquals deletes key if it holds expectedValue and, when it is not 0,
+// expectedVersion.
+func (c *Client)DeleteIfEquals(ctx context.Context,key string,expectedValue string,expectedVersion int64) (*pb.CommandResponse,error){
+	return c.Command(ctx,&pb.CommandRequest{Key:key,Op:pb.OpType_OpDeleteIfEquals,ExpectedValue:expectedValue,ExpectedVersion:expectedVersion})
+}
+
+func (c *Client)Txn(ctx context.Context,req *pb.TxnRequest) (*pb.TxnResponse,error){
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.commandId++
+	req.ClientId,req.CommandId=c.clientId,c.commandId
+	var res *pb.TxnResponse
+	code,err:=c.do(ctx,func(ctx context.Context,cli pb.ShardKVServiceClient) (pb.ErrCode,error){
+		var err error
+		if res,err=cli.Txn(ctx,req);err!=nil{
+			return pb.ErrCode_ErrTimeout,err
+		}
+		return res.Err,nil
+	})
+	if err!=nil{
+		return nil,err
+	}
+	return res,codeErr(code)
+}
+
+// Scan returns up to limit keys of [start,end) in order, all read at the same
+// applied index of each range or hash-sharded group; limit 0 means no limit
+// and end "" no bound.
+func (c *Client)Scan(ctx context.Context,start string,end string,limit int64) ([]*pb.KeyValue,error){
+	req:=&pb.ScanRequest{StartKey:start,EndKey:end}
+	kvs:=[]*pb.KeyValue{}
+	for{
+		if limit>0{
+			req.Limit=limit-int64(len(kvs))
+		}
+		var page []*pb.KeyValue
+		var token []byte
+		code,err:=c.do(ctx,func(ctx context.Context,cli pb.ShardKVServiceClient) (pb.ErrCode,error){
+			stream,err:=cli.Scan(ctx,req)
+			if err!=nil{
+				return pb.ErrCode_ErrTimeout,err
+			}
+			page,token=nil,nil
+			for{
+				res,err:=stream.Recv()
+				if err==io.EOF{
+					return pb.ErrCode_ErrOK,nil
+				}
+				if err!=nil{
+					return pb.ErrCode_ErrTimeout,err
+				}
+				if res.Err!=pb.ErrCode_ErrOK{
+					return res.Err,nil
+				}
+				page=append(page,res.Kvs...)
+				token=res.NextPageToken
+			}
+		})
+		if err!=nil{
+			return nil,err
+		}
+		if err:=codeErr(code);err!=nil{
+			return nil,err
+		}
+		kvs=append(kvs,page...)
+		if len(token)==0 || (limit>0 && int64(len(kvs))>=limit){
+			return kvs,nil
+		}
+		req.PageToken=token
+	}
+}
+
+func (c *Client)LeaseGrant(ctx context.Context,key string,ttl int64,leaseId int64) (*pb.LeaseGrantResponse,error){
+	var res *pb.LeaseGrantResponse
+	code,err:=c.do(ctx,func(ctx context.Context,cli pb.ShardKVServiceClient) (pb.ErrCode,error){
+		var err error
+		if res,err=cli.LeaseGrant(ctx,&pb.LeaseGrantRequest{Key:key,TTL:ttl,LeaseId:leaseId});err!=nil{
+			return pb.ErrCode_ErrTimeout,err
+		}
+		return res.Err,nil
+	})
+	if err!=nil{
+		return nil,err
+	}
+	return res,codeErr(code)
+}
+
+// LeaseRevoke and LeaseKeepAlive act on the copy of the lease in the group
+// owning key, or on every copy a server holds when key is "".
+func (c *Client)LeaseRevoke(ctx context.Context,key string,leaseId int64) error{
+	code,err:=c.do(ctx,func(ctx context.Context,cli pb.ShardKVServiceClient) (pb.ErrCode,error){
+		res,err:=cli.LeaseRevoke(ctx,&pb.LeaseRevokeRequest{Key:key,LeaseId:leaseId})
+		if err!=nil{
+			return pb.ErrCode_ErrTimeout,err
+		}
+		return res.Err,nil
+	})
+	if err!=nil{
+		return err
+	}
+	return codeErr(code)
+}
+
+func (c *Client)LeaseKeepAlive(ctx context.Context,key string,leaseId int64) (int64,error){
+	var ttl int64
+	code,err:=c.do(ctx,func(ctx context.Context,cli pb.ShardKVServiceClient) (pb.ErrCode,error){
+		res,err:=cli.LeaseKeepAlive(ctx,&pb.LeaseKeepAliveRequest{Key:key,LeaseId:leaseId})
+		if err!=nil{
+			return pb.ErrCode_ErrTimeout,err
+		}
+		ttl=res.TTL
+		return res.Err,nil
+	})
+	if err!=nil{
+		return 0,err
+	}
+	return ttl,codeErr(code)
+}
@@ -0,0 +1,76 @@
+package shardkvclient
+
+import(
+	"time"
+	"context"
+
+	pb "neweraft/raftpb"
+)
+
+// Watch streams the responses of a watch on key, or on every key starting with
+// it when prefix is set, from startIndex on (0 means from now). When a server
+// goes away the watch resumes on another replica of the same group from the
+// last NextIndex, so no event is lost or repeated. The channel is closed when
+// ctx ends; before that a response with an error is the last one, e.g.
+// ErrCompacted when the history is gone or ErrWrongGroup when the key moved.
+// A prefix watch needs the prefix inside one key range; on hash sharded groups
+// it ends with ErrWrongGroup.
+func (c *Client)Watch(ctx context.Context,key string,prefix bool,startIndex int64) <-chan *pb.WatchResponse{
+	ch:=make(chan *pb.WatchResponse,16)
+	go func(){
+		defer close(ch)
+		req:=&pb.WatchRequest{Key:key,Prefix:prefix,StartIndex:startIndex}
+		c.mu.Lock()
+		start:=c.next
+		c.mu.Unlock()
+		misses:=0
+		for try:=start;ctx.Err()==nil;try++{
+			if misses>0 && misses%len(c.servers)==0{
+				time.Sleep(RetryInterval)
+			}
+			cli,err:=c.svrClient(try)
+			if err!=nil{
+				return
+			}
+			stream,err:=cli.Watch(ctx,req)
+			if err!=nil{
+				misses++
+				continue
+			}
+			for{
+				res,err:=stream.Recv()
+				if err!=nil{
+					misses++
+					break
+				}
+				if res.Err==pb.ErrCode_ErrWrongGroup || res.Err==pb.ErrCode_ErrNotReady{
+					// this server does not hold the group; one of the others may
+					misses++
+					if res.Err==pb.ErrCode_ErrWrongGroup && misses>=2*len(c.servers){
+						select{
+						case ch<-res:
+						case <-ctx.Done():
+						}
+						return
+					}
+					break
+				}
+				if res.Err!=pb.ErrCode_ErrOK{
+					select{
+					case ch<-res:
+					case <-ctx.Done():
+					}
+					return
+				}
+				misses=0
+				req.Gid,req.StartIndex=res.Gid,res.NextIndex
+				select{
+				case ch<-res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,393 @@
+package shardkvserver
+
+import(
+	"io"
+	"os"
+	"fmt"
+	"time"
+	"bytes"
+	"bufio"
+	"errors"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"neweraft/raftcore"
+	pb "neweraft/raftpb"
+	"neweraft/storage"
+)
+
+const BackupFormat = "neweraft-backup/1"
+
+// BackupManifest sits next to a backup file, as <file>.manifest.json, and
+// tells what it holds and the checksum of its bytes.
+type BackupManifest struct{
+	Format string
+	CreatedAt time.Time
+	Gid int64
+	NodeId int64
+	AppliedIndex int64
+	AppliedTerm int64
+	Term int64
+	Peers []string
+	HasData bool
+	LogStart int64
+	LogEnd int64
+	Pairs int64
+	Entries int64
+	Bytes int64
+	Sha256 string
+}
+
+func ManifestPath(path string) string{
+	return path+".manifest.json"
+}
+
+// backup sends the group as of its applied index: the meta first, then the
+// data pairs unless req.SkipData, then the committed entries from
+// req.LogStart up to that index. The data comes from one snapshot taken with
+// the index, so no entry is half in it.
+func (sg *ShardGroup)backup(req *pb.BackupRequest,send func(*pb.BackupChunk) error) error{
+	sg.mu.Lock()
+	snap,err:=sg.dataEng.GetSnapshot()
+	if err!=nil{
+		sg.mu.Unlock()
+		return err
+	}
+	applied:=sg.lastApplied
+	sg.mu.Unlock()
+	defer snap.Release()
+
+	status:=sg.raft.Status()
+	meta:=&pb.BackupMeta{
+		GroupId:sg.gid,
+		NodeId:sg.id,
+		AppliedIndex:applied,
+		Term:status.Term,
+		Peers:sg.peersAddrs,
+		HasData:!req.SkipData,
+	}
+	// a restore starts its log at the applied entry, so its term has to be
+	// known; a log that no longer holds it cannot be backed up
+	entries:=sg.raft.GetCommittedEntries(applied,applied)
+	if len(entries)>0 && entries[0].Index==applied{
+		meta.AppliedTerm=entries[0].CurTerm
+	}
+	if applied>0 && meta.AppliedTerm==0{
+		return fmt.Errorf("group %d: the log does not hold the term of the applied index %d",sg.gid,applied)
+	}
+	if req.LogStart>0{
+		if req.LogStart<=status.FirstIndex{
+			return fmt.Errorf("group %d keeps the log after %d only",sg.gid,status.FirstIndex)
+		}
+		meta.LogStart,meta.LogEnd=req.LogStart,applied
+	}
+	if err:=send(&pb.BackupChunk{Meta:meta});err!=nil{
+		return err
+	}
+
+	if !req.SkipData{
+		chunk:=&pb.BackupChunk{}
+		iter:=snap.NewIterator(nil,nil,false)
+		for iter.Next(){
+			chunk.Pairs=append(chunk.Pairs,&pb.BackupPair{
+				Key:append([]byte{},iter.Key()...),
+				Value:append([]byte{},iter.Value()...),
+			})
+			if len(chunk.Pairs)>=ScanBatchSize{
+				if err:=send(chunk);err!=nil{
+					iter.Release()
+					return err
+				}
+				chunk=&pb.BackupChunk{}
+			}
+		}
+		err:=iter.Error()
+		iter.Release()
+		if err!=nil{
+			return err
+		}
+		if len(chunk.Pairs)>0{
+			if err:=send(chunk);err!=nil{
+				return err
+			}
+		}
+	}
+
+	for lo:=meta.LogStart;meta.LogStart>0 && lo<=applied;lo+=CdcReplayBatch{
+		hi:=lo+CdcReplayBatch-1
+		if hi>applied{
+			hi=applied
+		}
+		entries:=sg.raft.GetCommittedEntries(lo,hi)
+		if int64(len(entries))!=hi-lo+1{
+			return fmt.Errorf("group %d: entries %d..%d are not all committed",sg.gid,lo,hi)
+		}
+		if err:=send(&pb.BackupChunk{Entries:entries});err!=nil{
+			return err
+		}
+	}
+	return nil
+}
+
+// Backup streams one group, the only one when req.GroupId is 0, for
+// FetchBackup to write down.
+func (shardsvr *ShardServer)Backup(req *pb.BackupRequest,stream grpc.ServerStreamingServer[pb.BackupChunk]) error{
+	group:=shardsvr.getGroup(req.GroupId)
+	if req.GroupId==0{
+		if groups:=shardsvr.getGroups();len(groups)==1{
+			group=groups[0]
+		}
+	}
+	if group==nil{
+		return fmt.Errorf("group %d not found on shardsvr %d",req.GroupId,shardsvr.id)
+	}
+	shardsvr.logger().Info("backup","group",group.gid,"data",!req.SkipData,"log_start",req.LogStart)
+	return group.backup(req,stream.Send)
+}
+
+// FetchBackup writes the Backup stream of req to path as length-delimited
+// chunks, then its manifest. Nothing is left at path when it fails.
+func FetchBackup(ctx context.Context,cli pb.MessageServiceClient,req *pb.BackupRequest,path string) (*BackupManifest,error){
+	stream,err:=cli.Backup(ctx,req)
+	if err!=nil{
+		return nil,err
+	}
+	file,err:=os.Create(path+".tmp")
+	if err!=nil{
+		return nil,err
+	}
+	defer os.Remove(path+".tmp")
+	defer file.Close()
+	buf:=bufio.NewWriter(file)
+	hash:=sha256.New()
+	w:=io.MultiWriter(buf,hash)
+	manifest:=&BackupManifest{Format:BackupFormat,CreatedAt:time.Now().UTC()}
+	for{
+		chunk,err:=stream.Recv()
+		if err==io.EOF{
+			break
+		}
+		if err!=nil{
+			return nil,err
+		}
+		if chunk.Meta!=nil{
+			manifest.Gid,manifest.NodeId,manifest.Peers=chunk.Meta.GroupId,chunk.Meta.NodeId,chunk.Meta.Peers
+			manifest.AppliedIndex,manifest.AppliedTerm,manifest.Term=chunk.Meta.AppliedIndex,chunk.Meta.AppliedTerm,chunk.Meta.Term
+			manifest.HasData,manifest.LogStart,manifest.LogEnd=chunk.Meta.HasData,chunk.Meta.LogStart,chunk.Meta.LogEnd
+		}
+		manifest.Pairs+=int64(len(chunk.Pairs))
+		manifest.Entries+=int64(len(chunk.Entries))
+		n,err:=protodelim.MarshalTo(w,chunk)
+		if err!=nil{
+			return nil,err
+		}
+		manifest.Bytes+=int64(n)
+	}
+	if manifest.Gid==0{
+		return nil,errors.New("backup stream ended before its meta")
+	}
+	if err:=buf.Flush();err!=nil{
+		return nil,err
+	}
+	if err:=file.Sync();err!=nil{
+		return nil,err
+	}
+	manifest.Sha256=hex.EncodeToString(hash.Sum(nil))
+	if err:=os.Rename(path+".tmp",path);err!=nil{
+		return nil,err
+	}
+	manifestData,_:=json.MarshalIndent(manifest,"","  ")
+	if err:=os.WriteFile(ManifestPath(path),append(manifestData,'\n'),0644);err!=nil{
+		return nil,err
+	}
+	return manifest,nil
+}
+
+// ReadBackup checks the file at path against its manifest, then hands its
+// chunks to fn in order.
+func ReadBackup(path string,fn func(*pb.BackupChunk) error) (*BackupManifest,error){
+	manifestData,err:=os.ReadFile(ManifestPath(path))
+	if err!=nil{
+		return nil,err
+	}
+	manifest:=&BackupManifest{}
+	if err:=json.Unmarshal(manifestData,manifest);err!=nil{
+		return nil,fmt.Errorf("%s: %v",ManifestPath(path),err)
+	}
+	if manifest.Format!=BackupFormat{
+		return nil,fmt.Errorf("%s: format %q, want %q",path,manifest.Format,BackupFormat)
+	}
+	file,err:=os.Open(path)
+	if err!=nil{
+		return nil,err
+	}
+	defer file.Close()
+	hash:=sha256.New()
+	n,err:=io.Copy(hash,file)
+	if err!=nil{
+		return nil,err
+	}
+	if sum:=hex.EncodeToString(hash.Sum(nil));n!=manifest.Bytes || sum!=manifest.Sha256{
+		return nil,fmt.Errorf("%s: %d bytes with sha256 %s, the manifest says %d bytes with %s",path,n,sum,manifest.Bytes,manifest.Sha256)
+	}
+	if fn==nil{
+		return manifest,nil
+	}
+	if _,err:=file.Seek(0,io.SeekStart);err!=nil{
+		return nil,err
+	}
+	r:=bufio.NewReader(file)
+	for{
+		chunk:=&pb.BackupChunk{}
+		if err:=(protodelim.UnmarshalOptions{MaxSize:-1}).UnmarshalFrom(r,chunk);err!=nil{
+			if err==io.EOF{
+				return manifest,nil
+			}
+			return nil,fmt.Errorf("%s: %v",path,err)
+		}
+		if err:=fn(chunk);err!=nil{
+			return nil,err
+		}
+	}
+}
+
+type RestoreOptions struct{
+	// Backup is a file FetchBackup wrote with data
+	Backup string
+	// Segments are backups of the group's log after Backup, in order; their
+	// entries are put in the log, to be committed and applied by the new
+	// cluster, up to TargetIndex when it is not 0
+	Segments []string
+	TargetIndex int64
+	// Peers are the gRPC addresses of the new group's members
+	Peers []string
+}
+
+// RestoreBackup seeds db, the store of a node that has never run the group,
+// with the group of the backup: its data, a log that starts at the backup's
+// applied index and goes on with the segments' entries, and a term no lower
+// than any of them. The group's configs name only Peers from then on, and the
+// returned Config is the one the new cluster's config.json has to serve. A
+// hash-sharded group restores only if it owns every shard.
+func RestoreBackup(db storage.KvStore,opts RestoreOptions) (*Config,int64,error){
+	var manifest *BackupManifest
+	var dataEng,logEng *storage.PrefixKvStore
+	var metaStr string
+	batch:=storage.MakeKvBatch()
+	manifest,err:=ReadBackup(opts.Backup,func(chunk *pb.BackupChunk) error{
+		if chunk.Meta!=nil{
+			if !chunk.Meta.HasData{
+				return fmt.Errorf("%s holds no data, only log",opts.Backup)
+			}
+			if chunk.Meta.AppliedIndex>0 && chunk.Meta.AppliedTerm==0{
+				return fmt.Errorf("%s does not know the term of its applied index %d",opts.Backup,chunk.Meta.AppliedIndex)
+			}
+			dataEng=storage.MakePrefixKvStore(db,GroupKeyPrefix(chunk.Meta.GroupId,GroupDataSpace))
+			logEng=storage.MakePrefixKvStore(db,GroupKeyPrefix(chunk.Meta.GroupId,GroupLogSpace))
+			if _,err:=logEng.GetByte(raftcore.RaftStateKey);err==nil{
+				return fmt.Errorf("the store already holds group %d",chunk.Meta.GroupId)
+			}
+		}
+		if dataEng==nil{
+			return fmt.Errorf("%s does not start with its meta",opts.Backup)
+		}
+		for _,pair:=range chunk.Pairs{
+			if string(pair.Key)==ShardMetaKey{
+				metaStr=string(pair.Value)
+				continue
+			}
+			batch.Put(string(pair.Key),string(pair.Value))
+		}
+		if batch.Len()>=ScanBatchSize{
+			if err:=dataEng.WriteBatch(batch);err!=nil{
+				return err
+			}
+			batch=storage.MakeKvBatch()
+		}
+		return nil
+	})
+	if err!=nil{
+		return nil,0,err
+	}
+	if err:=dataEng.WriteBatch(batch);err!=nil{
+		return nil,0,err
+	}
+
+	gid:=manifest.Gid
+	config:=&Config{Num:1,Groups:map[int64][]string{gid:opts.Peers},Ranges:[]*KeyRange{{Gid:gid}}}
+	if metaStr!=""{
+		meta:=&shardMeta{}
+		if err:=gob.NewDecoder(bytes.NewBufferString(metaStr)).Decode(meta);err!=nil{
+			return nil,0,fmt.Errorf("shard meta: %v",err)
+		}
+		// the backup holds the data of this group's shards only, so the
+		// restored group cannot serve the shards of the others
+		if meta.Range==nil && meta.CurConfig!=nil && meta.CurConfig.Num>0{
+			for shard,owner:=range meta.CurConfig.Shards{
+				if owner!=gid{
+					return nil,0,fmt.Errorf("shard %d belongs to group %d, only a group owning every shard restores alone",shard,owner)
+				}
+			}
+		}
+		for _,cf:=range []*Config{meta.LastConfig,meta.CurConfig}{
+			if cf!=nil{
+				cf.Groups=map[int64][]string{gid:opts.Peers}
+			}
+		}
+		if meta.CurConfig!=nil && meta.CurConfig.Num>0{
+			config=meta.CurConfig.Copy()
+		}
+		if meta.Range!=nil{
+			config.Ranges=[]*KeyRange{meta.Range.Copy()}
+		}
+		if config.Num==0{
+			config.Num=1
+		}
+		var buf bytes.Buffer
+		if err:=gob.NewEncoder(&buf).Encode(meta);err!=nil{
+			return nil,0,err
+		}
+		dataEng.Put(ShardMetaKey,buf.String())
+	}
+
+	rflog:=raftcore.MakeRaftLog(logEng)
+	rflog.UpdatePersistentIndex(manifest.AppliedIndex,manifest.AppliedIndex)
+	rflog.AppendLogEntries([]*pb.Entry{{EntryType:pb.Entrytype_EntryNormal,CurTerm:manifest.AppliedTerm,Index:manifest.AppliedIndex}})
+	lastIdx,term:=manifest.AppliedIndex,max(manifest.Term,manifest.AppliedTerm)
+	for _,segment:=range opts.Segments{
+		_,err:=ReadBackup(segment,func(chunk *pb.BackupChunk) error{
+			if chunk.Meta!=nil && chunk.Meta.GroupId!=gid{
+				return fmt.Errorf("%s is of group %d, not %d",segment,chunk.Meta.GroupId,gid)
+			}
+			if chunk.Meta!=nil && chunk.Meta.LogStart>lastIdx+1{
+				return fmt.Errorf("%s starts at %d, the log ends at %d",segment,chunk.Meta.LogStart,lastIdx)
+			}
+			var entries []*pb.Entry
+			for _,entry:=range chunk.Entries{
+				if entry.Index<=lastIdx || (opts.TargetIndex>0 && entry.Index>opts.TargetIndex){
+					continue
+				}
+				if entry.Index!=lastIdx+1{
+					return fmt.Errorf("%s: entry %d follows %d",segment,entry.Index,lastIdx)
+				}
+				entries=append(entries,entry)
+				lastIdx=entry.Index
+				term=max(term,entry.CurTerm)
+			}
+			return rflog.AppendLogEntries(entries)
+		})
+		if err!=nil{
+			return nil,0,err
+		}
+	}
+	if opts.TargetIndex>0 && lastIdx<opts.TargetIndex{
+		return nil,0,fmt.Errorf("the log ends at %d, before the target %d",lastIdx,opts.TargetIndex)
+	}
+	err=raftcore.WritePersistState(logEng,&raftcore.RaftPersistentState{CurTerm:term,VoteFor:-1,AppliedIdx:manifest.AppliedIndex})
+	return config,lastIdx,err
+}
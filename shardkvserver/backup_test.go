@@ -0,0 +1,105 @@
+package shardkvserver
+
+import(
+	"errors"
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"path/filepath"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+	"neweraft/storage"
+)
+
+func fetchBackup(t *testing.T,addr string,req *pb.BackupRequest,path string) *BackupManifest{
+	t.Helper()
+	conn,err:=grpc.NewClient(addr,grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err!=nil{
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+	manifest,err:=FetchBackup(ctx,pb.NewMessageServiceClient(conn),req,path)
+	if err!=nil{
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+func TestBackupRestore(t *testing.T){
+	dir:=t.TempDir()
+	_,addrs:=startTestServers(t,1,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+
+	putKeys(t,addrs,[]string{"a","b"})
+	backup:=filepath.Join(dir,"backup")
+	manifest:=fetchBackup(t,addrs[0],&pb.BackupRequest{},backup)
+	if manifest.AppliedTerm==0 || manifest.Pairs==0{
+		t.Fatalf("manifest %+v",manifest)
+	}
+	// writes after the backup go to a segment; the restore stops after "d"
+	putKeys(t,addrs,[]string{"c"})
+	res,err:=cli.Put(ctx,"d","v-d")
+	if err!=nil{
+		t.Fatal(err)
+	}
+	target:=res.ModIndex
+	putKeys(t,addrs,[]string{"e"})
+	if err:=cli.Delete(ctx,"a");err!=nil{
+		t.Fatal(err)
+	}
+	segment:=filepath.Join(dir,"segment")
+	fetchBackup(t,addrs[0],&pb.BackupRequest{SkipData:true,LogStart:manifest.AppliedIndex+1},segment)
+
+	opts:=RestoreOptions{Backup:backup,Segments:[]string{segment},TargetIndex:target}
+	svrs,addrs:=startTestServers(t,1,func(addrs []string) *Config{
+		db,err:=storage.MakeLevelDBKvStore("./out/data/db/0_db")
+		if err!=nil{
+			t.Fatal(err)
+		}
+		defer db.Close()
+		opts.Peers=addrs
+		config,lastIdx,err:=RestoreBackup(db,opts)
+		if err!=nil{
+			t.Fatal(err)
+		}
+		if lastIdx!=target{
+			t.Fatalf("restored log ends at %d, want %d",lastIdx,target)
+		}
+		return config
+	})
+	checkKeys(t,"restored",scanAll(t,svrs[0],&pb.ScanRequest{Limit:10}),[]string{"a","b","c","d"})
+	restored:=shardkvclient.MakeClient(addrs)
+	defer restored.Close()
+	if kv,err:=restored.Get(ctx,"d");err!=nil || kv.Value!="v-d" || kv.ModIndex!=target{
+		t.Fatalf("get d: %v %v",kv,err)
+	}
+	if _,err:=restored.Get(ctx,"e");!errors.Is(err,shardkvclient.ErrNoKey){
+		t.Fatalf("get e past the target: %v",err)
+	}
+}
+
+func TestRestoreRefusesPartialHashGroup(t *testing.T){
+	dir:=t.TempDir()
+	_,addrs:=startTestServers(t,2,hashConfig)
+	backup:=filepath.Join(dir,"backup")
+	fetchBackup(t,addrs[0],&pb.BackupRequest{GroupId:1},backup)
+
+	db,err:=storage.MakeLevelDBKvStore(filepath.Join(dir,"db"))
+	if err!=nil{
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_,_,err=RestoreBackup(db,RestoreOptions{Backup:backup,Peers:[]string{"127.0.0.1:1"}})
+	if err==nil || !strings.Contains(err.Error(),"belongs to group 2"){
+		t.Fatalf("restore of one of two hash groups: %v",err)
+	}
+}
@@ -0,0 +1,389 @@
+package shardkvserver
+
+import(
+	"os"
+	"sync"
+	"time"
+	"bufio"
+	"errors"
+	"context"
+	"strconv"
+	"path/filepath"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"neweraft/raftcore"
+	pb "neweraft/raftpb"
+	"neweraft/storage"
+)
+
+const(
+	CdcBufferSize = 256
+	CdcReplayBatch = 256
+)
+
+// cdcQuietTypes move data between groups instead of changing it; their MVCC
+// writes are not mutations, so replay leaves them out like the apply loop does.
+var cdcQuietTypes=map[CommandType]bool{
+	CmdConfiguration:true,
+	CmdInsertShards:true,
+	CmdDeleteShards:true,
+	CmdSplitRange:true,
+	CmdFreezeRange:true,
+	CmdMergeRange:true,
+}
+
+// cdcSubscriber gets one record per applied entry. Like a watcher it is
+// dropped when it falls behind and catches up from the log.
+type cdcSubscriber struct{
+	ch chan *pb.CdcRecord
+	removed bool
+}
+
+func (sg *ShardGroup)publishCdc(msg *raftcore.ApplyMsg,cmdType CommandType,events []*pb.WatchEvent){
+	if len(sg.cdcSubs)==0{
+		return
+	}
+	rec:=&pb.CdcRecord{
+		Gid:sg.gid,
+		Index:msg.CommandIndex,
+		Term:msg.CommandTerm,
+		Type:cmdType.String(),
+		Mutations:events,
+	}
+	for id,sub:=range sg.cdcSubs{
+		select{
+		case sub.ch<-rec:
+		default:
+			close(sub.ch)
+			delete(sg.cdcSubs,id)
+		}
+	}
+}
+
+func (sg *ShardGroup)unregisterCdc(sub *cdcSubscriber){
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	for id,other:=range sg.cdcSubs{
+		if other==sub{
+			delete(sg.cdcSubs,id)
+		}
+	}
+}
+
+func (sg *ShardGroup)cdcShards() []*Shard{
+	if sg.rng!=nil{
+		return []*Shard{sg.rangeShard}
+	}
+	shards:=make([]*Shard,0,NShards)
+	for i:=0;i<NShards;i++{
+		shards=append(shards,sg.shards[i])
+	}
+	return shards
+}
+
+func versionEvent(ver *storage.MvccVersion) *pb.WatchEvent{
+	ev:=&pb.WatchEvent{Type:pb.EventType_EventPut,Key:ver.Key,ModIndex:ver.CommitIndex}
+	if ver.Deleted{
+		ev.Type=pb.EventType_EventDelete
+	} else {
+		ev.Version,ev.Value=decodeValue(ver.Value)
+	}
+	return ev
+}
+
+// replayCdc rebuilds the records of [from,to] from the raft log, taking the
+// mutations from the MVCC history at each entry's index.
+func (sg *ShardGroup)replayCdc(r storage.KvReader,shards []*Shard,from int64,to int64) ([]*pb.CdcRecord,error){
+	mutations:=make(map[int64][]*pb.WatchEvent)
+	for _,shard:=range shards{
+		versions,err:=shard.History(r,"","",from,to)
+		if err!=nil{
+			return nil,err
+		}
+		for _,ver:=range versions{
+			mutations[ver.CommitIndex]=append(mutations[ver.CommitIndex],versionEvent(ver))
+		}
+	}
+	records:=[]*pb.CdcRecord{}
+	for _,entry:=range sg.raft.GetCommittedEntries(from,to){
+		cmd,err:=DecodeCommand(entry.Date)
+		if err!=nil{
+			continue
+		}
+		rec:=&pb.CdcRecord{Gid:sg.gid,Index:entry.Index,Term:entry.CurTerm,Type:cmd.Type.String()}
+		if !cdcQuietTypes[cmd.Type]{
+			rec.Mutations=mutations[entry.Index]
+		}
+		records=append(records,rec)
+	}
+	return records,nil
+}
+
+// snapshotCdc sends the whole group state as of index as puts, split over
+// records that all carry that index; only the last one has More unset.
+func (sg *ShardGroup)snapshotCdc(r storage.KvReader,shards []*Shard,index int64,send func(*pb.CdcResponse) error) error{
+	var term int64
+	if entries:=sg.raft.GetCommittedEntries(index,index);len(entries)>0{
+		term=entries[0].CurTerm
+	}
+	rec:=&pb.CdcRecord{Gid:sg.gid,Index:index,Term:term,Type:"Snapshot",Snapshot:true}
+	for _,shard:=range shards{
+		iter:=shard.Scan(r,"","",index,false)
+		for iter.Next(){
+			version,value:=decodeValue(iter.Value())
+			rec.Mutations=append(rec.Mutations,&pb.WatchEvent{
+				Type:pb.EventType_EventPut,
+				Key:iter.Key()[len(shard.prefix()):],
+				Value:value,
+				Version:version,
+				ModIndex:iter.CommitIndex(),
+			})
+			if len(rec.Mutations)>=ScanBatchSize{
+				rec.More=true
+				if err:=send(&pb.CdcResponse{Err:pb.ErrCode_ErrOK,Records:[]*pb.CdcRecord{rec}});err!=nil{
+					iter.Release()
+					return err
+				}
+				rec=&pb.CdcRecord{Gid:sg.gid,Index:index,Term:term,Type:"Snapshot",Snapshot:true}
+			}
+		}
+		err:=iter.Error()
+		iter.Release()
+		if err!=nil{
+			return err
+		}
+	}
+	return send(&pb.CdcResponse{Err:pb.ErrCode_ErrOK,Records:[]*pb.CdcRecord{rec}})
+}
+
+// streamCdc sends every applied entry from req.StartIndex on in log order.
+// With req.Snapshot it first sends the current state and continues after it,
+// which is also the way back in once the start index has been compacted.
+func (sg *ShardGroup)streamCdc(ctx context.Context,req *pb.CdcRequest,send func(*pb.CdcResponse) error) error{
+	from:=req.StartIndex
+	if from<=0{
+		from=1
+	}
+	snapshot:=req.Snapshot
+	for{
+		sub:=&cdcSubscriber{ch:make(chan *pb.CdcRecord,CdcBufferSize)}
+		sg.mu.Lock()
+		if !snapshot && from<=sg.compactedIndex{
+			compacted:=sg.compactedIndex
+			sg.mu.Unlock()
+			return send(&pb.CdcResponse{Err:pb.ErrCode_ErrCompacted,CompactIndex:compacted})
+		}
+		snap,err:=sg.dataEng.GetSnapshot()
+		if err!=nil{
+			sg.mu.Unlock()
+			return send(&pb.CdcResponse{Err:pb.ErrCode_ErrNotReady})
+		}
+		applied:=sg.lastApplied
+		shards:=sg.cdcShards()
+		sg.nextCdcId++
+		sg.cdcSubs[sg.nextCdcId]=sub
+		sg.mu.Unlock()
+
+		err=nil
+		if snapshot{
+			err=sg.snapshotCdc(snap,shards,applied,send)
+			from,snapshot=applied+1,false
+		}
+		for lo:=from;err==nil && lo<=applied;lo+=CdcReplayBatch{
+			hi:=lo+CdcReplayBatch-1
+			if hi>applied{
+				hi=applied
+			}
+			var records []*pb.CdcRecord
+			if records,err=sg.replayCdc(snap,shards,lo,hi);err==nil && len(records)>0{
+				err=send(&pb.CdcResponse{Err:pb.ErrCode_ErrOK,Records:records})
+			}
+		}
+		snap.Release()
+		if err!=nil{
+			sg.unregisterCdc(sub)
+			return err
+		}
+		from=applied+1
+
+		for caughtUp:=true;caughtUp;{
+			select{
+			case <-ctx.Done():
+				sg.unregisterCdc(sub)
+				return nil
+			case rec,ok:=<-sub.ch:
+				if !ok{
+					if sub.removed{
+						return send(&pb.CdcResponse{Err:pb.ErrCode_ErrWrongGroup})
+					}
+					caughtUp=false
+					continue
+				}
+				if err:=send(&pb.CdcResponse{Err:pb.ErrCode_ErrOK,Records:[]*pb.CdcRecord{rec}});err!=nil{
+					sg.unregisterCdc(sub)
+					return err
+				}
+				from=rec.Index+1
+			}
+		}
+	}
+}
+
+func (sg *ShardGroup)CdcSubscribe(req *pb.CdcRequest,stream grpc.ServerStreamingServer[pb.CdcResponse]) error{
+	return sg.streamCdc(stream.Context(),req,func(res *pb.CdcResponse) error{
+		return stream.Send(res)
+	})
+}
+
+// CdcSubscribe serves one group: indexes are per group, so a consumer of a
+// sharded cluster subscribes to every gid it finds.
+func (shardsvr *ShardServer)CdcSubscribe(req *pb.CdcRequest,stream grpc.ServerStreamingServer[pb.CdcResponse]) error{
+	group:=shardsvr.getGroup(req.Gid)
+	if req.Gid==0{
+		if groups:=shardsvr.getGroups();len(groups)==1{
+			group=groups[0]
+		}
+	}
+	if group==nil{
+		return stream.Send(&pb.CdcResponse{Err:pb.ErrCode_ErrWrongGroup})
+	}
+	return group.CdcSubscribe(req,stream)
+}
+
+type cdcMutation struct{
+	Op string
+	Key string
+	Value string
+	Version int64
+	ModIndex int64
+}
+
+type cdcLine struct{
+	Gid int64
+	Index int64
+	Term int64
+	Type string
+	Snapshot bool
+	Mutations []*cdcMutation
+}
+
+var errCdcStop = errors.New("cdc stream stopped")
+
+// CdcFileSink appends the records of every local group to dir/cdc_<gid>.jsonl,
+// one JSON object per line, and checkpoints the last complete index in
+// dir/cdc_<gid>.ckpt. Delivery is at least once: after a crash the lines past
+// the checkpoint are written again.
+type CdcFileSink struct{
+	mu sync.Mutex
+	svr *ShardServer
+	dir string
+	running map[int64]bool
+}
+
+func StartCdcFileSink(svr *ShardServer,dir string) (*CdcFileSink,error){
+	if err:=os.MkdirAll(dir,0755);err!=nil{
+		return nil,err
+	}
+	sink:=&CdcFileSink{svr:svr,dir:dir,running:make(map[int64]bool)}
+	go sink.ticker()
+	return sink,nil
+}
+
+func (sink *CdcFileSink)ticker(){
+	for{
+		for _,group:=range sink.svr.getGroups(){
+			sink.mu.Lock()
+			if !sink.running[group.gid]{
+				sink.running[group.gid]=true
+				go sink.run(group)
+			}
+			sink.mu.Unlock()
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func (sink *CdcFileSink)checkpointPath(gid int64) string{
+	return filepath.Join(sink.dir,"cdc_"+strconv.FormatInt(gid,10)+".ckpt")
+}
+
+func (sink *CdcFileSink)readCheckpoint(gid int64) int64{
+	data,err:=os.ReadFile(sink.checkpointPath(gid))
+	if err!=nil{
+		return 0
+	}
+	ckpt,_:=strconv.ParseInt(string(data),10,64)
+	return ckpt
+}
+
+func (sink *CdcFileSink)writeCheckpoint(gid int64,index int64) error{
+	tmp:=sink.checkpointPath(gid)+".tmp"
+	if err:=os.WriteFile(tmp,[]byte(strconv.FormatInt(index,10)),0644);err!=nil{
+		return err
+	}
+	return os.Rename(tmp,sink.checkpointPath(gid))
+}
+
+func (sink *CdcFileSink)run(group *ShardGroup){
+	defer func(){
+		sink.mu.Lock()
+		delete(sink.running,group.gid)
+		sink.mu.Unlock()
+	}()
+	f,err:=os.OpenFile(filepath.Join(sink.dir,"cdc_"+strconv.FormatInt(group.gid,10)+".jsonl"),os.O_CREATE|os.O_WRONLY|os.O_APPEND,0644)
+	if err!=nil{
+		group.logger().Error("cdc sink open failed","err",err)
+		return
+	}
+	defer f.Close()
+
+	ckpt:=sink.readCheckpoint(group.gid)
+	req:=&pb.CdcRequest{Gid:group.gid,StartIndex:ckpt+1}
+	write:=func(res *pb.CdcResponse) error{
+		switch res.Err{
+		case pb.ErrCode_ErrOK:
+		case pb.ErrCode_ErrCompacted:
+			group.logger().Warn("cdc sink index compacted, restarting from a snapshot","index",req.StartIndex)
+			req.Snapshot=true
+			return nil
+		default:
+			return errCdcStop
+		}
+		w:=bufio.NewWriter(f)
+		for _,rec:=range res.Records{
+			line:=&cdcLine{Gid:rec.Gid,Index:rec.Index,Term:rec.Term,Type:rec.Type,Snapshot:rec.Snapshot}
+			for _,ev:=range rec.Mutations{
+				op:="Put"
+				if ev.Type==pb.EventType_EventDelete{
+					op="Delete"
+				}
+				line.Mutations=append(line.Mutations,&cdcMutation{Op:op,Key:ev.Key,Value:ev.Value,Version:ev.Version,ModIndex:ev.ModIndex})
+			}
+			data,err:=json.Marshal(line)
+			if err!=nil{
+				return err
+			}
+			w.Write(append(data,'\n'))
+			if !rec.More{
+				ckpt=rec.Index
+			}
+		}
+		if err:=w.Flush();err!=nil{
+			return err
+		}
+		if ckpt>=req.StartIndex{
+			req.StartIndex,req.Snapshot=ckpt+1,false
+			return sink.writeCheckpoint(group.gid,ckpt)
+		}
+		return nil
+	}
+	for !group.raft.Killed(){
+		if err:=group.streamCdc(context.Background(),req,write);err==errCdcStop{
+			return
+		} else if err!=nil{
+			group.logger().Error("cdc sink failed","err",err)
+		}
+		time.Sleep(time.Second)
+	}
+}
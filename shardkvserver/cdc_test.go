@@ -0,0 +1,238 @@
+package shardkvserver
+
+import(
+	"os"
+	"fmt"
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"path/filepath"
+	"encoding/json"
+
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+)
+
+// subscribeCdc streams the records of group gid on svr from req into the
+// returned channel until the test ends.
+func subscribeCdc(t *testing.T,svr *ShardServer,gid int64,req *pb.CdcRequest) <-chan *pb.CdcResponse{
+	ctx,cancel:=context.WithCancel(context.Background())
+	ch:=make(chan *pb.CdcResponse,1024)
+	done:=make(chan struct{})
+	go func(){
+		defer close(done)
+		svr.getGroup(gid).streamCdc(ctx,req,func(res *pb.CdcResponse) error{
+			ch<-res
+			return nil
+		})
+	}()
+	t.Cleanup(func(){
+		cancel()
+		<-done
+	})
+	return ch
+}
+
+// nextRecords reads records from ch up to and including index to.
+func nextRecords(t *testing.T,ch <-chan *pb.CdcResponse,to int64) []*pb.CdcRecord{
+	t.Helper()
+	var records []*pb.CdcRecord
+	for len(records)==0 || records[len(records)-1].Index<to{
+		select{
+		case res:=<-ch:
+			if res.Err!=pb.ErrCode_ErrOK{
+				t.Fatalf("cdc ended after %d records: %v",len(records),res)
+			}
+			records=append(records,res.Records...)
+		case <-time.After(10*time.Second):
+			t.Fatalf("no record up to %d after %d records",to,len(records))
+		}
+	}
+	return records
+}
+
+// checkRecords checks the records run from index from without a gap, carry
+// the term of their log entry, and that each put in puts shows up as a
+// mutation of its own record.
+func checkRecords(t *testing.T,group *ShardGroup,records []*pb.CdcRecord,from int64,puts map[int64]string){
+	t.Helper()
+	for i,rec:=range records{
+		if rec.Index!=from+int64(i){
+			t.Fatalf("record %d has index %d, want %d",i,rec.Index,from+int64(i))
+		}
+		entries:=group.raft.GetCommittedEntries(rec.Index,rec.Index)
+		if len(entries)!=1 || rec.Term!=entries[0].CurTerm || rec.Term==0{
+			t.Fatalf("record %d has term %d",rec.Index,rec.Term)
+		}
+		if key,ok:=puts[rec.Index];ok{
+			if rec.Type!="Operation" || len(rec.Mutations)!=1 || rec.Mutations[0].Key!=key || rec.Mutations[0].ModIndex!=rec.Index{
+				t.Fatalf("record of the put of %s: %v",key,rec)
+			}
+		}
+	}
+}
+
+func cdcPuts(t *testing.T,cli *shardkvclient.Client,keys ...string) (map[int64]string,int64){
+	t.Helper()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+	puts:=make(map[int64]string)
+	var last int64
+	for _,key:=range keys{
+		res,err:=cli.Put(ctx,key,"v-"+key)
+		if err!=nil{
+			t.Fatal(err)
+		}
+		puts[res.ModIndex]=key
+		last=res.ModIndex
+	}
+	return puts,last
+}
+
+func leaderOf(t *testing.T,svrs []*ShardServer,gid int64) *ShardServer{
+	t.Helper()
+	var leader *ShardServer
+	waitFor(t,"a leader",func() bool{
+		for _,svr:=range svrs{
+			if _,isLeader:=svr.getGroup(gid).raft.GetState();isLeader{
+				leader=svr
+				return true
+			}
+		}
+		return false
+	})
+	return leader
+}
+
+func TestCdcRecords(t *testing.T){
+	svrs,addrs:=startTestServers(t,3,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	puts,last:=cdcPuts(t,cli,"a","b","c")
+
+	// a follower streams what it applied, from the first entry on
+	for _,svr:=range svrs{
+		records:=nextRecords(t,subscribeCdc(t,svr,1,&pb.CdcRequest{Gid:1}),last)
+		checkRecords(t,svr.getGroup(1),records,1,puts)
+	}
+
+	// records of later entries follow live
+	ch:=subscribeCdc(t,svrs[0],1,&pb.CdcRequest{Gid:1,StartIndex:last+1})
+	more,next:=cdcPuts(t,cli,"d","e")
+	checkRecords(t,svrs[0].getGroup(1),nextRecords(t,ch,next),last+1,more)
+}
+
+func TestCdcResume(t *testing.T){
+	svrs,addrs:=startTestServers(t,3,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	puts,last:=cdcPuts(t,cli,"a","b","c","d")
+	leader:=leaderOf(t,svrs,1)
+
+	// a consumer that checkpointed up to the put of b goes on from there
+	var ckpt int64
+	for index,key:=range puts{
+		if key=="b"{
+			ckpt=index
+		}
+	}
+	records:=nextRecords(t,subscribeCdc(t,leader,1,&pb.CdcRequest{Gid:1,StartIndex:ckpt+1}),last)
+	checkRecords(t,leader.getGroup(1),records,ckpt+1,puts)
+}
+
+func TestCdcSnapshotAfterCompaction(t *testing.T){
+	retention,interval:=MvccRetention,MvccGCInterval
+	MvccRetention,MvccGCInterval=5,50*time.Millisecond
+	t.Cleanup(func(){
+		MvccRetention,MvccGCInterval=retention,interval
+	})
+	svrs,addrs:=startTestServers(t,1,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	keys:=testKeys(20)
+	cdcPuts(t,cli,keys...)
+	group:=svrs[0].getGroup(1)
+	waitFor(t,"compaction",func() bool{
+		group.mu.RLock()
+		defer group.mu.RUnlock()
+		return group.compactedIndex>0
+	})
+	MvccRetention=1<<40
+
+	if res:=<-subscribeCdc(t,svrs[0],1,&pb.CdcRequest{Gid:1,StartIndex:1});res.Err!=pb.ErrCode_ErrCompacted{
+		t.Fatalf("stream from a compacted index: %v",res)
+	}
+
+	// the snapshot holds every key, then the log goes on after its index
+	ch:=subscribeCdc(t,svrs[0],1,&pb.CdcRequest{Gid:1,StartIndex:1,Snapshot:true})
+	var snapshot []*pb.CdcRecord
+	for len(snapshot)==0 || snapshot[len(snapshot)-1].More{
+		snapshot=append(snapshot,nextRecords(t,ch,0)...)
+	}
+	var got []string
+	index:=snapshot[0].Index
+	for _,rec:=range snapshot{
+		if !rec.Snapshot || rec.Index!=index{
+			t.Fatalf("snapshot record %v",rec)
+		}
+		for _,ev:=range rec.Mutations{
+			if ev.Value!="v-"+ev.Key{
+				t.Fatalf("snapshot value %v",ev)
+			}
+			got=append(got,ev.Key)
+		}
+	}
+	checkKeys(t,"snapshot",got,keys)
+	more,last:=cdcPuts(t,cli,"z1","z2")
+	checkRecords(t,group,nextRecords(t,ch,last),index+1,more)
+}
+
+func TestCdcFileSinkResumesAfterCheckpoint(t *testing.T){
+	svrs,addrs:=startTestServers(t,1,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	_,last:=cdcPuts(t,cli,"a","b","c","d","e")
+
+	// a sink that wrote lines up to last-1 but checkpointed only up to
+	// last-3 before it stopped
+	dir:=t.TempDir()
+	ckpt:=last-3
+	var before []string
+	for index:=int64(1);index<last;index++{
+		data,_:=json.Marshal(&cdcLine{Gid:1,Index:index,Type:"Before"})
+		before=append(before,string(data))
+	}
+	os.WriteFile(filepath.Join(dir,"cdc_1.jsonl"),[]byte(strings.Join(before,"\n")+"\n"),0644)
+	os.WriteFile(filepath.Join(dir,"cdc_1.ckpt"),[]byte(fmt.Sprint(ckpt)),0644)
+
+	sink,err:=StartCdcFileSink(svrs[0],dir)
+	if err!=nil{
+		t.Fatal(err)
+	}
+	waitFor(t,"the checkpoint",func() bool{
+		return sink.readCheckpoint(1)>=last
+	})
+	f,err:=os.Open(filepath.Join(dir,"cdc_1.jsonl"))
+	if err!=nil{
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []*cdcLine
+	for scanner:=bufio.NewScanner(f);scanner.Scan();{
+		line:=&cdcLine{}
+		if err:=json.Unmarshal(scanner.Bytes(),line);err!=nil{
+			t.Fatal(err)
+		}
+		lines=append(lines,line)
+	}
+	for i,line:=range lines[len(before):]{
+		if line.Index!=ckpt+1+int64(i) || line.Type=="Before"{
+			t.Fatalf("line %d after the restart is %v, want index %d",i,line,ckpt+1+int64(i))
+		}
+	}
+	if n:=len(lines)-len(before);int64(n)<last-ckpt{
+		t.Fatalf("%d lines after the restart, want at least %d",n,last-ckpt)
+	}
+}
@@ -0,0 +1,86 @@
+package shardkvserver
+
+import(
+	"bytes"
+	"encoding/gob"
+
+	pb "neweraft/raftpb"
+)
+
+type CommandType int
+
+const(
+	CmdOperation CommandType=iota
+	CmdConfiguration
+	CmdInsertShards
+	CmdDeleteShards
+	CmdEmptyEntry
+	CmdSplitRange
+	CmdFreezeRange
+	CmdMergeRange
+	CmdTxn
+	CmdTxnBegin
+	CmdTxnPrepare
+	CmdTxnDecide
+	CmdTxnResolve
+	CmdTxnFinish
+	CmdLeaseGrant
+	CmdLeaseRevoke
+	CmdCompact
+)
+
+var commandTypeNames=[]string{
+	"Operation","Configuration","InsertShards","DeleteShards","EmptyEntry",
+	"SplitRange","FreezeRange","MergeRange",
+	"Txn","TxnBegin","TxnPrepare","TxnDecide","TxnResolve","TxnFinish",
+	"LeaseGrant","LeaseRevoke","Compact",
+}
+
+func (t CommandType) String() string{
+	if t<0 || int(t)>=len(commandTypeNames){
+		return "Unknown"
+	}
+	return commandTypeNames[t]
+}
+
+type Command struct{
+	Type CommandType
+	Request *pb.CommandRequest
+	Config *Config
+	ShardsResp *pb.ShardOperationResponse
+	ShardsReq *pb.ShardOperationRequest
+	Range *RangeCommand
+	Txn *pb.TxnRequest
+	DistTxn *DistTxnCommand
+	Lease *LeaseCommand
+	CompactIndex int64
+}
+
+type RangeCommand struct{
+	Epoch int64
+	SplitKey string
+	NewGid int64
+	TargetGid int64
+	SourceRange *KeyRange
+	Data map[string]string
+	LastOperations map[int64]*pb.OperationContext
+	Leases []*pb.LeaseInfo
+}
+
+func EncodeCommand(cmd *Command) ([]byte,error){
+	var buf bytes.Buffer
+	enc:=gob.NewEncoder(&buf)
+	err:=enc.Encode(cmd)
+	if err!=nil{
+		return []byte{},err
+	}
+	return buf.Bytes(),nil
+}
+
+func DecodeCommand(cmdByte []byte) (*Command,error){
+	buf:=bytes.NewBuffer(cmdByte)
+	dec:=gob.NewDecoder(buf)
+	cmd:=&Command{}
+	err:=dec.Decode(cmd)
+	return cmd,err
+}
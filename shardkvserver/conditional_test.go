@@ -0,0 +1,75 @@
+package shardkvserver
+
+import(
+	"errors"
+	"context"
+	"testing"
+	"time"
+
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+)
+
+func TestConditionalWrites(t *testing.T){
+	svrs,addrs:=startTestServers(t,3,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+
+	res,err:=cli.PutIfAbsent(ctx,"k","a",0)
+	if err!=nil || res.Version!=1{
+		t.Fatalf("put if absent on a new key: %v %v",res,err)
+	}
+	res,err=cli.PutIfAbsent(ctx,"k","b",0)
+	if !errors.Is(err,shardkvclient.ErrCompareFailed) || res.Value!="a" || res.Version!=1{
+		t.Fatalf("put if absent on an existing key: %v %v",res,err)
+	}
+
+	if res,err=cli.Cas(ctx,"k",2,"b");!errors.Is(err,shardkvclient.ErrCompareFailed) || res.Version!=1{
+		t.Fatalf("cas on a stale version: %v %v",res,err)
+	}
+	if res,err=cli.Cas(ctx,"k",1,"b");err!=nil || res.Version!=2{
+		t.Fatalf("cas on the current version: %v %v",res,err)
+	}
+	res,err=cli.Command(ctx,&pb.CommandRequest{Key:"k",Value:"c",Op:pb.OpType_OpCas,ExpectedValue:"a"})
+	if !errors.Is(err,shardkvclient.ErrCompareFailed) || res.Value!="b"{
+		t.Fatalf("cas on a stale value: %v %v",res,err)
+	}
+	if res,err=cli.Command(ctx,&pb.CommandRequest{Key:"k",Value:"c",Op:pb.OpType_OpCas,ExpectedValue:"b"});err!=nil || res.Version!=3{
+		t.Fatalf("cas on the current value: %v %v",res,err)
+	}
+	if _,err=cli.Cas(ctx,"missing",1,"x");!errors.Is(err,shardkvclient.ErrCompareFailed){
+		t.Fatalf("cas on a missing key: %v",err)
+	}
+
+	if _,err=cli.DeleteIfEquals(ctx,"k","c",2);!errors.Is(err,shardkvclient.ErrCompareFailed){
+		t.Fatalf("delete if equals on a stale version: %v",err)
+	}
+	if _,err=cli.DeleteIfEquals(ctx,"k","b",0);!errors.Is(err,shardkvclient.ErrCompareFailed){
+		t.Fatalf("delete if equals on a stale value: %v",err)
+	}
+	if _,err=cli.DeleteIfEquals(ctx,"k","c",3);err!=nil{
+		t.Fatalf("delete if equals: %v",err)
+	}
+	if _,err=cli.DeleteIfEquals(ctx,"k","c",0);!errors.Is(err,shardkvclient.ErrNoKey){
+		t.Fatalf("delete if equals on a deleted key: %v",err)
+	}
+	// a deleted key starts over at version 1
+	if res,err=cli.PutIfAbsent(ctx,"k","d",0);err!=nil || res.Version!=1{
+		t.Fatalf("put if absent after delete: %v %v",res,err)
+	}
+
+	// every replica decided the same from its applied state
+	waitApplied(t,svrs,1)
+	for _,svr:=range svrs{
+		group:=svr.getGroup(1)
+		group.mu.RLock()
+		v,version,err:=group.rangeShard.GetWithVersion("k")
+		_,_,missErr:=group.rangeShard.GetWithVersion("missing")
+		group.mu.RUnlock()
+		if err!=nil || v!="d" || version!=1 || missErr==nil{
+			t.Fatalf("node %d holds %q version %d (%v), missing key %v",svr.id,v,version,err,missErr)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package shardkvserver
+
+import(
+	"os"
+	"errors"
+	"hash/fnv"
+	"encoding/json"
+)
+
+const NShards = 10
+
+type Config struct{
+	Num int64
+	Shards [NShards]int64
+	Groups map[int64][]string
+	Ranges []*KeyRange
+}
+
+func DefaultConfig() *Config{
+	return &Config{
+		Num:0,
+		Groups:make(map[int64][]string),
+	}
+}
+
+func (cf *Config) Copy() *Config{
+	newConfig:=&Config{
+		Num:cf.Num,
+		Shards:cf.Shards,
+		Groups:make(map[int64][]string),
+	}
+	for gid,servers:=range cf.Groups{
+		newConfig.Groups[gid]=append([]string{},servers...)
+	}
+	for _,rng:=range cf.Ranges{
+		newConfig.Ranges=append(newConfig.Ranges,rng.Copy())
+	}
+	return newConfig
+}
+
+func (cf *Config) RangeOf(gid int64) *KeyRange{
+	for _,rng:=range cf.Ranges{
+		if rng.Gid==gid{
+			return rng.Copy()
+		}
+	}
+	return nil
+}
+
+func Key2Shard(key string) int{
+	h:=fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32()%NShards)
+}
+
+type CtrlerClient interface{
+	Query(num int64) (*Config,error)
+}
+
+// FileCtrlerClient reads the config history from a json file on every query,
+// so editing the file is how shards are moved between groups.
+type FileCtrlerClient struct{
+	path string
+}
+
+func MakeFileCtrlerClient(path string) *FileCtrlerClient{
+	return &FileCtrlerClient{
+		path:path,
+	}
+}
+
+func (fc *FileCtrlerClient) Query(num int64) (*Config,error){
+	data,err:=os.ReadFile(fc.path)
+	if err!=nil{
+		return nil,err
+	}
+	configs:=[]*Config{}
+	if err:=json.Unmarshal(data,&configs);err!=nil{
+		return nil,err
+	}
+	if len(configs)==0{
+		return DefaultConfig(),nil
+	}
+	if num<0 || num>=configs[len(configs)-1].Num{
+		return configs[len(configs)-1],nil
+	}
+	for _,config:=range configs{
+		if config.Num==num{
+			return config,nil
+		}
+	}
+	return nil,errors.New("config not found")
+}
@@ -0,0 +1,587 @@
+package shardkvserver
+
+import(
+	"sync"
+	"time"
+	"bytes"
+	"context"
+	"strconv"
+	"encoding/gob"
+
+	pb "neweraft/raftpb"
+)
+
+var(
+	TxnIntentTimeout = 5*time.Second
+	TxnRecoveryInterval = time.Second
+)
+
+const(
+	TxnRecordPrefix = "__txn_record_"
+	TxnIntentPrefix = "__txn_intent_"
+	TxnDonePrefix = "__txn_done_"
+)
+
+type TxnParticipant struct{
+	Gid int64
+	Peers []string
+	Compares []*pb.Compare
+	Ops []*pb.TxnOp
+}
+
+// DistTxnCommand carries every two-phase commit record through the raft log
+// of the group it is proposed to: Begin/Decide/Finish go to the coordinator
+// group, Prepare/Resolve to the participants.
+type DistTxnCommand struct{
+	TxnId int64
+	Time int64
+	Commit bool
+	ClientId int64
+	CommandId int64
+	Participants []*TxnParticipant
+	CoordinatorGid int64
+	CoordinatorPeers []string
+	Compares []*pb.Compare
+	Ops []*pb.TxnOp
+}
+
+// txnRecord lives in the coordinator group; its State is the commit point.
+type txnRecord struct{
+	TxnId int64
+	State pb.TxnState
+	StartTime int64
+	ClientId int64
+	CommandId int64
+	Participants []*TxnParticipant
+}
+
+// txnIntent is the replicated lock a participant holds between prepare and
+// resolve. It locks Keys, the keys of the compares as well as of the ops: a
+// compare checked at prepare only holds while nobody writes its key.
+type txnIntent struct{
+	TxnId int64
+	PrepareTime int64
+	CoordinatorGid int64
+	CoordinatorPeers []string
+	Ops []*pb.TxnOp
+	Keys []string
+}
+
+func txnKey(prefix string,txnId int64) string{
+	return prefix+strconv.FormatInt(txnId,10)
+}
+
+func encodeGob(v interface{}) string{
+	var buf bytes.Buffer
+	gob.NewEncoder(&buf).Encode(v)
+	return buf.String()
+}
+
+func (sg *ShardGroup)restoreTxns(){
+	records,_:=sg.dataEng.DumpPrefix(TxnRecordPrefix,true)
+	for _,data:=range records{
+		record:=&txnRecord{}
+		if gob.NewDecoder(bytes.NewBufferString(data)).Decode(record)==nil{
+			sg.txnRecords[record.TxnId]=record
+		}
+	}
+	intents,_:=sg.dataEng.DumpPrefix(TxnIntentPrefix,true)
+	for _,data:=range intents{
+		intent:=&txnIntent{}
+		if gob.NewDecoder(bytes.NewBufferString(data)).Decode(intent)==nil{
+			sg.addIntent(intent)
+		}
+	}
+	dones,_:=sg.dataEng.DumpPrefix(TxnDonePrefix,true)
+	for txnIdStr:=range dones{
+		if txnId,err:=strconv.ParseInt(txnIdStr,10,64);err==nil{
+			sg.doneTxns[txnId]=true
+		}
+	}
+}
+
+func (sg *ShardGroup)addIntent(intent *txnIntent){
+	sg.intents[intent.TxnId]=intent
+	for _,key:=range intent.Keys{
+		sg.locks[key]=intent.TxnId
+	}
+}
+
+func (sg *ShardGroup)removeIntent(txnId int64){
+	if intent,ok:=sg.intents[txnId];ok{
+		for _,key:=range intent.Keys{
+			if sg.locks[key]==txnId{
+				delete(sg.locks,key)
+			}
+		}
+		delete(sg.intents,txnId)
+	}
+}
+
+func (sg *ShardGroup)isLocked(key string) bool{
+	_,ok:=sg.locks[key]
+	return ok
+}
+
+func (sg *ShardGroup)hasPendingTxns() bool{
+	return len(sg.intents)>0 || len(sg.txnRecords)>0
+}
+
+func (sg *ShardGroup)applyTxnBegin(dc *DistTxnCommand) *pb.CommandResponse{
+	if sg.isDuplicateRequest(dc.ClientId,dc.CommandId){
+		return sg.lastOperations[dc.ClientId].LastResponse
+	}
+	record:=&txnRecord{
+		TxnId:dc.TxnId,
+		State:pb.TxnState_TxnPending,
+		StartTime:dc.Time,
+		ClientId:dc.ClientId,
+		CommandId:dc.CommandId,
+		Participants:dc.Participants,
+	}
+	sg.txnRecords[dc.TxnId]=record
+	sg.dataEng.Put(txnKey(TxnRecordPrefix,dc.TxnId),encodeGob(record))
+	res:=&pb.CommandResponse{Err:pb.ErrCode_ErrNotReady,Value:strconv.FormatInt(dc.TxnId,10)}
+	sg.updateLastOperation(dc.ClientId,&pb.OperationContext{MaxAppliedCommandId:dc.CommandId,LastResponse:res})
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK,Value:res.Value}
+}
+
+func (sg *ShardGroup)applyTxnDecide(dc *DistTxnCommand) *pb.CommandResponse{
+	record,ok:=sg.txnRecords[dc.TxnId]
+	if !ok{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrOK,Version:int64(pb.TxnState_TxnUnknown)}
+	}
+	if record.State==pb.TxnState_TxnPending{
+		record.State=pb.TxnState_TxnAborted
+		if dc.Commit{
+			record.State=pb.TxnState_TxnCommitted
+		}
+		sg.dataEng.Put(txnKey(TxnRecordPrefix,dc.TxnId),encodeGob(record))
+		if opCtx,ok:=sg.lastOperations[record.ClientId];ok && opCtx.MaxAppliedCommandId==record.CommandId{
+			res:=&pb.CommandResponse{Err:pb.ErrCode_ErrOK,Value:strconv.FormatInt(dc.TxnId,10),
+				Txn:&pb.TxnResponse{Succeeded:record.State==pb.TxnState_TxnCommitted}}
+			sg.updateLastOperation(record.ClientId,&pb.OperationContext{MaxAppliedCommandId:record.CommandId,LastResponse:res})
+		}
+	}
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK,Version:int64(record.State)}
+}
+
+func (sg *ShardGroup)applyTxnFinish(dc *DistTxnCommand) *pb.CommandResponse{
+	if record,ok:=sg.txnRecords[dc.TxnId];ok && record.State!=pb.TxnState_TxnPending{
+		delete(sg.txnRecords,dc.TxnId)
+		sg.dataEng.Del(txnKey(TxnRecordPrefix,dc.TxnId))
+	}
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
+
+func (sg *ShardGroup)applyTxnPrepare(dc *DistTxnCommand) *pb.CommandResponse{
+	if _,ok:=sg.intents[dc.TxnId];ok{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+	}
+	if sg.doneTxns[dc.TxnId]{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrOutDated}
+	}
+	keys:=txnKeys(&pb.TxnRequest{Compares:dc.Compares,Success:dc.Ops})
+	for _,key:=range keys{
+		if !sg.canServeKey(key){
+			return &pb.CommandResponse{Err:pb.ErrCode_ErrWrongGroup}
+		}
+		if sg.isLocked(key){
+			return &pb.CommandResponse{Err:pb.ErrCode_ErrLocked}
+		}
+	}
+	tv:=sg.makeTxnView()
+	for _,cmp:=range dc.Compares{
+		if !compareTxn(tv,cmp){
+			return &pb.CommandResponse{Err:pb.ErrCode_ErrCompareFailed}
+		}
+	}
+	intent:=&txnIntent{
+		TxnId:dc.TxnId,
+		PrepareTime:dc.Time,
+		CoordinatorGid:dc.CoordinatorGid,
+		CoordinatorPeers:dc.CoordinatorPeers,
+		Ops:dc.Ops,
+		Keys:keys,
+	}
+	sg.addIntent(intent)
+	sg.dataEng.Put(txnKey(TxnIntentPrefix,dc.TxnId),encodeGob(intent))
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
+
+func (sg *ShardGroup)applyTxnResolve(dc *DistTxnCommand) *pb.CommandResponse{
+	intent,ok:=sg.intents[dc.TxnId]
+	if !ok{
+		if !sg.doneTxns[dc.TxnId]{
+			sg.doneTxns[dc.TxnId]=true
+			sg.dataEng.Put(txnKey(TxnDonePrefix,dc.TxnId),"")
+		}
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+	}
+	tv:=sg.makeTxnView()
+	if dc.Commit{
+		for _,op:=range intent.Ops{
+			switch op.Op{
+			case pb.OpType_OpPut:
+				tv.put(op.Key,op.Value)
+			case pb.OpType_OpAppend:
+				v,_,_:=tv.get(op.Key)
+				tv.put(op.Key,v+op.Value)
+			case pb.OpType_OpDel:
+				tv.del(op.Key)
+			}
+		}
+	}
+	tv.batch.Del(txnKey(TxnIntentPrefix,dc.TxnId))
+	tv.batch.Put(txnKey(TxnDonePrefix,dc.TxnId),"")
+	if err:=tv.write();err!=nil{
+		sg.pendingEvents=nil
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrNotReady}
+	}
+	sg.removeIntent(dc.TxnId)
+	sg.doneTxns[dc.TxnId]=true
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
+
+func (sg *ShardGroup)TxnPrepare(ctx context.Context,req *pb.TxnPrepareRequest) (*pb.TxnPrepareResponse,error){
+	res:=sg.Execute(&Command{Type:CmdTxnPrepare,DistTxn:&DistTxnCommand{
+		TxnId:req.TxnId,
+		Time:time.Now().UnixNano(),
+		CoordinatorGid:req.CoordinatorGid,
+		CoordinatorPeers:req.CoordinatorPeers,
+		Compares:req.Compares,
+		Ops:req.Ops,
+	}})
+	switch res.Err{
+	case pb.ErrCode_ErrOK:
+		return &pb.TxnPrepareResponse{Err:pb.ErrCode_ErrOK,Prepared:true},nil
+	case pb.ErrCode_ErrCompareFailed,pb.ErrCode_ErrOutDated:
+		return &pb.TxnPrepareResponse{Err:pb.ErrCode_ErrOK},nil
+	}
+	return &pb.TxnPrepareResponse{Err:res.Err},nil
+}
+
+func (sg *ShardGroup)TxnResolve(ctx context.Context,req *pb.TxnResolveRequest) (*pb.TxnResolveResponse,error){
+	res:=sg.Execute(&Command{Type:CmdTxnResolve,DistTxn:&DistTxnCommand{TxnId:req.TxnId,Commit:req.Commit}})
+	return &pb.TxnResolveResponse{Err:res.Err},nil
+}
+
+func (sg *ShardGroup)TxnStatus(ctx context.Context,req *pb.TxnStatusRequest) (*pb.TxnStatusResponse,error){
+	if _,isLeader:=sg.raft.GetState();!isLeader{
+		return &pb.TxnStatusResponse{Err:pb.ErrCode_ErrWrongLeader},nil
+	}
+	if req.AbortIfPending{
+		res:=sg.Execute(&Command{Type:CmdTxnDecide,DistTxn:&DistTxnCommand{TxnId:req.TxnId}})
+		return &pb.TxnStatusResponse{Err:res.Err,State:pb.TxnState(res.Version)},nil
+	}
+	// read through the log so a fresh leader answers with every decision applied
+	if res:=sg.Execute(&Command{Type:CmdEmptyEntry});res.Err!=pb.ErrCode_ErrOK{
+		return &pb.TxnStatusResponse{Err:res.Err},nil
+	}
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	if record,ok:=sg.txnRecords[req.TxnId];ok{
+		return &pb.TxnStatusResponse{Err:pb.ErrCode_ErrOK,State:record.State},nil
+	}
+	return &pb.TxnStatusResponse{Err:pb.ErrCode_ErrOK,State:pb.TxnState_TxnUnknown},nil
+}
+
+// resolveParticipants pushes the decision to every participant and drops the
+// txn record once all of them applied it.
+func (sg *ShardGroup)resolveParticipants(record *txnRecord){
+	commit:=record.State==pb.TxnState_TxnCommitted
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done:=true
+	for _,p:=range record.Participants{
+		wg.Add(1)
+		go func(p *TxnParticipant){
+			defer wg.Done()
+			ok:=sg.svr.callGroup(p.Peers,func(cli pb.ShardKVServiceClient,ctx context.Context) pb.ErrCode{
+				res,err:=cli.TxnResolve(ctx,&pb.TxnResolveRequest{GroupId:p.Gid,TxnId:record.TxnId,Commit:commit})
+				if err!=nil{
+					return pb.ErrCode_ErrTimeout
+				}
+				// groups holding intents are never frozen, so a vanished group has nothing to resolve
+				if res.Err==pb.ErrCode_ErrWrongGroup{
+					return pb.ErrCode_ErrOK
+				}
+				return res.Err
+			})
+			if !ok{
+				mu.Lock()
+				done=false
+				mu.Unlock()
+			}
+		}(p)
+	}
+	wg.Wait()
+	if done{
+		sg.Execute(&Command{Type:CmdTxnFinish,DistTxn:&DistTxnCommand{TxnId:record.TxnId}})
+	}
+}
+
+func (sg *ShardGroup)txnRecoveryAction(){
+	now:=time.Now().UnixNano()
+	sg.mu.RLock()
+	records:=make([]*txnRecord,0,len(sg.txnRecords))
+	for _,record:=range sg.txnRecords{
+		recordCopy:=*record
+		records=append(records,&recordCopy)
+	}
+	intents:=make([]*txnIntent,0,len(sg.intents))
+	for _,intent:=range sg.intents{
+		if now-intent.PrepareTime>int64(TxnIntentTimeout){
+			intents=append(intents,intent)
+		}
+	}
+	sg.mu.RUnlock()
+
+	for _,record:=range records{
+		if record.State==pb.TxnState_TxnPending{
+			if now-record.StartTime<=int64(TxnIntentTimeout){
+				continue
+			}
+			sg.logger().Info("abort stale txn","txn",record.TxnId)
+			res:=sg.Execute(&Command{Type:CmdTxnDecide,DistTxn:&DistTxnCommand{TxnId:record.TxnId}})
+			if res.Err!=pb.ErrCode_ErrOK{
+				continue
+			}
+			record.State=pb.TxnState(res.Version)
+		}
+		sg.resolveParticipants(record)
+	}
+
+	for _,intent:=range intents{
+		state:=pb.TxnState_TxnPending
+		sg.svr.callGroup(intent.CoordinatorPeers,func(cli pb.ShardKVServiceClient,ctx context.Context) pb.ErrCode{
+			res,err:=cli.TxnStatus(ctx,&pb.TxnStatusRequest{GroupId:intent.CoordinatorGid,TxnId:intent.TxnId,AbortIfPending:true})
+			if err!=nil{
+				return pb.ErrCode_ErrTimeout
+			}
+			state=res.State
+			return res.Err
+		})
+		if state==pb.TxnState_TxnPending{
+			continue
+		}
+		sg.logger().Info("recover in-doubt txn","txn",intent.TxnId,"state",state)
+		sg.Execute(&Command{Type:CmdTxnResolve,DistTxn:&DistTxnCommand{TxnId:intent.TxnId,Commit:state==pb.TxnState_TxnCommitted}})
+	}
+}
+
+// DistTxn coordinates a transaction whose keys may live in several groups.
+// The group owning the first key is the coordinator and keeps the txn record.
+func (sg *ShardGroup)DistTxn(ctx context.Context,req *pb.DistTxnRequest) (*pb.DistTxnResponse,error){
+	participants,errCode:=sg.svr.groupTxnByOwner(req)
+	if errCode!=pb.ErrCode_ErrOK{
+		return &pb.DistTxnResponse{Err:errCode},nil
+	}
+	res:=sg.Execute(&Command{Type:CmdTxnBegin,DistTxn:&DistTxnCommand{
+		TxnId:time.Now().UnixNano(),
+		Time:time.Now().UnixNano(),
+		ClientId:req.ClientId,
+		CommandId:req.CommandId,
+		Participants:participants,
+	}})
+	txnId,_:=strconv.ParseInt(res.Value,10,64)
+	if res.Txn!=nil{
+		return &pb.DistTxnResponse{Err:pb.ErrCode_ErrOK,Committed:res.Txn.Succeeded,TxnId:txnId},nil
+	}
+	if res.Err!=pb.ErrCode_ErrOK{
+		return &pb.DistTxnResponse{Err:res.Err,LeaderId:res.LeaderId,TxnId:txnId},nil
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	commit,locked:=true,false
+	for _,p:=range participants{
+		wg.Add(1)
+		go func(p *TxnParticipant){
+			defer wg.Done()
+			prepared:=false
+			sg.svr.callGroup(p.Peers,func(cli pb.ShardKVServiceClient,ctx context.Context) pb.ErrCode{
+				res,err:=cli.TxnPrepare(ctx,&pb.TxnPrepareRequest{
+					GroupId:p.Gid,
+					TxnId:txnId,
+					CoordinatorGid:sg.gid,
+					CoordinatorPeers:sg.peersAddrs,
+					Compares:p.Compares,
+					Ops:p.Ops,
+				})
+				if err!=nil{
+					return pb.ErrCode_ErrTimeout
+				}
+				if res.Err==pb.ErrCode_ErrLocked{
+					mu.Lock()
+					locked=true
+					mu.Unlock()
+				}
+				prepared=res.Prepared
+				return res.Err
+			})
+			if !prepared{
+				mu.Lock()
+				commit=false
+				mu.Unlock()
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	res=sg.Execute(&Command{Type:CmdTxnDecide,DistTxn:&DistTxnCommand{TxnId:txnId,Commit:commit}})
+	if res.Err!=pb.ErrCode_ErrOK{
+		// the record stays pending and recovery aborts it
+		return &pb.DistTxnResponse{Err:res.Err,TxnId:txnId},nil
+	}
+	record:=&txnRecord{TxnId:txnId,State:pb.TxnState(res.Version),Participants:participants}
+	go sg.resolveParticipants(record)
+
+	if record.State!=pb.TxnState_TxnCommitted && locked{
+		return &pb.DistTxnResponse{Err:pb.ErrCode_ErrLocked,TxnId:txnId},nil
+	}
+	return &pb.DistTxnResponse{Err:pb.ErrCode_ErrOK,Committed:record.State==pb.TxnState_TxnCommitted,TxnId:txnId},nil
+}
+
+// groupTxnByOwner splits compares and ops by the group that owns their key.
+func (shardsvr *ShardServer)groupTxnByOwner(req *pb.DistTxnRequest) ([]*TxnParticipant,pb.ErrCode){
+	byGid:=make(map[int64]*TxnParticipant)
+	participants:=[]*TxnParticipant{}
+	participant:=func(key string) *TxnParticipant{
+		gid,peers,ok:=shardsvr.locateKey(key)
+		if !ok{
+			return nil
+		}
+		if _,ok:=byGid[gid];!ok{
+			byGid[gid]=&TxnParticipant{Gid:gid,Peers:peers}
+			participants=append(participants,byGid[gid])
+		}
+		return byGid[gid]
+	}
+	for _,cmp:=range req.Compares{
+		p:=participant(cmp.Key)
+		if p==nil{
+			return nil,pb.ErrCode_ErrWrongGroup
+		}
+		p.Compares=append(p.Compares,cmp)
+	}
+	for _,op:=range req.Ops{
+		p:=participant(op.Key)
+		if p==nil{
+			return nil,pb.ErrCode_ErrWrongGroup
+		}
+		p.Ops=append(p.Ops,op)
+	}
+	return participants,pb.ErrCode_ErrOK
+}
+
+// locateKey finds the group serving key: local groups first, then the shard
+// config, and for range groups the ranges reported by the config's servers.
+func (shardsvr *ShardServer)locateKey(key string) (int64,[]string,bool){
+	for _,group:=range shardsvr.getGroups(){
+		if group.owns(key){
+			return group.gid,group.peersAddrs,true
+		}
+	}
+	config,err:=shardsvr.ctrler.Query(-1)
+	if err!=nil{
+		return 0,nil,false
+	}
+	if len(config.Ranges)==0{
+		gid:=config.Shards[Key2Shard(key)]
+		servers,ok:=config.Groups[gid]
+		return gid,servers,ok
+	}
+	asked:=make(map[string]bool)
+	for _,servers:=range config.Groups{
+		for _,server:=range servers{
+			if asked[server]{
+				continue
+			}
+			asked[server]=true
+			cli:=shardsvr.getSvrClient(server)
+			if cli==nil{
+				continue
+			}
+			ctx,cancel:=context.WithTimeout(context.Background(),ExecuteTimeout)
+			res,err:=cli.GetRanges(ctx,&pb.GetRangesRequest{})
+			cancel()
+			if err!=nil{
+				continue
+			}
+			for _,rng:=range res.Ranges{
+				if (&KeyRange{StartKey:rng.StartKey,EndKey:rng.EndKey}).Contains(key){
+					return rng.Gid,rng.Peers,true
+				}
+			}
+		}
+	}
+	return 0,nil,false
+}
+
+// callGroup retries fn against the servers of a group until one of them
+// answers as leader, or gives up after a few rounds.
+func (shardsvr *ShardServer)callGroup(servers []string,fn func(cli pb.ShardKVServiceClient,ctx context.Context) pb.ErrCode) bool{
+	for round:=0;round<3;round++{
+		for _,server:=range servers{
+			cli:=shardsvr.getSvrClient(server)
+			if cli==nil{
+				continue
+			}
+			ctx,cancel:=context.WithTimeout(context.Background(),ExecuteTimeout)
+			errCode:=fn(cli,ctx)
+			cancel()
+			switch errCode{
+			case pb.ErrCode_ErrOK:
+				return true
+			case pb.ErrCode_ErrWrongLeader,pb.ErrCode_ErrTimeout:
+			default:
+				return false
+			}
+		}
+		time.Sleep(100*time.Millisecond)
+	}
+	return false
+}
+
+func (shardsvr *ShardServer)DistTxn(ctx context.Context,req *pb.DistTxnRequest) (*pb.DistTxnResponse,error){
+	keys:=txnKeys(&pb.TxnRequest{Compares:req.Compares,Success:req.Ops})
+	if len(keys)==0 || !validTxn(&pb.TxnRequest{Success:req.Ops}){
+		return &pb.DistTxnResponse{Err:pb.ErrCode_ErrBadRequest},nil
+	}
+	for _,op:=range req.Ops{
+		if op.Op==pb.OpType_OpGet{
+			return &pb.DistTxnResponse{Err:pb.ErrCode_ErrBadRequest},nil
+		}
+	}
+	for _,group:=range shardsvr.getGroups(){
+		if group.owns(keys[0]){
+			return group.DistTxn(ctx,req)
+		}
+	}
+	return &pb.DistTxnResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+}
+
+func (shardsvr *ShardServer)TxnPrepare(ctx context.Context,req *pb.TxnPrepareRequest) (*pb.TxnPrepareResponse,error){
+	group:=shardsvr.getGroup(req.GroupId)
+	if group==nil{
+		return &pb.TxnPrepareResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+	}
+	return group.TxnPrepare(ctx,req)
+}
+
+func (shardsvr *ShardServer)TxnResolve(ctx context.Context,req *pb.TxnResolveRequest) (*pb.TxnResolveResponse,error){
+	group:=shardsvr.getGroup(req.GroupId)
+	if group==nil{
+		return &pb.TxnResolveResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+	}
+	return group.TxnResolve(ctx,req)
+}
+
+func (shardsvr *ShardServer)TxnStatus(ctx context.Context,req *pb.TxnStatusRequest) (*pb.TxnStatusResponse,error){
+	group:=shardsvr.getGroup(req.GroupId)
+	if group==nil{
+		return &pb.TxnStatusResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+	}
+	return group.TxnStatus(ctx,req)
+}
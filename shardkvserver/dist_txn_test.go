@@ -0,0 +1,196 @@
+package shardkvserver
+
+import(
+	"time"
+	"context"
+	"testing"
+
+	pb "neweraft/raftpb"
+)
+
+// shortTxnTimeouts makes recovery act within the test.
+func shortTxnTimeouts(t *testing.T){
+	intentTimeout,recoveryInterval:=TxnIntentTimeout,TxnRecoveryInterval
+	TxnIntentTimeout,TxnRecoveryInterval=300*time.Millisecond,50*time.Millisecond
+	t.Cleanup(func(){
+		TxnIntentTimeout,TxnRecoveryInterval=intentTimeout,recoveryInterval
+	})
+}
+
+func getKey(svr *ShardServer,key string) (string,pb.ErrCode){
+	res,_:=svr.Command(context.Background(),&pb.CommandRequest{Key:key,Op:pb.OpType_OpGet})
+	return res.Value,res.Err
+}
+
+func putKey(svr *ShardServer,key string,value string) pb.ErrCode{
+	res,_:=svr.Command(context.Background(),&pb.CommandRequest{Key:key,Value:value,Op:pb.OpType_OpPut,ClientId:1,CommandId:time.Now().UnixNano()})
+	return res.Err
+}
+
+// waitFor polls cond until it holds or the test times out on it.
+func waitFor(t *testing.T,what string,cond func() bool){
+	t.Helper()
+	deadline:=time.Now().Add(10*time.Second)
+	for !cond(){
+		if time.Now().After(deadline){
+			t.Fatalf("timed out waiting for %s",what)
+		}
+		time.Sleep(20*time.Millisecond)
+	}
+}
+
+func pendingTxns(svr *ShardServer,gid int64) bool{
+	group:=svr.getGroup(gid)
+	group.mu.RLock()
+	defer group.mu.RUnlock()
+	return group.hasPendingTxns() || len(group.locks)>0
+}
+
+func valueIs(svr *ShardServer,key string,want string) func() bool{
+	return func() bool{
+		v,code:=getKey(svr,key)
+		return code==pb.ErrCode_ErrOK && v==want
+	}
+}
+
+func TestDistTxn(t *testing.T){
+	svrs,_:=startTestServers(t,2,rangeConfig)
+	if putKey(svrs[0],"a","1")!=pb.ErrCode_ErrOK || putKey(svrs[1],"n","1")!=pb.ErrCode_ErrOK{
+		t.Fatal("seed writes failed")
+	}
+	cmp:=func(key string,value string) *pb.Compare{
+		return &pb.Compare{Key:key,Target:pb.CompareTarget_CmpValue,Result:pb.CompareResult_CmpEqual,Value:value}
+	}
+	put:=func(key string,value string) *pb.TxnOp{
+		return &pb.TxnOp{Op:pb.OpType_OpPut,Key:key,Value:value}
+	}
+
+	res,err:=svrs[0].DistTxn(context.Background(),&pb.DistTxnRequest{
+		Compares:[]*pb.Compare{cmp("a","1"),cmp("n","1")},
+		Ops:[]*pb.TxnOp{put("b","2"),put("o","2")},
+		ClientId:7,CommandId:1,
+	})
+	if err!=nil || res.Err!=pb.ErrCode_ErrOK || !res.Committed{
+		t.Fatalf("txn over both groups: %v %v",res,err)
+	}
+	waitFor(t,"the commit to reach both groups",func() bool{
+		return valueIs(svrs[0],"b","2")() && valueIs(svrs[1],"o","2")() && !pendingTxns(svrs[0],1) && !pendingTxns(svrs[1],2)
+	})
+
+	// one failed compare aborts the writes in every group
+	res,err=svrs[0].DistTxn(context.Background(),&pb.DistTxnRequest{
+		Compares:[]*pb.Compare{cmp("a","1"),cmp("n","stale")},
+		Ops:[]*pb.TxnOp{put("b","3"),put("o","3")},
+		ClientId:7,CommandId:2,
+	})
+	if err!=nil || res.Err!=pb.ErrCode_ErrOK || res.Committed{
+		t.Fatalf("txn with a failed compare: %v %v",res,err)
+	}
+	waitFor(t,"the abort to release the intents",func() bool{
+		return !pendingTxns(svrs[0],1) && !pendingTxns(svrs[1],2)
+	})
+	if !valueIs(svrs[0],"b","2")() || !valueIs(svrs[1],"o","2")(){
+		t.Fatal("aborted txn wrote")
+	}
+}
+
+func TestTxnPrepareLocksCompares(t *testing.T){
+	svrs,addrs:=startTestServers(t,2,rangeConfig)
+	group:=svrs[1].getGroup(2)
+	prepare:=&pb.TxnPrepareRequest{
+		GroupId:2,
+		TxnId:42,
+		CoordinatorGid:1,
+		CoordinatorPeers:[]string{addrs[0]},
+		Compares:[]*pb.Compare{{Key:"p",Target:pb.CompareTarget_CmpVersion,Result:pb.CompareResult_CmpEqual,Version:0}},
+		Ops:[]*pb.TxnOp{{Op:pb.OpType_OpPut,Key:"q",Value:"1"}},
+	}
+	res,err:=group.TxnPrepare(context.Background(),prepare)
+	if err!=nil || !res.Prepared{
+		t.Fatalf("prepare: %v %v",res,err)
+	}
+	// the compared key is as locked as the written one
+	for _,key:=range []string{"p","q"}{
+		if code:=putKey(svrs[1],key,"x");code!=pb.ErrCode_ErrLocked{
+			t.Fatalf("put to %s under an intent: %v",key,code)
+		}
+	}
+	other:=&pb.TxnPrepareRequest{GroupId:2,TxnId:43,Ops:[]*pb.TxnOp{{Op:pb.OpType_OpPut,Key:"p",Value:"2"}}}
+	if res,_:=group.TxnPrepare(context.Background(),other);res.Prepared || res.Err!=pb.ErrCode_ErrLocked{
+		t.Fatalf("second prepare on a compared key: %v",res)
+	}
+
+	// prepare and resolve are idempotent, and a resolve for a txn that never
+	// prepared here keeps a late prepare out
+	if res,_:=group.TxnPrepare(context.Background(),prepare);!res.Prepared{
+		t.Fatalf("repeated prepare: %v",res)
+	}
+	for i:=0;i<2;i++{
+		if res,_:=group.TxnResolve(context.Background(),&pb.TxnResolveRequest{GroupId:2,TxnId:42,Commit:true});res.Err!=pb.ErrCode_ErrOK{
+			t.Fatalf("resolve: %v",res)
+		}
+	}
+	if !valueIs(svrs[1],"q","1")() || putKey(svrs[1],"p","x")!=pb.ErrCode_ErrOK{
+		t.Fatal("resolved txn did not write or left a lock")
+	}
+	group.TxnResolve(context.Background(),&pb.TxnResolveRequest{GroupId:2,TxnId:44})
+	if res,_:=group.TxnPrepare(context.Background(),&pb.TxnPrepareRequest{GroupId:2,TxnId:44,Ops:prepare.Ops});res.Prepared{
+		t.Fatal("prepare after resolve took an intent")
+	}
+}
+
+func TestTxnRecovery(t *testing.T){
+	shortTxnTimeouts(t)
+	svrs,addrs:=startTestServers(t,2,rangeConfig)
+	coordinator,participant:=svrs[0].getGroup(1),svrs[1].getGroup(2)
+	prepare:=func(txnId int64,key string){
+		t.Helper()
+		res,_:=participant.TxnPrepare(context.Background(),&pb.TxnPrepareRequest{
+			GroupId:2,
+			TxnId:txnId,
+			CoordinatorGid:1,
+			CoordinatorPeers:[]string{addrs[0]},
+			Ops:[]*pb.TxnOp{{Op:pb.OpType_OpPut,Key:key,Value:"1"}},
+		})
+		if !res.Prepared{
+			t.Fatalf("prepare %d: %v",txnId,res)
+		}
+	}
+	begin:=func(txnId int64,key string){
+		t.Helper()
+		res:=coordinator.Execute(&Command{Type:CmdTxnBegin,DistTxn:&DistTxnCommand{
+			TxnId:txnId,
+			Time:time.Now().UnixNano(),
+			ClientId:9,
+			CommandId:txnId,
+			Participants:[]*TxnParticipant{{Gid:2,Peers:[]string{addrs[1]},Ops:[]*pb.TxnOp{{Op:pb.OpType_OpPut,Key:key,Value:"1"}}}},
+		}})
+		if res.Err!=pb.ErrCode_ErrOK{
+			t.Fatalf("begin %d: %v",txnId,res.Err)
+		}
+	}
+
+	// the coordinator decided commit but crashed before telling anyone
+	begin(1,"r1")
+	prepare(1,"r1")
+	if res:=coordinator.Execute(&Command{Type:CmdTxnDecide,DistTxn:&DistTxnCommand{TxnId:1,Commit:true}});res.Version!=int64(pb.TxnState_TxnCommitted){
+		t.Fatalf("decide: %v",res)
+	}
+	// the coordinator never decided
+	begin(2,"r2")
+	prepare(2,"r2")
+	// the participant holds an intent its coordinator has no record of
+	prepare(3,"r3")
+
+	waitFor(t,"recovery to settle every txn",func() bool{
+		return !pendingTxns(svrs[0],1) && !pendingTxns(svrs[1],2)
+	})
+	if !valueIs(svrs[1],"r1","1")(){
+		t.Fatal("committed txn not applied by recovery")
+	}
+	for _,key:=range []string{"r2","r3"}{
+		if _,code:=getKey(svrs[1],key);code!=pb.ErrCode_ErrNoKey{
+			t.Fatalf("aborted txn wrote %s: %v",key,code)
+		}
+	}
+}
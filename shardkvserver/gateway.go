@@ -0,0 +1,420 @@
+package shardkvserver
+
+import(
+	"net"
+	"sort"
+	"sync"
+	"time"
+	"context"
+	"strconv"
+	"net/url"
+	"net/http"
+	"math/rand"
+	"encoding/json"
+	"encoding/base64"
+
+	"neweraft/raftcore"
+	pb "neweraft/raftpb"
+)
+
+// HttpGateway serves the KV and admin APIs as JSON over HTTP, going through the
+// same group paths as the gRPC service. A node that is not the leader of the
+// group answers 307 with the same URL on the leader's gateway.
+type HttpGateway struct{
+	svr *ShardServer
+	advertise func(addr string) string
+	mux *http.ServeMux
+}
+
+// writeSession is a client id lent to one write at a time, so concurrent
+// requests never reuse a command id while the table of client ids the groups
+// keep for dedup only grows with the peak number of writes in flight.
+type writeSession struct{
+	clientId int64
+	commandId int64
+}
+
+// sessionPool holds the idle write sessions of a server, shared by the
+// gateway and the RESP connections.
+type sessionPool struct{
+	mu sync.Mutex
+	idle []*writeSession
+}
+
+func (sp *sessionPool)get() *writeSession{
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if n:=len(sp.idle);n>0{
+		s:=sp.idle[n-1]
+		sp.idle=sp.idle[:n-1]
+		return s
+	}
+	return &writeSession{clientId:rand.Int63()}
+}
+
+func (sp *sessionPool)put(s *writeSession){
+	sp.mu.Lock()
+	sp.idle=append(sp.idle,s)
+	sp.mu.Unlock()
+}
+
+type gatewayKv struct{
+	Key string
+	Value string
+	Version int64
+	ModIndex int64
+}
+
+type gatewayPut struct{
+	Value string
+	LeaseId int64
+	// ExpectedVersion>0 turns the put into a compare-and-swap, IfAbsent into
+	// a put-if-absent
+	ExpectedVersion int64
+	IfAbsent bool
+}
+
+type gatewayWrite struct{
+	Version int64
+	ModIndex int64
+}
+
+type gatewayScan struct{
+	Kvs []*gatewayKv
+	AppliedIndex int64
+	NextPageToken []byte
+}
+
+type gatewayError struct{
+	Error string
+	Current *gatewayKv `json:",omitempty"`
+}
+
+type gatewayReplica struct{
+	Addr string
+	*pb.RaftStatus
+}
+
+type gatewayGroup struct{
+	Gid int64
+	Leader string
+	Term int64
+	Range *KeyRange `json:",omitempty"`
+	Shards []int `json:",omitempty"`
+	Replicas []*gatewayReplica
+}
+
+type gatewayNode struct{
+	Id int64
+	Addr string
+	Error string `json:",omitempty"`
+}
+
+type gatewayClusterStatus struct{
+	Nodes []*gatewayNode
+	Groups []*gatewayGroup
+}
+
+// MakeHttpGateway takes a function mapping a server's gRPC address to the
+// address its gateway is reachable at.
+func MakeHttpGateway(svr *ShardServer,advertise func(addr string) string) *HttpGateway{
+	gw:=&HttpGateway{svr:svr,advertise:advertise,mux:http.NewServeMux()}
+	gw.mux.HandleFunc("GET /v1/kv/{key...}",gw.handleGet)
+	gw.mux.HandleFunc("PUT /v1/kv/{key...}",gw.handlePut)
+	gw.mux.HandleFunc("DELETE /v1/kv/{key...}",gw.handleDelete)
+	gw.mux.HandleFunc("GET /v1/scan",gw.handleScan)
+	gw.mux.HandleFunc("GET /v1/cluster/status",gw.handleClusterStatus)
+	return gw
+}
+
+func (gw *HttpGateway)ServeHTTP(w http.ResponseWriter,r *http.Request){
+	gw.mux.ServeHTTP(w,r)
+}
+
+func writeJson(w http.ResponseWriter,status int,v interface{}){
+	w.Header().Set("Content-Type","application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func gatewayStatusOf(code pb.ErrCode) int{
+	switch code{
+	case pb.ErrCode_ErrOK:
+		return http.StatusOK
+	case pb.ErrCode_ErrNoKey:
+		return http.StatusNotFound
+	case pb.ErrCode_ErrCompareFailed:
+		return http.StatusConflict
+	case pb.ErrCode_ErrBadRequest,pb.ErrCode_ErrLeaseNotFound:
+		return http.StatusBadRequest
+	case pb.ErrCode_ErrOutDated,pb.ErrCode_ErrCompacted:
+		return http.StatusGone
+	}
+	return http.StatusServiceUnavailable
+}
+
+// writeErr answers a failed call: a redirect to the leader when it is known,
+// else the status matching code.
+func (gw *HttpGateway)writeErr(w http.ResponseWriter,r *http.Request,group *ShardGroup,code pb.ErrCode,leaderId int64){
+	if code==pb.ErrCode_ErrWrongLeader && group!=nil && leaderId>=0 && leaderId<int64(len(group.peersAddrs)) && leaderId!=group.id{
+		addr:=group.peersAddrs[leaderId]
+		if gw.advertise!=nil{
+			addr=gw.advertise(addr)
+		}
+		if addr!=""{
+			u:=url.URL{Scheme:"http",Host:addr,Path:r.URL.Path,RawPath:r.URL.RawPath,RawQuery:r.URL.RawQuery}
+			http.Redirect(w,r,u.String(),http.StatusTemporaryRedirect)
+			return
+		}
+	}
+	writeJson(w,gatewayStatusOf(code),&gatewayError{Error:code.String()})
+}
+
+func (gw *HttpGateway)command(w http.ResponseWriter,r *http.Request,req *pb.CommandRequest) (*pb.CommandResponse,bool){
+	group:=gw.svr.ownerOf(req.Key)
+	if group==nil{
+		gw.writeErr(w,r,nil,pb.ErrCode_ErrWrongGroup,-1)
+		return nil,false
+	}
+	if req.Op!=pb.OpType_OpGet{
+		s:=gw.svr.sessions.get()
+		defer gw.svr.sessions.put(s)
+		s.commandId++
+		req.ClientId,req.CommandId=s.clientId,s.commandId
+	}
+	res,err:=group.Command(r.Context(),req)
+	if err!=nil{
+		writeJson(w,http.StatusInternalServerError,&gatewayError{Error:err.Error()})
+		return nil,false
+	}
+	if res.Err==pb.ErrCode_ErrCompareFailed{
+		writeJson(w,http.StatusConflict,&gatewayError{Error:res.Err.String(),Current:&gatewayKv{Key:req.Key,Value:res.Value,Version:res.Version}})
+		return res,false
+	}
+	if res.Err!=pb.ErrCode_ErrOK{
+		gw.writeErr(w,r,group,res.Err,res.LeaderId)
+		return res,false
+	}
+	return res,true
+}
+
+// handleGet reads the key, as of index when ?index= is given.
+func (gw *HttpGateway)handleGet(w http.ResponseWriter,r *http.Request){
+	key:=r.PathValue("key")
+	req:=&pb.CommandRequest{Key:key,Op:pb.OpType_OpGet}
+	if s:=r.URL.Query().Get("index");s!=""{
+		index,err:=strconv.ParseInt(s,10,64)
+		if err!=nil{
+			writeJson(w,http.StatusBadRequest,&gatewayError{Error:"bad index"})
+			return
+		}
+		req.ReadIndex=index
+	}
+	if res,ok:=gw.command(w,r,req);ok{
+		writeJson(w,http.StatusOK,&gatewayKv{Key:key,Value:res.Value,Version:res.Version,ModIndex:res.ModIndex})
+	}
+}
+
+func (gw *HttpGateway)handlePut(w http.ResponseWriter,r *http.Request){
+	key:=r.PathValue("key")
+	put:=&gatewayPut{}
+	if err:=json.NewDecoder(r.Body).Decode(put);err!=nil{
+		writeJson(w,http.StatusBadRequest,&gatewayError{Error:"bad body: "+err.Error()})
+		return
+	}
+	req:=&pb.CommandRequest{Key:key,Value:put.Value,Op:pb.OpType_OpPut,LeaseId:put.LeaseId}
+	if put.IfAbsent{
+		req.Op=pb.OpType_OpPutIfAbsent
+	} else if put.ExpectedVersion>0{
+		req.Op,req.ExpectedVersion=pb.OpType_OpCas,put.ExpectedVersion
+	}
+	res,ok:=gw.command(w,r,req)
+	if ok{
+		writeJson(w,http.StatusOK,&gatewayWrite{Version:res.Version,ModIndex:res.ModIndex})
+	}
+}
+
+// handleDelete deletes the key, only at ?version= when it is given.
+func (gw *HttpGateway)handleDelete(w http.ResponseWriter,r *http.Request){
+	key:=r.PathValue("key")
+	req:=&pb.CommandRequest{Key:key,Op:pb.OpType_OpDel}
+	if s:=r.URL.Query().Get("version");s!=""{
+		version,err:=strconv.ParseInt(s,10,64)
+		if err!=nil || version<=0{
+			writeJson(w,http.StatusBadRequest,&gatewayError{Error:"bad version"})
+			return
+		}
+		cur,ok:=gw.command(w,r,&pb.CommandRequest{Key:key,Op:pb.OpType_OpGet})
+		if !ok{
+			return
+		}
+		req.Op,req.ExpectedValue,req.ExpectedVersion=pb.OpType_OpDeleteIfEquals,cur.Value,version
+	}
+	res,ok:=gw.command(w,r,req)
+	if ok{
+		writeJson(w,http.StatusOK,&gatewayWrite{Version:res.Version,ModIndex:res.ModIndex})
+	}
+}
+
+// handleScan reads one page of [start,end). Following pages pass back
+// NextPageToken as ?page_token= and read the same snapshot.
+func (gw *HttpGateway)handleScan(w http.ResponseWriter,r *http.Request){
+	q:=r.URL.Query()
+	req:=&pb.ScanRequest{StartKey:q.Get("start"),EndKey:q.Get("end"),Reverse:q.Get("reverse")=="true"}
+	var err error
+	if s:=q.Get("limit");s!="" && err==nil{
+		req.Limit,err=strconv.ParseInt(s,10,64)
+	}
+	if s:=q.Get("index");s!="" && err==nil{
+		req.AppliedIndex,err=strconv.ParseInt(s,10,64)
+	}
+	if s:=q.Get("page_token");s!="" && err==nil{
+		req.PageToken,err=base64.StdEncoding.DecodeString(s)
+	}
+	if err!=nil{
+		writeJson(w,http.StatusBadRequest,&gatewayError{Error:err.Error()})
+		return
+	}
+	res,group,err:=gw.svr.scanPage(r.Context(),req)
+	if err!=nil{
+		writeJson(w,http.StatusInternalServerError,&gatewayError{Error:err.Error()})
+		return
+	}
+	if res.Err!=pb.ErrCode_ErrOK{
+		gw.writeErr(w,r,group,res.Err,res.LeaderId)
+		return
+	}
+	page:=&gatewayScan{Kvs:[]*gatewayKv{},AppliedIndex:res.AppliedIndex,NextPageToken:res.NextPageToken}
+	for _,kv:=range res.Kvs{
+		page.Kvs=append(page.Kvs,&gatewayKv{Key:kv.Key,Value:kv.Value,Version:kv.Version,ModIndex:kv.ModIndex})
+	}
+	writeJson(w,http.StatusOK,page)
+}
+
+// clusterAddrs lists every server this node knows of: the members of the
+// groups in the latest config and of the groups it hosts, which covers range
+// groups made by splits after the config was written.
+func (gw *HttpGateway)clusterAddrs() ([]string,*Config){
+	addrs:=map[string]bool{gw.svr.addr:true}
+	config,err:=gw.svr.ctrler.Query(-1)
+	if err!=nil{
+		config=DefaultConfig()
+	}
+	for _,servers:=range config.Groups{
+		for _,addr:=range servers{
+			addrs[addr]=true
+		}
+	}
+	for _,group:=range gw.svr.getGroups(){
+		for _,addr:=range group.peersAddrs{
+			addrs[addr]=true
+		}
+	}
+	list:=make([]string,0,len(addrs))
+	for addr:=range addrs{
+		list=append(list,addr)
+	}
+	sort.Strings(list)
+	return list,config
+}
+
+// nodeStatus asks one server for the raft state and the key ranges of the
+// groups it hosts.
+func (gw *HttpGateway)nodeStatus(ctx context.Context,addr string) (*pb.StatusResponse,*pb.GetRangesResponse,error){
+	if addr==gw.svr.addr{
+		status,_:=gw.svr.Status(ctx,&pb.StatusRequest{})
+		ranges,_:=gw.svr.GetRanges(ctx,&pb.GetRangesRequest{})
+		return status,ranges,nil
+	}
+	conn,err:=raftcore.GetSharedConn(addr)
+	if err!=nil{
+		return nil,nil,err
+	}
+	status,err:=pb.NewMessageServiceClient(conn).Status(ctx,&pb.StatusRequest{})
+	if err!=nil{
+		return nil,nil,err
+	}
+	ranges,err:=pb.NewShardKVServiceClient(conn).GetRanges(ctx,&pb.GetRangesRequest{})
+	if err!=nil{
+		return nil,nil,err
+	}
+	return status,ranges,nil
+}
+
+// handleClusterStatus asks every known server for the groups it hosts and
+// merges the answers per group. A group's leader is the replica leading in the
+// highest term any replica reports; servers that do not answer are listed
+// with their error.
+func (gw *HttpGateway)handleClusterStatus(w http.ResponseWriter,r *http.Request){
+	addrs,config:=gw.clusterAddrs()
+	ctx,cancel:=context.WithTimeout(r.Context(),time.Second)
+	defer cancel()
+	statuses:=make([]*pb.StatusResponse,len(addrs))
+	ranges:=make([]*pb.GetRangesResponse,len(addrs))
+	errs:=make([]error,len(addrs))
+	var wg sync.WaitGroup
+	for i,addr:=range addrs{
+		wg.Add(1)
+		go func(){
+			defer wg.Done()
+			statuses[i],ranges[i],errs[i]=gw.nodeStatus(ctx,addr)
+		}()
+	}
+	wg.Wait()
+
+	status:=&gatewayClusterStatus{Nodes:[]*gatewayNode{},Groups:[]*gatewayGroup{}}
+	groups:=make(map[int64]*gatewayGroup)
+	for i,addr:=range addrs{
+		node:=&gatewayNode{Id:-1,Addr:addr}
+		status.Nodes=append(status.Nodes,node)
+		if errs[i]!=nil{
+			node.Error=errs[i].Error()
+			continue
+		}
+		node.Id=statuses[i].NodeId
+		for _,rs:=range statuses[i].Groups{
+			g,ok:=groups[rs.GroupId]
+			if !ok{
+				g=&gatewayGroup{Gid:rs.GroupId,Replicas:[]*gatewayReplica{}}
+				groups[rs.GroupId]=g
+				status.Groups=append(status.Groups,g)
+			}
+			g.Replicas=append(g.Replicas,&gatewayReplica{Addr:addr,RaftStatus:rs})
+			if rs.Term>g.Term{
+				g.Term,g.Leader=rs.Term,""
+			}
+			if rs.Term==g.Term && rs.Role=="leader"{
+				g.Leader=addr
+			}
+		}
+		for _,ri:=range ranges[i].Ranges{
+			if g,ok:=groups[ri.Gid];ok && (g.Range==nil || ri.Epoch>g.Range.Epoch){
+				g.Range=&KeyRange{Gid:ri.Gid,StartKey:ri.StartKey,EndKey:ri.EndKey,Epoch:ri.Epoch}
+			}
+		}
+	}
+	for _,g:=range status.Groups{
+		if g.Range!=nil{
+			continue
+		}
+		for shard,gid:=range config.Shards{
+			if gid==g.Gid{
+				g.Shards=append(g.Shards,shard)
+			}
+		}
+	}
+	sort.Slice(status.Groups,func(i,j int) bool{ return status.Groups[i].Gid<status.Groups[j].Gid })
+	writeJson(w,http.StatusOK,status)
+}
+
+// ServeHttpGateway listens on addr and serves the gateway until the listener
+// fails.
+func ServeHttpGateway(svr *ShardServer,addr string,advertise func(addr string) string) error{
+	lis,err:=net.Listen("tcp",addr)
+	if err!=nil{
+		return err
+	}
+	svr.logger().Info("serving HTTP","addr",addr)
+	go http.Serve(lis,MakeHttpGateway(svr,advertise))
+	return nil
+}
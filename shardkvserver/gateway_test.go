@@ -0,0 +1,174 @@
+package shardkvserver
+
+import(
+	"io"
+	"bytes"
+	"testing"
+	"net/url"
+	"net/http"
+	"encoding/json"
+	"net/http/httptest"
+)
+
+// startTestGateways serves a gateway in front of each server; the returned
+// map takes a server's gRPC address to its gateway's base URL.
+func startTestGateways(t *testing.T,svrs []*ShardServer,addrs []string) map[string]string{
+	urls:=make(map[string]string)
+	gws:=make([]*httptest.Server,len(svrs))
+	for i:=range svrs{
+		gws[i]=httptest.NewUnstartedServer(nil)
+		urls[addrs[i]]="http://"+gws[i].Listener.Addr().String()
+	}
+	for i,svr:=range svrs{
+		gws[i].Config.Handler=MakeHttpGateway(svr,func(addr string) string{
+			u,_:=url.Parse(urls[addr])
+			return u.Host
+		})
+		gws[i].Start()
+		t.Cleanup(gws[i].Close)
+	}
+	return urls
+}
+
+var noRedirect=&http.Client{CheckRedirect:func(req *http.Request,via []*http.Request) error{
+	return http.ErrUseLastResponse
+}}
+
+// gatewayDo sends a request with an optional JSON body and decodes the reply
+// into out when it is not nil.
+func gatewayDo(t *testing.T,method string,u string,body any,out any) *http.Response{
+	t.Helper()
+	var r io.Reader
+	if body!=nil{
+		data,_:=json.Marshal(body)
+		r=bytes.NewReader(data)
+	}
+	req,err:=http.NewRequest(method,u,r)
+	if err!=nil{
+		t.Fatal(err)
+	}
+	res,err:=noRedirect.Do(req)
+	if err!=nil{
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if out!=nil{
+		if err:=json.NewDecoder(res.Body).Decode(out);err!=nil{
+			t.Fatalf("%s %s: %v",method,u,err)
+		}
+	}
+	return res
+}
+
+func checkStatus(t *testing.T,what string,res *http.Response,want int){
+	t.Helper()
+	if res.StatusCode!=want{
+		t.Fatalf("%s: status %d, want %d",what,res.StatusCode,want)
+	}
+}
+
+func TestGatewayRedirect(t *testing.T){
+	svrs,addrs:=startTestServers(t,3,oneRangeConfig)
+	urls:=startTestGateways(t,svrs,addrs)
+	leader:=leaderOf(t,svrs,1)
+	follower:=svrs[0]
+	if follower==leader{
+		follower=svrs[1]
+	}
+	// the follower redirects once it has heard from the leader
+	var res *http.Response
+	waitFor(t,"a redirect",func() bool{
+		res=gatewayDo(t,"PUT",urls[follower.addr]+"/v1/kv/a?x=1",&gatewayPut{Value:"1"},nil)
+		return res.StatusCode!=http.StatusServiceUnavailable
+	})
+	checkStatus(t,"put on a follower",res,http.StatusTemporaryRedirect)
+	if got,want:=res.Header.Get("Location"),urls[leader.addr]+"/v1/kv/a?x=1";got!=want{
+		t.Fatalf("redirected to %s, want %s",got,want)
+	}
+}
+
+func TestGatewayKv(t *testing.T){
+	svrs,addrs:=startTestServers(t,1,oneRangeConfig)
+	base:=startTestGateways(t,svrs,addrs)[addrs[0]]
+	waitFor(t,"a leader",func() bool{
+		_,isLeader:=svrs[0].getGroup(1).raft.GetState()
+		return isLeader
+	})
+
+	checkStatus(t,"get a missing key",gatewayDo(t,"GET",base+"/v1/kv/a",nil,nil),http.StatusNotFound)
+	put:=&gatewayWrite{}
+	checkStatus(t,"put",gatewayDo(t,"PUT",base+"/v1/kv/a",&gatewayPut{Value:"1"},put),http.StatusOK)
+	kv:=&gatewayKv{}
+	checkStatus(t,"get",gatewayDo(t,"GET",base+"/v1/kv/a",nil,kv),http.StatusOK)
+	if kv.Value!="1" || kv.Version!=put.Version || kv.ModIndex!=put.ModIndex{
+		t.Fatalf("get %+v after put %+v",kv,put)
+	}
+
+	// a compare-and-swap at the wrong version reports the current value
+	failed:=&gatewayError{}
+	checkStatus(t,"cas",gatewayDo(t,"PUT",base+"/v1/kv/a",&gatewayPut{Value:"2",ExpectedVersion:put.Version+1},failed),http.StatusConflict)
+	if failed.Current==nil || failed.Current.Value!="1" || failed.Current.Version!=put.Version{
+		t.Fatalf("cas mismatch: %+v",failed)
+	}
+	checkStatus(t,"put if absent",gatewayDo(t,"PUT",base+"/v1/kv/a",&gatewayPut{Value:"2",IfAbsent:true},nil),http.StatusConflict)
+
+	checkStatus(t,"delete at another version",gatewayDo(t,"DELETE",base+"/v1/kv/a?version=9",nil,nil),http.StatusConflict)
+	checkStatus(t,"bad version",gatewayDo(t,"DELETE",base+"/v1/kv/a?version=x",nil,nil),http.StatusBadRequest)
+	checkStatus(t,"delete at the version",gatewayDo(t,"DELETE",base+"/v1/kv/a?version=1",nil,nil),http.StatusOK)
+	checkStatus(t,"get after delete",gatewayDo(t,"GET",base+"/v1/kv/a",nil,nil),http.StatusNotFound)
+}
+
+func TestGatewayScan(t *testing.T){
+	svrs,addrs:=startTestServers(t,1,oneRangeConfig)
+	base:=startTestGateways(t,svrs,addrs)[addrs[0]]
+	keys:=testKeys(7)
+	putKeys(t,addrs,keys)
+
+	var got []string
+	q:=url.Values{"limit":{"3"}}
+	for pages:=0;;pages++{
+		page:=&gatewayScan{}
+		checkStatus(t,"scan",gatewayDo(t,"GET",base+"/v1/scan?"+q.Encode(),nil,page),http.StatusOK)
+		if len(page.Kvs)>3{
+			t.Fatalf("page of %d",len(page.Kvs))
+		}
+		for _,kv:=range page.Kvs{
+			got=append(got,kv.Key)
+		}
+		if len(page.NextPageToken)==0{
+			break
+		}
+		if pages>7{
+			t.Fatal("scan does not end")
+		}
+		// the token comes back base64 in the JSON and goes out the same way
+		data,_:=json.Marshal(page.NextPageToken)
+		var token string
+		json.Unmarshal(data,&token)
+		q.Set("page_token",token)
+	}
+	checkKeys(t,"scan",got,keys)
+}
+
+func TestGatewayClusterStatus(t *testing.T){
+	svrs,addrs:=startTestServers(t,2,hashConfig)
+	urls:=startTestGateways(t,svrs,addrs)
+	putKeys(t,addrs,testKeys(4))
+
+	// each server hosts one group, and either gateway reports both
+	for _,addr:=range addrs{
+		status:=&gatewayClusterStatus{}
+		checkStatus(t,"cluster status",gatewayDo(t,"GET",urls[addr]+"/v1/cluster/status",nil,status),http.StatusOK)
+		if len(status.Nodes)!=2 || len(status.Groups)!=2{
+			t.Fatalf("status from %s: %d nodes, %d groups",addr,len(status.Nodes),len(status.Groups))
+		}
+		for i,g:=range status.Groups{
+			if g.Gid!=int64(i+1) || g.Leader!=addrs[i] || len(g.Replicas)!=1 || len(g.Shards)!=NShards/2{
+				t.Fatalf("status from %s: group %+v",addr,g)
+			}
+			if g.Replicas[0].Addr!=addrs[i] || g.Replicas[0].CommitIndex==0{
+				t.Fatalf("status from %s: replica %+v",addr,g.Replicas[0])
+			}
+		}
+	}
+}
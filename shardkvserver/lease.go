@@ -0,0 +1,274 @@
+package shardkvserver
+
+import(
+	"sort"
+	"time"
+	"bytes"
+	"context"
+	"strconv"
+	"encoding/gob"
+
+	pb "neweraft/raftpb"
+)
+
+var(
+	LeaseMinTTL int64 = 1
+	LeaseCheckInterval = 100*time.Millisecond
+)
+
+const LeaseKeyPrefix = "__lease_"
+
+type LeaseCommand struct{
+	LeaseId int64
+	TTL int64
+}
+
+// leaseState is replicated; when a lease expires is not. Only the leader
+// tracks deadlines, restarts them at full TTL when it takes over, and turns an
+// expiry into a CmdLeaseRevoke entry so every replica deletes the same keys at
+// the same log position.
+//
+// A lease belongs to the group it was granted in. A key of another group can
+// only attach to it if the same lease id was granted there too; otherwise the
+// write fails with ErrLeaseNotFound. Keys that migrate take their lease along.
+type leaseState struct{
+	Id int64
+	TTL int64
+	Keys map[string]bool
+}
+
+func (sg *ShardGroup)restoreLeases(){
+	leases,_:=sg.dataEng.DumpPrefix(LeaseKeyPrefix,true)
+	for _,data:=range leases{
+		l:=&leaseState{}
+		if gob.NewDecoder(bytes.NewBufferString(data)).Decode(l)!=nil{
+			continue
+		}
+		if l.Keys==nil{
+			l.Keys=make(map[string]bool)
+		}
+		sg.leases[l.Id]=l
+		for key:=range l.Keys{
+			sg.keyLease[key]=l.Id
+		}
+	}
+}
+
+func leaseKey(leaseId int64) string{
+	return LeaseKeyPrefix+strconv.FormatInt(leaseId,10)
+}
+
+func (sg *ShardGroup)persistLease(l *leaseState){
+	sg.dataEng.Put(leaseKey(l.Id),encodeGob(l))
+}
+
+// attachLease moves key onto leaseId, or detaches it when leaseId is 0.
+func (sg *ShardGroup)attachLease(key string,leaseId int64){
+	oldId,ok:=sg.keyLease[key]
+	if ok && oldId==leaseId{
+		return
+	}
+	if ok{
+		if l,ok:=sg.leases[oldId];ok{
+			delete(l.Keys,key)
+			sg.persistLease(l)
+		}
+		delete(sg.keyLease,key)
+	}
+	if l,ok:=sg.leases[leaseId];ok{
+		l.Keys[key]=true
+		sg.keyLease[key]=leaseId
+		sg.persistLease(l)
+	}
+}
+
+func (sg *ShardGroup)hasLease(leaseId int64) bool{
+	_,ok:=sg.leases[leaseId]
+	return ok
+}
+
+func (sg *ShardGroup)leaseInfos(match func(key string) bool) []*pb.LeaseInfo{
+	infos:=make([]*pb.LeaseInfo,0,len(sg.leases))
+	for _,l:=range sg.leases{
+		info:=&pb.LeaseInfo{LeaseId:l.Id,TTL:l.TTL}
+		for key:=range l.Keys{
+			if match==nil || match(key){
+				info.Keys=append(info.Keys,key)
+			}
+		}
+		if match==nil || len(info.Keys)>0{
+			sort.Strings(info.Keys)
+			infos=append(infos,info)
+		}
+	}
+	return infos
+}
+
+func (sg *ShardGroup)mergeLeases(infos []*pb.LeaseInfo){
+	for _,info:=range infos{
+		l,ok:=sg.leases[info.LeaseId]
+		if !ok{
+			l=&leaseState{Id:info.LeaseId,TTL:info.TTL,Keys:make(map[string]bool)}
+			sg.leases[l.Id]=l
+		}
+		for _,key:=range info.Keys{
+			sg.attachLease(key,l.Id)
+		}
+		sg.persistLease(l)
+	}
+}
+
+func (sg *ShardGroup)applyLeaseGrant(lc *LeaseCommand) *pb.CommandResponse{
+	l,ok:=sg.leases[lc.LeaseId]
+	if !ok{
+		l=&leaseState{Id:lc.LeaseId,TTL:lc.TTL,Keys:make(map[string]bool)}
+		sg.leases[l.Id]=l
+		sg.persistLease(l)
+	}
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK,Version:l.TTL}
+}
+
+func (sg *ShardGroup)applyLeaseRevoke(lc *LeaseCommand) *pb.CommandResponse{
+	l,ok:=sg.leases[lc.LeaseId]
+	if !ok{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrLeaseNotFound}
+	}
+	for key:=range l.Keys{
+		if sg.canServeKey(key){
+			sg.shardOf(key).Del(key,sg.lastApplied)
+		}
+		delete(sg.keyLease,key)
+	}
+	delete(sg.leases,l.Id)
+	delete(sg.leaseDeadlines,l.Id)
+	sg.dataEng.Del(leaseKey(l.Id))
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
+
+func (sg *ShardGroup)leaseExpiryAction(){
+	term,isLeader:=sg.raft.GetState()
+	if !isLeader{
+		return
+	}
+	now:=time.Now()
+	expired:=[]int64{}
+	sg.mu.Lock()
+	if term!=sg.leaseTerm{
+		sg.leaseTerm=term
+		sg.leaseDeadlines=make(map[int64]time.Time)
+	}
+	for id,l:=range sg.leases{
+		deadline,ok:=sg.leaseDeadlines[id]
+		if !ok{
+			sg.leaseDeadlines[id]=now.Add(time.Duration(l.TTL)*time.Second)
+			continue
+		}
+		if now.After(deadline){
+			expired=append(expired,id)
+		}
+	}
+	sg.mu.Unlock()
+
+	for _,id:=range expired{
+		sg.logger().Info("lease expired","lease",id)
+		sg.Execute(&Command{Type:CmdLeaseRevoke,Lease:&LeaseCommand{LeaseId:id}})
+	}
+}
+
+func (sg *ShardGroup)LeaseGrant(ctx context.Context,req *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse,error){
+	ttl:=req.TTL
+	if ttl<LeaseMinTTL{
+		ttl=LeaseMinTTL
+	}
+	leaseId:=req.LeaseId
+	if leaseId==0{
+		leaseId=time.Now().UnixNano()
+	}
+	res:=sg.Execute(&Command{Type:CmdLeaseGrant,Lease:&LeaseCommand{LeaseId:leaseId,TTL:ttl}})
+	if res.Err!=pb.ErrCode_ErrOK{
+		return &pb.LeaseGrantResponse{Err:res.Err,LeaderId:res.LeaderId},nil
+	}
+	return &pb.LeaseGrantResponse{Err:pb.ErrCode_ErrOK,LeaseId:leaseId,TTL:res.Version},nil
+}
+
+func (sg *ShardGroup)LeaseRevoke(ctx context.Context,req *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse,error){
+	res:=sg.Execute(&Command{Type:CmdLeaseRevoke,Lease:&LeaseCommand{LeaseId:req.LeaseId}})
+	return &pb.LeaseRevokeResponse{Err:res.Err,LeaderId:res.LeaderId},nil
+}
+
+func (sg *ShardGroup)LeaseKeepAlive(ctx context.Context,req *pb.LeaseKeepAliveRequest) (*pb.LeaseKeepAliveResponse,error){
+	if _,isLeader:=sg.raft.GetState();!isLeader{
+		return &pb.LeaseKeepAliveResponse{Err:pb.ErrCode_ErrWrongLeader,LeaderId:sg.raft.GetLeaderId()},nil
+	}
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	l,ok:=sg.leases[req.LeaseId]
+	if !ok{
+		return &pb.LeaseKeepAliveResponse{Err:pb.ErrCode_ErrLeaseNotFound},nil
+	}
+	sg.leaseDeadlines[l.Id]=time.Now().Add(time.Duration(l.TTL)*time.Second)
+	return &pb.LeaseKeepAliveResponse{Err:pb.ErrCode_ErrOK,TTL:l.TTL},nil
+}
+
+func (shardsvr *ShardServer)ownerOf(key string) *ShardGroup{
+	for _,group:=range shardsvr.getGroups(){
+		if group.owns(key){
+			return group
+		}
+	}
+	return nil
+}
+
+func (shardsvr *ShardServer)LeaseGrant(ctx context.Context,req *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse,error){
+	if group:=shardsvr.ownerOf(req.Key);group!=nil{
+		return group.LeaseGrant(ctx,req)
+	}
+	return &pb.LeaseGrantResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+}
+
+// groupsWithLease lists the local groups holding a lease, or only the one
+// owning key when the client names it. A range split hands a copy of the lease
+// to the new group, so there can be more than one.
+func (shardsvr *ShardServer)groupsWithLease(leaseId int64,key string) []*ShardGroup{
+	groups:=[]*ShardGroup{}
+	for _,group:=range shardsvr.getGroups(){
+		if key!="" && !group.owns(key){
+			continue
+		}
+		group.mu.RLock()
+		ok:=group.hasLease(leaseId)
+		group.mu.RUnlock()
+		if ok{
+			groups=append(groups,group)
+		}
+	}
+	return groups
+}
+
+func (shardsvr *ShardServer)LeaseRevoke(ctx context.Context,req *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse,error){
+	if req.Key!="" && shardsvr.ownerOf(req.Key)==nil{
+		return &pb.LeaseRevokeResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+	}
+	res:=&pb.LeaseRevokeResponse{Err:pb.ErrCode_ErrLeaseNotFound}
+	for _,group:=range shardsvr.groupsWithLease(req.LeaseId,req.Key){
+		groupRes,_:=group.LeaseRevoke(ctx,req)
+		if res.Err!=pb.ErrCode_ErrOK{
+			res=groupRes
+		}
+	}
+	return res,nil
+}
+
+func (shardsvr *ShardServer)LeaseKeepAlive(ctx context.Context,req *pb.LeaseKeepAliveRequest) (*pb.LeaseKeepAliveResponse,error){
+	if req.Key!="" && shardsvr.ownerOf(req.Key)==nil{
+		return &pb.LeaseKeepAliveResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+	}
+	res:=&pb.LeaseKeepAliveResponse{Err:pb.ErrCode_ErrLeaseNotFound}
+	for _,group:=range shardsvr.groupsWithLease(req.LeaseId,req.Key){
+		groupRes,_:=group.LeaseKeepAlive(ctx,req)
+		if res.Err!=pb.ErrCode_ErrOK{
+			res=groupRes
+		}
+	}
+	return res,nil
+}
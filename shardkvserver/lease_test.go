@@ -0,0 +1,57 @@
+package shardkvserver
+
+import(
+	"errors"
+	"context"
+	"testing"
+	"time"
+
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+)
+
+func TestTxnDetachesLease(t *testing.T){
+	svrs,addrs:=startTestServers(t,2,hashConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+	config:=hashConfig(addrs)
+	key,other:=keyOfGroup(config,1),keyOfGroup(config,2)
+
+	grant,err:=cli.LeaseGrant(ctx,key,60,0)
+	if err!=nil{
+		t.Fatal(err)
+	}
+	leaseId:=grant.LeaseId
+	// the lease lives in group 1 only
+	if _,err=cli.PutWithLease(ctx,other,"v",leaseId);!errors.Is(err,shardkvclient.ErrLeaseNotFound){
+		t.Fatalf("attach a key of another group: %v",err)
+	}
+	if _,err=cli.PutWithLease(ctx,key,"v",leaseId);err!=nil{
+		t.Fatal(err)
+	}
+
+	if _,err=cli.Txn(ctx,&pb.TxnRequest{Success:[]*pb.TxnOp{{Op:pb.OpType_OpPut,Key:key,Value:"w"}}});err!=nil{
+		t.Fatal(err)
+	}
+	group:=svrs[0].getGroup(1)
+	group.mu.Lock()
+	_,attached:=group.keyLease[key]
+	group.leases=make(map[int64]*leaseState)
+	group.keyLease=make(map[string]int64)
+	group.restoreLeases()
+	l:=group.leases[leaseId]
+	group.mu.Unlock()
+	if attached || l==nil || l.Keys[key]{
+		t.Fatalf("key still attached after a txn put: %v %v",attached,l)
+	}
+
+	// revoking the lease leaves the key the txn wrote
+	if err=cli.LeaseRevoke(ctx,key,leaseId);err!=nil{
+		t.Fatal(err)
+	}
+	if kv,err:=cli.Get(ctx,key);err!=nil || kv.Value!="w"{
+		t.Fatalf("get after revoke: %v %v",kv,err)
+	}
+}
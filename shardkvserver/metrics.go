@@ -0,0 +1,10 @@
+package shardkvserver
+
+import(
+	"neweraft/metrics"
+)
+
+var(
+	commandSeconds = metrics.NewHistogram("shardkv_command_seconds","Time from receiving a command to answering it, including replication and apply.",metrics.LatencyBuckets,"op","err")
+	shardSnapshotBytes = metrics.NewHistogram("shardkv_shard_snapshot_bytes","Size of the shard snapshots served to groups pulling shards during migration.",metrics.SizeBuckets)
+)
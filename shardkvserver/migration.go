@@ -0,0 +1,248 @@
+package shardkvserver
+
+import(
+	"sync"
+	"context"
+
+	"google.golang.org/protobuf/proto"
+	pb "neweraft/raftpb"
+)
+
+func (sg *ShardGroup)configureAction(){
+	if sg.isRangeGroup(){
+		return
+	}
+	canPerformNextConfig:=true
+	sg.mu.RLock()
+	if sg.hasPendingTxns(){
+		canPerformNextConfig=false
+	}
+	for _,shard:=range sg.shards{
+		if shard.status!=ShardServing{
+			canPerformNextConfig=false
+			break
+		}
+	}
+	curConfigNum:=sg.curConfig.Num
+	sg.mu.RUnlock()
+	if !canPerformNextConfig{
+		return
+	}
+	nextConfig,err:=sg.svr.ctrler.Query(curConfigNum+1)
+	if err!=nil{
+		return
+	}
+	if nextConfig.Num==curConfigNum+1{
+		sg.Execute(&Command{Type:CmdConfiguration,Config:nextConfig.Copy()})
+	}
+}
+
+func (sg *ShardGroup)migrationAction(){
+	sg.mu.RLock()
+	gid2ShardIds:=sg.getShardIdsByStatus(ShardPulling)
+	configNum:=sg.curConfig.Num
+	lastGroups:=sg.lastConfig.Copy().Groups
+	sg.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for gid,shardIds:=range gid2ShardIds{
+		wg.Add(1)
+		go func(gid int64,servers []string,shardIds []int64){
+			defer wg.Done()
+			req:=&pb.ShardOperationRequest{ConfigNum:configNum,ShardIds:shardIds,GroupId:gid}
+			for _,server:=range servers{
+				cli:=sg.svr.getSvrClient(server)
+				if cli==nil{
+					continue
+				}
+				ctx,cancel:=context.WithTimeout(context.Background(),ExecuteTimeout)
+				res,err:=cli.PullShard(ctx,req)
+				cancel()
+				if err==nil && res.Err==pb.ErrCode_ErrOK{
+					sg.Execute(&Command{Type:CmdInsertShards,ShardsResp:res})
+					return
+				}
+			}
+		}(gid,lastGroups[gid],shardIds)
+	}
+	wg.Wait()
+}
+
+func (sg *ShardGroup)gcAction(){
+	sg.mu.RLock()
+	gid2ShardIds:=sg.getShardIdsByStatus(ShardGC)
+	configNum:=sg.curConfig.Num
+	lastGroups:=sg.lastConfig.Copy().Groups
+	sg.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for gid,shardIds:=range gid2ShardIds{
+		wg.Add(1)
+		go func(gid int64,servers []string,shardIds []int64){
+			defer wg.Done()
+			req:=&pb.ShardOperationRequest{ConfigNum:configNum,ShardIds:shardIds,GroupId:gid}
+			for _,server:=range servers{
+				cli:=sg.svr.getSvrClient(server)
+				if cli==nil{
+					continue
+				}
+				ctx,cancel:=context.WithTimeout(context.Background(),ExecuteTimeout)
+				res,err:=cli.DeleteShard(ctx,req)
+				cancel()
+				if err==nil && res.Err==pb.ErrCode_ErrOK{
+					sg.Execute(&Command{Type:CmdDeleteShards,ShardsReq:req})
+					return
+				}
+			}
+		}(gid,lastGroups[gid],shardIds)
+	}
+	wg.Wait()
+}
+
+func (sg *ShardGroup)getShardIdsByStatus(status ShardStatus) map[int64][]int64{
+	gid2ShardIds:=make(map[int64][]int64)
+	for shardId,shard:=range sg.shards{
+		if shard.status==status{
+			gid:=sg.lastConfig.Shards[shardId]
+			gid2ShardIds[gid]=append(gid2ShardIds[gid],int64(shardId))
+		}
+	}
+	return gid2ShardIds
+}
+
+func (sg *ShardGroup)PullShard(ctx context.Context,req *pb.ShardOperationRequest) (*pb.ShardOperationResponse,error){
+	res:=&pb.ShardOperationResponse{}
+	if _,isLeader:=sg.raft.GetState();!isLeader{
+		res.Err=pb.ErrCode_ErrWrongLeader
+		return res,nil
+	}
+
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	if sg.curConfig.Num<req.ConfigNum{
+		res.Err=pb.ErrCode_ErrNotReady
+		return res,nil
+	}
+
+	res.Shards=make(map[int64]*pb.ShardData)
+	for _,shardId:=range req.ShardIds{
+		kvs,err:=sg.shards[int(shardId)].DeepCopy()
+		if err!=nil{
+			res.Err=pb.ErrCode_ErrNotReady
+			return res,nil
+		}
+		res.Shards[shardId]=&pb.ShardData{Kvs:kvs}
+		shardSnapshotBytes.With().Observe(float64(proto.Size(res.Shards[shardId])))
+	}
+	res.Leases=sg.leaseInfos(func(key string) bool{
+		_,ok:=res.Shards[int64(Key2Shard(key))]
+		return ok
+	})
+	res.LastOperations=make(map[int64]*pb.OperationContext)
+	for clientId,opCtx:=range sg.lastOperations{
+		res.LastOperations[clientId]=proto.Clone(opCtx).(*pb.OperationContext)
+	}
+	res.ConfigNum=req.ConfigNum
+	res.Err=pb.ErrCode_ErrOK
+	return res,nil
+}
+
+func (sg *ShardGroup)DeleteShard(ctx context.Context,req *pb.ShardOperationRequest) (*pb.ShardOperationResponse,error){
+	res:=&pb.ShardOperationResponse{}
+	if _,isLeader:=sg.raft.GetState();!isLeader{
+		res.Err=pb.ErrCode_ErrWrongLeader
+		return res,nil
+	}
+
+	sg.mu.RLock()
+	if sg.curConfig.Num>req.ConfigNum{
+		sg.mu.RUnlock()
+		res.Err=pb.ErrCode_ErrOK
+		return res,nil
+	}
+	sg.mu.RUnlock()
+
+	cmdRes:=sg.Execute(&Command{Type:CmdDeleteShards,ShardsReq:req})
+	res.Err=cmdRes.Err
+	return res,nil
+}
+
+func (sg *ShardGroup)applyConfiguration(nextConfig *Config) *pb.CommandResponse{
+	if nextConfig.Num!=sg.curConfig.Num+1{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrOutDated}
+	}
+	for shardId:=0;shardId<NShards;shardId++{
+		curGid,nextGid:=sg.curConfig.Shards[shardId],nextConfig.Shards[shardId]
+		if curGid!=sg.gid && nextGid==sg.gid && curGid!=0{
+			sg.shards[shardId].status=ShardPulling
+		}
+		if curGid==sg.gid && nextGid!=sg.gid && nextGid!=0{
+			sg.shards[shardId].status=ShardBeingPulled
+		}
+	}
+	sg.logger().Info("apply config","from",sg.curConfig.Num,"to",nextConfig.Num)
+	sg.lastConfig=sg.curConfig
+	sg.curConfig=nextConfig
+	sg.persistMeta()
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
+
+func (sg *ShardGroup)applyInsertShards(shardsResp *pb.ShardOperationResponse) *pb.CommandResponse{
+	if shardsResp.ConfigNum!=sg.curConfig.Num{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrOutDated}
+	}
+	for shardId,shardData:=range shardsResp.Shards{
+		shard:=sg.shards[int(shardId)]
+		if shard.status!=ShardPulling{
+			continue
+		}
+		for k,v:=range shardData.Kvs{
+			shard.PutRaw(k,v,sg.lastApplied)
+		}
+		shard.compacted=sg.lastApplied
+		shard.status=ShardGC
+		for _,info:=range shardsResp.Leases{
+			keys:=[]string{}
+			for _,key:=range info.Keys{
+				if int64(Key2Shard(key))==shardId{
+					keys=append(keys,key)
+				}
+			}
+			if len(keys)>0{
+				sg.mergeLeases([]*pb.LeaseInfo{{LeaseId:info.LeaseId,TTL:info.TTL,Keys:keys}})
+			}
+		}
+	}
+	for clientId,opCtx:=range shardsResp.LastOperations{
+		if lastOpCtx,ok:=sg.lastOperations[clientId];!ok || lastOpCtx.MaxAppliedCommandId<opCtx.MaxAppliedCommandId{
+			sg.updateLastOperation(clientId,opCtx)
+		}
+	}
+	sg.persistMeta()
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
+
+func (sg *ShardGroup)applyDeleteShards(shardsReq *pb.ShardOperationRequest) *pb.CommandResponse{
+	if shardsReq.ConfigNum!=sg.curConfig.Num{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+	}
+	for _,shardId:=range shardsReq.ShardIds{
+		shard:=sg.shards[int(shardId)]
+		switch shard.status{
+		case ShardGC:
+			shard.status=ShardServing
+		case ShardBeingPulled:
+			// the history of the shard leaves with it
+			shard.Clear()
+			shard.compacted=sg.lastApplied
+			for key:=range sg.keyLease{
+				if int64(Key2Shard(key))==shardId{
+					sg.attachLease(key,0)
+				}
+			}
+			shard.status=ShardServing
+		}
+	}
+	sg.persistMeta()
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
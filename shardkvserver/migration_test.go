@@ -0,0 +1,122 @@
+package shardkvserver
+
+import(
+	"sync"
+	"context"
+	"testing"
+	"time"
+
+	pb "neweraft/raftpb"
+)
+
+// watchStatuses samples the status of a shard of group gid on svr until stop
+// is closed, and returns the distinct statuses it went through in order.
+func watchStatuses(svr *ShardServer,gid int64,shardId int,stop chan struct{}) func() []ShardStatus{
+	var mu sync.Mutex
+	var seen []ShardStatus
+	done:=make(chan struct{})
+	go func(){
+		defer close(done)
+		group:=svr.getGroup(gid)
+		for{
+			group.mu.RLock()
+			status:=group.shards[shardId].status
+			group.mu.RUnlock()
+			mu.Lock()
+			if len(seen)==0 || seen[len(seen)-1]!=status{
+				seen=append(seen,status)
+			}
+			mu.Unlock()
+			select{
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}()
+	return func() []ShardStatus{
+		<-done
+		mu.Lock()
+		defer mu.Unlock()
+		return seen
+	}
+}
+
+func TestShardMigration(t *testing.T){
+	svrs,addrs:=startTestServers(t,2,hashConfig)
+	config:=hashConfig(addrs)
+	key:=keyOfGroup(config,2)
+	shardId:=Key2Shard(key)
+	putKeys(t,addrs,testKeys(20))
+
+	// a write of group 2 whose retry comes after the move
+	write:=&pb.CommandRequest{Key:key,Value:"first",Op:pb.OpType_OpPut,ClientId:7,CommandId:1}
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+	if res,_:=svrs[1].Command(ctx,write);res.Err!=pb.ErrCode_ErrOK{
+		t.Fatalf("write on group 2: %v",res.Err)
+	}
+
+	stop:=make(chan struct{})
+	pulled:=watchStatuses(svrs[0],1,shardId,stop)
+	left:=watchStatuses(svrs[1],2,shardId,stop)
+	next:=config.Copy()
+	for i:=range next.Shards{
+		next.Shards[i]=1
+	}
+	addTestConfig(t,svrs[0],next)
+	waitFor(t,"the shards to move",func() bool{
+		for _,svr:=range svrs{
+			for _,group:=range svr.getGroups(){
+				group.mu.RLock()
+				done:=group.curConfig.Num==next.Num
+				for _,shard:=range group.shards{
+					done=done && shard.status==ShardServing
+				}
+				group.mu.RUnlock()
+				if !done{
+					return false
+				}
+			}
+		}
+		return true
+	})
+	close(stop)
+	checkStatuses(t,"new owner",pulled(),[]ShardStatus{ShardServing,ShardPulling,ShardGC,ShardServing})
+	checkStatuses(t,"old owner",left(),[]ShardStatus{ShardServing,ShardBeingPulled,ShardServing})
+
+	keys:=testKeys(20)
+	for _,k:=range keys{
+		want:="v-"+k
+		if k==key{
+			want="first"
+		}
+		if v,code:=getKey(svrs[0],k);code!=pb.ErrCode_ErrOK || v!=want{
+			t.Fatalf("get %s on the new owner: %q %v",k,v,code)
+		}
+	}
+	if _,code:=getKey(svrs[1],key);code!=pb.ErrCode_ErrWrongGroup{
+		t.Fatalf("get on the old owner: %v",code)
+	}
+
+	// the dedup table came along, so the retry is not applied again
+	retry:=&pb.CommandRequest{Key:key,Value:"second",Op:pb.OpType_OpPut,ClientId:7,CommandId:1}
+	if res,_:=svrs[0].Command(ctx,retry);res.Err!=pb.ErrCode_ErrOK{
+		t.Fatalf("retry on group 1: %v",res.Err)
+	}
+	if v,_:=getKey(svrs[0],key);v!="first"{
+		t.Fatalf("retried write applied again: %q",v)
+	}
+}
+
+func checkStatuses(t *testing.T,what string,got []ShardStatus,want []ShardStatus){
+	t.Helper()
+	if len(got)!=len(want){
+		t.Fatalf("%s went through %v, want %v",what,got,want)
+	}
+	for i:=range got{
+		if got[i]!=want[i]{
+			t.Fatalf("%s went through %v, want %v",what,got,want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package shardkvserver
+
+import(
+	"time"
+	"strconv"
+
+	"neweraft/storage"
+	pb "neweraft/raftpb"
+)
+
+// MvccRetention is how many applied raft entries of history a group keeps
+// readable; versions only needed below lastApplied-MvccRetention are collected.
+var(
+	MvccRetention int64 = 10000
+	MvccGCInterval = 10*time.Second
+)
+
+const MvccCompactedKey = "__mvcc_compacted"
+
+func (sg *ShardGroup)restoreCompactedIndex(){
+	if v,err:=sg.dataEng.Get(MvccCompactedKey);err==nil{
+		sg.compactedIndex,_=strconv.ParseInt(v,10,64)
+	}
+}
+
+// readFloor is the index below which reads of shards are refused: the
+// compaction floor of the group, or later the index one of the shards last
+// arrived or left at.
+func (sg *ShardGroup)readFloor(shards ...*Shard) int64{
+	floor:=sg.compactedIndex
+	for _,shard:=range shards{
+		floor=max(floor,shard.compacted)
+	}
+	return floor
+}
+
+// compactAction proposes a new compaction floor. The floor decides which reads
+// fail, so it is raised in log order on every replica rather than by a timer.
+// It moves in steps, or an idle group would log a compaction every interval.
+func (sg *ShardGroup)compactAction(){
+	sg.mu.Lock()
+	keepIndex:=sg.lastApplied-MvccRetention
+	compacted:=sg.compactedIndex
+	sg.mu.Unlock()
+	if keepIndex-compacted>MvccRetention/10{
+		sg.Execute(&Command{Type:CmdCompact,CompactIndex:keepIndex})
+	}
+}
+
+func (sg *ShardGroup)applyCompact(index int64) *pb.CommandResponse{
+	if index>sg.compactedIndex{
+		sg.compactedIndex=index
+		sg.dataEng.Put(MvccCompactedKey,strconv.FormatInt(index,10))
+	}
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
+
+// mvccGCLoop runs on every replica and drops the versions below the applied
+// compaction floor; no read can reach them, so it only frees space.
+func (sg *ShardGroup)mvccGCLoop(){
+	mvcc:=storage.MakeMvccStore(sg.dataEng,[]byte(MvccKeyPrefix))
+	var collected int64
+	for !sg.raft.Killed(){
+		time.Sleep(MvccGCInterval)
+		sg.mu.Lock()
+		keepIndex:=sg.compactedIndex
+		sg.mu.Unlock()
+		if keepIndex<=collected{
+			continue
+		}
+		removed,err:=mvcc.GC(keepIndex)
+		if err!=nil{
+			sg.logger().Error("mvcc gc failed","err",err)
+			continue
+		}
+		collected=keepIndex
+		if removed>0{
+			sg.logger().Debug("mvcc gc","below",keepIndex,"removed",removed)
+		}
+	}
+}
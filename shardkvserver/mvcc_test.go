@@ -0,0 +1,160 @@
+package shardkvserver
+
+import(
+	"errors"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+	"neweraft/storage"
+)
+
+func TestMvccCompaction(t *testing.T){
+	retention,interval:=MvccRetention,MvccGCInterval
+	MvccRetention,MvccGCInterval=5,50*time.Millisecond
+	t.Cleanup(func(){
+		MvccRetention,MvccGCInterval=retention,interval
+	})
+	svrs,addrs:=startTestServers(t,3,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+
+	var indexes []int64
+	for i:=0;i<20;i++{
+		res,err:=cli.Put(ctx,"k",strconv.Itoa(i))
+		if err!=nil{
+			t.Fatal(err)
+		}
+		indexes=append(indexes,res.ModIndex)
+	}
+	first,last:=indexes[0],indexes[len(indexes)-1]
+	floor:=func(svr *ShardServer) int64{
+		group:=svr.getGroup(1)
+		group.mu.RLock()
+		defer group.mu.RUnlock()
+		return group.compactedIndex
+	}
+	waitFor(t,"compaction",func() bool{
+		for _,svr:=range svrs{
+			if floor(svr)<=indexes[1]{
+				return false
+			}
+		}
+		return true
+	})
+	if _,err:=cli.GetAt(ctx,"k",first);!errors.Is(err,shardkvclient.ErrOutDated){
+		t.Fatalf("read below the floor: %v",err)
+	}
+	if kv,err:=cli.GetAt(ctx,"k",last);err!=nil || kv.Value!="19"{
+		t.Fatalf("read at the last write: %v %v",kv,err)
+	}
+
+	// the floor is raised in log order, so replicas that applied as far agree
+	MvccRetention=1<<40
+	waitFor(t,"replicas to agree",func() bool{
+		var applied,floors []int64
+		for _,svr:=range svrs{
+			group:=svr.getGroup(1)
+			group.mu.RLock()
+			applied=append(applied,group.lastApplied)
+			floors=append(floors,group.compactedIndex)
+			group.mu.RUnlock()
+		}
+		for i:=range svrs[1:]{
+			if applied[i+1]!=applied[0]{
+				return false
+			}
+			if floors[i+1]!=floors[0]{
+				t.Fatalf("at %d node %d floor %d, node 0 floor %d",applied[0],svrs[i+1].id,floors[i+1],floors[0])
+			}
+		}
+		return true
+	})
+	// every replica drops the versions below it
+	for _,svr:=range svrs{
+		group:=svr.getGroup(1)
+		waitFor(t,"mvcc gc",func() bool{
+			_,_,_,err:=group.rangeShard.GetAt("k",first)
+			return err==ErrKeyNotFound
+		})
+	}
+}
+
+func TestShardFloor(t *testing.T){
+	eng:=storage.Engineerfactory("leveldb",t.TempDir())
+	defer eng.Close()
+	sg:=&ShardGroup{
+		gid:1,
+		dataEng:eng,
+		lastConfig:DefaultConfig(),
+		curConfig:&Config{Num:1},
+		shards:make(map[int]*Shard),
+		leases:make(map[int64]*leaseState),
+		keyLease:make(map[string]int64),
+		locks:make(map[string]int64),
+		lastOperations:make(map[int64]*pb.OperationContext),
+	}
+	for i:=0;i<NShards;i++{
+		sg.shards[i]=MakeShard(i,ShardServing,eng)
+		sg.curConfig.Shards[i]=1
+	}
+	gone,stays:="",""
+	for i:=0;gone=="" || stays=="";i++{
+		key:="k"+strconv.Itoa(i)
+		switch Key2Shard(key){
+		case 0:
+			gone=key
+		case 1:
+			stays=key
+		}
+	}
+	get:=func(key string,index int64) *pb.CommandResponse{
+		return sg.applyOperation(&pb.CommandRequest{Key:key,Op:pb.OpType_OpGet,ReadIndex:index})
+	}
+	sg.shards[0].Put(gone,"a",2)
+	sg.shards[1].Put(stays,"b",2)
+
+	// shard 0 leaves at 10; shard 1 keeps its history
+	sg.lastApplied=10
+	sg.curConfig.Shards[0]=2
+	sg.shards[0].status=ShardBeingPulled
+	sg.applyDeleteShards(&pb.ShardOperationRequest{ConfigNum:1,ShardIds:[]int64{0}})
+	if res:=get(stays,3);res.Err!=pb.ErrCode_ErrOK || res.Value!="b"{
+		t.Fatalf("read of a shard that stayed: %v",res)
+	}
+
+	// it comes back at 20 without its history
+	sg.lastApplied=20
+	sg.curConfig=&Config{Num:2,Shards:sg.curConfig.Shards}
+	sg.curConfig.Shards[0]=1
+	sg.shards[0].status=ShardPulling
+	sg.applyInsertShards(&pb.ShardOperationResponse{ConfigNum:2,Shards:map[int64]*pb.ShardData{
+		0:{Kvs:map[string]string{gone:encodeValue(1,"a")}},
+	}})
+	if res:=get(gone,15);res.Err!=pb.ErrCode_ErrOutDated{
+		t.Fatalf("read of an arrived shard before it arrived: %v",res)
+	}
+	if res:=get(gone,20);res.Err!=pb.ErrCode_ErrOK || res.Value!="a"{
+		t.Fatalf("read of an arrived shard: %v",res)
+	}
+	if res:=get(stays,3);res.Err!=pb.ErrCode_ErrOK || res.Value!="b"{
+		t.Fatalf("read of a shard that stayed: %v",res)
+	}
+	if sg.compactedIndex!=0{
+		t.Fatalf("group floor raised to %d",sg.compactedIndex)
+	}
+
+	restored:=&ShardGroup{dataEng:eng,lastConfig:DefaultConfig(),curConfig:DefaultConfig(),shards:make(map[int]*Shard)}
+	for i:=0;i<NShards;i++{
+		restored.shards[i]=MakeShard(i,ShardServing,eng)
+	}
+	restored.restoreMeta()
+	if restored.shards[0].compacted!=20 || restored.shards[1].compacted!=0{
+		t.Fatalf("restored floors %d %d",restored.shards[0].compacted,restored.shards[1].compacted)
+	}
+}
@@ -0,0 +1,479 @@
+package shardkvserver
+
+import(
+	"math"
+	"sort"
+	"time"
+	"bytes"
+	"context"
+	"sync/atomic"
+	"encoding/gob"
+
+	"google.golang.org/protobuf/proto"
+	pb "neweraft/raftpb"
+	"neweraft/storage"
+)
+
+var(
+	RangeCheckInterval = time.Second
+	RangeSplitKeys = 1024
+	RangeSplitBytes = 4<<20
+	RangeSplitQPS = 2000
+	RangeMergeKeys = 128
+)
+
+const RangeGroupsKey = "__range_groups"
+
+type KeyRange struct{
+	Gid int64
+	StartKey string
+	EndKey string
+	Epoch int64
+	Frozen bool
+	MergeTarget int64
+}
+
+func (kr *KeyRange) Contains(key string) bool{
+	return key>=kr.StartKey && (kr.EndKey=="" || key<kr.EndKey)
+}
+
+func (kr *KeyRange) Copy() *KeyRange{
+	newRange:=*kr
+	return &newRange
+}
+
+type rangeGroupMeta struct{
+	SelfId int64
+	Peers []string
+	Removed bool
+}
+
+func (sg *ShardGroup)isRangeGroup() bool{
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	return sg.rng!=nil
+}
+
+func (sg *ShardGroup)rangeInfo() *pb.RangeInfo{
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	if sg.rng==nil{
+		return nil
+	}
+	return &pb.RangeInfo{
+		Gid:sg.gid,
+		StartKey:sg.rng.StartKey,
+		EndKey:sg.rng.EndKey,
+		Epoch:sg.rng.Epoch,
+		Peers:append([]string{},sg.peersAddrs...),
+		LeaderId:sg.raft.GetLeaderId(),
+	}
+}
+
+func (sg *ShardGroup)rangeData() map[string]string{
+	kvs,_:=sg.rangeShard.DeepCopy()
+	for k:=range kvs{
+		if !sg.rng.Contains(k){
+			delete(kvs,k)
+		}
+	}
+	return kvs
+}
+
+func (sg *ShardGroup)copyLastOperations() map[int64]*pb.OperationContext{
+	lastOps:=make(map[int64]*pb.OperationContext,len(sg.lastOperations))
+	for clientId,opCtx:=range sg.lastOperations{
+		lastOps[clientId]=proto.Clone(opCtx).(*pb.OperationContext)
+	}
+	return lastOps
+}
+
+func (sg *ShardGroup)splitAction(){
+	sg.mu.RLock()
+	if sg.rng==nil || sg.rng.Frozen{
+		sg.mu.RUnlock()
+		return
+	}
+	epoch,startKey:=sg.rng.Epoch,sg.rng.StartKey
+	keys,size:=sg.rangeShard.Size()
+	sg.mu.RUnlock()
+
+	qps:=int(atomic.SwapInt64(&sg.opCount,0)*int64(time.Second)/int64(RangeCheckInterval))
+	if keys<2{
+		return
+	}
+	if keys<=int64(RangeSplitKeys) && size<=int64(RangeSplitBytes) && qps<=RangeSplitQPS{
+		return
+	}
+	splitKey,ok:=sg.middleKey(keys)
+	if !ok || splitKey==startKey{
+		return
+	}
+	sg.logger().Info("split range","key",splitKey,"keys",keys,"bytes",size,"qps",qps)
+	sg.Execute(&Command{Type:CmdSplitRange,Range:&RangeCommand{
+		Epoch:epoch,
+		SplitKey:splitKey,
+		NewGid:time.Now().UnixNano(),
+	}})
+}
+
+// middleKey walks the keys of the range, without their values, up to the
+// middle one of about keys keys.
+func (sg *ShardGroup)middleKey(keys int64) (string,bool){
+	snap,err:=sg.dataEng.GetSnapshot()
+	if err!=nil{
+		return "",false
+	}
+	defer snap.Release()
+	iter:=sg.rangeShard.Scan(snap,"","",math.MaxInt64,false)
+	defer iter.Release()
+	for i:=int64(0);iter.Next();i++{
+		if i==keys/2{
+			return iter.Key()[len(sg.rangeShard.prefix()):],true
+		}
+	}
+	return "",false
+}
+
+func (sg *ShardGroup)mergeAction(){
+	sg.mu.RLock()
+	if sg.rng==nil || sg.rng.Frozen || sg.rng.EndKey==""{
+		sg.mu.RUnlock()
+		return
+	}
+	myRange:=sg.rng.Copy()
+	mySize,_:=sg.rangeShard.Size()
+	sg.mu.RUnlock()
+
+	right:=sg.svr.getRangeGroupStartingAt(myRange.EndKey)
+	if right==nil || !sameMembers(sg.peersAddrs,right.peersAddrs){
+		return
+	}
+	right.mu.RLock()
+	rightRange:=right.rng.Copy()
+	rightSize,_:=right.rangeShard.Size()
+	right.mu.RUnlock()
+
+	if !rightRange.Frozen && (mySize>=int64(RangeMergeKeys) || rightSize>=int64(RangeMergeKeys)){
+		return
+	}
+
+	req:=&pb.RangeOperationRequest{GroupId:right.gid,Epoch:rightRange.Epoch,TargetGid:sg.gid}
+	for _,server:=range right.peersAddrs{
+		cli:=sg.svr.getSvrClient(server)
+		if cli==nil{
+			continue
+		}
+		ctx,cancel:=context.WithTimeout(context.Background(),ExecuteTimeout)
+		res,err:=cli.FreezeRange(ctx,req)
+		cancel()
+		if err==nil && res.Err==pb.ErrCode_ErrOK{
+			sg.logger().Info("merge range","right",right.gid)
+			kvs:=map[string]string{}
+			if res.Data!=nil{
+				kvs=res.Data.Kvs
+			}
+			sg.Execute(&Command{Type:CmdMergeRange,Range:&RangeCommand{
+				Epoch:myRange.Epoch,
+				SourceRange:&KeyRange{
+					Gid:res.Range.Gid,
+					StartKey:res.Range.StartKey,
+					EndKey:res.Range.EndKey,
+					Epoch:res.Range.Epoch,
+				},
+				Data:kvs,
+				LastOperations:res.LastOperations,
+				Leases:res.Leases,
+			}})
+			return
+		}
+	}
+}
+
+func sameMembers(a []string,b []string) bool{
+	if len(a)!=len(b){
+		return false
+	}
+	sa:=append([]string{},a...)
+	sb:=append([]string{},b...)
+	sort.Strings(sa)
+	sort.Strings(sb)
+	for i:=range sa{
+		if sa[i]!=sb[i]{
+			return false
+		}
+	}
+	return true
+}
+
+func (sg *ShardGroup)FreezeRange(ctx context.Context,req *pb.RangeOperationRequest) (*pb.RangeOperationResponse,error){
+	res:=&pb.RangeOperationResponse{}
+	if _,isLeader:=sg.raft.GetState();!isLeader{
+		res.Err=pb.ErrCode_ErrWrongLeader
+		return res,nil
+	}
+	sg.mu.RLock()
+	if sg.rng==nil{
+		sg.mu.RUnlock()
+		res.Err=pb.ErrCode_ErrWrongGroup
+		return res,nil
+	}
+	frozen:=sg.rng.Frozen && sg.rng.MergeTarget==req.TargetGid
+	sg.mu.RUnlock()
+
+	if !frozen{
+		cmdRes:=sg.Execute(&Command{Type:CmdFreezeRange,Range:&RangeCommand{Epoch:req.Epoch,TargetGid:req.TargetGid}})
+		if cmdRes.Err!=pb.ErrCode_ErrOK{
+			res.Err=cmdRes.Err
+			return res,nil
+		}
+	}
+
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	res.Range=&pb.RangeInfo{
+		Gid:sg.gid,
+		StartKey:sg.rng.StartKey,
+		EndKey:sg.rng.EndKey,
+		Epoch:sg.rng.Epoch,
+		Peers:append([]string{},sg.peersAddrs...),
+	}
+	res.Data=&pb.ShardData{Kvs:sg.rangeData()}
+	res.LastOperations=sg.copyLastOperations()
+	res.Leases=sg.leaseInfos(nil)
+	res.Err=pb.ErrCode_ErrOK
+	return res,nil
+}
+
+func (sg *ShardGroup)applySplitRange(rc *RangeCommand) *pb.CommandResponse{
+	if sg.hasPendingTxns(){
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrNotReady}
+	}
+	if sg.rng==nil || sg.rng.Frozen || rc.Epoch!=sg.rng.Epoch || !sg.rng.Contains(rc.SplitKey) || rc.SplitKey==sg.rng.StartKey{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrOutDated}
+	}
+	rightData:=make(map[string]string)
+	for k,v:=range sg.rangeData(){
+		if k>=rc.SplitKey{
+			rightData[k]=v
+		}
+	}
+	rightRange:=&KeyRange{
+		Gid:rc.NewGid,
+		StartKey:rc.SplitKey,
+		EndKey:sg.rng.EndKey,
+		Epoch:sg.rng.Epoch+1,
+	}
+	rightLeases:=sg.leaseInfos(func(key string) bool{ return key>=rc.SplitKey })
+	sg.svr.createRangeGroup(rc.NewGid,sg.id,sg.peersAddrs,rightRange,rightData,sg.copyLastOperations(),rightLeases)
+	for k:=range rightData{
+		sg.rangeShard.DelRaw(k,sg.lastApplied)
+	}
+	for _,info:=range rightLeases{
+		for _,key:=range info.Keys{
+			sg.attachLease(key,0)
+		}
+	}
+	sg.rng.EndKey=rc.SplitKey
+	sg.rng.Epoch++
+	sg.persistMeta()
+	sg.logger().Info("split","start",sg.rng.StartKey,"end",sg.rng.EndKey,"new_group",rc.NewGid,"new_start",rightRange.StartKey,"new_end",rightRange.EndKey)
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
+
+func (sg *ShardGroup)applyFreezeRange(rc *RangeCommand) *pb.CommandResponse{
+	if sg.rng==nil{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrWrongGroup}
+	}
+	// a frozen range may be handed to a new left neighbour: the old target
+	// got frozen itself before merging us, so it can never apply the merge
+	if !sg.rng.Frozen && rc.Epoch!=sg.rng.Epoch{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrOutDated}
+	}
+	if !sg.rng.Frozen && sg.hasPendingTxns(){
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrNotReady}
+	}
+	sg.rng.Frozen=true
+	sg.rng.MergeTarget=rc.TargetGid
+	sg.persistMeta()
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
+
+func (sg *ShardGroup)applyMergeRange(rc *RangeCommand) *pb.CommandResponse{
+	if sg.rng==nil || sg.rng.Frozen || rc.Epoch!=sg.rng.Epoch || sg.rng.EndKey!=rc.SourceRange.StartKey{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrOutDated}
+	}
+	for k,v:=range rc.Data{
+		sg.rangeShard.PutRaw(k,v,sg.lastApplied)
+	}
+	for clientId,opCtx:=range rc.LastOperations{
+		if lastOpCtx,ok:=sg.lastOperations[clientId];!ok || lastOpCtx.MaxAppliedCommandId<opCtx.MaxAppliedCommandId{
+			sg.updateLastOperation(clientId,opCtx)
+		}
+	}
+	sg.mergeLeases(rc.Leases)
+	sg.rng.EndKey=rc.SourceRange.EndKey
+	if rc.SourceRange.Epoch>sg.rng.Epoch{
+		sg.rng.Epoch=rc.SourceRange.Epoch
+	}
+	sg.rng.Epoch++
+	sg.persistMeta()
+	sg.logger().Info("merged","source",rc.SourceRange.Gid,"start",sg.rng.StartKey,"end",sg.rng.EndKey)
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrOK}
+}
+
+func (shardsvr *ShardServer)createRangeGroup(gid int64,selfId int64,peers []string,rng *KeyRange,kvs map[string]string,lastOps map[int64]*pb.OperationContext,leases []*pb.LeaseInfo){
+	shardsvr.mu.Lock()
+	defer shardsvr.mu.Unlock()
+	if _,ok:=shardsvr.groups[gid];ok{
+		return
+	}
+	registry:=shardsvr.loadRangeRegistry()
+	if _,ok:=registry[gid];ok{
+		return
+	}
+
+	dataeng:=storage.MakePrefixKvStore(shardsvr.db,GroupKeyPrefix(gid,GroupDataSpace))
+	rangeShard:=MakeRangeShard(dataeng)
+	for k,v:=range kvs{
+		rangeShard.PutRaw(k,v,0)
+	}
+	seed:=&ShardGroup{
+		gid:gid,
+		id:selfId,
+		dataEng:dataeng,
+		lastConfig:DefaultConfig(),
+		curConfig:DefaultConfig(),
+		shards:make(map[int]*Shard),
+		rng:rng,
+		lastOperations:make(map[int64]*pb.OperationContext),
+		leases:make(map[int64]*leaseState),
+		keyLease:make(map[string]int64),
+	}
+	for i:=0;i<NShards;i++{
+		seed.shards[i]=MakeShard(i,ShardServing,dataeng)
+	}
+	for clientId,opCtx:=range lastOps{
+		seed.updateLastOperation(clientId,opCtx)
+	}
+	seed.mergeLeases(leases)
+	seed.persistMeta()
+
+	registry[gid]=&rangeGroupMeta{SelfId:selfId,Peers:append([]string{},peers...)}
+	shardsvr.saveRangeRegistry(registry)
+	shardsvr.groups[gid]=MakeShardGroup(shardsvr,gid,selfId,peers,nil)
+}
+
+func (shardsvr *ShardServer)gcMergedGroups(){
+	groups:=shardsvr.getGroups()
+	ranges:=make(map[int64]*KeyRange,len(groups))
+	for _,group:=range groups{
+		group.mu.RLock()
+		if group.rng!=nil{
+			ranges[group.gid]=group.rng.Copy()
+		}
+		group.mu.RUnlock()
+	}
+	for gid,rng:=range ranges{
+		for otherGid,other:=range ranges{
+			if otherGid!=gid && !other.Frozen && other.Epoch>rng.Epoch && other.Contains(rng.StartKey){
+				shardsvr.removeGroup(gid)
+				break
+			}
+		}
+	}
+}
+
+func (shardsvr *ShardServer)removeGroup(gid int64){
+	shardsvr.mu.Lock()
+	group,ok:=shardsvr.groups[gid]
+	if !ok{
+		shardsvr.mu.Unlock()
+		return
+	}
+	delete(shardsvr.groups,gid)
+	registry:=shardsvr.loadRangeRegistry()
+	if _,ok:=registry[gid];!ok{
+		registry[gid]=&rangeGroupMeta{SelfId:group.id,Peers:group.peersAddrs}
+	}
+	registry[gid].Removed=true
+	shardsvr.saveRangeRegistry(registry)
+	shardsvr.mu.Unlock()
+
+	group.raft.Kill()
+	group.mu.Lock()
+	for index,ss:=range group.scanSnaps{
+		ss.snap.Release()
+		delete(group.scanSnaps,index)
+	}
+	for id,w:=range group.watchers{
+		w.wrongGroup=true
+		close(w.ch)
+		delete(group.watchers,id)
+	}
+	for id,sub:=range group.cdcSubs{
+		sub.removed=true
+		close(sub.ch)
+		delete(group.cdcSubs,id)
+	}
+	group.mu.Unlock()
+	shardsvr.db.DelPrefix(string(GroupKeyPrefix(gid,GroupDataSpace)))
+	shardsvr.db.DelPrefix(string(GroupKeyPrefix(gid,GroupLogSpace)))
+	shardsvr.logger().Info("removed merged group","group",gid)
+}
+
+func (shardsvr *ShardServer)loadRangeRegistry() map[int64]*rangeGroupMeta{
+	registry:=make(map[int64]*rangeGroupMeta)
+	registryStr,err:=shardsvr.db.Get(RangeGroupsKey)
+	if err!=nil{
+		return registry
+	}
+	gob.NewDecoder(bytes.NewBufferString(registryStr)).Decode(&registry)
+	return registry
+}
+
+func (shardsvr *ShardServer)saveRangeRegistry(registry map[int64]*rangeGroupMeta){
+	var buf bytes.Buffer
+	if err:=gob.NewEncoder(&buf).Encode(registry);err!=nil{
+		shardsvr.logger().Error("encode range registry failed","err",err)
+		return
+	}
+	shardsvr.db.Put(RangeGroupsKey,buf.String())
+}
+
+func (shardsvr *ShardServer)getRangeGroupStartingAt(startKey string) *ShardGroup{
+	for _,group:=range shardsvr.getGroups(){
+		group.mu.RLock()
+		match:=group.rng!=nil && group.rng.StartKey==startKey
+		group.mu.RUnlock()
+		if match{
+			return group
+		}
+	}
+	return nil
+}
+
+func (shardsvr *ShardServer)GetRanges(ctx context.Context,req *pb.GetRangesRequest) (*pb.GetRangesResponse,error){
+	res:=&pb.GetRangesResponse{}
+	for _,group:=range shardsvr.getGroups(){
+		group.mu.RLock()
+		frozen:=group.rng==nil || group.rng.Frozen
+		group.mu.RUnlock()
+		if frozen{
+			continue
+		}
+		res.Ranges=append(res.Ranges,group.rangeInfo())
+	}
+	sort.Slice(res.Ranges,func(i,j int) bool{
+		return res.Ranges[i].StartKey<res.Ranges[j].StartKey
+	})
+	return res,nil
+}
+
+func (shardsvr *ShardServer)FreezeRange(ctx context.Context,req *pb.RangeOperationRequest) (*pb.RangeOperationResponse,error){
+	group:=shardsvr.getGroup(req.GroupId)
+	if group==nil{
+		return &pb.RangeOperationResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+	}
+	return group.FreezeRange(ctx,req)
+}
@@ -0,0 +1,126 @@
+package shardkvserver
+
+import(
+	"context"
+	"testing"
+	"time"
+
+	pb "neweraft/raftpb"
+)
+
+// shortRangeChecks makes the split and merge checks run often, with the
+// given split threshold, and never merges ranges for being small.
+func shortRangeChecks(t *testing.T,splitKeys int){
+	interval,split,merge:=RangeCheckInterval,RangeSplitKeys,RangeMergeKeys
+	RangeCheckInterval,RangeSplitKeys,RangeMergeKeys=100*time.Millisecond,splitKeys,0
+	t.Cleanup(func(){
+		RangeCheckInterval,RangeSplitKeys,RangeMergeKeys=interval,split,merge
+	})
+}
+
+// groupGet reads key through group gid itself, on whichever replica leads it.
+func groupGet(t *testing.T,svrs []*ShardServer,gid int64,key string) (string,pb.ErrCode){
+	t.Helper()
+	deadline:=time.Now().Add(10*time.Second)
+	for time.Now().Before(deadline){
+		for _,svr:=range svrs{
+			group:=svr.getGroup(gid)
+			if group==nil{
+				continue
+			}
+			res,_:=group.Command(context.Background(),&pb.CommandRequest{Key:key,Op:pb.OpType_OpGet})
+			if res.Err!=pb.ErrCode_ErrWrongLeader && res.Err!=pb.ErrCode_ErrTimeout{
+				return res.Value,res.Err
+			}
+		}
+		time.Sleep(20*time.Millisecond)
+	}
+	t.Fatalf("group %d has no leader",gid)
+	return "",pb.ErrCode_ErrTimeout
+}
+
+func TestRangeSplit(t *testing.T){
+	shortRangeChecks(t,15)
+	svrs,addrs:=startTestServers(t,3,oneRangeConfig)
+	keys:=testKeys(20)
+	putKeys(t,addrs,keys)
+
+	var ranges []*pb.RangeInfo
+	waitFor(t,"the split",func() bool{
+		for _,svr:=range svrs{
+			res,_:=svr.GetRanges(context.Background(),&pb.GetRangesRequest{})
+			if len(res.Ranges)!=2{
+				return false
+			}
+			ranges=res.Ranges
+		}
+		return true
+	})
+	left,right:=ranges[0],ranges[1]
+	if left.Gid!=1 || left.StartKey!="" || right.StartKey!=left.EndKey || right.EndKey!=""{
+		t.Fatalf("split into %v and %v",left,right)
+	}
+
+	for _,key:=range keys{
+		owner,other:=left.Gid,right.Gid
+		if key>=right.StartKey{
+			owner,other=right.Gid,left.Gid
+		}
+		if v,code:=groupGet(t,svrs,owner,key);code!=pb.ErrCode_ErrOK || v!="v-"+key{
+			t.Fatalf("get %s from group %d: %q %v",key,owner,v,code)
+		}
+		if _,code:=groupGet(t,svrs,other,key);code!=pb.ErrCode_ErrWrongGroup{
+			t.Fatalf("get %s from group %d: %v",key,other,code)
+		}
+	}
+	// both halves take writes through the server
+	putKeys(t,addrs,[]string{left.StartKey+"0",right.StartKey+"0"})
+}
+
+func TestMergeRetiresFrozenRange(t *testing.T){
+	shortRangeChecks(t,1<<20)
+	svrs,addrs:=startTestServers(t,3,func(addrs []string) *Config{
+		return &Config{
+			Num:1,
+			Groups:map[int64][]string{1:append([]string{},addrs...),2:append([]string{},addrs...)},
+			Ranges:[]*KeyRange{{Gid:1,EndKey:"m"},{Gid:2,StartKey:"m"}},
+		}
+	})
+	keys:=testKeys(20)
+	putKeys(t,addrs,keys)
+
+	// group 2 is frozen for group 1 as the first step of a merge whose
+	// proposal never lands, as when group 1 loses its leader in between
+	req:=&pb.RangeOperationRequest{GroupId:2,TargetGid:1}
+	waitFor(t,"the freeze",func() bool{
+		for _,svr:=range svrs{
+			res,_:=svr.FreezeRange(context.Background(),req)
+			if res.Err==pb.ErrCode_ErrOK{
+				return true
+			}
+		}
+		return false
+	})
+
+	// group 1 finds its right neighbour frozen for it and finishes the merge
+	waitFor(t,"the frozen range to be retired",func() bool{
+		for _,svr:=range svrs{
+			if svr.getGroup(2)!=nil{
+				return false
+			}
+			group:=svr.getGroup(1)
+			group.mu.RLock()
+			merged:=group.rng.EndKey=="" && !group.rng.Frozen
+			group.mu.RUnlock()
+			if !merged{
+				return false
+			}
+		}
+		return true
+	})
+	for _,key:=range keys{
+		if v,code:=groupGet(t,svrs,1,key);code!=pb.ErrCode_ErrOK || v!="v-"+key{
+			t.Fatalf("get %s after the merge: %q %v",key,v,code)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package shardkvserver
+
+import(
+	"io"
+	"net"
+	"sync"
+	"context"
+	"testing"
+	"time"
+
+	"neweraft/recipes"
+	"neweraft/shardkvclient"
+)
+
+// cutProxy forwards connections to addr until cut, then refuses them, as a
+// client process that died would stop talking to the server.
+type cutProxy struct{
+	mu sync.Mutex
+	lis net.Listener
+	conns []net.Conn
+}
+
+func startCutProxy(t *testing.T,addr string) *cutProxy{
+	lis,err:=net.Listen("tcp","127.0.0.1:0")
+	if err!=nil{
+		t.Fatal(err)
+	}
+	p:=&cutProxy{lis:lis}
+	t.Cleanup(p.cut)
+	go func(){
+		for{
+			conn,err:=lis.Accept()
+			if err!=nil{
+				return
+			}
+			server,err:=net.Dial("tcp",addr)
+			if err!=nil{
+				conn.Close()
+				continue
+			}
+			p.mu.Lock()
+			p.conns=append(p.conns,conn,server)
+			p.mu.Unlock()
+			go io.Copy(server,conn)
+			go io.Copy(conn,server)
+		}
+	}()
+	return p
+}
+
+func (p *cutProxy)addr() string{
+	return p.lis.Addr().String()
+}
+
+func (p *cutProxy)cut(){
+	p.lis.Close()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _,conn:=range p.conns{
+		conn.Close()
+	}
+}
+
+func newTestSession(t *testing.T,addrs []string,ttl int64) *recipes.Session{
+	cli:=shardkvclient.MakeClient(addrs)
+	s:=recipes.NewSession(cli,ttl)
+	t.Cleanup(func(){
+		ctx,cancel:=context.WithTimeout(context.Background(),time.Second)
+		defer cancel()
+		s.Close(ctx)
+		cli.Close()
+	})
+	return s
+}
+
+func TestRecipesMutex(t *testing.T){
+	_,addrs:=startTestServers(t,3,oneRangeConfig)
+	ctx,cancel:=context.WithTimeout(context.Background(),30*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var holders int
+	var tokens []int64
+	var wg sync.WaitGroup
+	for i:=0;i<3;i++{
+		m:=recipes.NewMutex(newTestSession(t,addrs,5),"lock")
+		wg.Add(1)
+		go func(){
+			defer wg.Done()
+			for j:=0;j<4;j++{
+				if err:=m.Lock(ctx);err!=nil{
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				holders++
+				if holders>1{
+					t.Errorf("%d holders",holders)
+				}
+				tokens=append(tokens,m.Token())
+				mu.Unlock()
+				time.Sleep(10*time.Millisecond)
+				mu.Lock()
+				holders--
+				mu.Unlock()
+				if err:=m.Unlock(ctx);err!=nil{
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for i:=1;i<len(tokens);i++{
+		if tokens[i]<=tokens[i-1]{
+			t.Fatalf("fencing tokens do not grow: %v",tokens)
+		}
+	}
+
+	m:=recipes.NewMutex(newTestSession(t,addrs,5),"lock")
+	if err:=m.TryLock(ctx);err!=nil{
+		t.Fatal(err)
+	}
+	if err:=recipes.NewMutex(newTestSession(t,addrs,5),"lock").TryLock(ctx);err!=recipes.ErrLocked{
+		t.Fatalf("try lock of a held mutex: %v",err)
+	}
+}
+
+func TestRecipesMutexLeaseExpiry(t *testing.T){
+	svrs,addrs:=startTestServers(t,3,oneRangeConfig)
+	ctx,cancel:=context.WithTimeout(context.Background(),30*time.Second)
+	defer cancel()
+
+	// the holder talks through a proxy that goes away, and never unlocks
+	proxy:=startCutProxy(t,leaderOf(t,svrs,1).addr)
+	holder:=recipes.NewMutex(newTestSession(t,[]string{proxy.addr()},1),"lock")
+	if err:=holder.Lock(ctx);err!=nil{
+		t.Fatal(err)
+	}
+	proxy.cut()
+
+	m:=recipes.NewMutex(newTestSession(t,addrs,5),"lock")
+	start:=time.Now()
+	if err:=m.Lock(ctx);err!=nil{
+		t.Fatal(err)
+	}
+	if time.Since(start)<500*time.Millisecond{
+		t.Fatalf("locked after %v, before the holder's lease ran out",time.Since(start))
+	}
+	if m.Token()<=holder.Token(){
+		t.Fatalf("token %d after the expired holder's %d",m.Token(),holder.Token())
+	}
+}
+
+func TestRecipesElection(t *testing.T){
+	_,addrs:=startTestServers(t,3,oneRangeConfig)
+	ctx,cancel:=context.WithTimeout(context.Background(),30*time.Second)
+	defer cancel()
+
+	first:=recipes.NewElection(newTestSession(t,addrs,5),"leader")
+	if err:=first.Campaign(ctx,"a");err!=nil{
+		t.Fatal(err)
+	}
+	observed:=recipes.NewElection(newTestSession(t,addrs,5),"leader").Observe(ctx)
+	next:=func(want string){
+		t.Helper()
+		select{
+		case got:=<-observed:
+			if got!=want{
+				t.Fatalf("observed %q, want %q",got,want)
+			}
+		case <-time.After(10*time.Second):
+			t.Fatalf("%q never observed",want)
+		}
+	}
+	next("a")
+
+	second:=recipes.NewElection(newTestSession(t,addrs,5),"leader")
+	elected:=make(chan error,1)
+	firstIndex:=first.Index()
+	go func(){ elected<-second.Campaign(ctx,"b") }()
+	select{
+	case err:=<-elected:
+		t.Fatalf("second campaign won while the first leads: %v",err)
+	case <-time.After(300*time.Millisecond):
+	}
+	if err:=first.Resign(ctx);err!=nil{
+		t.Fatal(err)
+	}
+	if err:=<-elected;err!=nil{
+		t.Fatal(err)
+	}
+	next("b")
+	if second.Index()<=firstIndex{
+		t.Fatalf("leader index %d after %d",second.Index(),firstIndex)
+	}
+	if err:=second.Proclaim(ctx,"c");err!=nil{
+		t.Fatal(err)
+	}
+	next("c")
+}
+
+func TestRecipesSemaphore(t *testing.T){
+	_,addrs:=startTestServers(t,3,oneRangeConfig)
+	ctx,cancel:=context.WithTimeout(context.Background(),30*time.Second)
+	defer cancel()
+
+	const n=2
+	var mu sync.Mutex
+	var holders,most int
+	var wg sync.WaitGroup
+	for i:=0;i<4;i++{
+		sem:=recipes.NewSemaphore(newTestSession(t,addrs,5),"sem",n)
+		wg.Add(1)
+		go func(){
+			defer wg.Done()
+			for j:=0;j<3;j++{
+				if err:=sem.Acquire(ctx);err!=nil{
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				holders++
+				most=max(most,holders)
+				mu.Unlock()
+				time.Sleep(20*time.Millisecond)
+				mu.Lock()
+				holders--
+				mu.Unlock()
+				if err:=sem.Release(ctx);err!=nil{
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if most>n{
+		t.Fatalf("%d holders of a semaphore of %d",most,n)
+	}
+}
@@ -0,0 +1,644 @@
+package shardkvserver
+
+import(
+	"io"
+	"fmt"
+	"net"
+	"bufio"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"context"
+
+	pb "neweraft/raftpb"
+)
+
+const(
+	RespScanCount = 10
+	RespMaxCursors = 16
+)
+
+// RespServer speaks RESP2 so Redis clients can drive the cluster. Every command
+// goes through the same replicated paths as the gRPC service. A node that is
+// not the leader of the key's group answers "NOTLEADER <addr>" with the
+// leader's RESP address. It is not a cluster-mode MOVED: the key space is not
+// split in Redis slots, so cluster-mode clients should not be pointed at it.
+type RespServer struct{
+	svr *ShardServer
+	advertise func(addr string) string
+}
+
+// MakeRespServer takes a function mapping a server's gRPC address to the
+// address its RESP listener is reachable at.
+func MakeRespServer(svr *ShardServer,advertise func(addr string) string) *RespServer{
+	return &RespServer{svr:svr,advertise:advertise}
+}
+
+func (rs *RespServer)Serve(lis net.Listener) error{
+	for{
+		conn,err:=lis.Accept()
+		if err!=nil{
+			return err
+		}
+		go rs.serveConn(conn)
+	}
+}
+
+type respError string
+
+func (e respError)Error() string{
+	return string(e)
+}
+
+var errRespQuit = errors.New("quit")
+
+// respConn is one client connection. Each write borrows a client id from the
+// server's session pool so it is applied once however the group retries it,
+// without a new id in the groups' dedup tables for every connection.
+type respConn struct{
+	rs *RespServer
+	r *bufio.Reader
+	w *bufio.Writer
+	cursors map[int64][]byte
+	nextCursor int64
+}
+
+func (rs *RespServer)serveConn(conn net.Conn){
+	defer conn.Close()
+	c:=&respConn{
+		rs:rs,
+		r:bufio.NewReader(conn),
+		w:bufio.NewWriter(conn),
+		cursors:make(map[int64][]byte),
+	}
+	for{
+		args,err:=c.readCommand()
+		if err!=nil{
+			if err!=io.EOF{
+				c.writeError("ERR Protocol error: "+err.Error())
+				c.w.Flush()
+			}
+			return
+		}
+		if len(args)>0{
+			err=c.dispatch(args)
+			if err==errRespQuit{
+				c.writeSimple("OK")
+				c.w.Flush()
+				return
+			}
+			if err!=nil{
+				c.writeError(err.Error())
+			}
+		}
+		// flush once the pipelined commands read so far are answered
+		if c.r.Buffered()==0{
+			if err:=c.w.Flush();err!=nil{
+				return
+			}
+		}
+	}
+}
+
+func (c *respConn)readLine() (string,error){
+	line,err:=c.r.ReadString('\n')
+	if err!=nil{
+		return "",err
+	}
+	return strings.TrimRight(line,"\r\n"),nil
+}
+
+// readCommand reads a multibulk request or an inline one as sent by telnet.
+func (c *respConn)readCommand() ([]string,error){
+	line,err:=c.readLine()
+	if err!=nil{
+		return nil,err
+	}
+	if !strings.HasPrefix(line,"*"){
+		return strings.Fields(line),nil
+	}
+	n,err:=strconv.Atoi(line[1:])
+	if err!=nil || n>1024*1024{
+		return nil,fmt.Errorf("invalid multibulk length")
+	}
+	args:=make([]string,0,n)
+	for i:=0;i<n;i++{
+		line,err=c.readLine()
+		if err!=nil{
+			return nil,err
+		}
+		if !strings.HasPrefix(line,"$"){
+			return nil,fmt.Errorf("expected '$', got '%s'",line)
+		}
+		size,err:=strconv.Atoi(line[1:])
+		if err!=nil || size<0 || size>512*1024*1024{
+			return nil,fmt.Errorf("invalid bulk length")
+		}
+		buf:=make([]byte,size+2)
+		if _,err:=io.ReadFull(c.r,buf);err!=nil{
+			return nil,err
+		}
+		args=append(args,string(buf[:size]))
+	}
+	return args,nil
+}
+
+func (c *respConn)writeSimple(s string){
+	c.w.WriteString("+"+s+"\r\n")
+}
+
+func (c *respConn)writeError(s string){
+	c.w.WriteString("-"+s+"\r\n")
+}
+
+func (c *respConn)writeInt(n int64){
+	c.w.WriteString(":"+strconv.FormatInt(n,10)+"\r\n")
+}
+
+func (c *respConn)writeBulk(s string){
+	c.w.WriteString("$"+strconv.Itoa(len(s))+"\r\n"+s+"\r\n")
+}
+
+func (c *respConn)writeNull(){
+	c.w.WriteString("$-1\r\n")
+}
+
+func (c *respConn)writeArray(n int){
+	c.w.WriteString("*"+strconv.Itoa(n)+"\r\n")
+}
+
+type respCommand struct{
+	arity int // negative means at least -arity
+	handler func(c *respConn,args []string) error
+}
+
+var respCommands map[string]respCommand
+
+func init(){
+	respCommands=map[string]respCommand{
+		"ping":{-1,respPing},
+		"echo":{2,func(c *respConn,args []string) error{ c.writeBulk(args[1]); return nil }},
+		"quit":{1,func(c *respConn,args []string) error{ return errRespQuit }},
+		"select":{2,respSelect},
+		"command":{-1,respEmptyArray},
+		"config":{-2,respEmptyArray},
+		"client":{-2,func(c *respConn,args []string) error{ c.writeSimple("OK"); return nil }},
+		"get":{2,respGet},
+		"set":{-3,respSet},
+		"del":{-2,respDel},
+		"exists":{-2,respExists},
+		"incr":{2,func(c *respConn,args []string) error{ return c.incrBy(args[1],1) }},
+		"decr":{2,func(c *respConn,args []string) error{ return c.incrBy(args[1],-1) }},
+		"incrby":{3,respIncrBy},
+		"decrby":{3,respIncrBy},
+		"mget":{-2,respMget},
+		"scan":{-2,respScan},
+		"expire":{3,respExpire},
+	}
+}
+
+func (c *respConn)dispatch(args []string) error{
+	name:=strings.ToLower(args[0])
+	cmd,ok:=respCommands[name]
+	if !ok{
+		return respError(fmt.Sprintf("ERR unknown command '%s'",args[0]))
+	}
+	if (cmd.arity>0 && len(args)!=cmd.arity) || (cmd.arity<0 && len(args)< -cmd.arity){
+		return respError(fmt.Sprintf("ERR wrong number of arguments for '%s' command",name))
+	}
+	return cmd.handler(c,args)
+}
+
+// redirect names the leader of group to the client, or asks it to retry when
+// there is none to name.
+func (c *respConn)redirect(group *ShardGroup,leaderId int64) error{
+	if group!=nil && leaderId>=0 && leaderId<int64(len(group.peersAddrs)) && leaderId!=group.id{
+		addr:=group.peersAddrs[leaderId]
+		if c.rs.advertise!=nil{
+			addr=c.rs.advertise(addr)
+		}
+		if addr!=""{
+			return respError("NOTLEADER "+addr)
+		}
+	}
+	return respError("TRYAGAIN no leader for the key yet")
+}
+
+// codeError turns the codes a command cannot act on into the reply to send.
+func (c *respConn)codeError(group *ShardGroup,code pb.ErrCode,leaderId int64) error{
+	switch code{
+	case pb.ErrCode_ErrWrongLeader:
+		return c.redirect(group,leaderId)
+	case pb.ErrCode_ErrWrongGroup,pb.ErrCode_ErrTimeout,pb.ErrCode_ErrNotReady,pb.ErrCode_ErrLocked:
+		return respError("TRYAGAIN "+code.String())
+	}
+	return respError("ERR "+code.String())
+}
+
+// command runs req on the local group owning its key. ErrOK, ErrNoKey and
+// ErrCompareFailed come back in the response; any other code is an error.
+func (c *respConn)command(req *pb.CommandRequest) (*pb.CommandResponse,error){
+	group:=c.rs.svr.ownerOf(req.Key)
+	if group==nil{
+		return nil,c.codeError(nil,pb.ErrCode_ErrWrongGroup,-1)
+	}
+	if req.Op!=pb.OpType_OpGet{
+		s:=c.rs.svr.sessions.get()
+		defer c.rs.svr.sessions.put(s)
+		s.commandId++
+		req.ClientId,req.CommandId=s.clientId,s.commandId
+	}
+	res,err:=group.Command(context.Background(),req)
+	if err!=nil{
+		return nil,err
+	}
+	switch res.Err{
+	case pb.ErrCode_ErrOK,pb.ErrCode_ErrNoKey,pb.ErrCode_ErrCompareFailed:
+		return res,nil
+	}
+	return nil,c.codeError(group,res.Err,res.LeaderId)
+}
+
+func (c *respConn)get(key string) (*pb.CommandResponse,error){
+	return c.command(&pb.CommandRequest{Key:key,Op:pb.OpType_OpGet})
+}
+
+// grantLease makes a lease that nobody keeps alive, so the group leader
+// deletes whatever is attached to it after ttl seconds.
+func (c *respConn)grantLease(key string,ttl int64) (int64,error){
+	group:=c.rs.svr.ownerOf(key)
+	if group==nil{
+		return 0,c.codeError(nil,pb.ErrCode_ErrWrongGroup,-1)
+	}
+	res,err:=group.LeaseGrant(context.Background(),&pb.LeaseGrantRequest{Key:key,TTL:ttl})
+	if err!=nil{
+		return 0,err
+	}
+	if res.Err!=pb.ErrCode_ErrOK{
+		return 0,c.codeError(group,res.Err,res.LeaderId)
+	}
+	return res.LeaseId,nil
+}
+
+func respPing(c *respConn,args []string) error{
+	if len(args)>1{
+		c.writeBulk(args[1])
+	} else {
+		c.writeSimple("PONG")
+	}
+	return nil
+}
+
+func respSelect(c *respConn,args []string) error{
+	if args[1]!="0"{
+		return respError("ERR DB index is out of range")
+	}
+	c.writeSimple("OK")
+	return nil
+}
+
+func respEmptyArray(c *respConn,args []string) error{
+	c.writeArray(0)
+	return nil
+}
+
+func respGet(c *respConn,args []string) error{
+	res,err:=c.get(args[1])
+	if err!=nil{
+		return err
+	}
+	if res.Err==pb.ErrCode_ErrNoKey{
+		c.writeNull()
+	} else {
+		c.writeBulk(res.Value)
+	}
+	return nil
+}
+
+// respSet supports EX, PX and NX. A plain SET drops any expiry, as in Redis.
+func respSet(c *respConn,args []string) error{
+	key,value:=args[1],args[2]
+	var ttl int64
+	nx:=false
+	for i:=3;i<len(args);i++{
+		switch strings.ToLower(args[i]){
+		case "nx":
+			nx=true
+		case "ex","px":
+			if i+1>=len(args){
+				return respError("ERR syntax error")
+			}
+			n,err:=strconv.ParseInt(args[i+1],10,64)
+			if err!=nil || n<=0{
+				return respError("ERR invalid expire time in 'set' command")
+			}
+			if strings.ToLower(args[i])=="px"{
+				n=(n+999)/1000
+			}
+			ttl=n
+			i++
+		default:
+			return respError("ERR syntax error")
+		}
+	}
+	var leaseId int64
+	if ttl>0{
+		var err error
+		if leaseId,err=c.grantLease(key,ttl);err!=nil{
+			return err
+		}
+	}
+	op:=pb.OpType_OpPut
+	if nx{
+		op=pb.OpType_OpPutIfAbsent
+	}
+	res,err:=c.command(&pb.CommandRequest{Key:key,Value:value,Op:op,LeaseId:leaseId})
+	if err!=nil{
+		return err
+	}
+	if res.Err==pb.ErrCode_ErrCompareFailed{
+		c.writeNull()
+	} else {
+		c.writeSimple("OK")
+	}
+	return nil
+}
+
+// del deletes key if it exists and tells whether it did, deleting only the
+// version it read so the answer is exact.
+func (c *respConn)del(key string) (bool,error){
+	res,err:=c.get(key)
+	for err==nil && res.Err!=pb.ErrCode_ErrNoKey{
+		res,err=c.command(&pb.CommandRequest{Key:key,Op:pb.OpType_OpDeleteIfEquals,ExpectedValue:res.Value,ExpectedVersion:res.Version})
+		if err==nil && res.Err==pb.ErrCode_ErrOK{
+			return true,nil
+		}
+	}
+	return false,err
+}
+
+func respDel(c *respConn,args []string) error{
+	var n int64
+	for _,key:=range args[1:]{
+		ok,err:=c.del(key)
+		if err!=nil{
+			return err
+		}
+		if ok{
+			n++
+		}
+	}
+	c.writeInt(n)
+	return nil
+}
+
+func respExists(c *respConn,args []string) error{
+	var n int64
+	for _,key:=range args[1:]{
+		res,err:=c.get(key)
+		if err!=nil{
+			return err
+		}
+		if res.Err==pb.ErrCode_ErrOK{
+			n++
+		}
+	}
+	c.writeInt(n)
+	return nil
+}
+
+func respIncrBy(c *respConn,args []string) error{
+	delta,err:=strconv.ParseInt(args[2],10,64)
+	if err!=nil{
+		return respError("ERR value is not an integer or out of range")
+	}
+	if strings.ToLower(args[0])=="decrby"{
+		delta=-delta
+	}
+	return c.incrBy(args[1],delta)
+}
+
+// incrBy is a read followed by a compare-and-swap on the version read, retried
+// until no other write got in between. The key keeps its expiry.
+func (c *respConn)incrBy(key string,delta int64) error{
+	for{
+		res,err:=c.get(key)
+		if err!=nil{
+			return err
+		}
+		var n int64
+		req:=&pb.CommandRequest{Key:key,Op:pb.OpType_OpPutIfAbsent}
+		if res.Err==pb.ErrCode_ErrOK{
+			if n,err=strconv.ParseInt(res.Value,10,64);err!=nil{
+				return respError("ERR value is not an integer or out of range")
+			}
+			req.Op,req.ExpectedVersion=pb.OpType_OpCas,res.Version
+		}
+		if (delta>0 && n>0 && n+delta<n) || (delta<0 && n<0 && n+delta>n){
+			return respError("ERR increment or decrement would overflow")
+		}
+		n+=delta
+		req.Value=strconv.FormatInt(n,10)
+		res,err=c.command(req)
+		if err!=nil{
+			return err
+		}
+		if res.Err==pb.ErrCode_ErrOK{
+			c.writeInt(n)
+			return nil
+		}
+	}
+}
+
+func respMget(c *respConn,args []string) error{
+	values:=make([]*pb.CommandResponse,0,len(args)-1)
+	for _,key:=range args[1:]{
+		res,err:=c.get(key)
+		if err!=nil{
+			return err
+		}
+		values=append(values,res)
+	}
+	c.writeArray(len(values))
+	for _,res:=range values{
+		if res.Err==pb.ErrCode_ErrNoKey{
+			c.writeNull()
+		} else {
+			c.writeBulk(res.Value)
+		}
+	}
+	return nil
+}
+
+// respExpire rewrites the value it read attached to a fresh lease of seconds,
+// so the expiry is replicated and carried out by the group leader.
+func respExpire(c *respConn,args []string) error{
+	key:=args[1]
+	seconds,err:=strconv.ParseInt(args[2],10,64)
+	if err!=nil{
+		return respError("ERR value is not an integer or out of range")
+	}
+	if seconds<=0{
+		ok,err:=c.del(key)
+		if err!=nil{
+			return err
+		}
+		if ok{
+			c.writeInt(1)
+		} else {
+			c.writeInt(0)
+		}
+		return nil
+	}
+	res,err:=c.get(key)
+	if err!=nil{
+		return err
+	}
+	if res.Err==pb.ErrCode_ErrNoKey{
+		c.writeInt(0)
+		return nil
+	}
+	leaseId,err:=c.grantLease(key,seconds)
+	if err!=nil{
+		return err
+	}
+	for res.Err!=pb.ErrCode_ErrNoKey{
+		res,err=c.command(&pb.CommandRequest{Key:key,Value:res.Value,Op:pb.OpType_OpCas,ExpectedVersion:res.Version,LeaseId:leaseId})
+		if err!=nil{
+			return err
+		}
+		if res.Err==pb.ErrCode_ErrOK{
+			c.writeInt(1)
+			return nil
+		}
+		if res,err=c.get(key);err!=nil{
+			return err
+		}
+	}
+	c.writeInt(0)
+	return nil
+}
+
+// respScan pages through the key space with a consistent snapshot per scan.
+// Cursors are handles on page tokens kept by the connection, at most
+// RespMaxCursors of them: opening one more forgets the oldest. MATCH filters
+// each page after it is read, so a page may come back short or empty.
+func respScan(c *respConn,args []string) error{
+	var token []byte
+	if args[1]!="0"{
+		id,err:=strconv.ParseInt(args[1],10,64)
+		if t,ok:=c.cursors[id];err==nil && ok{
+			token=t
+			delete(c.cursors,id)
+		} else {
+			return respError("ERR invalid cursor")
+		}
+	}
+	count:=int64(RespScanCount)
+	var match *regexp.Regexp
+	for i:=2;i<len(args);i+=2{
+		if i+1>=len(args){
+			return respError("ERR syntax error")
+		}
+		switch strings.ToLower(args[i]){
+		case "count":
+			n,err:=strconv.ParseInt(args[i+1],10,64)
+			if err!=nil || n<=0{
+				return respError("ERR syntax error")
+			}
+			count=n
+		case "match":
+			re,err:=globRegexp(args[i+1])
+			if err!=nil{
+				return respError("ERR invalid pattern")
+			}
+			match=re
+		default:
+			return respError("ERR syntax error")
+		}
+	}
+	res,group,err:=c.rs.svr.scanPage(context.Background(),&pb.ScanRequest{Limit:count,PageToken:token})
+	if err!=nil{
+		return err
+	}
+	if res.Err!=pb.ErrCode_ErrOK{
+		return c.codeError(group,res.Err,res.LeaderId)
+	}
+	cursor:="0"
+	if len(res.NextPageToken)>0{
+		c.nextCursor++
+		c.cursors[c.nextCursor]=res.NextPageToken
+		if len(c.cursors)>RespMaxCursors{
+			oldest:=c.nextCursor
+			for id:=range c.cursors{
+				oldest=min(oldest,id)
+			}
+			delete(c.cursors,oldest)
+		}
+		cursor=strconv.FormatInt(c.nextCursor,10)
+	}
+	keys:=make([]string,0,len(res.Kvs))
+	for _,kv:=range res.Kvs{
+		if match==nil || match.MatchString(kv.Key){
+			keys=append(keys,kv.Key)
+		}
+	}
+	c.writeArray(2)
+	c.writeBulk(cursor)
+	c.writeArray(len(keys))
+	for _,key:=range keys{
+		c.writeBulk(key)
+	}
+	return nil
+}
+
+// globRegexp compiles a Redis glob: *, ?, [...] with ^ or ! negation, and
+// backslash escapes.
+func globRegexp(pattern string) (*regexp.Regexp,error){
+	var sb strings.Builder
+	sb.WriteString("^(?s:")
+	for i:=0;i<len(pattern);i++{
+		switch ch:=pattern[i];ch{
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '\\':
+			if i+1<len(pattern){
+				i++
+			}
+			sb.WriteString(regexp.QuoteMeta(pattern[i:i+1]))
+		case '[':
+			end:=strings.IndexByte(pattern[i+1:],']')
+			if end<0{
+				sb.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class:=pattern[i+1:i+1+end]
+			sb.WriteString("[")
+			if strings.HasPrefix(class,"^") || strings.HasPrefix(class,"!"){
+				sb.WriteString("^")
+				class=class[1:]
+			}
+			sb.WriteString(strings.ReplaceAll(class,"\\","\\\\"))
+			sb.WriteString("]")
+			i+=end+1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	sb.WriteString(")$")
+	return regexp.Compile(sb.String())
+}
+
+// ServeResp listens on addr and serves RESP until the listener fails.
+func ServeResp(svr *ShardServer,addr string,advertise func(addr string) string) error{
+	lis,err:=net.Listen("tcp",addr)
+	if err!=nil{
+		return err
+	}
+	svr.logger().Info("serving RESP","addr",addr)
+	go MakeRespServer(svr,advertise).Serve(lis)
+	return nil
+}
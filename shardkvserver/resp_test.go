@@ -0,0 +1,245 @@
+package shardkvserver
+
+import(
+	"io"
+	"net"
+	"bufio"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type respClient struct{
+	t *testing.T
+	conn net.Conn
+	r *bufio.Reader
+}
+
+func dialResp(t *testing.T,svr *ShardServer) *respClient{
+	t.Helper()
+	lis,err:=net.Listen("tcp","127.0.0.1:0")
+	if err!=nil{
+		t.Fatal(err)
+	}
+	t.Cleanup(func(){ lis.Close() })
+	go MakeRespServer(svr,func(addr string) string{ return "resp-"+addr }).Serve(lis)
+	conn,err:=net.Dial("tcp",lis.Addr().String())
+	if err!=nil{
+		t.Fatal(err)
+	}
+	t.Cleanup(func(){ conn.Close() })
+	return &respClient{t:t,conn:conn,r:bufio.NewReader(conn)}
+}
+
+func (rc *respClient)send(args ...string){
+	var sb strings.Builder
+	sb.WriteString("*"+strconv.Itoa(len(args))+"\r\n")
+	for _,arg:=range args{
+		sb.WriteString("$"+strconv.Itoa(len(arg))+"\r\n"+arg+"\r\n")
+	}
+	if _,err:=rc.conn.Write([]byte(sb.String()));err!=nil{
+		rc.t.Fatal(err)
+	}
+}
+
+// reply reads one reply: a string for simple strings and bulks, "-..." for
+// errors, an int64, nil for a null bulk, or a []any.
+func (rc *respClient)reply() any{
+	rc.t.Helper()
+	rc.conn.SetReadDeadline(time.Now().Add(10*time.Second))
+	line,err:=rc.r.ReadString('\n')
+	if err!=nil{
+		rc.t.Fatal(err)
+	}
+	line=strings.TrimSuffix(line,"\r\n")
+	switch line[0]{
+	case '+':
+		return line[1:]
+	case '-':
+		return line
+	case ':':
+		n,_:=strconv.ParseInt(line[1:],10,64)
+		return n
+	case '$':
+		n,_:=strconv.Atoi(line[1:])
+		if n<0{
+			return nil
+		}
+		buf:=make([]byte,n+2)
+		if _,err:=io.ReadFull(rc.r,buf);err!=nil{
+			rc.t.Fatal(err)
+		}
+		return string(buf[:n])
+	case '*':
+		n,_:=strconv.Atoi(line[1:])
+		items:=make([]any,n)
+		for i:=range items{
+			items[i]=rc.reply()
+		}
+		return items
+	}
+	rc.t.Fatalf("bad reply %q",line)
+	return nil
+}
+
+func (rc *respClient)do(want any,args ...string){
+	rc.t.Helper()
+	rc.send(args...)
+	if got:=rc.reply();!reflect.DeepEqual(got,want){
+		rc.t.Fatalf("%v: got %#v, want %#v",args,got,want)
+	}
+}
+
+func TestRespCommands(t *testing.T){
+	svrs,_:=startTestServers(t,1,oneRangeConfig)
+	rc:=dialResp(t,svrs[0])
+
+	rc.do("PONG","PING")
+	rc.do("hi","ECHO","hi")
+	rc.do(nil,"GET","a")
+	rc.do("OK","SET","a","1")
+	rc.do("1","GET","a")
+	rc.do(nil,"SET","a","2","NX")
+	rc.do(int64(2),"INCR","a")
+	rc.do(int64(-3),"DECRBY","a","5")
+	rc.do(int64(1),"INCR","n")
+	rc.do("-ERR value is not an integer or out of range","INCRBY","a","x")
+	rc.do([]any{"-3",nil,"1"},"MGET","a","b","n")
+	rc.do(int64(2),"EXISTS","a","b","n")
+	rc.do(int64(2),"DEL","a","b","n")
+	rc.do(int64(0),"EXISTS","a","n")
+	rc.do("-ERR unknown command 'NOPE'","NOPE")
+	rc.do("-ERR wrong number of arguments for 'get' command","GET")
+
+	// pipelined and inline commands are answered in order
+	rc.conn.Write([]byte("*3\r\n$3\r\nSET\r\n$1\r\np\r\n$1\r\nx\r\nGET p\r\n"))
+	if got,got2:=rc.reply(),rc.reply();got!="OK" || got2!="x"{
+		t.Fatalf("pipeline: %#v %#v",got,got2)
+	}
+
+	rc.do("OK","SET","t","v","PX","500")
+	deadline:=time.Now().Add(10*time.Second)
+	for{
+		rc.send("GET","t")
+		if rc.reply()==nil{
+			break
+		}
+		if time.Now().After(deadline){
+			t.Fatal("SET PX never expired")
+		}
+		time.Sleep(100*time.Millisecond)
+	}
+	rc.do(int64(0),"EXPIRE","t","1")
+	rc.do(int64(1),"EXPIRE","p","0")
+}
+
+func TestRespScan(t *testing.T){
+	svrs,_:=startTestServers(t,1,oneRangeConfig)
+	rc:=dialResp(t,svrs[0])
+	want:=[]string{}
+	for _,key:=range testKeys(25){
+		rc.do("OK","SET",key,"v")
+		if strings.HasSuffix(key,"1"){
+			want=append(want,key)
+		}
+	}
+	var got []string
+	cursor:="0"
+	for pages:=0;;pages++{
+		rc.send("SCAN",cursor,"MATCH","*1","COUNT","4")
+		page:=rc.reply().([]any)
+		for _,key:=range page[1].([]any){
+			got=append(got,key.(string))
+		}
+		if cursor=page[0].(string);cursor=="0"{
+			break
+		}
+		if pages>25{
+			t.Fatal("scan does not end")
+		}
+	}
+	checkKeys(t,"scan",got,want)
+	rc.do("-ERR invalid cursor","SCAN","12345")
+}
+
+func TestRespNotLeader(t *testing.T){
+	svrs,addrs:=startTestServers(t,2,oneRangeConfig)
+	follower:=svrs[0]
+	if _,isLeader:=follower.getGroup(1).raft.GetState();isLeader{
+		follower=svrs[1]
+	}
+	leaderId:=follower.getGroup(1).raft.GetLeaderId()
+	rc:=dialResp(t,follower)
+	rc.do("-NOTLEADER resp-"+addrs[leaderId],"SET","k","v")
+}
+
+func TestRespReusesClientIds(t *testing.T){
+	svrs,_:=startTestServers(t,1,oneRangeConfig)
+	group:=svrs[0].getGroup(1)
+	clients:=func() int{
+		group.mu.RLock()
+		defer group.mu.RUnlock()
+		return len(group.lastOperations)
+	}
+	before:=clients()
+	for i:=0;i<5;i++{
+		rc:=dialResp(t,svrs[0])
+		rc.do("OK","SET","k"+strconv.Itoa(i),"v")
+		rc.do(int64(i+1),"INCR","n")
+		rc.conn.Close()
+	}
+	if n:=clients()-before;n!=1{
+		t.Fatalf("%d client ids added to the dedup table by serial writes",n)
+	}
+}
+
+func TestRespCursorLimit(t *testing.T){
+	svrs,_:=startTestServers(t,1,oneRangeConfig)
+	rc:=dialResp(t,svrs[0])
+	for _,key:=range testKeys(3){
+		rc.do("OK","SET",key,"v")
+	}
+	var cursors []string
+	for i:=0;i<=RespMaxCursors;i++{
+		rc.send("SCAN","0","COUNT","1")
+		cursors=append(cursors,rc.reply().([]any)[0].(string))
+	}
+	rc.do("-ERR invalid cursor","SCAN",cursors[0])
+	rc.send("SCAN",cursors[len(cursors)-1],"COUNT","1")
+	if page:=rc.reply().([]any);len(page[1].([]any))!=1{
+		t.Fatalf("newest cursor: %#v",page)
+	}
+}
+
+func TestGlobRegexp(t *testing.T){
+	for _,c:=range []struct{
+		pattern string
+		match []string
+		miss []string
+	}{
+		{"*",[]string{"","a","a\nb"},nil},
+		{"a?c",[]string{"abc","a.c"},[]string{"ac","abbc"}},
+		{"k[0-2]*",[]string{"k0","k21"},[]string{"k3","k"}},
+		{"k[^0-2]",[]string{"k3"},[]string{"k1"}},
+		{"k[!a]",[]string{"kb"},[]string{"ka"}},
+		{"a\\*",[]string{"a*"},[]string{"ab"}},
+		{"a.b[",[]string{"a.b["},[]string{"axb["}},
+	}{
+		re,err:=globRegexp(c.pattern)
+		if err!=nil{
+			t.Fatalf("%q: %v",c.pattern,err)
+		}
+		for _,s:=range c.match{
+			if !re.MatchString(s){
+				t.Errorf("%q should match %q",c.pattern,s)
+			}
+		}
+		for _,s:=range c.miss{
+			if re.MatchString(s){
+				t.Errorf("%q should not match %q",c.pattern,s)
+			}
+		}
+	}
+}
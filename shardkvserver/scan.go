@@ -0,0 +1,579 @@
+package shardkvserver
+
+import(
+	"io"
+	"sort"
+	"time"
+	"context"
+	"bytes"
+	"encoding/gob"
+
+	"google.golang.org/grpc"
+	pb "neweraft/raftpb"
+	"neweraft/storage"
+)
+
+var ScanSnapshotTTL = 30*time.Second
+
+const ScanBatchSize = 128
+
+// scanSnapshot pins the group state so every page of a paginated scan reads
+// the same versions as of index. A range group reads rng, a hash-sharded
+// group the shards it owned at index.
+type scanSnapshot struct{
+	index int64
+	rng *KeyRange
+	shards []*Shard
+	snap storage.KvSnapshot
+	expire time.Time
+}
+
+// scanToken is handed back to the client as an opaque page token. Gid 0 means
+// the next page starts in another range and gets a fresh read. Groups is set
+// instead for a scan merged over hash-sharded groups and holds the index each
+// group is read at.
+type scanToken struct{
+	Gid int64
+	AppliedIndex int64
+	NextKey string
+	Groups map[int64]int64
+}
+
+func encodeScanToken(token *scanToken) []byte{
+	var buf bytes.Buffer
+	gob.NewEncoder(&buf).Encode(token)
+	return buf.Bytes()
+}
+
+func decodeScanToken(data []byte) (*scanToken,error){
+	token:=&scanToken{}
+	err:=gob.NewDecoder(bytes.NewBuffer(data)).Decode(token)
+	return token,err
+}
+
+func (kr *KeyRange) ContainsEnd(end string) bool{
+	if end==""{
+		return kr.EndKey==""
+	}
+	return end>kr.StartKey && (kr.EndKey=="" || end<=kr.EndKey)
+}
+
+func (sg *ShardGroup)ownsScan(startKey string,endKey string,reverse bool) bool{
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	if sg.rng==nil || sg.rng.Frozen{
+		return false
+	}
+	if reverse{
+		return sg.rng.ContainsEnd(endKey)
+	}
+	return sg.rng.Contains(startKey)
+}
+
+func (sg *ShardGroup)releaseExpiredScans(){
+	now:=time.Now()
+	for index,ss:=range sg.scanSnaps{
+		if now.After(ss.expire){
+			ss.snap.Release()
+			delete(sg.scanSnaps,index)
+		}
+	}
+}
+
+// getScanSnapshot returns a snapshot to read the group at index, or at the
+// latest applied index when index is 0. Pages of a running scan find theirs
+// in scanSnaps; an index below the MVCC compaction point is ErrOutDated, and
+// a hash-sharded group still pulling one of its shards is ErrNotReady.
+func (sg *ShardGroup)getScanSnapshot(index int64) (*scanSnapshot,pb.ErrCode){
+	sg.mu.Lock()
+	sg.releaseExpiredScans()
+	if ss,ok:=sg.scanSnaps[index];ok{
+		ss.expire=time.Now().Add(ScanSnapshotTTL)
+		sg.mu.Unlock()
+		return ss,pb.ErrCode_ErrOK
+	}
+	sg.mu.Unlock()
+
+	if res:=sg.Execute(&Command{Type:CmdEmptyEntry});res.Err!=pb.ErrCode_ErrOK{
+		return nil,res.Err
+	}
+	deadline:=time.Now().Add(ExecuteTimeout)
+	for{
+		sg.mu.Lock()
+		if index>0 && index<sg.compactedIndex{
+			sg.mu.Unlock()
+			return nil,pb.ErrCode_ErrOutDated
+		}
+		if sg.lastApplied>=index{
+			break
+		}
+		sg.mu.Unlock()
+		if time.Now().After(deadline){
+			return nil,pb.ErrCode_ErrTimeout
+		}
+		time.Sleep(10*time.Millisecond)
+	}
+	defer sg.mu.Unlock()
+	if sg.rng!=nil && sg.rng.Frozen{
+		return nil,pb.ErrCode_ErrWrongGroup
+	}
+	var shards []*Shard
+	if sg.rng==nil{
+		for shardId,gid:=range sg.curConfig.Shards{
+			if gid!=sg.gid{
+				continue
+			}
+			if !sg.canServe(shardId){
+				return nil,pb.ErrCode_ErrNotReady
+			}
+			shards=append(shards,sg.shards[shardId])
+		}
+	}
+	if index>0 && index<sg.readFloor(shards...){
+		return nil,pb.ErrCode_ErrOutDated
+	}
+	snap,err:=sg.dataEng.GetSnapshot()
+	if err!=nil{
+		return nil,pb.ErrCode_ErrNotReady
+	}
+	readIndex:=sg.lastApplied
+	if index>0{
+		readIndex=index
+	}
+	if ss,ok:=sg.scanSnaps[readIndex];ok{
+		snap.Release()
+		return ss,pb.ErrCode_ErrOK
+	}
+	ss:=&scanSnapshot{
+		index:readIndex,
+		shards:shards,
+		snap:snap,
+		expire:time.Now().Add(ScanSnapshotTTL),
+	}
+	if sg.rng!=nil{
+		ss.rng=sg.rng.Copy()
+	}
+	sg.scanSnaps[ss.index]=ss
+	return ss,pb.ErrCode_ErrOK
+}
+
+// scanIter walks one or more shards as a single iterator in key order, with
+// the shard prefixes trimmed from the keys. A key lives in one shard only.
+type scanIter struct{
+	iters []*storage.MvccIterator
+	prefixes []string
+	valid []bool
+	reverse bool
+	cur int
+}
+
+func makeScanIter(r storage.KvReader,shards []*Shard,start string,end string,index int64,reverse bool) *scanIter{
+	it:=&scanIter{reverse:reverse,cur:-1}
+	for _,shard:=range shards{
+		iter:=shard.Scan(r,start,end,index,reverse)
+		it.iters=append(it.iters,iter)
+		it.prefixes=append(it.prefixes,shard.prefix())
+		it.valid=append(it.valid,iter.Next())
+	}
+	return it
+}
+
+func (it *scanIter) key(i int) string{
+	return it.iters[i].Key()[len(it.prefixes[i]):]
+}
+
+func (it *scanIter) Next() bool{
+	if it.cur>=0{
+		it.valid[it.cur]=it.iters[it.cur].Next()
+	}
+	it.cur=-1
+	for i:=range it.iters{
+		if it.valid[i] && (it.cur<0 || (it.key(i)<it.key(it.cur))!=it.reverse){
+			it.cur=i
+		}
+	}
+	return it.cur>=0
+}
+
+func (it *scanIter) Key() string{
+	return it.key(it.cur)
+}
+
+func (it *scanIter) Value() string{
+	return it.iters[it.cur].Value()
+}
+
+func (it *scanIter) CommitIndex() int64{
+	return it.iters[it.cur].CommitIndex()
+}
+
+func (it *scanIter) Error() error{
+	for _,iter:=range it.iters{
+		if err:=iter.Error();err!=nil{
+			return err
+		}
+	}
+	return nil
+}
+
+func (it *scanIter) Release(){
+	for _,iter:=range it.iters{
+		iter.Release()
+	}
+}
+
+func (sg *ShardGroup)Scan(req *pb.ScanRequest,stream grpc.ServerStreamingServer[pb.ScanResponse]) error{
+	if _,isLeader:=sg.raft.GetState();!isLeader{
+		return stream.Send(&pb.ScanResponse{Err:pb.ErrCode_ErrWrongLeader,LeaderId:sg.raft.GetLeaderId()})
+	}
+	startKey,endKey,index:=req.StartKey,req.EndKey,req.AppliedIndex
+	if len(req.PageToken)>0{
+		token,err:=decodeScanToken(req.PageToken)
+		if err!=nil || (token.Gid!=0 && token.Gid!=sg.gid){
+			return stream.Send(&pb.ScanResponse{Err:pb.ErrCode_ErrWrongGroup})
+		}
+		index=token.AppliedIndex
+		if req.Reverse{
+			endKey=token.NextKey
+		} else {
+			startKey=token.NextKey
+		}
+	}
+	ss,errCode:=sg.getScanSnapshot(index)
+	if errCode!=pb.ErrCode_ErrOK{
+		return stream.Send(&pb.ScanResponse{Err:errCode,LeaderId:sg.raft.GetLeaderId()})
+	}
+
+	lo,hi:=startKey,endKey
+	shards:=ss.shards
+	if ss.rng!=nil{
+		if lo<ss.rng.StartKey{
+			lo=ss.rng.StartKey
+		}
+		if ss.rng.EndKey!="" && (hi=="" || hi>ss.rng.EndKey){
+			hi=ss.rng.EndKey
+		}
+		shards=[]*Shard{sg.rangeShard}
+	}
+	iter:=makeScanIter(ss.snap,shards,lo,hi,ss.index,req.Reverse)
+	defer iter.Release()
+
+	res:=&pb.ScanResponse{Err:pb.ErrCode_ErrOK,AppliedIndex:ss.index}
+	var count int64
+	var lastKey string
+	var next *scanToken
+	for iter.Next(){
+		key:=iter.Key()
+		if req.Limit>0 && count>=req.Limit{
+			next=&scanToken{Gid:sg.gid,AppliedIndex:ss.index,NextKey:key}
+			if req.Reverse{
+				next.NextKey=lastKey
+			}
+			break
+		}
+		version,value:=decodeValue(iter.Value())
+		res.Kvs=append(res.Kvs,&pb.KeyValue{Key:key,Value:value,Version:version,ModIndex:iter.CommitIndex()})
+		lastKey=key
+		count++
+		if len(res.Kvs)>=ScanBatchSize && (req.Limit==0 || count<req.Limit){
+			if err:=stream.Send(res);err!=nil{
+				return err
+			}
+			res=&pb.ScanResponse{Err:pb.ErrCode_ErrOK,AppliedIndex:ss.index}
+		}
+	}
+	if err:=iter.Error();err!=nil{
+		return err
+	}
+	if next==nil && ss.rng!=nil{
+		if !req.Reverse && ss.rng.EndKey!="" && (endKey=="" || ss.rng.EndKey<endKey){
+			next=&scanToken{NextKey:ss.rng.EndKey}
+		}
+		if req.Reverse && ss.rng.StartKey>startKey{
+			next=&scanToken{NextKey:ss.rng.StartKey}
+		}
+	}
+	if next!=nil{
+		res.NextPageToken=encodeScanToken(next)
+	}
+	return stream.Send(res)
+}
+
+// scanGroup picks the local group serving the page req asks for, or returns
+// the error to answer with. A token naming a group this server does not host
+// is ErrWrongGroup, so the page is tried elsewhere, unless the group was
+// merged away here.
+func (shardsvr *ShardServer)scanGroup(req *pb.ScanRequest) (*ShardGroup,pb.ErrCode){
+	startKey,endKey:=req.StartKey,req.EndKey
+	if len(req.PageToken)>0{
+		token,err:=decodeScanToken(req.PageToken)
+		if err!=nil{
+			return nil,pb.ErrCode_ErrWrongGroup
+		}
+		if token.Gid!=0{
+			if group:=shardsvr.getGroup(token.Gid);group!=nil{
+				return group,pb.ErrCode_ErrOK
+			}
+			if meta,ok:=shardsvr.loadRangeRegistry()[token.Gid];ok && meta.Removed{
+				return nil,pb.ErrCode_ErrOutDated
+			}
+			return nil,pb.ErrCode_ErrWrongGroup
+		}
+		if req.Reverse{
+			endKey=token.NextKey
+		} else {
+			startKey=token.NextKey
+		}
+	}
+	for _,group:=range shardsvr.getGroups(){
+		if group.ownsScan(startKey,endKey,req.Reverse){
+			return group,pb.ErrCode_ErrOK
+		}
+	}
+	return nil,pb.ErrCode_ErrWrongGroup
+}
+
+// spreadScan tells whether req scans hash-sharded groups and returns them
+// with their servers. Each such group holds keys from the whole key space, so
+// no one of them can serve a page alone.
+func (shardsvr *ShardServer)spreadScan(req *pb.ScanRequest) (map[int64][]string,bool,pb.ErrCode){
+	if len(req.PageToken)>0{
+		token,err:=decodeScanToken(req.PageToken)
+		if err!=nil || len(token.Groups)==0{
+			return nil,false,pb.ErrCode_ErrOK
+		}
+	} else {
+		for _,group:=range shardsvr.getGroups(){
+			if group.isRangeGroup(){
+				return nil,false,pb.ErrCode_ErrOK
+			}
+		}
+	}
+	config,err:=shardsvr.ctrler.Query(-1)
+	if err!=nil{
+		return nil,true,pb.ErrCode_ErrNotReady
+	}
+	if len(req.PageToken)==0 && (len(config.Ranges)>0 || len(config.Groups)==0){
+		return nil,false,pb.ErrCode_ErrOK
+	}
+	return config.Groups,true,pb.ErrCode_ErrOK
+}
+
+// scanGroups merges a page of every hash-sharded group into one page in key
+// order. Each group is read at its own applied index, which the page token
+// pins for the following pages as it does for a range.
+func (shardsvr *ShardServer)scanGroups(ctx context.Context,req *pb.ScanRequest,groups map[int64][]string) (*pb.ScanResponse,error){
+	if req.AppliedIndex>0{
+		return &pb.ScanResponse{Err:pb.ErrCode_ErrBadRequest},nil
+	}
+	startKey,endKey:=req.StartKey,req.EndKey
+	indexes:=make(map[int64]int64)
+	for gid:=range groups{
+		indexes[gid]=0
+	}
+	if len(req.PageToken)>0{
+		token,_:=decodeScanToken(req.PageToken)
+		indexes=token.Groups
+		if req.Reverse{
+			endKey=token.NextKey
+		} else {
+			startKey=token.NextKey
+		}
+	}
+	res:=&pb.ScanResponse{Err:pb.ErrCode_ErrOK}
+	more:=false
+	for gid,index:=range indexes{
+		from:=startKey
+		if req.Reverse{
+			from=endKey
+		}
+		sub:=&pb.ScanRequest{StartKey:startKey,EndKey:endKey,Limit:req.Limit,Reverse:req.Reverse,
+			PageToken:encodeScanToken(&scanToken{Gid:gid,AppliedIndex:index,NextKey:from})}
+		page,err:=shardsvr.scanGroupPage(ctx,gid,groups[gid],sub)
+		if err!=nil{
+			return nil,err
+		}
+		if page.Err!=pb.ErrCode_ErrOK{
+			return &pb.ScanResponse{Err:page.Err},nil
+		}
+		indexes[gid]=page.AppliedIndex
+		res.Kvs=append(res.Kvs,page.Kvs...)
+		more=more || len(page.NextPageToken)>0
+	}
+	sort.Slice(res.Kvs,func(i,j int) bool{
+		if req.Reverse{
+			return res.Kvs[i].Key>res.Kvs[j].Key
+		}
+		return res.Kvs[i].Key<res.Kvs[j].Key
+	})
+	if req.Limit>0 && int64(len(res.Kvs))>req.Limit{
+		res.Kvs=res.Kvs[:req.Limit]
+		more=true
+	}
+	if more && len(res.Kvs)>0{
+		last:=res.Kvs[len(res.Kvs)-1].Key
+		next:=&scanToken{NextKey:last+"\x00",Groups:indexes}
+		if req.Reverse{
+			next.NextKey=last
+		}
+		res.NextPageToken=encodeScanToken(next)
+	}
+	return res,nil
+}
+
+// scanGroupPage reads sub from group gid, locally when this server hosts it,
+// else from its servers in turn until its leader answers.
+func (shardsvr *ShardServer)scanGroupPage(ctx context.Context,gid int64,servers []string,sub *pb.ScanRequest) (*pb.ScanResponse,error){
+	code:=pb.ErrCode_ErrOutDated
+	if group:=shardsvr.getGroup(gid);group!=nil{
+		sc:=&scanCollector{ctx:ctx}
+		if err:=group.Scan(sub,sc);err!=nil{
+			return nil,err
+		}
+		if sc.res.Err==pb.ErrCode_ErrOK{
+			return sc.res,nil
+		}
+		code=sc.res.Err
+	}
+	for _,server:=range servers{
+		if server==shardsvr.addr{
+			continue
+		}
+		res:=shardsvr.remoteScan(ctx,server,sub)
+		if res.Err==pb.ErrCode_ErrOK{
+			return res,nil
+		}
+		code=res.Err
+	}
+	return &pb.ScanResponse{Err:code},nil
+}
+
+// forwardScan hands req to the other servers of the cluster in turn until
+// one serves it. Only the front ends forward: a gRPC client retries
+// ErrWrongGroup on its next server by itself.
+func (shardsvr *ShardServer)forwardScan(ctx context.Context,req *pb.ScanRequest) *pb.ScanResponse{
+	config,err:=shardsvr.ctrler.Query(-1)
+	if err!=nil{
+		return &pb.ScanResponse{Err:pb.ErrCode_ErrNotReady}
+	}
+	code:=pb.ErrCode_ErrWrongGroup
+	tried:=map[string]bool{shardsvr.addr:true}
+	for _,servers:=range config.Groups{
+		for _,server:=range servers{
+			if tried[server]{
+				continue
+			}
+			tried[server]=true
+			res:=shardsvr.remoteScan(ctx,server,req)
+			switch res.Err{
+			case pb.ErrCode_ErrWrongGroup:
+			case pb.ErrCode_ErrWrongLeader,pb.ErrCode_ErrTimeout,pb.ErrCode_ErrNotReady:
+				code=res.Err
+			default:
+				return res
+			}
+		}
+	}
+	return &pb.ScanResponse{Err:code}
+}
+
+// remoteScan reads one page of req from another server.
+func (shardsvr *ShardServer)remoteScan(ctx context.Context,server string,req *pb.ScanRequest) *pb.ScanResponse{
+	cli:=shardsvr.getSvrClient(server)
+	if cli==nil{
+		return &pb.ScanResponse{Err:pb.ErrCode_ErrTimeout}
+	}
+	ctx,cancel:=context.WithTimeout(ctx,ExecuteTimeout)
+	defer cancel()
+	stream,err:=cli.Scan(ctx,req)
+	if err!=nil{
+		return &pb.ScanResponse{Err:pb.ErrCode_ErrTimeout}
+	}
+	sc:=&scanCollector{ctx:ctx}
+	for{
+		res,err:=stream.Recv()
+		if err==io.EOF && sc.res!=nil{
+			return sc.res
+		}
+		if err!=nil{
+			return &pb.ScanResponse{Err:pb.ErrCode_ErrTimeout}
+		}
+		sc.Send(res)
+	}
+}
+
+// sendScanPage streams a page gathered in one response, ScanBatchSize keys
+// at a time, the page token riding on the last batch.
+func sendScanPage(stream grpc.ServerStreamingServer[pb.ScanResponse],res *pb.ScanResponse) error{
+	for len(res.Kvs)>ScanBatchSize{
+		if err:=stream.Send(&pb.ScanResponse{Err:res.Err,AppliedIndex:res.AppliedIndex,Kvs:res.Kvs[:ScanBatchSize]});err!=nil{
+			return err
+		}
+		res.Kvs=res.Kvs[ScanBatchSize:]
+	}
+	return stream.Send(res)
+}
+
+func (shardsvr *ShardServer)Scan(req *pb.ScanRequest,stream grpc.ServerStreamingServer[pb.ScanResponse]) error{
+	if groups,spread,errCode:=shardsvr.spreadScan(req);spread{
+		if errCode!=pb.ErrCode_ErrOK{
+			return stream.Send(&pb.ScanResponse{Err:errCode})
+		}
+		res,err:=shardsvr.scanGroups(stream.Context(),req,groups)
+		if err!=nil{
+			return err
+		}
+		return sendScanPage(stream,res)
+	}
+	group,errCode:=shardsvr.scanGroup(req)
+	if group==nil{
+		return stream.Send(&pb.ScanResponse{Err:errCode})
+	}
+	return group.Scan(req,stream)
+}
+
+// scanCollector gathers the batches of one page for callers inside the
+// process, such as the RESP and HTTP front ends.
+type scanCollector struct{
+	grpc.ServerStream
+	ctx context.Context
+	res *pb.ScanResponse
+}
+
+func (sc *scanCollector)Context() context.Context{
+	return sc.ctx
+}
+
+func (sc *scanCollector)Send(res *pb.ScanResponse) error{
+	if sc.res==nil{
+		sc.res=res
+		return nil
+	}
+	sc.res.Kvs=append(sc.res.Kvs,res.Kvs...)
+	sc.res.Err,sc.res.LeaderId,sc.res.NextPageToken=res.Err,res.LeaderId,res.NextPageToken
+	return nil
+}
+
+// scanPage reads one page of req and returns it with the local group that
+// served it, nil when it was merged or forwarded from other servers.
+func (shardsvr *ShardServer)scanPage(ctx context.Context,req *pb.ScanRequest) (*pb.ScanResponse,*ShardGroup,error){
+	if groups,spread,errCode:=shardsvr.spreadScan(req);spread{
+		if errCode!=pb.ErrCode_ErrOK{
+			return &pb.ScanResponse{Err:errCode},nil,nil
+		}
+		res,err:=shardsvr.scanGroups(ctx,req,groups)
+		return res,nil,err
+	}
+	group,errCode:=shardsvr.scanGroup(req)
+	if group==nil{
+		if errCode==pb.ErrCode_ErrWrongGroup{
+			return shardsvr.forwardScan(ctx,req),nil,nil
+		}
+		return &pb.ScanResponse{Err:errCode},nil,nil
+	}
+	sc:=&scanCollector{ctx:ctx}
+	if err:=group.Scan(req,sc);err!=nil{
+		return nil,group,err
+	}
+	return sc.res,group,nil
+}
@@ -0,0 +1,208 @@
+package shardkvserver
+
+import(
+	"fmt"
+	"sort"
+	"time"
+	"context"
+	"testing"
+
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+)
+
+func rangeConfig(addrs []string) *Config{
+	return &Config{
+		Num:1,
+		Groups:map[int64][]string{1:{addrs[0]},2:{addrs[1]}},
+		Ranges:[]*KeyRange{{Gid:1,EndKey:"m"},{Gid:2,StartKey:"m"}},
+	}
+}
+
+func hashConfig(addrs []string) *Config{
+	config:=&Config{Num:1,Groups:map[int64][]string{1:{addrs[0]},2:{addrs[1]}}}
+	for i:=range config.Shards{
+		config.Shards[i]=int64(1+i%2)
+	}
+	return config
+}
+
+func putKeys(t *testing.T,addrs []string,keys []string){
+	t.Helper()
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+	for _,key:=range keys{
+		if _,err:=cli.Put(ctx,key,"v-"+key);err!=nil{
+			t.Fatalf("put %s: %v",key,err)
+		}
+	}
+}
+
+func testKeys(n int) []string{
+	keys:=make([]string,n)
+	for i:=range keys{
+		keys[i]=fmt.Sprintf("%c%02d",'a'+i%26,i)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// scanAll pages through req on svr, checking every page keeps to the limit.
+func scanAll(t *testing.T,svr *ShardServer,req *pb.ScanRequest) []string{
+	t.Helper()
+	keys:=[]string{}
+	for pages:=0;;pages++{
+		if pages>100{
+			t.Fatal("scan does not end")
+		}
+		res,_,err:=svr.scanPage(context.Background(),req)
+		if err!=nil{
+			t.Fatal(err)
+		}
+		if res.Err!=pb.ErrCode_ErrOK{
+			t.Fatalf("page %d: %v",pages,res.Err)
+		}
+		if req.Limit>0 && int64(len(res.Kvs))>req.Limit{
+			t.Fatalf("page %d has %d keys, limit %d",pages,len(res.Kvs),req.Limit)
+		}
+		for _,kv:=range res.Kvs{
+			if kv.Value!="v-"+kv.Key{
+				t.Fatalf("%s holds %q",kv.Key,kv.Value)
+			}
+			keys=append(keys,kv.Key)
+		}
+		if len(res.NextPageToken)==0{
+			return keys
+		}
+		req.PageToken=res.NextPageToken
+	}
+}
+
+func reversed(keys []string) []string{
+	rev:=make([]string,len(keys))
+	for i,key:=range keys{
+		rev[len(keys)-1-i]=key
+	}
+	return rev
+}
+
+func checkKeys(t *testing.T,what string,got []string,want []string){
+	t.Helper()
+	if fmt.Sprint(got)!=fmt.Sprint(want){
+		t.Fatalf("%s:\n got %v\nwant %v",what,got,want)
+	}
+}
+
+func between(keys []string,start string,end string) []string{
+	in:=[]string{}
+	for _,key:=range keys{
+		if key>=start && (end=="" || key<end){
+			in=append(in,key)
+		}
+	}
+	return in
+}
+
+func testScan(t *testing.T,svrs []*ShardServer,addrs []string,keys []string){
+	for _,limit:=range []int64{0,1,4,100}{
+		checkKeys(t,fmt.Sprintf("forward, limit %d",limit),scanAll(t,svrs[0],&pb.ScanRequest{Limit:limit}),keys)
+		checkKeys(t,fmt.Sprintf("reverse, limit %d",limit),scanAll(t,svrs[1],&pb.ScanRequest{Limit:limit,Reverse:true}),reversed(keys))
+	}
+	checkKeys(t,"bounded",scanAll(t,svrs[0],&pb.ScanRequest{StartKey:"c",EndKey:"q",Limit:3}),between(keys,"c","q"))
+	checkKeys(t,"bounded reverse",scanAll(t,svrs[0],&pb.ScanRequest{StartKey:"c",EndKey:"q",Limit:3,Reverse:true}),reversed(between(keys,"c","q")))
+
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),10*time.Second)
+	defer cancel()
+	kvs,err:=cli.Scan(ctx,"","",0)
+	if err!=nil{
+		t.Fatal(err)
+	}
+	got:=[]string{}
+	for _,kv:=range kvs{
+		got=append(got,kv.Key)
+	}
+	checkKeys(t,"client",got,keys)
+}
+
+func TestScanRanges(t *testing.T){
+	svrs,addrs:=startTestServers(t,2,rangeConfig)
+	keys:=testKeys(40)
+	putKeys(t,addrs,keys)
+	testScan(t,svrs,addrs,keys)
+
+	// server 0 only hosts [,m): a page starting at n is forwarded
+	res,group,err:=svrs[0].scanPage(context.Background(),&pb.ScanRequest{StartKey:"n"})
+	if err!=nil || res.Err!=pb.ErrCode_ErrOK || group!=nil{
+		t.Fatalf("forwarded scan: %v %v %v",res,group,err)
+	}
+	checkKeys(t,"forwarded",func() []string{
+		got:=[]string{}
+		for _,kv:=range res.Kvs{
+			got=append(got,kv.Key)
+		}
+		return got
+	}(),between(keys,"n",""))
+}
+
+func TestScanRangeSnapshot(t *testing.T){
+	svrs,addrs:=startTestServers(t,2,rangeConfig)
+	putKeys(t,addrs,[]string{"a1","a2","a3"})
+	req:=&pb.ScanRequest{Limit:1}
+	res,_,err:=svrs[0].scanPage(context.Background(),req)
+	if err!=nil || res.Err!=pb.ErrCode_ErrOK{
+		t.Fatal(res,err)
+	}
+	// written after the first page, so later pages of the scan miss it
+	putKeys(t,addrs,[]string{"a25"})
+	req.PageToken=res.NextPageToken
+	checkKeys(t,"pinned pages",scanAll(t,svrs[0],req),[]string{"a2","a3"})
+	checkKeys(t,"new scan",scanAll(t,svrs[0],&pb.ScanRequest{}),[]string{"a1","a2","a25","a3"})
+}
+
+func TestScanHashGroups(t *testing.T){
+	svrs,addrs:=startTestServers(t,2,hashConfig)
+	keys:=testKeys(40)
+	putKeys(t,addrs,keys)
+	testScan(t,svrs,addrs,keys)
+
+	res,_,err:=svrs[0].scanPage(context.Background(),&pb.ScanRequest{AppliedIndex:5})
+	if err!=nil || res.Err!=pb.ErrCode_ErrBadRequest{
+		t.Fatalf("scan at an index over hash groups: %v %v",res,err)
+	}
+}
+
+func TestScanIterMerge(t *testing.T){
+	svrs,addrs:=startTestServers(t,1,func(addrs []string) *Config{
+		config:=&Config{Num:1,Groups:map[int64][]string{1:{addrs[0]}}}
+		for i:=range config.Shards{
+			config.Shards[i]=1
+		}
+		return config
+	})
+	keys:=testKeys(30)
+	putKeys(t,addrs,keys)
+	group:=svrs[0].getGroup(1)
+	ss,code:=group.getScanSnapshot(0)
+	if code!=pb.ErrCode_ErrOK || len(ss.shards)!=NShards{
+		t.Fatalf("snapshot: %v, %d shards",code,len(ss.shards))
+	}
+	for _,reverse:=range []bool{false,true}{
+		iter:=makeScanIter(ss.snap,ss.shards,"","",ss.index,reverse)
+		got:=[]string{}
+		for iter.Next(){
+			if key:=iter.Key();key[0]!='~'{
+				got=append(got,key)
+			}
+		}
+		iter.Release()
+		want:=keys
+		if reverse{
+			want=reversed(keys)
+		}
+		checkKeys(t,fmt.Sprintf("merged shards, reverse %v",reverse),got,want)
+	}
+}
@@ -0,0 +1,153 @@
+package shardkvserver
+
+import(
+	"io"
+	"os"
+	"net"
+	"time"
+	"context"
+	"testing"
+	"encoding/json"
+	"path/filepath"
+
+	"google.golang.org/grpc"
+	"neweraft/raftcore"
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+)
+
+// startTestServers runs n servers in the process on loopback ports, with
+// their data under a fresh working directory, and serves the config
+// makeConfig builds from their addresses from a config file, which
+// addTestConfig extends. It returns once every group of the config takes
+// writes.
+func startTestServers(t *testing.T,n int,makeConfig func(addrs []string) *Config) ([]*ShardServer,[]string){
+	t.Helper()
+	defer raftcore.SetLogger(raftcore.GetLogger())
+	raftcore.ConfigureLogging(io.Discard,"error",false)
+	dir:=t.TempDir()
+	t.Chdir(dir)
+
+	lis:=make([]net.Listener,n)
+	addrs:=make([]string,n)
+	for i:=range lis{
+		var err error
+		if lis[i],err=net.Listen("tcp","127.0.0.1:0");err!=nil{
+			t.Fatal(err)
+		}
+		addrs[i]=lis[i].Addr().String()
+	}
+	config:=makeConfig(addrs)
+	ctrler:=MakeFileCtrlerClient(filepath.Join(dir,"config.json"))
+	writeTestConfigs(t,ctrler.path,[]*Config{config})
+	svrs:=make([]*ShardServer,n)
+	for i:=range svrs{
+		svrs[i]=MakeShardServer(addrs[i],int64(i),ctrler)
+		s:=grpc.NewServer()
+		pb.RegisterMessageServiceServer(s,svrs[i])
+		pb.RegisterShardKVServiceServer(s,svrs[i])
+		go s.Serve(lis[i])
+		t.Cleanup(s.Stop)
+	}
+	t.Cleanup(func(){
+		for _,svr:=range svrs{
+			for _,group:=range svr.getGroups(){
+				group.raft.Kill()
+			}
+		}
+	})
+
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	for gid:=range config.Groups{
+		key:=keyOfGroup(config,gid)
+		ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+		_,err:=cli.Put(ctx,key,"")
+		if err==nil{
+			err=cli.Delete(ctx,key)
+		}
+		cancel()
+		if err!=nil{
+			t.Fatalf("group %d not ready: %v",gid,err)
+		}
+	}
+	return svrs,addrs
+}
+
+func writeTestConfigs(t *testing.T,path string,configs []*Config){
+	t.Helper()
+	data,err:=json.Marshal(configs)
+	if err==nil{
+		err=os.WriteFile(path+".tmp",data,0644)
+	}
+	if err==nil{
+		err=os.Rename(path+".tmp",path)
+	}
+	if err!=nil{
+		t.Fatal(err)
+	}
+}
+
+// addTestConfig appends config to the config file the servers of svr's
+// cluster poll, with the next config number.
+func addTestConfig(t *testing.T,svr *ShardServer,config *Config){
+	t.Helper()
+	path:=svr.ctrler.(*FileCtrlerClient).path
+	data,err:=os.ReadFile(path)
+	if err!=nil{
+		t.Fatal(err)
+	}
+	configs:=[]*Config{}
+	if err:=json.Unmarshal(data,&configs);err!=nil{
+		t.Fatal(err)
+	}
+	config.Num=configs[len(configs)-1].Num+1
+	writeTestConfigs(t,path,append(configs,config))
+}
+
+// keyOfGroup finds a key that config places in group gid.
+func keyOfGroup(config *Config,gid int64) string{
+	if rng:=config.RangeOf(gid);rng!=nil{
+		return rng.StartKey+"\x00"
+	}
+	for i:=0;;i++{
+		key:="~ready"+string(rune('a'+i%26))+string(rune('a'+i/26))
+		if config.Shards[Key2Shard(key)]==gid{
+			return key
+		}
+	}
+}
+
+// oneRangeConfig puts every server in group 1, which holds the whole key space.
+func oneRangeConfig(addrs []string) *Config{
+	return &Config{
+		Num:1,
+		Groups:map[int64][]string{1:append([]string{},addrs...)},
+		Ranges:[]*KeyRange{{Gid:1}},
+	}
+}
+
+// waitApplied waits for every replica of gid to apply as far as the one
+// furthest ahead, so their state machines can be compared.
+func waitApplied(t *testing.T,svrs []*ShardServer,gid int64){
+	t.Helper()
+	applied:=func(svr *ShardServer) int64{
+		group:=svr.getGroup(gid)
+		group.mu.RLock()
+		defer group.mu.RUnlock()
+		return group.lastApplied
+	}
+	var target int64
+	for _,svr:=range svrs{
+		target=max(target,applied(svr))
+	}
+	deadline:=time.Now().Add(10*time.Second)
+	for _,svr:=range svrs{
+		for applied(svr)<target{
+			if time.Now().After(deadline){
+				t.Fatalf("node %d stuck at %d, want %d",svr.id,applied(svr),target)
+			}
+			time.Sleep(10*time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package shardkvserver
+
+import(
+	"fmt"
+	"math"
+	"errors"
+	"encoding/binary"
+
+	"neweraft/storage"
+)
+
+type ShardStatus int
+
+const(
+	ShardServing ShardStatus=iota
+	ShardPulling
+	ShardBeingPulled
+	ShardGC
+)
+
+func (st ShardStatus) String() string{
+	switch st{
+	case ShardServing:
+		return "Serving"
+	case ShardPulling:
+		return "Pulling"
+	case ShardBeingPulled:
+		return "BeingPulled"
+	case ShardGC:
+		return "GC"
+	}
+	return "Unknown"
+}
+
+var ErrKeyNotFound = errors.New("Key not found")
+
+const ShardKeyPrefix = "shard_"
+
+const RangeKeyPrefix = "range_"
+
+const MvccKeyPrefix = "m_"
+
+type Shard struct{
+	id int
+	status ShardStatus
+	keyPrefix string
+	dataEng storage.KvStore
+	mvcc *storage.MvccStore
+	// onWrite sees the writes made by applied commands; PutRaw and Clear move
+	// data around and stay silent.
+	onWrite func(key string,raw string,deleted bool,index int64)
+	// keys and bytes size the latest values of a range shard, kept up by the
+	// writes below so range checks need not read the data.
+	sized bool
+	keys int64
+	bytes int64
+	// compacted is the index the shard last arrived or left at: its history
+	// from before that does not move with it.
+	compacted int64
+}
+
+func MakeShard(id int,status ShardStatus,dataEng storage.KvStore) *Shard{
+	return &Shard{
+		id:id,
+		status:status,
+		keyPrefix:fmt.Sprintf("%s%d_",ShardKeyPrefix,id),
+		dataEng:dataEng,
+		mvcc:storage.MakeMvccStore(dataEng,[]byte(MvccKeyPrefix)),
+	}
+}
+
+func MakeRangeShard(dataEng storage.KvStore) *Shard{
+	sd:=&Shard{
+		id:-1,
+		status:ShardServing,
+		keyPrefix:RangeKeyPrefix,
+		dataEng:dataEng,
+		mvcc:storage.MakeMvccStore(dataEng,[]byte(MvccKeyPrefix)),
+		sized:true,
+	}
+	iter:=sd.Scan(dataEng,"","",math.MaxInt64,false)
+	defer iter.Release()
+	for iter.Next(){
+		sd.keys++
+		sd.bytes+=int64(len(iter.Key())-len(sd.keyPrefix)+len(iter.Value()))
+	}
+	return sd
+}
+
+// Size returns the number of keys and their bytes, values with version
+// header, of a range shard.
+func (sd *Shard) Size() (int64,int64){
+	return sd.keys,sd.bytes
+}
+
+func (sd *Shard) latestRaw(key string) (string,bool){
+	raw,_,err:=sd.mvcc.Get(sd.dataEng,sd.prefix()+key,math.MaxInt64)
+	return raw,err==nil
+}
+
+// resize moves the size from the old latest value of key to the new one,
+// had and has telling whether either exists.
+func (sd *Shard) resize(key string,oldRaw string,had bool,newRaw string,has bool){
+	if !sd.sized{
+		return
+	}
+	if had{
+		sd.keys--
+		sd.bytes-=int64(len(key)+len(oldRaw))
+	}
+	if has{
+		sd.keys++
+		sd.bytes+=int64(len(key)+len(newRaw))
+	}
+}
+
+func (sd *Shard) prefix() string{
+	return sd.keyPrefix
+}
+
+// values are stored behind an 8 byte big endian version that starts at 1 and
+// grows on every write; deleting a key resets it.
+func encodeValue(version int64,value string) string{
+	buf:=make([]byte,8,8+len(value))
+	binary.BigEndian.PutUint64(buf,uint64(version))
+	return string(append(buf,value...))
+}
+
+func decodeValue(raw string) (int64,string){
+	if len(raw)<8{
+		return 0,raw
+	}
+	return int64(binary.BigEndian.Uint64([]byte(raw[:8]))),raw[8:]
+}
+
+// GetAt reads key as of raft index, returning the value, its version and the
+// index that wrote it.
+func (sd *Shard) GetAt(key string,index int64) (string,int64,int64,error){
+	raw,modIndex,err:=sd.mvcc.Get(sd.dataEng,sd.prefix()+key,index)
+	if err!=nil{
+		return "",0,0,ErrKeyNotFound
+	}
+	version,v:=decodeValue(raw)
+	return v,version,modIndex,nil
+}
+
+func (sd *Shard) GetWithVersion(key string) (string,int64,error){
+	v,version,_,err:=sd.GetAt(key,math.MaxInt64)
+	return v,version,err
+}
+
+func (sd *Shard) Get(key string) (string,error){
+	v,_,err:=sd.GetWithVersion(key)
+	return v,err
+}
+
+func (sd *Shard) notify(key string,raw string,deleted bool,index int64){
+	if sd.onWrite!=nil{
+		sd.onWrite(key,raw,deleted,index)
+	}
+}
+
+func (sd *Shard) Put(key string,value string,index int64) (int64,error){
+	oldRaw,had:=sd.latestRaw(key)
+	version,_:=decodeValue(oldRaw)
+	raw:=encodeValue(version+1,value)
+	if err:=sd.mvcc.Put(sd.prefix()+key,raw,index);err!=nil{
+		return version+1,err
+	}
+	sd.resize(key,oldRaw,had,raw,true)
+	sd.notify(key,raw,false,index)
+	return version+1,nil
+}
+
+func (sd *Shard) PutRaw(key string,raw string,index int64) error{
+	oldRaw,had:=sd.latestRaw(key)
+	if err:=sd.mvcc.Put(sd.prefix()+key,raw,index);err!=nil{
+		return err
+	}
+	sd.resize(key,oldRaw,had,raw,true)
+	return nil
+}
+
+func (sd *Shard) Append(key string,value string,index int64) (int64,error){
+	oldValue,_:=sd.Get(key)
+	return sd.Put(key,oldValue+value,index)
+}
+
+func (sd *Shard) Del(key string,index int64) error{
+	if err:=sd.DelRaw(key,index);err!=nil{
+		return err
+	}
+	sd.notify(key,"",true,index)
+	return nil
+}
+
+// DelRaw is the silent counterpart of Del for data moving to another group.
+func (sd *Shard) DelRaw(key string,index int64) error{
+	oldRaw,had:=sd.latestRaw(key)
+	if err:=sd.mvcc.Del(sd.prefix()+key,index);err!=nil{
+		return err
+	}
+	sd.resize(key,oldRaw,had,"",false)
+	return nil
+}
+
+// BatchPut and BatchDel take the latest value the batch sees for key, which
+// only the caller knows once the batch holds earlier writes to it.
+func (sd *Shard) BatchPut(b *storage.KvBatch,key string,oldRaw string,had bool,raw string,index int64){
+	sd.mvcc.BatchPut(b,sd.prefix()+key,raw,index)
+	sd.resize(key,oldRaw,had,raw,true)
+	sd.notify(key,raw,false,index)
+}
+
+func (sd *Shard) BatchDel(b *storage.KvBatch,key string,oldRaw string,had bool,index int64){
+	sd.mvcc.BatchDel(b,sd.prefix()+key,index)
+	sd.resize(key,oldRaw,had,"",false)
+	sd.notify(key,"",true,index)
+}
+
+// Scan iterates [start,end) of this shard as of index, end "" meaning the
+// end of the shard. Keys keep the shard prefix and values their version header.
+func (sd *Shard) Scan(r storage.KvReader,start string,end string,index int64,reverse bool) *storage.MvccIterator{
+	hi:=string(storage.PrefixEnd([]byte(sd.prefix())))
+	if end!=""{
+		hi=sd.prefix()+end
+	}
+	return sd.mvcc.Scan(r,sd.prefix()+start,hi,index,reverse)
+}
+
+// History lists the writes to [start,end) of this shard committed in
+// [from,to], oldest first, with the shard prefix trimmed from the keys.
+func (sd *Shard) History(r storage.KvReader,start string,end string,from int64,to int64) ([]*storage.MvccVersion,error){
+	hi:=string(storage.PrefixEnd([]byte(sd.prefix())))
+	if end!=""{
+		hi=sd.prefix()+end
+	}
+	versions,err:=sd.mvcc.Versions(r,sd.prefix()+start,hi,from,to)
+	for _,ver:=range versions{
+		ver.Key=ver.Key[len(sd.prefix()):]
+	}
+	return versions,err
+}
+
+// DeepCopy returns the latest values with their version header, ready for PutRaw.
+func (sd *Shard) DeepCopy() (map[string]string,error){
+	kvs:=make(map[string]string)
+	iter:=sd.Scan(sd.dataEng,"","",math.MaxInt64,false)
+	defer iter.Release()
+	for iter.Next(){
+		kvs[iter.Key()[len(sd.prefix()):]]=iter.Value()
+	}
+	return kvs,iter.Error()
+}
+
+func (sd *Shard) Clear() error{
+	sd.keys,sd.bytes=0,0
+	return sd.mvcc.DelPrefix(sd.prefix())
+}
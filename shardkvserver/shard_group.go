@@ -0,0 +1,506 @@
+package shardkvserver
+
+import(
+	"math"
+	"sync"
+	"time"
+	"bytes"
+	"context"
+	"strconv"
+	"sync/atomic"
+	"encoding/gob"
+
+	"neweraft/raftcore"
+	pb "neweraft/raftpb"
+	"neweraft/storage"
+	"neweraft/tracing"
+)
+
+const ExecuteTimeout = 500 * time.Millisecond
+
+const ShardMetaKey = "__shardsvr_meta"
+
+const DedupKeyPrefix = "__dedup_"
+
+type shardMeta struct{
+	LastConfig *Config
+	CurConfig *Config
+	Statuses [NShards]ShardStatus
+	Compacted [NShards]int64
+	Range *KeyRange
+}
+
+type ShardGroup struct{
+	mu sync.RWMutex
+
+	id int64
+	gid int64
+	peersAddrs []string
+	svr *ShardServer
+
+	raft *raftcore.Raft
+	applyCh chan *raftcore.ApplyMsg
+	lastApplied int64
+	compactedIndex int64
+	dataEng storage.KvStore
+
+	lastConfig *Config
+	curConfig *Config
+	shards map[int]*Shard
+
+	rng *KeyRange
+	rangeShard *Shard
+	opCount int64
+	scanSnaps map[int64]*scanSnapshot
+
+	txnRecords map[int64]*txnRecord
+	intents map[int64]*txnIntent
+	locks map[string]int64
+	doneTxns map[int64]bool
+
+	leases map[int64]*leaseState
+	keyLease map[string]int64
+	leaseDeadlines map[int64]time.Time
+	leaseTerm int64
+
+	watchers map[int64]*watcher
+	nextWatcherId int64
+	pendingEvents []*pb.WatchEvent
+	cdcSubs map[int64]*cdcSubscriber
+	nextCdcId int64
+
+	lastOperations map[int64]*pb.OperationContext
+	notifyChans map[int64]chan *pb.CommandResponse
+}
+
+func MakeShardGroup(svr *ShardServer,gid int64,idMe int64,peersAddrs []string,rng *KeyRange) *ShardGroup{
+	peers:=make([]*raftcore.RaftClient,len(peersAddrs))
+	for id,addr:=range peersAddrs{
+		peers[id]=raftcore.MakeRaftClient(addr,int64(id))
+	}
+	logeng:=storage.MakePrefixKvStore(svr.db,GroupKeyPrefix(gid,GroupLogSpace))
+	dataeng:=storage.MakePrefixKvStore(svr.db,GroupKeyPrefix(gid,GroupDataSpace))
+
+	applyCh:=make(chan *raftcore.ApplyMsg)
+	raft:=raftcore.MakeRaft(idMe,gid,peers,logeng,applyCh,svr.heartbeater)
+
+	shardGroup:=&ShardGroup{
+		id:idMe,
+		gid:gid,
+		peersAddrs:peersAddrs,
+		svr:svr,
+		raft:raft,
+		applyCh:applyCh,
+		lastApplied:raft.Status().AppliedIndex,
+		dataEng:dataeng,
+		lastConfig:DefaultConfig(),
+		curConfig:DefaultConfig(),
+		shards:make(map[int]*Shard),
+		rng:rng,
+		rangeShard:MakeRangeShard(dataeng),
+		scanSnaps:make(map[int64]*scanSnapshot),
+		txnRecords:make(map[int64]*txnRecord),
+		intents:make(map[int64]*txnIntent),
+		locks:make(map[string]int64),
+		doneTxns:make(map[int64]bool),
+		leases:make(map[int64]*leaseState),
+		keyLease:make(map[string]int64),
+		leaseDeadlines:make(map[int64]time.Time),
+		watchers:make(map[int64]*watcher),
+		cdcSubs:make(map[int64]*cdcSubscriber),
+		lastOperations:make(map[int64]*pb.OperationContext),
+		notifyChans:make(map[int64]chan *pb.CommandResponse),
+	}
+	for i:=0;i<NShards;i++{
+		shardGroup.shards[i]=MakeShard(i,ShardServing,dataeng)
+		shardGroup.shards[i].onWrite=shardGroup.recordEvent
+	}
+	shardGroup.rangeShard.onWrite=shardGroup.recordEvent
+	shardGroup.restoreMeta()
+	shardGroup.restoreTxns()
+	shardGroup.restoreLeases()
+	shardGroup.restoreCompactedIndex()
+
+	go shardGroup.ApplyingToStm()
+	go shardGroup.mvccGCLoop()
+	go shardGroup.Monitor(shardGroup.compactAction,MvccGCInterval)
+	go shardGroup.Monitor(shardGroup.configureAction,100*time.Millisecond)
+	go shardGroup.Monitor(shardGroup.migrationAction,50*time.Millisecond)
+	go shardGroup.Monitor(shardGroup.gcAction,50*time.Millisecond)
+	go shardGroup.Monitor(shardGroup.checkEntryInCurrentTermAction,200*time.Millisecond)
+	go shardGroup.Monitor(shardGroup.splitAction,RangeCheckInterval)
+	go shardGroup.Monitor(shardGroup.mergeAction,RangeCheckInterval)
+	go shardGroup.Monitor(shardGroup.txnRecoveryAction,TxnRecoveryInterval)
+	go shardGroup.Monitor(shardGroup.leaseExpiryAction,LeaseCheckInterval)
+
+	return shardGroup
+}
+
+// logger stamps records with the node, group, term and role of the group's
+// raft.
+func (sg *ShardGroup)logger() raftcore.Logger{
+	return sg.raft.Logger()
+}
+
+func (sg *ShardGroup)owns(key string) bool{
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	if sg.rng!=nil{
+		return sg.rng.Contains(key) && !sg.rng.Frozen
+	}
+	return sg.curConfig.Shards[Key2Shard(key)]==sg.gid
+}
+
+func (sg *ShardGroup)Command(ctx context.Context,req *pb.CommandRequest) (*pb.CommandResponse,error){
+	sg.mu.RLock()
+	if req.Op!=pb.OpType_OpGet && sg.isDuplicateRequest(req.ClientId,req.CommandId){
+		lastResponse:=sg.lastOperations[req.ClientId].LastResponse
+		sg.mu.RUnlock()
+		return lastResponse,nil
+	}
+	if !sg.canServeKey(req.Key){
+		sg.mu.RUnlock()
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+	}
+	sg.mu.RUnlock()
+	atomic.AddInt64(&sg.opCount,1)
+
+	start:=time.Now()
+	ctx,span:=tracing.StartSpan(ctx,"shardkv.command","node",sg.id,"group",sg.gid,"op",req.Op)
+	res:=sg.ExecuteContext(ctx,&Command{Type:CmdOperation,Request:req})
+	span.SetAttr("err",res.Err)
+	span.End()
+	commandSeconds.With(req.Op.String(),res.Err.String()).Observe(time.Since(start).Seconds())
+	return res,nil
+}
+
+func (sg *ShardGroup)Execute(cmd *Command) *pb.CommandResponse{
+	return sg.ExecuteContext(context.Background(),cmd)
+}
+
+// ExecuteContext is Execute with the proposal traced under the span in ctx.
+// It stops waiting when ctx is done; the entry may still be applied.
+func (sg *ShardGroup)ExecuteContext(ctx context.Context,cmd *Command) *pb.CommandResponse{
+	cmdByte,err:=EncodeCommand(cmd)
+	if err!=nil{
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrTimeout}
+	}
+	// the apply loop holds sg.mu, so the entry cannot be applied before its
+	// channel is there to take the reply
+	sg.mu.Lock()
+	idx,_,isLeader:=sg.raft.ProposeContext(ctx,cmdByte)
+	if !isLeader{
+		sg.mu.Unlock()
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrWrongLeader,LeaderId:sg.raft.GetLeaderId()}
+	}
+	ch:=sg.getNotifyChan(idx)
+	sg.mu.Unlock()
+
+	res:=&pb.CommandResponse{}
+	select{
+	case res=<-ch:
+	case <-ctx.Done():
+		res.Err=pb.ErrCode_ErrTimeout
+	case <-time.After(ExecuteTimeout):
+		res.Err=pb.ErrCode_ErrTimeout
+	}
+
+	go func(){
+		sg.mu.Lock()
+		delete(sg.notifyChans,idx)
+		sg.mu.Unlock()
+	}()
+	return res
+}
+
+func (sg *ShardGroup)getNotifyChan(idx int64) chan *pb.CommandResponse{
+	if _,ok:=sg.notifyChans[idx];!ok{
+		sg.notifyChans[idx]=make(chan *pb.CommandResponse,1)
+	}
+	return sg.notifyChans[idx]
+}
+
+func (sg *ShardGroup)canServeKey(key string) bool{
+	if sg.rng!=nil{
+		return sg.rng.Contains(key) && !sg.rng.Frozen
+	}
+	return sg.canServe(Key2Shard(key))
+}
+
+func (sg *ShardGroup)shardOf(key string) *Shard{
+	if sg.rng!=nil{
+		return sg.rangeShard
+	}
+	return sg.shards[Key2Shard(key)]
+}
+
+func (sg *ShardGroup)canServe(shardId int) bool{
+	return sg.curConfig.Shards[shardId]==sg.gid &&
+		(sg.shards[shardId].status==ShardServing || sg.shards[shardId].status==ShardGC)
+}
+
+func (sg *ShardGroup)isDuplicateRequest(clientId int64,commandId int64) bool{
+	opCtx,ok:=sg.lastOperations[clientId]
+	return ok && commandId<=opCtx.MaxAppliedCommandId
+}
+
+func (sg *ShardGroup)ApplyingToStm(){
+	for msg:=range sg.applyCh{
+		if !msg.CommandValid{
+			continue
+		}
+		var span *tracing.Span
+		if msg.Trace.IsValid(){
+			span=tracing.Start(msg.Trace,"shardkv.apply","node",sg.id,"group",sg.gid,"index",msg.CommandIndex)
+		}
+		sg.mu.Lock()
+		if msg.CommandIndex<=sg.lastApplied{
+			sg.raft.Applied(msg.CommandIndex)
+			sg.mu.Unlock()
+			span.SetAttr("skipped",true)
+			span.End()
+			continue
+		}
+		sg.lastApplied=msg.CommandIndex
+
+		res:=&pb.CommandResponse{}
+		cmd,err:=DecodeCommand(msg.Command)
+		if err!=nil{
+			sg.logger().Error("decode command failed","index",msg.CommandIndex,"err",err)
+		} else {
+			switch cmd.Type{
+			case CmdOperation:
+				res=sg.applyOperation(cmd.Request)
+			case CmdConfiguration:
+				res=sg.applyConfiguration(cmd.Config)
+			case CmdInsertShards:
+				res=sg.applyInsertShards(cmd.ShardsResp)
+			case CmdDeleteShards:
+				res=sg.applyDeleteShards(cmd.ShardsReq)
+			case CmdSplitRange:
+				res=sg.applySplitRange(cmd.Range)
+			case CmdFreezeRange:
+				res=sg.applyFreezeRange(cmd.Range)
+			case CmdMergeRange:
+				res=sg.applyMergeRange(cmd.Range)
+			case CmdTxn:
+				res=sg.applyTxn(cmd.Txn)
+			case CmdTxnBegin:
+				res=sg.applyTxnBegin(cmd.DistTxn)
+			case CmdTxnPrepare:
+				res=sg.applyTxnPrepare(cmd.DistTxn)
+			case CmdTxnDecide:
+				res=sg.applyTxnDecide(cmd.DistTxn)
+			case CmdTxnResolve:
+				res=sg.applyTxnResolve(cmd.DistTxn)
+			case CmdTxnFinish:
+				res=sg.applyTxnFinish(cmd.DistTxn)
+			case CmdLeaseGrant:
+				res=sg.applyLeaseGrant(cmd.Lease)
+			case CmdLeaseRevoke:
+				res=sg.applyLeaseRevoke(cmd.Lease)
+			case CmdCompact:
+				res=sg.applyCompact(cmd.CompactIndex)
+			case CmdEmptyEntry:
+			}
+		}
+		sg.raft.Applied(msg.CommandIndex)
+		events:=sg.pendingEvents
+		sg.pendingEvents=nil
+		sg.publishEvents(events)
+		if err==nil{
+			sg.publishCdc(msg,cmd.Type,events)
+		}
+
+		if curTerm,isLeader:=sg.raft.GetState();isLeader && msg.CommandTerm==curTerm{
+			if ch,ok:=sg.notifyChans[msg.CommandIndex];ok{
+				ch<-res
+			}
+		}
+		sg.mu.Unlock()
+		span.End()
+	}
+}
+
+func (sg *ShardGroup)applyOperation(req *pb.CommandRequest) *pb.CommandResponse{
+	res:=&pb.CommandResponse{}
+	if !sg.canServeKey(req.Key){
+		res.Err=pb.ErrCode_ErrWrongGroup
+		return res
+	}
+	if req.Op!=pb.OpType_OpGet && sg.isDuplicateRequest(req.ClientId,req.CommandId){
+		return sg.lastOperations[req.ClientId].LastResponse
+	}
+	if sg.isLocked(req.Key){
+		res.Err=pb.ErrCode_ErrLocked
+		return res
+	}
+	if req.LeaseId!=0 && !sg.hasLease(req.LeaseId){
+		res.Err=pb.ErrCode_ErrLeaseNotFound
+		return res
+	}
+
+	shard:=sg.shardOf(req.Key)
+	switch req.Op{
+	case pb.OpType_OpGet:
+		readIndex:=req.ReadIndex
+		if readIndex<=0{
+			readIndex=math.MaxInt64
+		} else if readIndex<sg.readFloor(shard){
+			res.Err=pb.ErrCode_ErrOutDated
+			return res
+		}
+		v,version,modIndex,err:=shard.GetAt(req.Key,readIndex)
+		if err!=nil{
+			res.Err=pb.ErrCode_ErrNoKey
+		}
+		res.Value,res.Version,res.ModIndex=v,version,modIndex
+	case pb.OpType_OpPut:
+		res.Version,_=shard.Put(req.Key,req.Value,sg.lastApplied)
+		res.ModIndex=sg.lastApplied
+		sg.attachLease(req.Key,req.LeaseId)
+	case pb.OpType_OpAppend:
+		res.Version,_=shard.Append(req.Key,req.Value,sg.lastApplied)
+		res.ModIndex=sg.lastApplied
+	case pb.OpType_OpDel:
+		shard.Del(req.Key,sg.lastApplied)
+		sg.attachLease(req.Key,0)
+	case pb.OpType_OpCas,pb.OpType_OpPutIfAbsent,pb.OpType_OpDeleteIfEquals:
+		sg.applyConditional(shard,req,res)
+	}
+
+	if req.Op!=pb.OpType_OpGet{
+		sg.updateLastOperation(req.ClientId,&pb.OperationContext{
+			MaxAppliedCommandId:req.CommandId,
+			LastResponse:res,
+		})
+	}
+	return res
+}
+
+// applyConditional decides a conditional write from the applied state only, so
+// every replica takes the same branch. On a failed compare the current value
+// and version are returned.
+func (sg *ShardGroup)applyConditional(shard *Shard,req *pb.CommandRequest,res *pb.CommandResponse){
+	cur,version,err:=shard.GetWithVersion(req.Key)
+	exists:=err==nil
+	var ok bool
+	switch req.Op{
+	case pb.OpType_OpCas:
+		if req.ExpectedVersion>0{
+			ok=exists && version==req.ExpectedVersion
+		} else {
+			ok=exists && cur==req.ExpectedValue
+		}
+	case pb.OpType_OpPutIfAbsent:
+		ok=!exists
+	case pb.OpType_OpDeleteIfEquals:
+		if !exists{
+			res.Err=pb.ErrCode_ErrNoKey
+			return
+		}
+		ok=cur==req.ExpectedValue && (req.ExpectedVersion==0 || version==req.ExpectedVersion)
+	}
+	if !ok{
+		res.Err=pb.ErrCode_ErrCompareFailed
+		res.Value,res.Version=cur,version
+		return
+	}
+	if req.Op==pb.OpType_OpDeleteIfEquals{
+		shard.Del(req.Key,sg.lastApplied)
+		sg.attachLease(req.Key,0)
+		return
+	}
+	res.Version,_=shard.Put(req.Key,req.Value,sg.lastApplied)
+	res.ModIndex=sg.lastApplied
+	// a Cas without a lease of its own leaves the key on the lease it has
+	if req.Op==pb.OpType_OpPutIfAbsent || req.LeaseId!=0{
+		sg.attachLease(req.Key,req.LeaseId)
+	}
+}
+
+func (sg *ShardGroup)updateLastOperation(clientId int64,opCtx *pb.OperationContext){
+	sg.lastOperations[clientId]=opCtx
+	if data,err:=encodeOperationContext(opCtx);err==nil{
+		sg.dataEng.Put(DedupKeyPrefix+strconv.FormatInt(clientId,10),data)
+	}
+}
+
+func encodeOperationContext(opCtx *pb.OperationContext) (string,error){
+	var buf bytes.Buffer
+	err:=gob.NewEncoder(&buf).Encode(opCtx)
+	return buf.String(),err
+}
+
+func (sg *ShardGroup)persistMeta(){
+	meta:=&shardMeta{
+		LastConfig:sg.lastConfig,
+		CurConfig:sg.curConfig,
+		Range:sg.rng,
+	}
+	for i:=0;i<NShards;i++{
+		meta.Statuses[i]=sg.shards[i].status
+		meta.Compacted[i]=sg.shards[i].compacted
+	}
+	var buf bytes.Buffer
+	if err:=gob.NewEncoder(&buf).Encode(meta);err!=nil{
+		sg.logger().Error("encode meta failed","err",err)
+		return
+	}
+	sg.dataEng.Put(ShardMetaKey,buf.String())
+}
+
+func (sg *ShardGroup)restoreMeta(){
+	metaStr,err:=sg.dataEng.Get(ShardMetaKey)
+	if err==nil{
+		meta:=&shardMeta{}
+		if gob.NewDecoder(bytes.NewBufferString(metaStr)).Decode(meta)==nil{
+			if meta.LastConfig!=nil{
+				sg.lastConfig=meta.LastConfig
+			}
+			if meta.CurConfig!=nil{
+				sg.curConfig=meta.CurConfig
+			}
+			for i:=0;i<NShards;i++{
+				sg.shards[i].status=meta.Statuses[i]
+				sg.shards[i].compacted=meta.Compacted[i]
+			}
+			if meta.Range!=nil{
+				sg.rng=meta.Range
+			}
+		}
+	}
+	if sg.lastConfig.Groups==nil{
+		sg.lastConfig.Groups=make(map[int64][]string)
+	}
+	if sg.curConfig.Groups==nil{
+		sg.curConfig.Groups=make(map[int64][]string)
+	}
+
+	dedups,_:=sg.dataEng.DumpPrefix(DedupKeyPrefix,true)
+	for clientIdStr,opCtxStr:=range dedups{
+		clientId,err:=strconv.ParseInt(clientIdStr,10,64)
+		if err!=nil{
+			continue
+		}
+		opCtx:=&pb.OperationContext{}
+		if gob.NewDecoder(bytes.NewBufferString(opCtxStr)).Decode(opCtx)==nil{
+			sg.lastOperations[clientId]=opCtx
+		}
+	}
+}
+
+func (sg *ShardGroup)Monitor(action func(),timeout time.Duration){
+	for !sg.raft.Killed(){
+		if _,isLeader:=sg.raft.GetState();isLeader{
+			action()
+		}
+		time.Sleep(timeout)
+	}
+}
+
+func (sg *ShardGroup)checkEntryInCurrentTermAction(){
+	if !sg.raft.HasLogInCurrentTerm(){
+		sg.Execute(&Command{Type:CmdEmptyEntry})
+	}
+}
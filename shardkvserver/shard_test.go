@@ -0,0 +1,57 @@
+package shardkvserver
+
+import(
+	"testing"
+
+	"neweraft/storage"
+)
+
+func checkSize(t *testing.T,sd *Shard,keys int64,bytes int64){
+	t.Helper()
+	if k,b:=sd.Size();k!=keys || b!=bytes{
+		t.Fatalf("size %d keys %d bytes, want %d keys %d bytes",k,b,keys,bytes)
+	}
+}
+
+func TestRangeShardSize(t *testing.T){
+	dir:=t.TempDir()
+	eng:=storage.Engineerfactory("leveldb",dir)
+	defer eng.Close()
+	sd:=MakeRangeShard(eng)
+	checkSize(t,sd,0,0)
+
+	sd.Put("a","1",1)
+	sd.Put("bb","22",2)
+	checkSize(t,sd,2,1+9+2+10)
+	sd.Append("a","11",3)
+	checkSize(t,sd,2,1+11+2+10)
+	sd.Del("bb",4)
+	sd.Del("missing",4)
+	checkSize(t,sd,1,1+11)
+	sd.PutRaw("c",encodeValue(1,"x"),5)
+	checkSize(t,sd,2,1+11+1+9)
+
+	// a batch writing the same key twice is sized from what it already holds
+	sg:=&ShardGroup{
+		dataEng:eng,
+		rng:&KeyRange{},
+		rangeShard:sd,
+		lastApplied:6,
+		leases:make(map[int64]*leaseState),
+		keyLease:make(map[string]int64),
+	}
+	tv:=sg.makeTxnView()
+	tv.put("d","1")
+	tv.put("d","12")
+	tv.del("c")
+	if err:=tv.write();err!=nil{
+		t.Fatal(err)
+	}
+	checkSize(t,sd,2,1+11+1+10)
+
+	keys,bytes:=sd.Size()
+	checkSize(t,MakeRangeShard(eng),keys,bytes)
+
+	sd.Clear()
+	checkSize(t,sd,0,0)
+}
@@ -1,52 +1,238 @@
 package shardkvserver
 
 import(
+	"sort"
 	"sync"
+	"time"
 	"context"
 	"fmt"
-	
+	"encoding/binary"
+
+	"neweraft/chaos"
 	"neweraft/raftcore"
 	pb "neweraft/raftpb"
 	"neweraft/storage"
 )
 
+const(
+	GroupLogSpace byte='l'
+	GroupDataSpace byte='d'
+)
+
+func GroupKeyPrefix(gid int64,space byte) []byte{
+	prefix:=make([]byte,10)
+	prefix[0]='g'
+	binary.BigEndian.PutUint64(prefix[1:9],uint64(gid))
+	prefix[9]=space
+	return prefix
+}
+
 type ShardServer struct{
 	mu sync.RWMutex
 
 	id int64
+	addr string
+	db storage.KvStore
+
+	ctrler CtrlerClient
+	groups map[int64]*ShardGroup
+	heartbeater *raftcore.Heartbeater
 
-	raft *raftcore.Raft
+	svrClients map[string]pb.ShardKVServiceClient
+	faults *chaos.Injector
+	sessions sessionPool
 
 	pb.UnimplementedMessageServiceServer
+	pb.UnimplementedShardKVServiceServer
 }
 
-func MakeShardServer(peersAddrsMap map[int]string,idMe int64) *ShardServer{
-	peers:=[]*raftcore.RaftClient{}
-	for id,addr:=range peersAddrsMap {
-		peer:=raftcore.MakeRaftClient(addr,int64(id))
-		peers=append(peers,peer)
-	}
-	logeng:=storage.Engineerfactory("leveldb",fmt.Sprintf("./out/data/log/%d_log",idMe))
-
-	raft:=raftcore.MakeRaft(idMe,peers,logeng)
+func MakeShardServer(addrMe string,idMe int64,ctrler CtrlerClient) *ShardServer{
+	db:=storage.Engineerfactory("leveldb",fmt.Sprintf("./out/data/db/%d_db",idMe))
 
 	shardServer:=&ShardServer{
-		raft:raft,
+		id:idMe,
+		addr:addrMe,
+		db:db,
+		ctrler:ctrler,
+		groups:make(map[int64]*ShardGroup),
+		heartbeater:raftcore.MakeHeartbeater(50*time.Millisecond),
+		svrClients:make(map[string]pb.ShardKVServiceClient),
 	}
+	shardServer.restartRangeGroups()
+	shardServer.startGroups()
+	go shardServer.groupsTicker()
 
 	return shardServer
 }
 
+func (shardsvr *ShardServer)groupsTicker(){
+	for{
+		time.Sleep(100*time.Millisecond)
+		shardsvr.startGroups()
+		shardsvr.gcMergedGroups()
+	}
+}
+
+func (shardsvr *ShardServer)startGroups(){
+	config,err:=shardsvr.ctrler.Query(-1)
+	if err!=nil{
+		return
+	}
+	shardsvr.mu.Lock()
+	defer shardsvr.mu.Unlock()
+	registry:=shardsvr.loadRangeRegistry()
+	for gid,servers:=range config.Groups{
+		if _,ok:=shardsvr.groups[gid];ok{
+			continue
+		}
+		if meta,ok:=registry[gid];ok && meta.Removed{
+			continue
+		}
+		for i,server:=range servers{
+			if server==shardsvr.addr{
+				shardsvr.logger().Info("start group","group",gid,"member",i)
+				shardsvr.groups[gid]=MakeShardGroup(shardsvr,gid,int64(i),servers,config.RangeOf(gid))
+				break
+			}
+		}
+	}
+}
+
+func (shardsvr *ShardServer)restartRangeGroups(){
+	shardsvr.mu.Lock()
+	defer shardsvr.mu.Unlock()
+	for gid,meta:=range shardsvr.loadRangeRegistry(){
+		if meta.Removed{
+			continue
+		}
+		shardsvr.groups[gid]=MakeShardGroup(shardsvr,gid,meta.SelfId,meta.Peers,nil)
+	}
+}
+
+func (shardsvr *ShardServer)logger() raftcore.Logger{
+	return raftcore.GetLogger().With("node",shardsvr.id)
+}
+
+func (shardsvr *ShardServer)getGroup(gid int64) *ShardGroup{
+	shardsvr.mu.RLock()
+	defer shardsvr.mu.RUnlock()
+	return shardsvr.groups[gid]
+}
+
+func (shardsvr *ShardServer)getGroups() []*ShardGroup{
+	shardsvr.mu.RLock()
+	defer shardsvr.mu.RUnlock()
+	groups:=make([]*ShardGroup,0,len(shardsvr.groups))
+	for _,group:=range shardsvr.groups{
+		groups=append(groups,group)
+	}
+	return groups
+}
+
 func (shardsvr *ShardServer)RequestVote(ctx context.Context,req *pb.VoteRequest) (*pb.VoteResponse,error){
 	res:=&pb.VoteResponse{}
-	shardsvr.raft.HandleRequestVote(req,res)
+	group:=shardsvr.getGroup(req.GroupId)
+	if group==nil{
+		return res,fmt.Errorf("group %d not found on shardsvr %d",req.GroupId,shardsvr.id)
+	}
+	group.raft.HandleRequestVote(req,res)
 
 	return res,nil
 }
 
 func (shardsvr *ShardServer)AppendEntry(ctx context.Context,req *pb.AppendEntryRequest) (*pb.AppendEntryResponse,error){
 	res:=&pb.AppendEntryResponse{}
-	shardsvr.raft.HandleAppendEntry(req,res)
+	group:=shardsvr.getGroup(req.GroupId)
+	if group==nil{
+		return res,fmt.Errorf("group %d not found on shardsvr %d",req.GroupId,shardsvr.id)
+	}
+	group.raft.HandleAppendEntry(ctx,req,res)
 
 	return res,nil
-}
\ No newline at end of file
+}
+
+func (shardsvr *ShardServer)Heartbeat(ctx context.Context,req *pb.HeartbeatRequest) (*pb.HeartbeatResponse,error){
+	res:=&pb.HeartbeatResponse{Results:make([]*pb.HeartbeatResult,0,len(req.Heartbeats))}
+	for _,msg:=range req.Heartbeats{
+		result:=&pb.HeartbeatResult{GroupId:msg.GroupId,PeerId:-1}
+		if group:=shardsvr.getGroup(msg.GroupId);group!=nil{
+			group.raft.HandleHeartbeat(msg,result)
+		}
+		res.Results=append(res.Results,result)
+	}
+	return res,nil
+}
+
+// Status reports the raft state of one hosted group, or of all of them when
+// GroupId is 0.
+func (shardsvr *ShardServer)Status(ctx context.Context,req *pb.StatusRequest) (*pb.StatusResponse,error){
+	res:=&pb.StatusResponse{NodeId:shardsvr.id,Addr:shardsvr.addr}
+	if req.GroupId!=0{
+		group:=shardsvr.getGroup(req.GroupId)
+		if group==nil{
+			return res,fmt.Errorf("group %d not found on shardsvr %d",req.GroupId,shardsvr.id)
+		}
+		res.Groups=append(res.Groups,group.raft.Status())
+		return res,nil
+	}
+	groups:=shardsvr.getGroups()
+	sort.Slice(groups,func(i,j int) bool{ return groups[i].gid<groups[j].gid })
+	for _,group:=range groups{
+		res.Groups=append(res.Groups,group.raft.Status())
+	}
+	return res,nil
+}
+
+// SetFaultInjector lets SetFaults change the rules of inj. It is called
+// before the server is registered.
+func (shardsvr *ShardServer)SetFaultInjector(inj *chaos.Injector){
+	shardsvr.faults=inj
+}
+
+func (shardsvr *ShardServer)SetFaults(ctx context.Context,req *pb.SetFaultsRequest) (*pb.SetFaultsResponse,error){
+	if shardsvr.faults==nil{
+		return nil,fmt.Errorf("fault injection is off on shardsvr %d",shardsvr.id)
+	}
+	return shardsvr.faults.SetFaults(ctx,req)
+}
+
+func (shardsvr *ShardServer)Command(ctx context.Context,req *pb.CommandRequest) (*pb.CommandResponse,error){
+	for _,group:=range shardsvr.getGroups(){
+		if group.owns(req.Key){
+			return group.Command(ctx,req)
+		}
+	}
+	return &pb.CommandResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+}
+
+func (shardsvr *ShardServer)PullShard(ctx context.Context,req *pb.ShardOperationRequest) (*pb.ShardOperationResponse,error){
+	group:=shardsvr.getGroup(req.GroupId)
+	if group==nil{
+		return &pb.ShardOperationResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+	}
+	return group.PullShard(ctx,req)
+}
+
+func (shardsvr *ShardServer)DeleteShard(ctx context.Context,req *pb.ShardOperationRequest) (*pb.ShardOperationResponse,error){
+	group:=shardsvr.getGroup(req.GroupId)
+	if group==nil{
+		return &pb.ShardOperationResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+	}
+	return group.DeleteShard(ctx,req)
+}
+
+func (shardsvr *ShardServer)getSvrClient(addr string) pb.ShardKVServiceClient{
+	shardsvr.mu.Lock()
+	defer shardsvr.mu.Unlock()
+	if cli,ok:=shardsvr.svrClients[addr];ok{
+		return cli
+	}
+	conn,err:=raftcore.GetSharedConn(addr)
+	if err!=nil{
+		shardsvr.logger().Error("conn failed","addr",addr,"err",err)
+		return nil
+	}
+	cli:=pb.NewShardKVServiceClient(conn)
+	shardsvr.svrClients[addr]=cli
+	return cli
+}
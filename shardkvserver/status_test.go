@@ -0,0 +1,86 @@
+package shardkvserver
+
+import(
+	"context"
+	"testing"
+	"time"
+
+	"neweraft/raftcore"
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+)
+
+func TestStatus(t *testing.T){
+	svrs,addrs:=startTestServers(t,3,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+	res,err:=cli.Put(ctx,"k","v")
+	if err!=nil{
+		t.Fatal(err)
+	}
+
+	status:=func(addr string,gid int64) (*pb.StatusResponse,error){
+		conn,err:=raftcore.GetSharedConn(addr)
+		if err!=nil{
+			t.Fatal(err)
+		}
+		return pb.NewMessageServiceClient(conn).Status(ctx,&pb.StatusRequest{GroupId:gid})
+	}
+	// every replica has applied the put and agrees on the term and leader
+	var replicas []*pb.RaftStatus
+	waitFor(t,"the replicas to apply the put",func() bool{
+		replicas=nil
+		for i,addr:=range addrs{
+			st,err:=status(addr,1)
+			if err!=nil{
+				t.Fatal(err)
+			}
+			if st.NodeId!=int64(i) || st.Addr!=addr || len(st.Groups)!=1{
+				t.Fatalf("status of %s: %v",addr,st)
+			}
+			rs:=st.Groups[0]
+			if rs.CommitIndex<res.ModIndex || rs.AppliedIndex<res.ModIndex{
+				return false
+			}
+			replicas=append(replicas,rs)
+		}
+		return true
+	})
+	leaders:=0
+	for _,rs:=range replicas{
+		if rs.GroupId!=1 || rs.Term!=replicas[0].Term || rs.LeaderId!=replicas[0].LeaderId || rs.Term==0{
+			t.Fatalf("replicas disagree: %v",replicas)
+		}
+		if rs.AppliedIndex>rs.CommitIndex || rs.CommitIndex>rs.LastIndex || rs.FirstIndex>rs.LastIndex{
+			t.Fatalf("indexes out of order: %v",rs)
+		}
+		if rs.Role!="leader"{
+			continue
+		}
+		leaders++
+		if rs.Id!=rs.LeaderId || len(rs.Peers)!=len(addrs){
+			t.Fatalf("leader %v",rs)
+		}
+		for _,ps:=range rs.Peers{
+			if ps.MatchIndex<res.ModIndex || ps.Lag!=rs.LastIndex-ps.MatchIndex{
+				t.Fatalf("leader's view of peer %v",ps)
+			}
+		}
+	}
+	if leaders!=1{
+		t.Fatalf("%d leaders: %v",leaders,replicas)
+	}
+	if _,isLeader:=svrs[replicas[0].LeaderId].getGroup(1).raft.GetState();!isLeader{
+		t.Fatalf("node %d reported as leader is not",replicas[0].LeaderId)
+	}
+
+	// without a group it lists all hosted groups; an unknown one is an error
+	if st,err:=status(addrs[0],0);err!=nil || len(st.Groups)!=1{
+		t.Fatalf("status of all groups: %v %v",st,err)
+	}
+	if _,err:=status(addrs[0],2);err==nil{
+		t.Fatal("status of a group not hosted")
+	}
+}
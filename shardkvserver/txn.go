@@ -0,0 +1,262 @@
+package shardkvserver
+
+import(
+	"maps"
+	"context"
+	"strconv"
+	"sync/atomic"
+
+	pb "neweraft/raftpb"
+	"neweraft/storage"
+)
+
+// txnView reads through the writes a transaction has made so far and
+// collects them into one batch, so later ops see earlier ones and nothing
+// reaches the store until the whole txn is applied.
+type txnView struct{
+	sg *ShardGroup
+	writes map[string]*string
+	detached map[string]bool
+	batch *storage.KvBatch
+}
+
+func (sg *ShardGroup)makeTxnView() *txnView{
+	return &txnView{
+		sg:sg,
+		writes:make(map[string]*string),
+		detached:make(map[string]bool),
+		batch:storage.MakeKvBatch(),
+	}
+}
+
+// write applies the batch. A txn write detaches its key from any lease, and
+// the lease records change in the same batch, so a failed write leaves both
+// the keys and the leases as they were.
+func (tv *txnView) write() error{
+	leases:=make(map[int64]*leaseState)
+	for key:=range tv.detached{
+		id,ok:=tv.sg.keyLease[key]
+		if !ok{
+			continue
+		}
+		if l,ok:=tv.sg.leases[id];ok && leases[id]==nil{
+			leases[id]=&leaseState{Id:l.Id,TTL:l.TTL,Keys:maps.Clone(l.Keys)}
+		}
+		if l,ok:=leases[id];ok{
+			delete(l.Keys,key)
+		}
+	}
+	for _,l:=range leases{
+		tv.batch.Put(leaseKey(l.Id),encodeGob(l))
+	}
+	if err:=tv.sg.dataEng.WriteBatch(tv.batch);err!=nil{
+		return err
+	}
+	for key:=range tv.detached{
+		delete(tv.sg.keyLease,key)
+	}
+	for _,l:=range leases{
+		tv.sg.leases[l.Id]=l
+	}
+	return nil
+}
+
+func (tv *txnView) getRaw(key string) (string,bool){
+	shard:=tv.sg.shardOf(key)
+	if raw,ok:=tv.writes[shard.prefix()+key];ok{
+		if raw==nil{
+			return "",false
+		}
+		return *raw,true
+	}
+	return shard.latestRaw(key)
+}
+
+func (tv *txnView) get(key string) (string,int64,bool){
+	raw,ok:=tv.getRaw(key)
+	if !ok{
+		return "",0,false
+	}
+	version,v:=decodeValue(raw)
+	return v,version,true
+}
+
+func (tv *txnView) put(key string,value string) int64{
+	oldRaw,had:=tv.getRaw(key)
+	version,_:=decodeValue(oldRaw)
+	raw:=encodeValue(version+1,value)
+	shard:=tv.sg.shardOf(key)
+	tv.writes[shard.prefix()+key]=&raw
+	shard.BatchPut(tv.batch,key,oldRaw,had,raw,tv.sg.lastApplied)
+	tv.detached[key]=true
+	return version+1
+}
+
+func (tv *txnView) del(key string){
+	oldRaw,had:=tv.getRaw(key)
+	shard:=tv.sg.shardOf(key)
+	tv.writes[shard.prefix()+key]=nil
+	shard.BatchDel(tv.batch,key,oldRaw,had,tv.sg.lastApplied)
+	tv.detached[key]=true
+}
+
+func compareTxn(tv *txnView,cmp *pb.Compare) bool{
+	v,version,_:=tv.get(cmp.Key)
+	var c int
+	switch cmp.Target{
+	case pb.CompareTarget_CmpValue:
+		switch{
+		case v<cmp.Value:
+			c=-1
+		case v>cmp.Value:
+			c=1
+		}
+	case pb.CompareTarget_CmpVersion:
+		switch{
+		case version<cmp.Version:
+			c=-1
+		case version>cmp.Version:
+			c=1
+		}
+	}
+	switch cmp.Result{
+	case pb.CompareResult_CmpEqual:
+		return c==0
+	case pb.CompareResult_CmpNotEqual:
+		return c!=0
+	case pb.CompareResult_CmpGreater:
+		return c>0
+	case pb.CompareResult_CmpLess:
+		return c<0
+	}
+	return false
+}
+
+func txnKeys(req *pb.TxnRequest) []string{
+	keys:=make([]string,0,len(req.Compares)+len(req.Success)+len(req.Failure))
+	for _,cmp:=range req.Compares{
+		keys=append(keys,cmp.Key)
+	}
+	for _,op:=range append(append([]*pb.TxnOp{},req.Success...),req.Failure...){
+		keys=append(keys,op.Key)
+	}
+	return keys
+}
+
+func validTxn(req *pb.TxnRequest) bool{
+	for _,op:=range append(append([]*pb.TxnOp{},req.Success...),req.Failure...){
+		switch op.Op{
+		case pb.OpType_OpGet,pb.OpType_OpPut,pb.OpType_OpAppend,pb.OpType_OpDel:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (sg *ShardGroup)canServeKeys(keys []string) bool{
+	for _,key:=range keys{
+		if !sg.canServeKey(key){
+			return false
+		}
+	}
+	return true
+}
+
+func (sg *ShardGroup)Txn(ctx context.Context,req *pb.TxnRequest) (*pb.TxnResponse,error){
+	if !validTxn(req){
+		return &pb.TxnResponse{Err:pb.ErrCode_ErrBadRequest},nil
+	}
+	sg.mu.RLock()
+	if sg.isDuplicateRequest(req.ClientId,req.CommandId){
+		lastResponse:=sg.lastOperations[req.ClientId].LastResponse
+		sg.mu.RUnlock()
+		if lastResponse.Txn!=nil{
+			return lastResponse.Txn,nil
+		}
+		return &pb.TxnResponse{Err:lastResponse.Err},nil
+	}
+	if !sg.canServeKeys(txnKeys(req)){
+		sg.mu.RUnlock()
+		return &pb.TxnResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+	}
+	sg.mu.RUnlock()
+	atomic.AddInt64(&sg.opCount,1)
+
+	res:=sg.Execute(&Command{Type:CmdTxn,Txn:req})
+	if res.Txn!=nil{
+		return res.Txn,nil
+	}
+	return &pb.TxnResponse{Err:res.Err,LeaderId:res.LeaderId},nil
+}
+
+func (sg *ShardGroup)applyTxn(req *pb.TxnRequest) *pb.CommandResponse{
+	if !sg.canServeKeys(txnKeys(req)){
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrWrongGroup}
+	}
+	if sg.isDuplicateRequest(req.ClientId,req.CommandId){
+		return sg.lastOperations[req.ClientId].LastResponse
+	}
+	for _,key:=range txnKeys(req){
+		if sg.isLocked(key){
+			return &pb.CommandResponse{Err:pb.ErrCode_ErrLocked,Txn:&pb.TxnResponse{Err:pb.ErrCode_ErrLocked}}
+		}
+	}
+
+	tv:=sg.makeTxnView()
+	txnRes:=&pb.TxnResponse{Err:pb.ErrCode_ErrOK,Succeeded:true}
+	for _,cmp:=range req.Compares{
+		if !compareTxn(tv,cmp){
+			txnRes.Succeeded=false
+			break
+		}
+	}
+	ops:=req.Success
+	if !txnRes.Succeeded{
+		ops=req.Failure
+	}
+	for _,op:=range ops{
+		opRes:=&pb.TxnOpResult{Err:pb.ErrCode_ErrOK}
+		switch op.Op{
+		case pb.OpType_OpGet:
+			v,version,ok:=tv.get(op.Key)
+			if !ok{
+				opRes.Err=pb.ErrCode_ErrNoKey
+			}
+			opRes.Value,opRes.Version=v,version
+		case pb.OpType_OpPut:
+			opRes.Version=tv.put(op.Key,op.Value)
+		case pb.OpType_OpAppend:
+			v,_,_:=tv.get(op.Key)
+			opRes.Version=tv.put(op.Key,v+op.Value)
+		case pb.OpType_OpDel:
+			tv.del(op.Key)
+		}
+		txnRes.Results=append(txnRes.Results,opRes)
+	}
+
+	res:=&pb.CommandResponse{Err:pb.ErrCode_ErrOK,Txn:txnRes}
+	opCtx:=&pb.OperationContext{MaxAppliedCommandId:req.CommandId,LastResponse:res}
+	if data,err:=encodeOperationContext(opCtx);err==nil{
+		tv.batch.Put(DedupKeyPrefix+strconv.FormatInt(req.ClientId,10),data)
+	}
+	if err:=tv.write();err!=nil{
+		sg.pendingEvents=nil
+		return &pb.CommandResponse{Err:pb.ErrCode_ErrNotReady}
+	}
+	sg.lastOperations[req.ClientId]=opCtx
+	return res
+}
+
+func (shardsvr *ShardServer)Txn(ctx context.Context,req *pb.TxnRequest) (*pb.TxnResponse,error){
+	keys:=txnKeys(req)
+	if len(keys)==0{
+		return &pb.TxnResponse{Err:pb.ErrCode_ErrBadRequest},nil
+	}
+	for _,group:=range shardsvr.getGroups(){
+		if group.owns(keys[0]){
+			return group.Txn(ctx,req)
+		}
+	}
+	return &pb.TxnResponse{Err:pb.ErrCode_ErrWrongGroup},nil
+}
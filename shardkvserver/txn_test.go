@@ -0,0 +1,95 @@
+package shardkvserver
+
+import(
+	"errors"
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+)
+
+// leaderTxn sends req as is to the servers until the group leader answers.
+func leaderTxn(t *testing.T,svrs []*ShardServer,req *pb.TxnRequest) *pb.TxnResponse{
+	t.Helper()
+	for _,svr:=range svrs{
+		res,err:=svr.Txn(context.Background(),req)
+		if err!=nil{
+			t.Fatal(err)
+		}
+		if res.Err!=pb.ErrCode_ErrWrongLeader{
+			return res
+		}
+	}
+	t.Fatal("no leader")
+	return nil
+}
+
+func TestTxn(t *testing.T){
+	svrs,addrs:=startTestServers(t,3,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+	if _,err:=cli.Put(ctx,"y","2");err!=nil{
+		t.Fatal(err)
+	}
+
+	// later ops see the earlier ones of the same txn
+	req:=&pb.TxnRequest{
+		Compares:[]*pb.Compare{{Key:"x",Target:pb.CompareTarget_CmpVersion,Result:pb.CompareResult_CmpEqual,Version:0}},
+		Success:[]*pb.TxnOp{
+			{Op:pb.OpType_OpPut,Key:"x",Value:"1"},
+			{Op:pb.OpType_OpAppend,Key:"y",Value:"3"},
+			{Op:pb.OpType_OpGet,Key:"x"},
+			{Op:pb.OpType_OpGet,Key:"y"},
+		},
+		Failure:[]*pb.TxnOp{{Op:pb.OpType_OpDel,Key:"y"}},
+	}
+	res,err:=cli.Txn(ctx,req)
+	if err!=nil || !res.Succeeded || len(res.Results)!=4{
+		t.Fatalf("txn on a missing key: %v %v",res,err)
+	}
+	if r:=res.Results[2];r.Value!="1" || r.Version!=1{
+		t.Fatalf("get of a key put by the txn: %v",r)
+	}
+	if r:=res.Results[3];r.Value!="23" || r.Version!=2{
+		t.Fatalf("get of a key appended by the txn: %v",r)
+	}
+
+	// a retried txn returns its first response and is not applied again
+	again:=leaderTxn(t,svrs,proto.Clone(req).(*pb.TxnRequest))
+	if !proto.Equal(again,res){
+		t.Fatalf("retry answered %v, want %v",again,res)
+	}
+
+	res,err=cli.Txn(ctx,&pb.TxnRequest{
+		Compares:[]*pb.Compare{
+			{Key:"y",Target:pb.CompareTarget_CmpValue,Result:pb.CompareResult_CmpEqual,Value:"23"},
+			{Key:"x",Target:pb.CompareTarget_CmpValue,Result:pb.CompareResult_CmpGreater,Value:"1"},
+		},
+		Success:[]*pb.TxnOp{{Op:pb.OpType_OpPut,Key:"x",Value:"won"}},
+		Failure:[]*pb.TxnOp{{Op:pb.OpType_OpDel,Key:"y"},{Op:pb.OpType_OpGet,Key:"y"}},
+	})
+	if err!=nil || res.Succeeded || len(res.Results)!=2 || res.Results[1].Err!=pb.ErrCode_ErrNoKey{
+		t.Fatalf("txn taking the failure branch: %v %v",res,err)
+	}
+
+	if _,err=cli.Txn(ctx,&pb.TxnRequest{Success:[]*pb.TxnOp{{Op:pb.OpType_OpCas,Key:"x"}}});!errors.Is(err,shardkvclient.ErrBadRequest){
+		t.Fatalf("txn with a conditional op: %v",err)
+	}
+
+	waitApplied(t,svrs,1)
+	for _,svr:=range svrs{
+		group:=svr.getGroup(1)
+		group.mu.RLock()
+		x,version,err:=group.rangeShard.GetWithVersion("x")
+		_,_,yErr:=group.rangeShard.GetWithVersion("y")
+		group.mu.RUnlock()
+		if err!=nil || x!="1" || version!=1 || yErr==nil{
+			t.Fatalf("node %d holds x=%q version %d (%v), y %v",svr.id,x,version,err,yErr)
+		}
+	}
+}
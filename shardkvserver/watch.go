@@ -0,0 +1,201 @@
+package shardkvserver
+
+import(
+	"google.golang.org/grpc"
+	pb "neweraft/raftpb"
+	"neweraft/storage"
+)
+
+const WatchBufferSize = 64
+
+// watcher gets the events of every applied command that touch [start,end).
+// When it falls more than WatchBufferSize commands behind, the apply loop
+// drops it and the stream catches up from the MVCC history instead.
+type watcher struct{
+	start string
+	end string
+	prefix bool
+	ch chan []*pb.WatchEvent
+	wrongGroup bool
+}
+
+func (w *watcher) match(key string) bool{
+	return key>=w.start && (w.end=="" || key<w.end)
+}
+
+func makeWatcher(req *pb.WatchRequest) *watcher{
+	w:=&watcher{start:req.Key,end:req.Key+"\x00",prefix:req.Prefix,ch:make(chan []*pb.WatchEvent,WatchBufferSize)}
+	if req.Prefix{
+		w.end=string(storage.PrefixEnd([]byte(req.Key)))
+	}
+	return w
+}
+
+func (sg *ShardGroup)coversWatch(w *watcher) bool{
+	if sg.rng==nil{
+		return !w.prefix && sg.canServeKey(w.start)
+	}
+	return !sg.rng.Frozen && sg.rng.Contains(w.start) &&
+		(sg.rng.EndKey=="" || (w.end!="" && w.end<=sg.rng.EndKey))
+}
+
+func (sg *ShardGroup)recordEvent(key string,raw string,deleted bool,index int64){
+	ev:=&pb.WatchEvent{Type:pb.EventType_EventPut,Key:key,ModIndex:index}
+	if deleted{
+		ev.Type=pb.EventType_EventDelete
+	} else {
+		ev.Version,ev.Value=decodeValue(raw)
+	}
+	// a txn writing a key twice commits only the last value, as MVCC keeps it
+	for i,pending:=range sg.pendingEvents{
+		if pending.Key==key && pending.ModIndex==index{
+			sg.pendingEvents[i]=ev
+			return
+		}
+	}
+	sg.pendingEvents=append(sg.pendingEvents,ev)
+}
+
+// publishEvents hands the events of the command just applied to the watchers;
+// it runs in the apply loop under sg.mu.
+func (sg *ShardGroup)publishEvents(events []*pb.WatchEvent){
+	for id,w:=range sg.watchers{
+		if !sg.coversWatch(w){
+			w.wrongGroup=true
+			close(w.ch)
+			delete(sg.watchers,id)
+			continue
+		}
+		matched:=[]*pb.WatchEvent{}
+		for _,ev:=range events{
+			if w.match(ev.Key){
+				matched=append(matched,ev)
+			}
+		}
+		if len(matched)==0{
+			continue
+		}
+		select{
+		case w.ch<-matched:
+		default:
+			close(w.ch)
+			delete(sg.watchers,id)
+		}
+	}
+}
+
+func (sg *ShardGroup)unregisterWatcher(w *watcher){
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	for id,other:=range sg.watchers{
+		if other==w{
+			delete(sg.watchers,id)
+		}
+	}
+}
+
+// Watch streams the writes to a key or prefix from req.StartIndex on, or from
+// now when it is 0. Every response carries NextIndex so a client can resume
+// on the same group; indexes are per group, so after ErrWrongGroup it has to
+// read again and watch the new owner from 0.
+func (sg *ShardGroup)Watch(req *pb.WatchRequest,stream grpc.ServerStreamingServer[pb.WatchResponse]) error{
+	from:=req.StartIndex
+	for{
+		w:=makeWatcher(req)
+		sg.mu.Lock()
+		if !sg.coversWatch(w){
+			sg.mu.Unlock()
+			return stream.Send(&pb.WatchResponse{Err:pb.ErrCode_ErrWrongGroup,Gid:sg.gid})
+		}
+		if compacted:=sg.readFloor(sg.shardOf(w.start));from>0 && from<=compacted{
+			sg.mu.Unlock()
+			return stream.Send(&pb.WatchResponse{Err:pb.ErrCode_ErrCompacted,Gid:sg.gid,CompactIndex:compacted})
+		}
+		snap,err:=sg.dataEng.GetSnapshot()
+		if err!=nil{
+			sg.mu.Unlock()
+			return stream.Send(&pb.WatchResponse{Err:pb.ErrCode_ErrNotReady,Gid:sg.gid})
+		}
+		applied:=sg.lastApplied
+		sg.nextWatcherId++
+		sg.watchers[sg.nextWatcherId]=w
+		shard:=sg.shardOf(req.Key)
+		sg.mu.Unlock()
+
+		var history []*storage.MvccVersion
+		if from>0 && from<=applied{
+			history,err=shard.History(snap,w.start,w.end,from,applied)
+		}
+		snap.Release()
+		if err!=nil{
+			sg.unregisterWatcher(w)
+			return err
+		}
+		from=applied+1
+		res:=&pb.WatchResponse{Err:pb.ErrCode_ErrOK,Gid:sg.gid,NextIndex:from}
+		for i,ver:=range history{
+			ev:=&pb.WatchEvent{Type:pb.EventType_EventPut,Key:ver.Key,ModIndex:ver.CommitIndex}
+			if ver.Deleted{
+				ev.Type=pb.EventType_EventDelete
+			} else {
+				ev.Version,ev.Value=decodeValue(ver.Value)
+			}
+			res.Events=append(res.Events,ev)
+			if len(res.Events)>=ScanBatchSize && i<len(history)-1{
+				if err:=stream.Send(res);err!=nil{
+					sg.unregisterWatcher(w)
+					return err
+				}
+				res=&pb.WatchResponse{Err:pb.ErrCode_ErrOK,Gid:sg.gid,NextIndex:from}
+			}
+		}
+		if err:=stream.Send(res);err!=nil{
+			sg.unregisterWatcher(w)
+			return err
+		}
+
+		for caughtUp:=true;caughtUp;{
+			select{
+			case <-stream.Context().Done():
+				sg.unregisterWatcher(w)
+				return nil
+			case events,ok:=<-w.ch:
+				if !ok{
+					if w.wrongGroup{
+						return stream.Send(&pb.WatchResponse{Err:pb.ErrCode_ErrWrongGroup,Gid:sg.gid,NextIndex:from})
+					}
+					caughtUp=false
+					continue
+				}
+				from=events[len(events)-1].ModIndex+1
+				if err:=stream.Send(&pb.WatchResponse{Err:pb.ErrCode_ErrOK,Gid:sg.gid,Events:events,NextIndex:from});err!=nil{
+					sg.unregisterWatcher(w)
+					return err
+				}
+			}
+		}
+	}
+}
+
+// Watch serves the watch on the group holding the whole key or prefix. Only a
+// range group can hold a prefix: under hash sharding its keys are spread over
+// every shard, so a prefix watch there is answered ErrWrongGroup.
+func (shardsvr *ShardServer)Watch(req *pb.WatchRequest,stream grpc.ServerStreamingServer[pb.WatchResponse]) error{
+	if req.Gid!=0{
+		group:=shardsvr.getGroup(req.Gid)
+		if group==nil{
+			return stream.Send(&pb.WatchResponse{Err:pb.ErrCode_ErrWrongGroup,Gid:req.Gid})
+		}
+		return group.Watch(req,stream)
+	}
+	w:=makeWatcher(req)
+	for _,group:=range shardsvr.getGroups(){
+		group.mu.RLock()
+		covers:=group.coversWatch(w)
+		group.mu.RUnlock()
+		if covers{
+			return group.Watch(req,stream)
+		}
+	}
+	return stream.Send(&pb.WatchResponse{Err:pb.ErrCode_ErrWrongGroup})
+}
@@ -0,0 +1,244 @@
+package shardkvserver
+
+import(
+	"fmt"
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	pb "neweraft/raftpb"
+	"neweraft/shardkvclient"
+)
+
+// nextEvents reads responses from ch until it has n events, failing on an
+// error response or when they do not come.
+func nextEvents(t *testing.T,ch <-chan *pb.WatchResponse,n int) ([]*pb.WatchEvent,int64){
+	t.Helper()
+	var events []*pb.WatchEvent
+	var next int64
+	for len(events)<n{
+		select{
+		case res,ok:=<-ch:
+			if !ok || res.Err!=pb.ErrCode_ErrOK{
+				t.Fatalf("watch ended after %d events: %v",len(events),res)
+			}
+			events=append(events,res.Events...)
+			next=res.NextIndex
+		case <-time.After(10*time.Second):
+			t.Fatalf("got %d events, want %d",len(events),n)
+		}
+	}
+	return events,next
+}
+
+// watchStarted waits for the first response of a watch, which comes once its
+// watcher is registered.
+func watchStarted(t *testing.T,ch <-chan *pb.WatchResponse) int64{
+	t.Helper()
+	select{
+	case res,ok:=<-ch:
+		if !ok || res.Err!=pb.ErrCode_ErrOK{
+			t.Fatalf("watch did not start: %v",res)
+		}
+		return res.NextIndex
+	case <-time.After(10*time.Second):
+		t.Fatal("watch did not start")
+	}
+	return 0
+}
+
+func checkEvents(t *testing.T,what string,got []*pb.WatchEvent,want []string){
+	t.Helper()
+	var keys []string
+	for i,ev:=range got{
+		keys=append(keys,fmt.Sprintf("%s %s",ev.Type,ev.Key))
+		if i>0 && ev.ModIndex<=got[i-1].ModIndex{
+			t.Fatalf("%s out of apply order: %v",what,got)
+		}
+	}
+	if fmt.Sprint(keys)!=fmt.Sprint(want){
+		t.Fatalf("%s got %v, want %v",what,keys,want)
+	}
+}
+
+func TestWatchEvents(t *testing.T){
+	_,addrs:=startTestServers(t,3,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+
+	prefix:=cli.Watch(ctx,"p/",true,0)
+	key:=cli.Watch(ctx,"k",false,0)
+	watchStarted(t,prefix)
+	watchStarted(t,key)
+	for _,put:=range []string{"p/a","k","p/b","q","kk"}{
+		if _,err:=cli.Put(ctx,put,"v");err!=nil{
+			t.Fatal(err)
+		}
+	}
+	if err:=cli.Delete(ctx,"p/a");err!=nil{
+		t.Fatal(err)
+	}
+	events,_:=nextEvents(t,prefix,3)
+	checkEvents(t,"prefix watch",events,[]string{"EventPut p/a","EventPut p/b","EventDelete p/a"})
+	events,_=nextEvents(t,key,1)
+	checkEvents(t,"key watch",events,[]string{"EventPut k"})
+	if events[0].Value!="v" || events[0].Version!=1{
+		t.Fatalf("key event %v",events[0])
+	}
+}
+
+func TestWatchResume(t *testing.T){
+	_,addrs:=startTestServers(t,3,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+
+	var indexes []int64
+	for i:=0;i<5;i++{
+		res,err:=cli.Put(ctx,fmt.Sprintf("p/%d",i),"v")
+		if err!=nil{
+			t.Fatal(err)
+		}
+		indexes=append(indexes,res.ModIndex)
+	}
+	// from an index in the history: the writes from there on and no earlier
+	watchCtx,stop:=context.WithCancel(ctx)
+	events,next:=nextEvents(t,cli.Watch(watchCtx,"p/",true,indexes[2]),3)
+	stop()
+	checkEvents(t,"history",events,[]string{"EventPut p/2","EventPut p/3","EventPut p/4"})
+
+	for i:=5;i<7;i++{
+		if _,err:=cli.Put(ctx,fmt.Sprintf("p/%d",i),"v");err!=nil{
+			t.Fatal(err)
+		}
+	}
+	// resuming from NextIndex misses nothing written while it was away and
+	// repeats nothing it had
+	events,_=nextEvents(t,cli.Watch(ctx,"p/",true,next),2)
+	checkEvents(t,"resumed",events,[]string{"EventPut p/5","EventPut p/6"})
+}
+
+func TestWatchCompacted(t *testing.T){
+	retention,interval:=MvccRetention,MvccGCInterval
+	MvccRetention,MvccGCInterval=5,50*time.Millisecond
+	t.Cleanup(func(){
+		MvccRetention,MvccGCInterval=retention,interval
+	})
+	svrs,addrs:=startTestServers(t,1,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+
+	for i:=0;i<20;i++{
+		if _,err:=cli.Put(ctx,"k",fmt.Sprint(i));err!=nil{
+			t.Fatal(err)
+		}
+	}
+	group:=svrs[0].getGroup(1)
+	var floor int64
+	compacted:=func() bool{
+		group.mu.RLock()
+		defer group.mu.RUnlock()
+		floor=group.compactedIndex
+		return floor>0
+	}
+	waitFor(t,"compaction",compacted)
+	// let a compaction already proposed land before reading the floor
+	MvccRetention=1<<40
+	time.Sleep(4*MvccGCInterval)
+	compacted()
+	for _,start:=range []int64{1,floor}{
+		res,ok:=<-cli.Watch(ctx,"k",false,start)
+		if !ok || res.Err!=pb.ErrCode_ErrCompacted || res.CompactIndex<floor{
+			t.Fatalf("watch from %d under the floor %d: %v",start,floor,res)
+		}
+	}
+	watchStarted(t,cli.Watch(ctx,"k",false,floor+1))
+}
+
+// gatedWatchStream is a Watch stream whose Send blocks until gate is closed,
+// as a client that stopped reading.
+type gatedWatchStream struct{
+	grpc.ServerStream
+	ctx context.Context
+	gate chan struct{}
+	sent chan *pb.WatchResponse
+}
+
+func (s *gatedWatchStream)Context() context.Context{
+	return s.ctx
+}
+
+func (s *gatedWatchStream)Send(res *pb.WatchResponse) error{
+	if len(res.Events)>0{
+		<-s.gate
+	}
+	s.sent<-res
+	return nil
+}
+
+func TestWatchOverflowCatchesUp(t *testing.T){
+	svrs,addrs:=startTestServers(t,1,oneRangeConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+	group:=svrs[0].getGroup(1)
+	waitFor(t,"a leader",func() bool{
+		_,isLeader:=group.raft.GetState()
+		return isLeader
+	})
+
+	stream:=&gatedWatchStream{ctx:ctx,gate:make(chan struct{}),sent:make(chan *pb.WatchResponse,1024)}
+	done:=make(chan error,1)
+	go func(){ done<-group.Watch(&pb.WatchRequest{Key:"o/",Prefix:true},stream) }()
+	watchStarted(t,stream.sent)
+
+	// the stream blocks on the first batch, so the rest pile up until the
+	// apply loop drops the watcher
+	n:=WatchBufferSize+16
+	var want []string
+	for i:=0;i<n;i++{
+		key:=fmt.Sprintf("o/%03d",i)
+		if _,err:=cli.Put(ctx,key,"v");err!=nil{
+			t.Fatal(err)
+		}
+		want=append(want,"EventPut "+key)
+	}
+	waitFor(t,"the watcher to be dropped",func() bool{
+		group.mu.RLock()
+		defer group.mu.RUnlock()
+		return len(group.watchers)==0
+	})
+	close(stream.gate)
+	events,_:=nextEvents(t,stream.sent,n)
+	checkEvents(t,"caught up",events,want)
+
+	// it is live again after catching up
+	if _,err:=cli.Put(ctx,"o/last","v");err!=nil{
+		t.Fatal(err)
+	}
+	events,_=nextEvents(t,stream.sent,1)
+	checkEvents(t,"live",events,[]string{"EventPut o/last"})
+	cancel()
+	if err:=<-done;err!=nil{
+		t.Fatal(err)
+	}
+}
+
+func TestWatchPrefixOnHashGroups(t *testing.T){
+	_,addrs:=startTestServers(t,2,hashConfig)
+	cli:=shardkvclient.MakeClient(addrs)
+	defer cli.Close()
+	ctx,cancel:=context.WithTimeout(context.Background(),20*time.Second)
+	defer cancel()
+	if res,ok:=<-cli.Watch(ctx,"p/",true,0);!ok || res.Err!=pb.ErrCode_ErrWrongGroup{
+		t.Fatalf("prefix watch on hash groups: %v",res)
+	}
+	watchStarted(t,cli.Watch(ctx,"p/",false,0))
+}
@@ -0,0 +1,28 @@
+package storage
+
+type batchOp struct{
+	del bool
+	key []byte
+	value []byte
+}
+
+// KvBatch collects writes that KvStore.WriteBatch applies atomically.
+type KvBatch struct{
+	ops []batchOp
+}
+
+func MakeKvBatch() *KvBatch{
+	return &KvBatch{}
+}
+
+func (b *KvBatch) Put(k string,v string){
+	b.ops=append(b.ops,batchOp{key:[]byte(k),value:[]byte(v)})
+}
+
+func (b *KvBatch) Del(k string){
+	b.ops=append(b.ops,batchOp{del:true,key:[]byte(k)})
+}
+
+func (b *KvBatch) Len() int{
+	return len(b.ops)
+}
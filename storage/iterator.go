@@ -0,0 +1,29 @@
+package storage
+
+// KvIterator walks keys in order over [start,end). Next must be called
+// before the first Key/Value; a nil end means no upper bound.
+type KvIterator interface{
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// KvSnapshot is a frozen read view of a KvStore.
+type KvSnapshot interface{
+	GetByte(k []byte) ([]byte,error)
+	NewIterator(start []byte,end []byte,reverse bool) KvIterator
+	Release()
+}
+
+func PrefixEnd(prefix []byte) []byte{
+	end:=append([]byte{},prefix...)
+	for i:=len(end)-1;i>=0;i--{
+		if end[i]<0xff{
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
@@ -16,6 +16,10 @@ type KvStore interface {
 
 	SeekPrefixLast(prefix []byte) ([]byte,[]byte,error)
 	SeekPrefixIdmax(prefix []byte) (int64,error)
+
+	NewIterator(start []byte,end []byte,reverse bool) KvIterator
+	GetSnapshot() (KvSnapshot,error)
+	WriteBatch(b *KvBatch) error
 	
 	Close() error
 }
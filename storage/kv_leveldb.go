@@ -1,11 +1,14 @@
 package storage
 
 import (
+	"time"
 	"errors"
 	"strings"
 	"encoding/binary"
 	
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
@@ -25,6 +28,19 @@ func MakeLevelDBKvStore(dbpath string) (*LevelDBKvStore,error) {
 	},err
 }
 
+// MakeReadOnlyLevelDBKvStore opens dbpath for inspection; it fails while a
+// server holds the database.
+func MakeReadOnlyLevelDBKvStore(dbpath string) (*LevelDBKvStore,error) {
+	db,err:=leveldb.OpenFile(dbpath,&opt.Options{ReadOnly:true,ErrorIfMissing:true})
+	if err != nil {
+		return nil,err
+	}
+	return &LevelDBKvStore{
+		Path: dbpath,
+		db: db,
+	},nil
+}
+
 func (l *LevelDBKvStore) Close() error {
 	if l.db != nil {
 		return l.db.Close()
@@ -36,6 +52,8 @@ func (l *LevelDBKvStore) Put(k string,v string) error {
 	if l.db == nil {
 		return errors.New("database not opened")
 	}
+	defer observeSince(putSeconds,time.Now())
+	written.Add(float64(len(k)+len(v)))
 	return l.db.Put([]byte(k), []byte(v),nil)
 }
 
@@ -43,6 +61,7 @@ func (l *LevelDBKvStore) Get(k string) (string,error) {
 	if l.db ==nil {
 		return "",errors.New("database not opened")
 	}
+	defer observeSince(getSeconds,time.Now())
 	data,err := l.db.Get([]byte(k),nil)
 	if err != nil {
 		if err == leveldb.ErrNotFound {
@@ -57,18 +76,23 @@ func (l *LevelDBKvStore) Del(k string) error {
 	if l.db ==nil {
 		return errors.New("database not opened")
 	}
+	defer observeSince(delSeconds,time.Now())
 	return l.db.Delete([]byte(k),nil)
 }
 
 func (l *LevelDBKvStore) PutByte(k []byte,v []byte) error{
+	defer observeSince(putSeconds,time.Now())
+	written.Add(float64(len(k)+len(v)))
 	return l.db.Put(k,v,nil)
 }
 
 func (l *LevelDBKvStore) GetByte(k []byte) ([]byte, error){
+	defer observeSince(getSeconds,time.Now())
 	return l.db.Get(k,nil)
 }
 
 func (l *LevelDBKvStore) DelByte(k []byte) error{
+	defer observeSince(delSeconds,time.Now())
 	return l.db.Delete(k,nil)
 }
 
@@ -134,4 +158,90 @@ func (l *LevelDBKvStore) SeekPrefixIdmax(prefix []byte) (int64,error){
 		}
 	}
 	return maxKeyId,nil
-}
\ No newline at end of file
+}
+
+func (l *LevelDBKvStore) WriteBatch(b *KvBatch) error{
+	defer observeSince(batchSeconds,time.Now())
+	batch:=new(leveldb.Batch)
+	size:=0
+	for _,op:=range b.ops{
+		if op.del{
+			batch.Delete(op.key)
+		} else {
+			batch.Put(op.key,op.value)
+			size+=len(op.key)+len(op.value)
+		}
+	}
+	written.Add(float64(size))
+	return l.db.Write(batch,nil)
+}
+
+type levelDBIterator struct{
+	iter iterator.Iterator
+	reverse bool
+	started bool
+}
+
+func (it *levelDBIterator) Next() bool{
+	if !it.started{
+		it.started=true
+		if it.reverse{
+			return it.iter.Last()
+		}
+		return it.iter.First()
+	}
+	if it.reverse{
+		return it.iter.Prev()
+	}
+	return it.iter.Next()
+}
+
+func (it *levelDBIterator) Key() []byte{
+	return it.iter.Key()
+}
+
+func (it *levelDBIterator) Value() []byte{
+	return it.iter.Value()
+}
+
+func (it *levelDBIterator) Error() error{
+	return it.iter.Error()
+}
+
+func (it *levelDBIterator) Release(){
+	it.iter.Release()
+}
+
+func (l *LevelDBKvStore) NewIterator(start []byte,end []byte,reverse bool) KvIterator{
+	return &levelDBIterator{
+		iter:l.db.NewIterator(&util.Range{Start:start,Limit:end},nil),
+		reverse:reverse,
+	}
+}
+
+type levelDBSnapshot struct{
+	snap *leveldb.Snapshot
+}
+
+func (l *LevelDBKvStore) GetSnapshot() (KvSnapshot,error){
+	snap,err:=l.db.GetSnapshot()
+	if err!=nil{
+		return nil,err
+	}
+	return &levelDBSnapshot{snap:snap},nil
+}
+
+func (s *levelDBSnapshot) GetByte(k []byte) ([]byte,error){
+	return s.snap.Get(k,nil)
+}
+
+func (s *levelDBSnapshot) NewIterator(start []byte,end []byte,reverse bool) KvIterator{
+	return &levelDBIterator{
+		iter:s.snap.NewIterator(&util.Range{Start:start,Limit:end},nil),
+		reverse:reverse,
+	}
+}
+
+func (s *levelDBSnapshot) Release(){
+	s.snap.Release()
+}
@@ -0,0 +1,137 @@
+package storage
+
+import(
+	"bytes"
+	"strings"
+)
+
+// PrefixKvStore puts every key under a fixed prefix of a shared engine, so
+// many raft groups can keep their log and data in one LevelDB.
+type PrefixKvStore struct{
+	prefix []byte
+	eng KvStore
+}
+
+func MakePrefixKvStore(eng KvStore,prefix []byte) *PrefixKvStore{
+	return &PrefixKvStore{
+		prefix:append([]byte{},prefix...),
+		eng:eng,
+	}
+}
+
+func (p *PrefixKvStore) key(k []byte) []byte{
+	return append(append([]byte{},p.prefix...),k...)
+}
+
+func (p *PrefixKvStore) Put(k string,v string) error{
+	return p.eng.Put(string(p.prefix)+k,v)
+}
+
+func (p *PrefixKvStore) Get(k string) (string,error){
+	return p.eng.Get(string(p.prefix)+k)
+}
+
+func (p *PrefixKvStore) Del(k string) error{
+	return p.eng.Del(string(p.prefix)+k)
+}
+
+func (p *PrefixKvStore) PutByte(k []byte,v []byte) error{
+	return p.eng.PutByte(p.key(k),v)
+}
+
+func (p *PrefixKvStore) GetByte(k []byte) ([]byte,error){
+	return p.eng.GetByte(p.key(k))
+}
+
+func (p *PrefixKvStore) DelByte(k []byte) error{
+	return p.eng.DelByte(p.key(k))
+}
+
+func (p *PrefixKvStore) SeekPrefixFirst(prefix string) ([]byte,[]byte,error){
+	k,v,err:=p.eng.SeekPrefixFirst(string(p.prefix)+prefix)
+	return bytes.TrimPrefix(k,p.prefix),v,err
+}
+
+func (p *PrefixKvStore) DumpPrefix(prefix string,trimPrefix bool) (map[string]string,error){
+	kvMap,err:=p.eng.DumpPrefix(string(p.prefix)+prefix,trimPrefix)
+	if err!=nil || trimPrefix{
+		return kvMap,err
+	}
+	newKvMap:=make(map[string]string,len(kvMap))
+	for k,v:=range kvMap{
+		newKvMap[strings.TrimPrefix(k,string(p.prefix))]=v
+	}
+	return newKvMap,nil
+}
+
+func (p *PrefixKvStore) DelPrefix(prefix string) error{
+	return p.eng.DelPrefix(string(p.prefix)+prefix)
+}
+
+func (p *PrefixKvStore) SeekPrefixLast(prefix []byte) ([]byte,[]byte,error){
+	k,v,err:=p.eng.SeekPrefixLast(p.key(prefix))
+	return bytes.TrimPrefix(k,p.prefix),v,err
+}
+
+func (p *PrefixKvStore) SeekPrefixIdmax(prefix []byte) (int64,error){
+	return p.eng.SeekPrefixIdmax(p.key(prefix))
+}
+
+func (p *PrefixKvStore) bounds(start []byte,end []byte) ([]byte,[]byte){
+	if end==nil{
+		return p.key(start),PrefixEnd(p.prefix)
+	}
+	return p.key(start),p.key(end)
+}
+
+func (p *PrefixKvStore) NewIterator(start []byte,end []byte,reverse bool) KvIterator{
+	start,end=p.bounds(start,end)
+	return &prefixIterator{KvIterator:p.eng.NewIterator(start,end,reverse),prefix:p.prefix}
+}
+
+func (p *PrefixKvStore) GetSnapshot() (KvSnapshot,error){
+	snap,err:=p.eng.GetSnapshot()
+	if err!=nil{
+		return nil,err
+	}
+	return &prefixSnapshot{snap:snap,store:p},nil
+}
+
+func (p *PrefixKvStore) WriteBatch(b *KvBatch) error{
+	batch:=&KvBatch{ops:make([]batchOp,len(b.ops))}
+	for i,op:=range b.ops{
+		batch.ops[i]=batchOp{del:op.del,key:p.key(op.key),value:op.value}
+	}
+	return p.eng.WriteBatch(batch)
+}
+
+func (p *PrefixKvStore) Close() error{
+	return nil
+}
+
+type prefixIterator struct{
+	KvIterator
+	prefix []byte
+}
+
+func (it *prefixIterator) Key() []byte{
+	return bytes.TrimPrefix(it.KvIterator.Key(),it.prefix)
+}
+
+type prefixSnapshot struct{
+	snap KvSnapshot
+	store *PrefixKvStore
+}
+
+func (s *prefixSnapshot) GetByte(k []byte) ([]byte,error){
+	return s.snap.GetByte(s.store.key(k))
+}
+
+func (s *prefixSnapshot) NewIterator(start []byte,end []byte,reverse bool) KvIterator{
+	start,end=s.store.bounds(start,end)
+	return &prefixIterator{KvIterator:s.snap.NewIterator(start,end,reverse),prefix:s.store.prefix}
+}
+
+func (s *prefixSnapshot) Release(){
+	s.snap.Release()
+}
@@ -0,0 +1,22 @@
+package storage
+
+import(
+	"time"
+
+	"neweraft/metrics"
+)
+
+var(
+	opSeconds = metrics.NewHistogram("storage_op_seconds","Latency of key-value engine operations.",metrics.LatencyBuckets,"op")
+	bytesWritten = metrics.NewCounter("storage_bytes_written_total","Key and value bytes written to the key-value engine.")
+
+	getSeconds = opSeconds.With("get")
+	putSeconds = opSeconds.With("put")
+	delSeconds = opSeconds.With("delete")
+	batchSeconds = opSeconds.With("batch")
+	written = bytesWritten.With()
+)
+
+func observeSince(h *metrics.Histogram,start time.Time){
+	h.Observe(time.Since(start).Seconds())
+}
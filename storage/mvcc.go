@@ -0,0 +1,309 @@
+package storage
+
+import(
+	"bytes"
+	"errors"
+	"math"
+	"sort"
+	"encoding/binary"
+)
+
+var ErrMvccNotFound = errors.New("mvcc key not found")
+
+const(
+	mvccPut byte='p'
+	mvccTombstone byte='t'
+)
+
+// KvReader is what MVCC reads need, so they work on a store or a snapshot.
+type KvReader interface{
+	NewIterator(start []byte,end []byte,reverse bool) KvIterator
+}
+
+// MvccStore keeps every write of a key as its own entry tagged with the raft
+// index that committed it. Encoded keys are prefix + escaped user key +
+// 0x00 0x01 + ^index, so user keys keep their order and the newest version of
+// a key sorts first.
+type MvccStore struct{
+	prefix []byte
+	eng KvStore
+}
+
+func MakeMvccStore(eng KvStore,prefix []byte) *MvccStore{
+	return &MvccStore{
+		prefix:append([]byte{},prefix...),
+		eng:eng,
+	}
+}
+
+func escapeKey(key []byte) []byte{
+	buf:=make([]byte,0,len(key)+2)
+	for _,c:=range key{
+		if c==0x00{
+			buf=append(buf,0x00,0xff)
+		} else {
+			buf=append(buf,c)
+		}
+	}
+	return buf
+}
+
+func (m *MvccStore) keyPrefix(key string) []byte{
+	buf:=append([]byte{},m.prefix...)
+	buf=append(buf,escapeKey([]byte(key))...)
+	return append(buf,0x00,0x01)
+}
+
+func (m *MvccStore) encodeKey(key string,index int64) []byte{
+	buf:=m.keyPrefix(key)
+	var idx [8]byte
+	binary.BigEndian.PutUint64(idx[:],^uint64(index))
+	return append(buf,idx[:]...)
+}
+
+func (m *MvccStore) decodeKey(enc []byte) (string,int64,bool){
+	if len(enc)<len(m.prefix)+10 || !bytes.HasPrefix(enc,m.prefix){
+		return "",0,false
+	}
+	body:=enc[len(m.prefix):len(enc)-10]
+	if enc[len(enc)-10]!=0x00 || enc[len(enc)-9]!=0x01{
+		return "",0,false
+	}
+	key:=make([]byte,0,len(body))
+	for i:=0;i<len(body);i++{
+		key=append(key,body[i])
+		if body[i]==0x00{
+			i++
+		}
+	}
+	return string(key),int64(^binary.BigEndian.Uint64(enc[len(enc)-8:])),true
+}
+
+func (m *MvccStore) bounds(start string,end string) ([]byte,[]byte){
+	lo:=append(append([]byte{},m.prefix...),escapeKey([]byte(start))...)
+	if end==""{
+		return lo,PrefixEnd(m.prefix)
+	}
+	return lo,append(append([]byte{},m.prefix...),escapeKey([]byte(end))...)
+}
+
+func (m *MvccStore) Put(key string,value string,index int64) error{
+	return m.eng.PutByte(m.encodeKey(key,index),append([]byte{mvccPut},value...))
+}
+
+func (m *MvccStore) Del(key string,index int64) error{
+	return m.eng.PutByte(m.encodeKey(key,index),[]byte{mvccTombstone})
+}
+
+func (m *MvccStore) BatchPut(b *KvBatch,key string,value string,index int64){
+	b.Put(string(m.encodeKey(key,index)),string(mvccPut)+value)
+}
+
+func (m *MvccStore) BatchDel(b *KvBatch,key string,index int64){
+	b.Put(string(m.encodeKey(key,index)),string(mvccTombstone))
+}
+
+// Get returns the newest value of key committed at or before index and the
+// index that committed it.
+func (m *MvccStore) Get(r KvReader,key string,index int64) (string,int64,error){
+	iter:=r.NewIterator(m.encodeKey(key,index),PrefixEnd(m.keyPrefix(key)),false)
+	defer iter.Release()
+	if !iter.Next(){
+		return "",0,ErrMvccNotFound
+	}
+	_,commit,ok:=m.decodeKey(iter.Key())
+	v:=iter.Value()
+	if !ok || len(v)==0 || v[0]==mvccTombstone{
+		return "",0,ErrMvccNotFound
+	}
+	return string(v[1:]),commit,nil
+}
+
+func (m *MvccStore) GetLatest(key string) (string,int64,error){
+	return m.Get(m.eng,key,math.MaxInt64)
+}
+
+// Scan iterates the keys in [start,end) as they were at index; an empty end
+// means no upper bound.
+func (m *MvccStore) Scan(r KvReader,start string,end string,index int64,reverse bool) *MvccIterator{
+	lo,hi:=m.bounds(start,end)
+	return &MvccIterator{m:m,iter:r.NewIterator(lo,hi,reverse),index:index,reverse:reverse}
+}
+
+// MvccVersion is one retained write of a key.
+type MvccVersion struct{
+	Key string
+	Value string
+	CommitIndex int64
+	Deleted bool
+}
+
+// Versions lists the retained writes to keys in [start,end) committed in
+// [from,to], oldest first; an empty end means no upper bound.
+func (m *MvccStore) Versions(r KvReader,start string,end string,from int64,to int64) ([]*MvccVersion,error){
+	lo,hi:=m.bounds(start,end)
+	iter:=r.NewIterator(lo,hi,false)
+	defer iter.Release()
+	versions:=[]*MvccVersion{}
+	for iter.Next(){
+		key,commit,ok:=m.decodeKey(iter.Key())
+		v:=iter.Value()
+		if !ok || commit<from || commit>to || len(v)==0{
+			continue
+		}
+		ver:=&MvccVersion{Key:key,CommitIndex:commit,Deleted:v[0]==mvccTombstone}
+		if !ver.Deleted{
+			ver.Value=string(v[1:])
+		}
+		versions=append(versions,ver)
+	}
+	sort.SliceStable(versions,func(i,j int) bool{
+		return versions[i].CommitIndex<versions[j].CommitIndex
+	})
+	return versions,iter.Error()
+}
+
+// DelPrefix physically drops every version of the keys starting with prefix.
+func (m *MvccStore) DelPrefix(prefix string) error{
+	enc:=append(append([]byte{},m.prefix...),escapeKey([]byte(prefix))...)
+	return m.eng.DelPrefix(string(enc))
+}
+
+// GC drops the versions nobody can read once reads below keepIndex are
+// refused: for every key it keeps the versions newer than keepIndex plus the
+// newest one at or below it, unless that one is a tombstone.
+func (m *MvccStore) GC(keepIndex int64) (int,error){
+	iter:=m.eng.NewIterator(m.prefix,PrefixEnd(m.prefix),false)
+	defer iter.Release()
+	batch:=MakeKvBatch()
+	removed:=0
+	var curKey string
+	kept:=false
+	for iter.Next(){
+		key,commit,ok:=m.decodeKey(iter.Key())
+		if !ok{
+			continue
+		}
+		if key!=curKey{
+			curKey,kept=key,false
+		}
+		if commit>keepIndex{
+			continue
+		}
+		v:=iter.Value()
+		if !kept{
+			kept=true
+			if len(v)>0 && v[0]==mvccPut{
+				continue
+			}
+		}
+		batch.Del(string(iter.Key()))
+		removed++
+		if batch.Len()>=1024{
+			if err:=m.eng.WriteBatch(batch);err!=nil{
+				return removed,err
+			}
+			batch=MakeKvBatch()
+		}
+	}
+	if err:=iter.Error();err!=nil{
+		return removed,err
+	}
+	return removed,m.eng.WriteBatch(batch)
+}
+
+// MvccIterator yields, per user key, the newest version visible at its index
+// and skips keys whose visible version is a tombstone.
+type MvccIterator struct{
+	m *MvccStore
+	iter KvIterator
+	index int64
+	reverse bool
+
+	key string
+	value string
+	commit int64
+
+	lastKey string
+	started bool
+
+	pending bool
+	pKey string
+	pCommit int64
+	pValue []byte
+}
+
+func (it *MvccIterator) Key() string{
+	return it.key
+}
+
+func (it *MvccIterator) Value() string{
+	return it.value
+}
+
+func (it *MvccIterator) CommitIndex() int64{
+	return it.commit
+}
+
+func (it *MvccIterator) Error() error{
+	return it.iter.Error()
+}
+
+func (it *MvccIterator) Release(){
+	it.iter.Release()
+}
+
+func (it *MvccIterator) Next() bool{
+	if it.reverse{
+		return it.prev()
+	}
+	for it.iter.Next(){
+		key,commit,ok:=it.m.decodeKey(it.iter.Key())
+		if !ok || commit>it.index || (it.started && key==it.lastKey){
+			continue
+		}
+		it.started,it.lastKey=true,key
+		v:=it.iter.Value()
+		if len(v)==0 || v[0]==mvccTombstone{
+			continue
+		}
+		it.key,it.value,it.commit=key,string(v[1:]),commit
+		return true
+	}
+	return false
+}
+
+// prev walks keys downwards; versions of one key arrive oldest first, so the
+// last visible one seen before the key changes is the answer.
+func (it *MvccIterator) prev() bool{
+	for{
+		if !it.iter.Next(){
+			if it.pending{
+				it.pending=false
+				if it.pValue[0]==mvccPut{
+					it.key,it.value,it.commit=it.pKey,string(it.pValue[1:]),it.pCommit
+					return true
+				}
+			}
+			return false
+		}
+		key,commit,ok:=it.m.decodeKey(it.iter.Key())
+		if !ok{
+			continue
+		}
+		var found bool
+		if it.started && key!=it.pKey && it.pending && it.pValue[0]==mvccPut{
+			it.key,it.value,it.commit=it.pKey,string(it.pValue[1:]),it.pCommit
+			found=true
+		}
+		if !it.started || key!=it.pKey{
+			it.started,it.pKey,it.pending=true,key,false
+		}
+		if v:=it.iter.Value();commit<=it.index && len(v)>0{
+			it.pending,it.pCommit,it.pValue=true,commit,append([]byte{},v...)
+		}
+		if found{
+			return true
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package tracing
+
+import(
+	"io"
+	"os"
+	"fmt"
+	"sort"
+	"sync"
+	"bufio"
+	"net/http"
+	"encoding/json"
+)
+
+// MemoryExporter keeps the last spans in a ring, for tests and for serving
+// over HTTP.
+type MemoryExporter struct{
+	mu sync.Mutex
+	spans []*Span
+	next int
+	full bool
+}
+
+func NewMemoryExporter(max int) *MemoryExporter{
+	return &MemoryExporter{spans:make([]*Span,max)}
+}
+
+func (me *MemoryExporter)Export(span *Span){
+	me.mu.Lock()
+	me.spans[me.next]=span
+	me.next++
+	if me.next==len(me.spans){
+		me.next,me.full=0,true
+	}
+	me.mu.Unlock()
+}
+
+// Spans returns the kept spans of traceId, or all of them for "", by start
+// time.
+func (me *MemoryExporter)Spans(traceId string) []*Span{
+	me.mu.Lock()
+	n:=me.next
+	if me.full{
+		n=len(me.spans)
+	}
+	spans:=make([]*Span,0,n)
+	for _,span:=range me.spans[:n]{
+		if traceId=="" || span.TraceId==traceId{
+			spans=append(spans,span)
+		}
+	}
+	me.mu.Unlock()
+	sort.Slice(spans,func(i,j int) bool{ return spans[i].Start.Before(spans[j].Start) })
+	return spans
+}
+
+// ServeHTTP answers with the spans of ?trace= as JSON, or as an indented tree
+// with ?format=tree.
+func (me *MemoryExporter)ServeHTTP(w http.ResponseWriter,r *http.Request){
+	spans:=me.Spans(r.URL.Query().Get("trace"))
+	if r.URL.Query().Get("format")=="tree"{
+		w.Header().Set("Content-Type","text/plain; charset=utf-8")
+		WriteTree(w,spans)
+		return
+	}
+	w.Header().Set("Content-Type","application/json")
+	json.NewEncoder(w).Encode(spans)
+}
+
+// FileExporter appends spans to a file, one JSON object per line. Files of
+// several nodes can be read back together with ReadSpans.
+type FileExporter struct{
+	mu sync.Mutex
+	f *os.File
+	enc *json.Encoder
+}
+
+func NewFileExporter(path string) (*FileExporter,error){
+	f,err:=os.OpenFile(path,os.O_CREATE|os.O_WRONLY|os.O_APPEND,0644)
+	if err!=nil{
+		return nil,err
+	}
+	return &FileExporter{f:f,enc:json.NewEncoder(f)},nil
+}
+
+func (fe *FileExporter)Export(span *Span){
+	fe.mu.Lock()
+	fe.enc.Encode(span)
+	fe.mu.Unlock()
+}
+
+func (fe *FileExporter)Close() error{
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	return fe.f.Close()
+}
+
+// ReadSpans reads what a FileExporter wrote, keeping the spans of traceId, or
+// all of them for "".
+func ReadSpans(r io.Reader,traceId string) ([]*Span,error){
+	var spans []*Span
+	scanner:=bufio.NewScanner(r)
+	scanner.Buffer(make([]byte,64*1024),16<<20)
+	for scanner.Scan(){
+		span:=&Span{}
+		if err:=json.Unmarshal(scanner.Bytes(),span);err!=nil{
+			return spans,err
+		}
+		if traceId=="" || span.TraceId==traceId{
+			spans=append(spans,span)
+		}
+	}
+	return spans,scanner.Err()
+}
+
+// WriteTree prints spans trace by trace, children indented under their parent
+// with their start offset from the trace's first span. Spans whose parent is
+// missing are printed as roots.
+func WriteTree(w io.Writer,spans []*Span){
+	sorted:=append([]*Span{},spans...)
+	sort.Slice(sorted,func(i,j int) bool{ return sorted[i].Start.Before(sorted[j].Start) })
+	byId:=make(map[string]bool)
+	children:=make(map[string][]*Span)
+	for _,span:=range sorted{
+		byId[span.TraceId+span.SpanId]=true
+	}
+	var roots []*Span
+	for _,span:=range sorted{
+		if span.ParentId!="" && byId[span.TraceId+span.ParentId]{
+			children[span.TraceId+span.ParentId]=append(children[span.TraceId+span.ParentId],span)
+		} else {
+			roots=append(roots,span)
+		}
+	}
+	traceStart:=make(map[string]*Span)
+	for _,span:=range sorted{
+		if _,ok:=traceStart[span.TraceId];!ok{
+			traceStart[span.TraceId]=span
+		}
+	}
+	var walk func(span *Span,depth int)
+	walk=func(span *Span,depth int){
+		keys:=make([]string,0,len(span.Attrs))
+		for k:=range span.Attrs{
+			keys=append(keys,k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(w,"%*s%s +%v %v",depth*2,"",span.Name,span.Start.Sub(traceStart[span.TraceId].Start),span.Duration)
+		for _,k:=range keys{
+			fmt.Fprintf(w," %s=%s",k,span.Attrs[k])
+		}
+		fmt.Fprintln(w)
+		for _,child:=range children[span.TraceId+span.SpanId]{
+			walk(child,depth+1)
+		}
+	}
+	lastTrace:=""
+	for _,root:=range roots{
+		if root.TraceId!=lastTrace{
+			fmt.Fprintf(w,"trace %s\n",root.TraceId)
+			lastTrace=root.TraceId
+		}
+		walk(root,1)
+	}
+}
@@ -0,0 +1,202 @@
+// Package tracing records spans of work and carries their context across gRPC
+// calls in metadata, so the steps one request took on several nodes can be put
+// back together by trace id.
+package tracing
+
+import(
+	"fmt"
+	"sync"
+	"time"
+	"context"
+	"strings"
+	"math/rand"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataKey is the gRPC metadata key a span context travels under.
+const MetadataKey = "trace-span"
+
+// SpanContext identifies a span across processes.
+type SpanContext struct{
+	TraceId string
+	SpanId string
+}
+
+func (sc SpanContext)IsValid() bool{
+	return sc.TraceId!="" && sc.SpanId!=""
+}
+
+func (sc SpanContext)String() string{
+	return sc.TraceId+"-"+sc.SpanId
+}
+
+func ParseSpanContext(s string) (SpanContext,bool){
+	traceId,spanId,ok:=strings.Cut(s,"-")
+	sc:=SpanContext{TraceId:traceId,SpanId:spanId}
+	return sc,ok && sc.IsValid()
+}
+
+// Span is one timed step of a trace. A nil *Span is what the Start functions
+// return when nothing is recorded, and all its methods do nothing, so callers
+// need no checks. A span is not safe for concurrent use.
+type Span struct{
+	TraceId string
+	SpanId string
+	ParentId string `json:",omitempty"`
+	Name string
+	Start time.Time
+	Duration time.Duration
+	Attrs map[string]string `json:",omitempty"`
+}
+
+func (span *Span)Context() SpanContext{
+	if span==nil{
+		return SpanContext{}
+	}
+	return SpanContext{TraceId:span.TraceId,SpanId:span.SpanId}
+}
+
+func (span *Span)SetAttr(key string,value interface{}){
+	if span==nil{
+		return
+	}
+	if span.Attrs==nil{
+		span.Attrs=make(map[string]string)
+	}
+	span.Attrs[key]=fmt.Sprint(value)
+}
+
+// End sets the duration and hands the span to the exporter.
+func (span *Span)End(){
+	if span==nil{
+		return
+	}
+	span.Duration=time.Since(span.Start)
+	if e:=GetExporter();e!=nil{
+		e.Export(span)
+	}
+}
+
+// Exporter receives every ended span.
+type Exporter interface{
+	Export(span *Span)
+}
+
+type exporterHolder struct{
+	exporter Exporter
+}
+
+var(
+	processExporter atomic.Value
+	sampleRate atomic.Value
+
+	idMu sync.Mutex
+	idRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+func init(){
+	processExporter.Store(&exporterHolder{})
+	sampleRate.Store(1.0)
+}
+
+// SetExporter starts recording spans into e; nil stops recording.
+func SetExporter(e Exporter){
+	processExporter.Store(&exporterHolder{e})
+}
+
+func GetExporter() Exporter{
+	return processExporter.Load().(*exporterHolder).exporter
+}
+
+// SetSampleRate sets the fraction of new traces recorded. Spans whose parent
+// was recorded are always recorded, so a trace is either whole or absent.
+func SetSampleRate(rate float64){
+	sampleRate.Store(rate)
+}
+
+func newId(n int) string{
+	idMu.Lock()
+	defer idMu.Unlock()
+	var sb strings.Builder
+	for i:=0;i<n;i++{
+		fmt.Fprintf(&sb,"%02x",idRand.Intn(256))
+	}
+	return sb.String()
+}
+
+// Start opens a span under parent, or a new trace when parent is not valid.
+// kv are alternating attribute keys and values.
+func Start(parent SpanContext,name string,kv ...interface{}) *Span{
+	if GetExporter()==nil{
+		return nil
+	}
+	span:=&Span{SpanId:newId(8),Name:name,Start:time.Now()}
+	if parent.IsValid(){
+		span.TraceId,span.ParentId=parent.TraceId,parent.SpanId
+	} else {
+		idMu.Lock()
+		sampled:=idRand.Float64()<sampleRate.Load().(float64)
+		idMu.Unlock()
+		if !sampled{
+			return nil
+		}
+		span.TraceId=newId(16)
+	}
+	for i:=0;i+1<len(kv);i+=2{
+		span.SetAttr(fmt.Sprint(kv[i]),kv[i+1])
+	}
+	return span
+}
+
+type spanKey struct{}
+
+func ContextWith(ctx context.Context,sc SpanContext) context.Context{
+	return context.WithValue(ctx,spanKey{},sc)
+}
+
+func FromContext(ctx context.Context) SpanContext{
+	sc,_:=ctx.Value(spanKey{}).(SpanContext)
+	return sc
+}
+
+// StartSpan is Start under the span in ctx, returning ctx carrying the new one.
+func StartSpan(ctx context.Context,name string,kv ...interface{}) (context.Context,*Span){
+	span:=Start(FromContext(ctx),name,kv...)
+	if span==nil{
+		return ctx,nil
+	}
+	return ContextWith(ctx,span.Context()),span
+}
+
+// Inject puts the span context of ctx into its outgoing gRPC metadata.
+func Inject(ctx context.Context) context.Context{
+	if sc:=FromContext(ctx);sc.IsValid(){
+		return metadata.AppendToOutgoingContext(ctx,MetadataKey,sc.String())
+	}
+	return ctx
+}
+
+// Extract takes the span context out of the incoming gRPC metadata of ctx.
+func Extract(ctx context.Context) context.Context{
+	md,ok:=metadata.FromIncomingContext(ctx)
+	if !ok{
+		return ctx
+	}
+	for _,v:=range md.Get(MetadataKey){
+		if sc,ok:=ParseSpanContext(v);ok{
+			return ContextWith(ctx,sc)
+		}
+	}
+	return ctx
+}
+
+func UnaryClientInterceptor(ctx context.Context,method string,req,reply interface{},cc *grpc.ClientConn,invoker grpc.UnaryInvoker,opts ...grpc.CallOption) error{
+	return invoker(Inject(ctx),method,req,reply,cc,opts...)
+}
+
+func UnaryServerInterceptor(ctx context.Context,req interface{},info *grpc.UnaryServerInfo,handler grpc.UnaryHandler) (interface{},error){
+	return handler(Extract(ctx),req)
+}